@@ -0,0 +1,123 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/metrics"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/trace"
+	iamSDK "github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+)
+
+// Middleware is the standard net/http middleware, e.g. for chi, gorilla/mux or the grpc-gateway mux
+type Middleware func(http.Handler) http.Handler
+
+type requestContextKey struct{}
+
+// Filter adapts the go-restful filter into Middleware. The adapted filters of the same request share
+// a single restful.Request stored in the request context, so the attributes set by one filter (e.g. the JWT claims
+// set by the auth filter) are read by the others and by the handler, see Attribute.
+// The request has no selected route, so the route metadata and the path parameters aren't available to the filter.
+func Filter(filter restful.FilterFunction) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			req := RequestFromContext(r.Context())
+			if req == nil {
+				req = restful.NewRequest(r)
+			}
+			req.Request = r
+
+			// the response is shared as well, so the status and the length are tracked once
+			resp, ok := w.(*restful.Response)
+			if !ok {
+				resp = restful.NewResponse(w)
+				resp.SetRequestAccepts(r.Header.Get(restful.HEADER_Accept))
+			}
+
+			chain := &restful.FilterChain{
+				Filters: []restful.FilterFunction{filter},
+				Target: func(req *restful.Request, resp *restful.Response) {
+					ctx := context.WithValue(req.Request.Context(), requestContextKey{}, req)
+					req.Request = req.Request.WithContext(ctx)
+					next.ServeHTTP(resp, req.Request)
+				},
+			}
+			chain.ProcessFilter(req, resp)
+		})
+	}
+}
+
+// Chain composes the middlewares, the first one is the outermost
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next http.Handler) http.Handler {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// AccessLog adapts log.AccessLog
+func AccessLog() Middleware {
+	return Filter(log.AccessLog)
+}
+
+// Trace adapts trace.Filter
+func Trace() Middleware {
+	return Filter(trace.Filter())
+}
+
+// RequestID adapts trace.RequestIDFilter
+func RequestID(options *trace.RequestIDOptions) Middleware {
+	return Filter(trace.RequestIDFilter(options))
+}
+
+// Auth adapts the Auth filter of the IAM filter. The route permission isn't enforced since there is no route,
+// use the FilterOption instead, e.g. iam.WithPermission.
+func Auth(filter *iam.Filter, opts ...iam.FilterOption) Middleware {
+	return Filter(filter.Auth(opts...))
+}
+
+// Metrics adapts the metrics filter, the operation label is "unknown" since there is no route
+func Metrics(filter *metrics.Filter) Middleware {
+	return Filter(filter.Filter)
+}
+
+// RequestFromContext returns the restful.Request shared by the adapted filters, or nil outside of them
+func RequestFromContext(ctx context.Context) *restful.Request {
+	req, _ := ctx.Value(requestContextKey{}).(*restful.Request)
+	return req
+}
+
+// Attribute returns the request attribute set by the adapted filters, e.g. trace.TraceIDKey
+func Attribute(ctx context.Context, name string) interface{} {
+	if req := RequestFromContext(ctx); req != nil {
+		return req.Attribute(name)
+	}
+	return nil
+}
+
+// JWTClaims returns the JWT claims set by the Auth middleware, or nil if there is none
+func JWTClaims(ctx context.Context) *iamSDK.JWTClaims {
+	if req := RequestFromContext(ctx); req != nil {
+		return iam.RetrieveJWTClaims(req)
+	}
+	return nil
+}
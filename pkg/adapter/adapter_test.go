@@ -0,0 +1,132 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adapter
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/trace"
+	iamSDK "github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterSharesAttributes(t *testing.T) {
+	t.Parallel()
+
+	setAttribute := func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		req.SetAttribute("first", "value")
+		chain.ProcessFilter(req, resp)
+	}
+	var seenByFilter interface{}
+	readAttribute := func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		seenByFilter = req.Attribute("first")
+		chain.ProcessFilter(req, resp)
+	}
+
+	var seenByHandler interface{}
+	handler := Chain(Filter(setAttribute), Filter(readAttribute))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seenByHandler = Attribute(r.Context(), "first")
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(t, "value", seenByFilter)
+	assert.Equal(t, "value", seenByHandler)
+}
+
+func TestFilterRejects(t *testing.T) {
+	t.Parallel()
+
+	reject := func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		_ = resp.WriteErrorString(http.StatusForbidden, "forbidden")
+	}
+	called := false
+	handler := Filter(reject)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	assert.False(t, called)
+}
+
+func TestAuth(t *testing.T) {
+	t.Parallel()
+
+	filter := iam.NewFilter(&iamSDK.MockClient{Healthy: true})
+	var claims *iamSDK.JWTClaims
+	handler := Auth(filter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims = JWTClaims(r.Context())
+	}))
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/items", nil))
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("Authorization", "Bearer user1")
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	if assert.NotNil(t, claims) {
+		assert.Equal(t, "user1", claims.Subject)
+	}
+}
+
+// nolint:paralleltest
+func TestAccessLog(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	log.SetAccessLogOutput(buffer)
+	log.FullAccessLogEnabled = true
+	defer func() {
+		log.SetAccessLogOutput(os.Stdout)
+		log.FullAccessLogEnabled = false
+	}()
+
+	filter := iam.NewFilter(&iamSDK.MockClient{Healthy: true})
+	var traceID interface{}
+	handler := Chain(AccessLog(), Trace(), Auth(filter))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceID = Attribute(r.Context(), trace.TraceIDKey)
+		log.FromContext(r.Context()).Info("handled")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("accepted"))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/items", nil)
+	req.Header.Set("Authorization", "Bearer user1")
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Equal(t, "accepted", resp.Body.String())
+	assert.NotEmpty(t, traceID)
+
+	line := buffer.String()
+	assert.Contains(t, line, `method=POST path="/items" status=202`)
+	assert.Contains(t, line, "user_id=user1")
+	assert.Contains(t, line, "trace_id="+traceID.(string))
+}
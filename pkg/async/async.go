@@ -0,0 +1,398 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package async
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/audit"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/response"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/trace"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// JobQueueFull is the error code when the job is rejected because the queue is full
+	JobQueueFull = response.JobQueueFull
+	// JobNotFound is the error code when the job doesn't exist or has expired
+	JobNotFound = 20008
+
+	defaultWorkers    = 4
+	defaultQueueSize  = 100
+	defaultStatusPath = "/jobs"
+	defaultRetention  = time.Hour
+
+	fieldJobID = "job_id"
+)
+
+// Status is the status of the job
+type Status string
+
+// job statuses
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+var (
+	// ErrQueueFull is returned when the queue of the Manager is full
+	ErrQueueFull = errors.New("too many pending jobs")
+	// ErrShutdown is returned when the Manager is shutting down
+	ErrShutdown = errors.New("job manager is shutting down")
+)
+
+// JobFunc is the work of the job. The context is canceled when the Manager shuts down.
+// The result is sent in the job status, the error is sent as *response.Error or internal server error.
+type JobFunc func(ctx context.Context) (interface{}, error)
+
+// Job is the state of the submitted job
+type Job struct {
+	ID          string          `json:"jobId"`
+	Status      Status          `json:"status"`
+	Operation   string          `json:"operation,omitempty"`
+	StatusURL   string          `json:"statusUrl"`
+	CreatedAt   time.Time       `json:"createdAt"`
+	StartedAt   *time.Time      `json:"startedAt,omitempty"`
+	CompletedAt *time.Time      `json:"completedAt,omitempty"`
+	Result      interface{}     `json:"result,omitempty"`
+	Error       *response.Error `json:"error,omitempty"`
+
+	traceID  string
+	userID   string
+	clientID string
+}
+
+// Options contains options for the job Manager
+type Options struct {
+	// Workers is the number of jobs running concurrently. Default: 4
+	Workers int
+	// QueueSize is the maximum number of jobs waiting for a worker. Default: 100
+	QueueSize int
+	// StatusPath is the path of the status WebService, the status URL is StatusPath/{jobId}. Default: /jobs
+	StatusPath string
+	// Retention is how long the completed job status is kept. Default: 1 hour
+	Retention time.Duration
+	// Publisher receives the audit events of the accepted and completed jobs. Default: audit.LogPublisher
+	Publisher audit.Publisher
+}
+
+type task struct {
+	job *Job
+	fn  JobFunc
+	// event is the audit event of the submission, the completion event is published based on it
+	event audit.Event
+}
+
+// Manager runs the submitted jobs in a worker pool and tracks their status,
+// implementing the async request pattern: the request is accepted with 202 and the status URL,
+// and the client polls the status until the job completes.
+type Manager struct {
+	options Options
+
+	mutex sync.RWMutex
+	jobs  map[string]*Job
+
+	tasks  chan task
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+}
+
+// NewManager creates new Manager instance and starts its workers
+func NewManager(options Options) *Manager {
+	if options.Workers <= 0 {
+		options.Workers = defaultWorkers
+	}
+	if options.QueueSize <= 0 {
+		options.QueueSize = defaultQueueSize
+	}
+	if options.StatusPath == "" {
+		options.StatusPath = defaultStatusPath
+	}
+	options.StatusPath = strings.TrimSuffix(options.StatusPath, "/")
+	if options.Retention <= 0 {
+		options.Retention = defaultRetention
+	}
+	if options.Publisher == nil {
+		options.Publisher = audit.LogPublisher()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	manager := &Manager{
+		options: options,
+		jobs:    make(map[string]*Job),
+		tasks:   make(chan task, options.QueueSize),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	manager.wg.Add(options.Workers)
+	for i := 0; i < options.Workers; i++ {
+		go manager.work()
+	}
+
+	return manager
+}
+
+// Submit queues the job and responds 202 with the job status and Location header of the status URL.
+// The request must be read before calling Submit, since the job runs after the request is completed.
+// When the queue is full, the request is rejected with 503 error response.
+// The job ID is printed as job_id field in the access log, and the accepted job is published as an audit event.
+func (m *Manager) Submit(req *restful.Request, resp *restful.Response, fn JobFunc) {
+	job, event, err := m.submit(req, fn)
+	if err != nil {
+		logrus.Warnf("%s %s is rejected: %v", req.Request.Method, req.Request.URL.Path, err)
+		resp.Header().Set("Retry-After", "1")
+		response.WriteErrorEnvelope(req, resp, http.StatusServiceUnavailable, response.NewError(JobQueueFull,
+			err.Error(), nil))
+		return
+	}
+
+	log.AdditionalFields(req, map[string]interface{}{fieldJobID: job.ID})
+	m.publish(&event)
+	resp.Header().Set("Location", job.StatusURL)
+	if err = resp.WriteHeaderAndJson(http.StatusAccepted, job, restful.MIME_JSON); err != nil {
+		logrus.Error(err)
+	}
+}
+
+func (m *Manager) submit(req *restful.Request, fn JobFunc) (Job, audit.Event, error) {
+	id := strings.ReplaceAll(uuid.New().String(), "-", "")
+	job := &Job{
+		ID:        id,
+		Status:    StatusPending,
+		StatusURL: m.options.StatusPath + "/" + id,
+		CreatedAt: time.Now().UTC(),
+	}
+	if route := req.SelectedRoute(); route != nil {
+		job.Operation = route.Operation()
+	}
+	if traceID, ok := req.Attribute(trace.TraceIDKey).(string); ok {
+		job.traceID = traceID
+	}
+	if claims := iam.RetrieveJWTClaims(req); claims != nil {
+		job.userID = claims.Subject
+		job.clientID = claims.ClientID
+	}
+
+	event := audit.NewEvent(req, http.StatusAccepted)
+	event.Result = audit.ResultAccepted
+	event.JobID = id
+	// the path params of the request are copied, since the event of the completion is published after it's completed
+	target := make(map[string]string, len(event.Target))
+	for key, value := range event.Target {
+		target[key] = value
+	}
+	event.Target = target
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.removeExpiredJobs()
+
+	if m.ctx.Err() != nil {
+		return Job{}, audit.Event{}, ErrShutdown
+	}
+	select {
+	case m.tasks <- task{job: job, fn: fn, event: *event}:
+	default:
+		return Job{}, audit.Event{}, ErrQueueFull
+	}
+	m.jobs[id] = job
+
+	return *job, *event, nil
+}
+
+// removeExpiredJobs must be called while holding the lock
+func (m *Manager) removeExpiredJobs() {
+	expiry := time.Now().Add(-m.options.Retention)
+	for id, job := range m.jobs {
+		if job.CompletedAt != nil && job.CompletedAt.Before(expiry) {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+func (m *Manager) work() {
+	defer m.wg.Done()
+
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case t := <-m.tasks:
+			m.run(t)
+		}
+	}
+}
+
+func (m *Manager) run(t task) {
+	m.mutex.Lock()
+	startedAt := time.Now().UTC()
+	t.job.Status = StatusRunning
+	t.job.StartedAt = &startedAt
+	m.mutex.Unlock()
+
+	result, err := m.call(t)
+
+	m.mutex.Lock()
+	completedAt := time.Now().UTC()
+	t.job.CompletedAt = &completedAt
+	if err != nil {
+		t.job.Status = StatusFailed
+		var errorResponse *response.Error
+		if !errors.As(err, &errorResponse) {
+			errorResponse = &response.Error{ErrorCode: response.InternalServerError, ErrorMessage: "internal server error"}
+		}
+		t.job.Error = errorResponse
+	} else {
+		t.job.Status = StatusSucceeded
+		t.job.Result = result
+	}
+	job := *t.job
+	m.mutex.Unlock()
+
+	record := log.JobRecord{
+		JobID:     job.ID,
+		Operation: job.Operation,
+		Status:    string(job.Status),
+		Duration:  completedAt.Sub(startedAt),
+		TraceID:   job.traceID,
+		UserID:    job.userID,
+		ClientID:  job.clientID,
+	}
+	// the completion event is the submission event with the outcome of the job,
+	// the status is 200 for the succeeded job and 500 for the failed one
+	event := t.event
+	event.Time = completedAt
+	event.Result = audit.ResultSuccess
+	event.Status = http.StatusOK
+	if err != nil {
+		record.ErrorCode = job.Error.ErrorCode
+		event.Result = audit.ResultFailure
+		event.Status = http.StatusInternalServerError
+		event.ErrorCode = job.Error.ErrorCode
+		logrus.WithField(fieldJobID, job.ID).Errorf("job %s failed: %v", job.ID, err)
+	}
+	// the completion is written into the access log, since the job's request is already logged as 202
+	log.WriteJobLog(record)
+	m.publish(&event)
+}
+
+func (m *Manager) publish(event *audit.Event) {
+	if err := m.options.Publisher.Publish(event); err != nil {
+		logrus.Errorf("unable to publish audit event of job %s: %v", event.JobID, err)
+	}
+}
+
+// call runs the job, the panic of the job fails the job instead of crashing the service
+func (m *Manager) call(t task) (result interface{}, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			logrus.Errorf("job %s panicked: %v\n%s", t.job.ID, recovered, debug.Stack())
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+
+	return t.fn(m.ctx)
+}
+
+// Get returns the job status, or false if the job doesn't exist or has expired
+func (m *Manager) Get(id string) (Job, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	job, ok := m.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// WebService returns the status WebService serving GET StatusPath/{jobId}.
+// The filters, e.g. the auth filter, are applied to the WebService. When the request has the JWT claims,
+// only the user or client who submitted the job can see its status.
+func (m *Manager) WebService(filters ...restful.FilterFunction) *restful.WebService {
+	ws := new(restful.WebService)
+	ws.Path(m.options.StatusPath).Produces(restful.MIME_JSON)
+	for _, filter := range filters {
+		ws.Filter(filter)
+	}
+
+	ws.Route(ws.GET("/{jobId}").
+		Operation("getJobStatus").
+		Param(ws.PathParameter("jobId", "ID of the job")).
+		To(m.getJobStatus))
+
+	return ws
+}
+
+func (m *Manager) getJobStatus(req *restful.Request, resp *restful.Response) {
+	job, ok := m.Get(req.PathParameter("jobId"))
+	if ok {
+		if claims := iam.RetrieveJWTClaims(req); claims != nil && !isOwner(job, claims.Subject, claims.ClientID) {
+			ok = false
+		}
+	}
+	if !ok {
+		response.WriteErrorEnvelope(req, resp, http.StatusNotFound, response.NewError(JobNotFound,
+			"job {jobId} does not exist", map[string]string{"jobId": req.PathParameter("jobId")}))
+		return
+	}
+
+	if err := resp.WriteHeaderAndJson(http.StatusOK, job, restful.MIME_JSON); err != nil {
+		logrus.Error(err)
+	}
+}
+
+func isOwner(job Job, userID, clientID string) bool {
+	if job.userID != "" {
+		return job.userID == userID
+	}
+	return job.clientID == "" || job.clientID == clientID
+}
+
+// Shutdown stops accepting new jobs, cancels the context of the running jobs,
+// and waits until the workers stop or the context is done. The queued jobs aren't run.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.once.Do(m.cancel)
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,326 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package async
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/go-jose/jwt"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/audit"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/response"
+	iamSDK "github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestContainer creates container whose POST /reports route submits the job
+func createTestContainer(manager *Manager, fn JobFunc, filters ...restful.FilterFunction) *restful.Container {
+	ws := new(restful.WebService)
+	for _, filter := range filters {
+		ws.Filter(filter)
+	}
+	ws.Route(ws.POST("/reports").
+		Operation("generateReport").
+		To(func(request *restful.Request, response *restful.Response) {
+			manager.Submit(request, response, fn)
+		}))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+	container.Add(manager.WebService(filters...))
+
+	return container
+}
+
+func serve(container *restful.Container, method, path string) *httptest.ResponseRecorder {
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, httptest.NewRequest(method, path, nil))
+	return resp
+}
+
+func submitJob(t *testing.T, container *restful.Container) Job {
+	t.Helper()
+
+	resp := serve(container, http.MethodPost, "/reports")
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+
+	job := Job{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &job))
+	assert.Equal(t, job.StatusURL, resp.Header().Get("Location"))
+
+	return job
+}
+
+func waitForJob(t *testing.T, container *restful.Container, statusURL string) map[string]interface{} {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		resp := serve(container, http.MethodGet, statusURL)
+		assert.Equal(t, http.StatusOK, resp.Code)
+
+		job := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &job))
+		if job["status"] == string(StatusSucceeded) || job["status"] == string(StatusFailed) {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job isn't completed: %v", job)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestManager_Succeeded(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(Options{StatusPath: "/reports/jobs/"})
+	defer manager.Shutdown(context.Background()) // nolint:errcheck
+
+	container := createTestContainer(manager, func(ctx context.Context) (interface{}, error) {
+		return map[string]string{"url": "https://example.com/report.csv"}, nil
+	})
+
+	job := submitJob(t, container)
+	assert.Equal(t, StatusPending, job.Status)
+	assert.Equal(t, "generateReport", job.Operation)
+	assert.Equal(t, "/reports/jobs/"+job.ID, job.StatusURL)
+
+	status := waitForJob(t, container, job.StatusURL)
+	assert.Equal(t, string(StatusSucceeded), status["status"])
+	assert.Equal(t, map[string]interface{}{"url": "https://example.com/report.csv"}, status["result"])
+	assert.NotEmpty(t, status["startedAt"])
+	assert.NotEmpty(t, status["completedAt"])
+}
+
+func TestManager_Failed(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(Options{})
+	defer manager.Shutdown(context.Background()) // nolint:errcheck
+
+	errs := []error{
+		response.NewError(20002, "invalid date range", nil),
+		errors.New("database is down"),
+	}
+	expected := []interface{}{
+		map[string]interface{}{"errorCode": float64(20002), "errorMessage": "invalid date range"},
+		map[string]interface{}{"errorCode": float64(20000), "errorMessage": "internal server error"},
+	}
+
+	for i, err := range errs {
+		err := err
+		container := createTestContainer(manager, func(ctx context.Context) (interface{}, error) {
+			return nil, err
+		})
+
+		status := waitForJob(t, container, submitJob(t, container).StatusURL)
+		assert.Equal(t, string(StatusFailed), status["status"])
+		assert.Equal(t, expected[i], status["error"])
+	}
+}
+
+// nolint:paralleltest
+func TestManager_CompletionLog(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	log.SetAccessLogOutput(buffer)
+	defer log.SetAccessLogOutput(os.Stdout)
+
+	manager := NewManager(Options{})
+	container := createTestContainer(manager, func(ctx context.Context) (interface{}, error) {
+		return nil, response.NewError(20001, "unable to generate report", nil)
+	})
+
+	job := submitJob(t, container)
+	waitForJob(t, container, job.StatusURL)
+	// the record is written after the status is updated, it's complete once the workers stop
+	assert.NoError(t, manager.Shutdown(context.Background()))
+
+	assert.Contains(t, buffer.String(), "log_type=job")
+	assert.Contains(t, buffer.String(), "job_id="+job.ID)
+	assert.Contains(t, buffer.String(), "status=failed")
+	assert.Contains(t, buffer.String(), `operation="generateReport"`)
+	assert.Contains(t, buffer.String(), "error_code=20001")
+}
+
+func TestManager_Panic(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(Options{})
+	defer manager.Shutdown(context.Background()) // nolint:errcheck
+
+	container := createTestContainer(manager, func(ctx context.Context) (interface{}, error) {
+		panic("unexpected")
+	})
+
+	status := waitForJob(t, container, submitJob(t, container).StatusURL)
+	assert.Equal(t, string(StatusFailed), status["status"])
+
+	// the worker keeps running after the panic
+	status = waitForJob(t, container, submitJob(t, container).StatusURL)
+	assert.Equal(t, string(StatusFailed), status["status"])
+}
+
+func TestManager_QueueFull(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(Options{Workers: 1, QueueSize: 1})
+	defer manager.Shutdown(context.Background()) // nolint:errcheck
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	container := createTestContainer(manager, func(ctx context.Context) (interface{}, error) {
+		started <- struct{}{}
+		<-release
+		return nil, nil
+	})
+
+	submitJob(t, container)
+	<-started
+	submitJob(t, container)
+
+	resp := serve(container, http.MethodPost, "/reports")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.JSONEq(t, `{"errorCode":20027,"errorMessage":"too many pending jobs"}`, resp.Body.String())
+
+	close(release)
+}
+
+func TestManager_NotFound(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(Options{})
+	defer manager.Shutdown(context.Background()) // nolint:errcheck
+
+	container := createTestContainer(manager, nil)
+
+	resp := serve(container, http.MethodGet, "/jobs/unknown")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	assert.JSONEq(t,
+		`{"errorCode":20008,"errorMessage":"job unknown does not exist","messageVariables":{"jobId":"unknown"}}`,
+		resp.Body.String())
+}
+
+func TestManager_Owner(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(Options{})
+	defer manager.Shutdown(context.Background()) // nolint:errcheck
+
+	userID := "user1"
+	container := createTestContainer(manager,
+		func(ctx context.Context) (interface{}, error) { return nil, nil },
+		func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+			req.SetAttribute(iam.ClaimsAttribute, &iamSDK.JWTClaims{Claims: jwt.Claims{Subject: userID}})
+			chain.ProcessFilter(req, resp)
+		})
+
+	job := submitJob(t, container)
+	waitForJob(t, container, job.StatusURL)
+
+	userID = "user2"
+	assert.Equal(t, http.StatusNotFound, serve(container, http.MethodGet, job.StatusURL).Code)
+}
+
+func TestManager_AuditEvents(t *testing.T) {
+	t.Parallel()
+
+	var mutex sync.Mutex
+	var events []audit.Event
+	manager := NewManager(Options{
+		Workers: 1,
+		Publisher: audit.PublisherFunc(func(event *audit.Event) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			events = append(events, *event)
+			return nil
+		}),
+	})
+
+	fail := false
+	container := createTestContainer(manager,
+		func(ctx context.Context) (interface{}, error) {
+			if fail {
+				return nil, response.NewError(20001, "unable to generate report", nil)
+			}
+			return nil, nil
+		},
+		func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+			req.SetAttribute(iam.ClaimsAttribute, &iamSDK.JWTClaims{Claims: jwt.Claims{Subject: "user1"}, ClientID: "client1"})
+			chain.ProcessFilter(req, resp)
+		})
+
+	succeeded := submitJob(t, container)
+	waitForJob(t, container, succeeded.StatusURL)
+	fail = true
+	failed := submitJob(t, container)
+	waitForJob(t, container, failed.StatusURL)
+	assert.NoError(t, manager.Shutdown(context.Background()))
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	require.Len(t, events, 4)
+
+	byResult := map[string]audit.Event{}
+	for _, event := range events {
+		assert.Equal(t, "generateReport", event.Action)
+		assert.Equal(t, audit.Actor{UserID: "user1", ClientID: "client1"}, event.Actor)
+		byResult[event.JobID+"/"+event.Result] = event
+	}
+
+	accepted := byResult[succeeded.ID+"/"+audit.ResultAccepted]
+	assert.Equal(t, http.StatusAccepted, accepted.Status)
+	assert.Equal(t, http.StatusOK, byResult[succeeded.ID+"/"+audit.ResultSuccess].Status)
+
+	assert.Contains(t, byResult, failed.ID+"/"+audit.ResultAccepted)
+	completed := byResult[failed.ID+"/"+audit.ResultFailure]
+	assert.Equal(t, http.StatusInternalServerError, completed.Status)
+	assert.Equal(t, 20001, completed.ErrorCode)
+}
+
+func TestManager_Shutdown(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(Options{Workers: 1})
+
+	started := make(chan struct{})
+	canceled := make(chan struct{})
+	container := createTestContainer(manager, func(ctx context.Context) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		close(canceled)
+		return nil, ctx.Err()
+	})
+
+	submitJob(t, container)
+	<-started
+	assert.NoError(t, manager.Shutdown(context.Background()))
+	<-canceled
+
+	resp := serve(container, http.MethodPost, "/reports")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+}
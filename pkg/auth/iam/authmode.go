@@ -0,0 +1,153 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+)
+
+// AuthMode is the kind of credential which authenticated the request
+type AuthMode string
+
+const (
+	// AuthModeUser is the user access token
+	AuthModeUser AuthMode = "user"
+	// AuthModeClient is the client access token without user, e.g. from the client credentials grant
+	AuthModeClient AuthMode = "client"
+	// AuthModeAPIKey is the static API key from the secret store
+	AuthModeAPIKey AuthMode = "api_key"
+
+	// AuthModesMetadata is the route metadata key of the []AuthMode accepted by the route.
+	// Default: AuthModeUser and AuthModeClient
+	AuthModesMetadata = "IAMAuthModes"
+	// AuthModeAttribute is the request attribute key of the AuthMode which authenticated the request
+	AuthModeAttribute = "AuthMode"
+
+	// DefaultAPIKeyHeader is the default request header of the API key
+	DefaultAPIKeyHeader = "X-Api-Key"
+	// DefaultAPIKeySecretName is the default secret name of the API keys
+	DefaultAPIKeySecretName = "api-key"
+)
+
+var defaultAuthModes = []AuthMode{AuthModeUser, AuthModeClient}
+
+// SecretStore provides the secret values, e.g. from Vault or AWS Secrets Manager.
+// The store is called on every API key request, so it should cache the secrets.
+type SecretStore interface {
+	GetSecret(name string) (string, error)
+}
+
+// APIKeyOptions contains options of the API key auth mode
+type APIKeyOptions struct {
+	// Store provides the API keys. Required
+	Store SecretStore
+	// SecretName is the name of the secret holding the valid API keys, separated by comma so the key can be rotated.
+	// Default: DefaultAPIKeySecretName
+	SecretName string
+	// Header is the request header of the API key. Default: DefaultAPIKeyHeader
+	Header string
+}
+
+// RouteAuthModes declares the auth modes accepted by the route, e.g. the internal endpoint called
+// service-to-service accepting the client token and the API key only:
+//
+//	ws.Route(ws.POST("/internal/sync").
+//		Do(iam.RouteAuthModes(iam.AuthModeClient, iam.AuthModeAPIKey)).
+//		To(sync))
+func RouteAuthModes(modes ...AuthMode) func(*restful.RouteBuilder) {
+	return func(builder *restful.RouteBuilder) {
+		builder.Metadata(AuthModesMetadata, modes)
+	}
+}
+
+// GetAuthMode returns the auth mode which authenticated the request, or empty string if it isn't authenticated
+func GetAuthMode(req *restful.Request) AuthMode {
+	mode, _ := req.Attribute(AuthModeAttribute).(AuthMode)
+	return mode
+}
+
+// getRouteAuthModes returns the auth modes accepted by the selected route
+func getRouteAuthModes(req *restful.Request) []AuthMode {
+	if route := req.SelectedRoute(); route != nil {
+		return routeAuthModes(route.Metadata())
+	}
+	return defaultAuthModes
+}
+
+// routeAuthModes returns the auth modes declared in the route metadata, or the default auth modes
+func routeAuthModes(metadata map[string]interface{}) []AuthMode {
+	if modes, ok := metadata[AuthModesMetadata].([]AuthMode); ok && len(modes) > 0 {
+		return modes
+	}
+	return defaultAuthModes
+}
+
+func hasAuthMode(modes []AuthMode, mode AuthMode) bool {
+	for _, m := range modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenAuthMode returns the auth mode of the access token, the client token has no subject
+func tokenAuthMode(claims *iam.JWTClaims) AuthMode {
+	if claims.Subject == "" {
+		return AuthModeClient
+	}
+	return AuthModeUser
+}
+
+func (filter *Filter) apiKeyHeader() string {
+	if options := filter.options.APIKey; options != nil && options.Header != "" {
+		return options.Header
+	}
+	return DefaultAPIKeyHeader
+}
+
+// validateAPIKey compares the API key against the keys of the secret store in constant time
+func (filter *Filter) validateAPIKey(apiKey string) error {
+	options := filter.options.APIKey
+	if options == nil || options.Store == nil {
+		return errors.New("API key auth mode isn't configured")
+	}
+
+	secretName := options.SecretName
+	if secretName == "" {
+		secretName = DefaultAPIKeySecretName
+	}
+	secret, err := options.Store.GetSecret(secretName)
+	if err != nil {
+		return err
+	}
+
+	valid := false
+	for _, key := range strings.Split(secret, ",") {
+		key = strings.TrimSpace(key)
+		if key != "" && subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) == 1 {
+			valid = true
+		}
+	}
+	if !valid {
+		return errors.New("invalid API key")
+	}
+	return nil
+}
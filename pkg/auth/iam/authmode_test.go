@@ -0,0 +1,156 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AccelByte/go-jose/jwt"
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type testSecretStore map[string]string
+
+func (s testSecretStore) GetSecret(name string) (string, error) {
+	secret, ok := s[name]
+	if !ok {
+		return "", errors.New("secret not found")
+	}
+	return secret, nil
+}
+
+// authModeTestClient accepts "user-token" as user token and "client-token" as client token
+type authModeTestClient struct {
+	*iam.MockClient
+}
+
+func (c *authModeTestClient) ValidateAndParseClaims(accessToken string, opts ...iam.Option) (*iam.JWTClaims, error) {
+	switch accessToken {
+	case "user-token":
+		return &iam.JWTClaims{Claims: jwt.Claims{Subject: "user1"}, ClientID: "client1"}, nil
+	case "client-token":
+		return &iam.JWTClaims{ClientID: "client1"}, nil
+	default:
+		return nil, errors.New("invalid token")
+	}
+}
+
+func serveAuthMode(filter *Filter, modes []AuthMode, header, value string) (*httptest.ResponseRecorder, AuthMode) {
+	var mode AuthMode
+
+	ws := new(restful.WebService)
+	ws.Filter(filter.Auth())
+	builder := ws.GET("/internal/sync").To(func(request *restful.Request, response *restful.Response) {
+		mode = GetAuthMode(request)
+	})
+	if modes != nil {
+		builder.Do(RouteAuthModes(modes...))
+	}
+	ws.Route(builder)
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	req := httptest.NewRequest(http.MethodGet, "/internal/sync", nil)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, req)
+
+	return resp, mode
+}
+
+func TestAuthMode_Default(t *testing.T) {
+	t.Parallel()
+
+	filter := NewFilterWithOptions(&authModeTestClient{MockClient: &iam.MockClient{}}, &FilterInitializationOptions{
+		APIKey: &APIKeyOptions{Store: testSecretStore{DefaultAPIKeySecretName: "key1"}},
+	})
+
+	resp, mode := serveAuthMode(filter, nil, "Authorization", "Bearer user-token")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, AuthModeUser, mode)
+
+	resp, mode = serveAuthMode(filter, nil, "Authorization", "Bearer client-token")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, AuthModeClient, mode)
+
+	// the API key isn't accepted unless the route declares it
+	resp, _ = serveAuthMode(filter, nil, DefaultAPIKeyHeader, "key1")
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+}
+
+func TestAuthMode_ClientOnly(t *testing.T) {
+	t.Parallel()
+
+	filter := NewFilter(&authModeTestClient{MockClient: &iam.MockClient{}})
+	modes := []AuthMode{AuthModeClient}
+
+	resp, mode := serveAuthMode(filter, modes, "Authorization", "Bearer client-token")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, AuthModeClient, mode)
+
+	resp, _ = serveAuthMode(filter, modes, "Authorization", "Bearer user-token")
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	assert.JSONEq(t, `{"errorCode":20003,"errorMessage":"access forbidden: forbidden access"}`, resp.Body.String())
+
+	resp, _ = serveAuthMode(filter, []AuthMode{AuthModeUser}, "Authorization", "Bearer client-token")
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	assert.JSONEq(t, `{"errorCode":20022,"errorMessage":"access forbidden: token is not user token"}`, resp.Body.String())
+}
+
+func TestAuthMode_APIKey(t *testing.T) {
+	t.Parallel()
+
+	filter := NewFilterWithOptions(&authModeTestClient{MockClient: &iam.MockClient{}}, &FilterInitializationOptions{
+		APIKey: &APIKeyOptions{
+			Store:      testSecretStore{"sync-keys": "old-key, new-key"},
+			SecretName: "sync-keys",
+			Header:     "X-Sync-Key",
+		},
+	})
+	modes := []AuthMode{AuthModeClient, AuthModeAPIKey}
+
+	for _, key := range []string{"old-key", "new-key"} {
+		resp, mode := serveAuthMode(filter, modes, "X-Sync-Key", key)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, AuthModeAPIKey, mode)
+	}
+
+	resp, _ := serveAuthMode(filter, modes, "X-Sync-Key", "wrong-key")
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	resp, mode := serveAuthMode(filter, modes, "Authorization", "Bearer client-token")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, AuthModeClient, mode)
+
+	resp, _ = serveAuthMode(filter, []AuthMode{AuthModeAPIKey}, "", "")
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+}
+
+func TestAuthMode_APIKeyNotConfigured(t *testing.T) {
+	t.Parallel()
+
+	filter := NewFilter(&authModeTestClient{MockClient: &iam.MockClient{}})
+
+	resp, _ := serveAuthMode(filter, []AuthMode{AuthModeAPIKey}, DefaultAPIKeyHeader, "key1")
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+}
@@ -0,0 +1,186 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultDenyListReloadInterval = 10 * time.Second
+
+var (
+	errDeniedToken  = errors.New("token is in the deny list")
+	errDeniedClient = errors.New("client is in the deny list")
+	errDeniedUser   = errors.New("user is in the deny list")
+)
+
+// DenyListEntries are the compromised credentials rejected by the filter
+type DenyListEntries struct {
+	TokenIDs  []string `json:"tokenIds"`  // JWT ID (jti) of the tokens
+	ClientIDs []string `json:"clientIds"` // Client ID of the tokens
+	UserIDs   []string `json:"userIds"`   // User ID (sub) of the tokens
+}
+
+// DenyListCount is the number of requests rejected by each kind of the deny list entries
+type DenyListCount struct {
+	Tokens  uint64
+	Clients uint64
+	Users   uint64
+}
+
+type denyListSet struct {
+	tokenIDs  map[string]struct{}
+	clientIDs map[string]struct{}
+	userIDs   map[string]struct{}
+}
+
+// DenyList rejects the tokens of compromised credentials, it can be replaced at runtime
+// for emergency response without redeploying the service.
+type DenyList struct {
+	deniedTokens  uint64
+	deniedClients uint64
+	deniedUsers   uint64
+
+	set atomic.Value // *denyListSet
+}
+
+// NewDenyList creates new empty DenyList instance
+func NewDenyList() *DenyList {
+	denyList := &DenyList{}
+	denyList.Set(DenyListEntries{})
+	return denyList
+}
+
+// Set replaces the entries of the deny list
+func (d *DenyList) Set(entries DenyListEntries) {
+	d.set.Store(&denyListSet{
+		tokenIDs:  toSet(entries.TokenIDs),
+		clientIDs: toSet(entries.ClientIDs),
+		userIDs:   toSet(entries.UserIDs),
+	})
+}
+
+// LoadFile replaces the entries of the deny list with the JSON file content, e.g.
+// {"tokenIds": ["jti"], "clientIds": ["client id"], "userIds": ["user id"]}.
+// The entries are kept if the file can't be loaded.
+func (d *DenyList) LoadFile(path string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	entries := DenyListEntries{}
+	if err = json.Unmarshal(content, &entries); err != nil {
+		return err
+	}
+
+	d.Set(entries)
+	return nil
+}
+
+// WatchFile reloads the deny list when the modification time of the file changes,
+// the file is checked every interval until the returned stop function is called. Default interval: 10 seconds
+func (d *DenyList) WatchFile(path string, interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		interval = defaultDenyListReloadInterval
+	}
+
+	var modTime time.Time
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime()
+	}
+
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			info, err := os.Stat(path)
+			if err != nil || info.ModTime().Equal(modTime) {
+				continue
+			}
+			modTime = info.ModTime()
+
+			if err = d.LoadFile(path); err != nil {
+				logrus.Errorf("unable to reload deny list %s: %v", path, err)
+				continue
+			}
+			logrus.Infof("deny list %s is reloaded", path)
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(done)
+	}
+}
+
+// Count returns the number of requests rejected by the deny list since the service started
+func (d *DenyList) Count() DenyListCount {
+	return DenyListCount{
+		Tokens:  atomic.LoadUint64(&d.deniedTokens),
+		Clients: atomic.LoadUint64(&d.deniedClients),
+		Users:   atomic.LoadUint64(&d.deniedUsers),
+	}
+}
+
+// check returns error if the claims match any of the entries, a nil deny list accepts every claims
+func (d *DenyList) check(claims *iam.JWTClaims) error {
+	if d == nil {
+		return nil
+	}
+
+	set, ok := d.set.Load().(*denyListSet)
+	if !ok {
+		return nil
+	}
+
+	if _, denied := set.tokenIDs[claims.ID]; denied && claims.ID != "" {
+		atomic.AddUint64(&d.deniedTokens, 1)
+		return errDeniedToken
+	}
+	if _, denied := set.clientIDs[claims.ClientID]; denied && claims.ClientID != "" {
+		atomic.AddUint64(&d.deniedClients, 1)
+		return errDeniedClient
+	}
+	if _, denied := set.userIDs[claims.Subject]; denied && claims.Subject != "" {
+		atomic.AddUint64(&d.deniedUsers, 1)
+		return errDeniedUser
+	}
+
+	return nil
+}
+
+func toSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		set[value] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,103 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/go-jose/jwt"
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDenyList_Check(t *testing.T) {
+	t.Parallel()
+
+	denyList := NewDenyList()
+	denyList.Set(DenyListEntries{
+		TokenIDs:  []string{"jti1"},
+		ClientIDs: []string{"client1"},
+		UserIDs:   []string{"user1"},
+	})
+
+	assert.Equal(t, errDeniedToken, denyList.check(&iam.JWTClaims{Claims: jwt.Claims{ID: "jti1"}}))
+	assert.Equal(t, errDeniedClient, denyList.check(&iam.JWTClaims{ClientID: "client1"}))
+	assert.Equal(t, errDeniedUser, denyList.check(&iam.JWTClaims{Claims: jwt.Claims{Subject: "user1"}}))
+	assert.NoError(t, denyList.check(&iam.JWTClaims{Claims: jwt.Claims{ID: "jti2", Subject: "user2"}, ClientID: "client2"}))
+	assert.Equal(t, DenyListCount{Tokens: 1, Clients: 1, Users: 1}, denyList.Count())
+
+	var nilDenyList *DenyList
+	assert.NoError(t, nilDenyList.check(&iam.JWTClaims{ClientID: "client1"}))
+}
+
+func TestDenyList_WatchFile(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "denylist")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "denylist.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"userIds":["user1"]}`), 0600))
+
+	denyList := NewDenyList()
+	assert.NoError(t, denyList.LoadFile(path))
+	assert.Error(t, denyList.check(&iam.JWTClaims{Claims: jwt.Claims{Subject: "user1"}}))
+
+	stop := denyList.WatchFile(path, 5*time.Millisecond)
+	defer stop()
+
+	// the invalid file keeps the current entries
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{invalid`), 0600))
+	assert.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Second)))
+	time.Sleep(20 * time.Millisecond)
+	assert.Error(t, denyList.check(&iam.JWTClaims{Claims: jwt.Claims{Subject: "user1"}}))
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"userIds":["user2"]}`), 0600))
+	assert.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Second)))
+	assert.Eventually(t, func() bool {
+		return denyList.check(&iam.JWTClaims{Claims: jwt.Claims{Subject: "user1"}}) == nil
+	}, time.Second, 5*time.Millisecond)
+	assert.Error(t, denyList.check(&iam.JWTClaims{Claims: jwt.Claims{Subject: "user2"}}))
+}
+
+func TestDenyList_Filter(t *testing.T) {
+	t.Parallel()
+
+	iamClient := &issuerTestClient{MockClient: &iam.MockClient{}, tokens: map[string]string{
+		"token1": "user1",
+		"token2": "user2",
+	}}
+	denyList := NewDenyList()
+	denyList.Set(DenyListEntries{UserIDs: []string{"user1"}})
+
+	filter := NewFilterWithOptions(iamClient, &FilterInitializationOptions{DenyList: denyList})
+
+	resp, _ := serveMigrationRequest(filter, "token1")
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	resp, _ = serveMigrationRequest(filter, "token2")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	// the credential is allowed again once it's removed from the deny list
+	denyList.Set(DenyListEntries{})
+	resp, _ = serveMigrationRequest(filter, "token1")
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
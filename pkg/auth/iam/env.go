@@ -0,0 +1,38 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/envdoc"
+)
+
+const envPackage = "auth/iam"
+
+func init() {
+	envdoc.Register(
+		envdoc.Variable{Name: "SUBDOMAIN_VALIDATION_ENABLED", Package: envPackage, Type: envdoc.TypeBoolean, Default: "false",
+			Description: "Validate the request subdomain against the token namespace"},
+		envdoc.Variable{Name: "SUBDOMAIN_VALIDATION_EXCLUDED_NAMESPACES", Package: envPackage, Type: envdoc.TypeList,
+			Description: "Namespaces excluded from the subdomain validation"},
+		envdoc.Variable{Name: "INTROSPECTION_FALLBACK_ENABLED", Package: envPackage, Type: envdoc.TypeBoolean, Default: "false",
+			Description: "Fall back to the remote token introspection when the local validation fails"},
+		envdoc.Variable{Name: "REMEDIATION_HINTS_ENABLED", Package: envPackage, Type: envdoc.TypeBoolean, Default: "false",
+			Description: "Include the remediation hints in the 401 and 403 error responses"},
+		envdoc.Variable{Name: "LOCAL_VALIDATION_ENABLED", Package: envPackage, Type: envdoc.TypeBoolean, Default: "false",
+			Description: "Validate the token locally with the token cache"},
+		envdoc.Variable{Name: "DENY_LIST_FILE", Package: envPackage, Type: envdoc.TypeString,
+			Description: "Path of the deny list file, reloaded when it changes"},
+	)
+}
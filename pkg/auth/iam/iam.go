@@ -24,6 +24,7 @@ import (
 	"strings"
 
 	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/util"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/clock"
 	"github.com/AccelByte/go-restful-plugins/v4/pkg/constant"
 	"github.com/AccelByte/iam-go-sdk"
 	"github.com/emicklei/go-restful/v3"
@@ -44,22 +45,43 @@ type FilterOption func(req *restful.Request, iamClient iam.Client, claims *iam.J
 
 // FilterInitializationOptions hold options for Filter during initialization
 type FilterInitializationOptions struct {
-	StrictRefererHeaderValidation              bool     // Enable full path check of redirect uri in referer header validation
-	AllowSubdomainMatchRefererHeaderValidation bool     // Allow checking with subdomain
-	SubdomainValidationEnabled                 bool     // Enable subdomain validation. When it is true, it will match the subdomain in the request url against claims namespace.
-	SubdomainValidationExcludedNamespaces      []string // List of namespaces to be excluded for subdomain validation. When it is not emtpy and the SUBDOMAIN_VALIDATION_ENABLED is true, it will ignore specified namespaces when doing the subdomain validation.
+	StrictRefererHeaderValidation              bool                          // Enable full path check of redirect uri in referer header validation
+	AllowSubdomainMatchRefererHeaderValidation bool                          // Allow checking with subdomain
+	SubdomainValidationEnabled                 bool                          // Enable subdomain validation. When it is true, it will match the subdomain in the request url against claims namespace.
+	SubdomainValidationExcludedNamespaces      []string                      // List of namespaces to be excluded for subdomain validation. When it is not emtpy and the SUBDOMAIN_VALIDATION_ENABLED is true, it will ignore specified namespaces when doing the subdomain validation.
+	IntrospectionFallback                      *IntrospectionFallbackOptions // Enable remote token introspection when the local validation fails because of unknown key ID or clock difference. Disabled when it is nil.
+	MigrationIssuer                            *TokenIssuer                  // Additional issuer accepted when the token isn't accepted by the primary IAM client, used during IAM endpoint or signing key migration. Disabled when it is nil.
+	DenyList                                   *DenyList                     // Rejects the tokens of compromised token IDs, client IDs and user IDs. Disabled when it is nil.
+	LocalValidation                            *LocalValidationOptions       // Start the local validation of the IAM client and cache the validated tokens. Disabled when it is nil.
+	APIKey                                     *APIKeyOptions                // Accept the static API key on the routes accepting AuthModeAPIKey. Disabled when it is nil.
+	RemediationHints                           bool                          // Include the remediation hints (required permission, required scope, expired token) in the 401 and 403 error responses.
+	Clock                                      clock.Clock                   // Time source of the token caches, the introspection circuit breaker and the local validation start retry, e.g. a fake clock in the tests. Default: the system clock
 }
 
 // Filter handles auth using filter
 type Filter struct {
-	iamClient iam.Client
-	options   *FilterInitializationOptions
+	iamClient      iam.Client
+	options        *FilterInitializationOptions
+	introspector   *tokenIntrospector
+	localValidator *localValidator
 }
 
 // ErrorResponse is the generic structure for communicating errors from a REST endpoint.
 type ErrorResponse struct {
 	ErrorCode    int    `json:"errorCode"`
 	ErrorMessage string `json:"errorMessage"`
+
+	// The remediation hints, sent when FilterInitializationOptions.RemediationHints is enabled.
+	// RequiredPermission is the unsatisfied requirement in the expression format of ParseRequirement,
+	// with the {namespace} and {userId} placeholders resolved.
+	RequiredPermission string `json:"requiredPermission,omitempty"`
+	RequiredScope      string `json:"requiredScope,omitempty"`
+	TokenExpired       bool   `json:"tokenExpired,omitempty"`
+}
+
+// withoutRemediationHints returns the copy of the error response without the remediation hints
+func (e ErrorResponse) withoutRemediationHints() ErrorResponse {
+	return ErrorResponse{ErrorCode: e.ErrorCode, ErrorMessage: e.ErrorMessage}
 }
 
 // NewFilter creates new Filter instance
@@ -80,7 +102,14 @@ func NewFilterWithOptions(client iam.Client, options *FilterInitializationOption
 	if options == nil {
 		return &Filter{iamClient: client, options: &FilterInitializationOptions{}}
 	}
-	return &Filter{iamClient: client, options: options}
+	filter := &Filter{iamClient: client, options: options}
+	if options.IntrospectionFallback != nil {
+		filter.introspector = newTokenIntrospector(client, options.IntrospectionFallback, options.Clock)
+	}
+	if options.LocalValidation != nil {
+		filter.localValidator = newLocalValidator(client, options.LocalValidation, options.Clock)
+	}
+	return filter
 }
 
 func FilterInitializationOptionsFromEnv() *FilterInitializationOptions {
@@ -103,12 +132,51 @@ func FilterInitializationOptionsFromEnv() *FilterInitializationOptions {
 		options.SubdomainValidationExcludedNamespaces = strings.Split(s, ",")
 	}
 
+	if s, exists := os.LookupEnv("INTROSPECTION_FALLBACK_ENABLED"); exists {
+		value, err := strconv.ParseBool(s)
+		if err != nil {
+			logrus.Errorf("Parse INTROSPECTION_FALLBACK_ENABLED env error: %v", err)
+		}
+		if value {
+			options.IntrospectionFallback = &IntrospectionFallbackOptions{}
+		}
+	}
+
+	if s, exists := os.LookupEnv("REMEDIATION_HINTS_ENABLED"); exists {
+		value, err := strconv.ParseBool(s)
+		if err != nil {
+			logrus.Errorf("Parse REMEDIATION_HINTS_ENABLED env error: %v", err)
+		}
+		options.RemediationHints = value
+	}
+
+	if s, exists := os.LookupEnv("LOCAL_VALIDATION_ENABLED"); exists {
+		value, err := strconv.ParseBool(s)
+		if err != nil {
+			logrus.Errorf("Parse LOCAL_VALIDATION_ENABLED env error: %v", err)
+		}
+		if value {
+			options.LocalValidation = &LocalValidationOptions{}
+		}
+	}
+
+	if path, exists := os.LookupEnv("DENY_LIST_FILE"); exists && path != "" {
+		options.DenyList = NewDenyList()
+		if err := options.DenyList.LoadFile(path); err != nil {
+			logrus.Errorf("Load DENY_LIST_FILE error: %v", err)
+		}
+		options.DenyList.WatchFile(path, 0)
+	}
+
 	return options
 }
 
 // Auth returns a filter that filters request with valid access token in auth header or cookie
 // The token's claims will be passed in the request.attributes["JWTClaims"] = *iam.JWTClaims{}
-// This filter is expandable through FilterOption parameter
+// This filter is expandable through FilterOption parameter,
+// and enforces the permission declared in the route's PermissionMetadata.
+// The route accepts the user and client tokens by default, see RouteAuthModes to accept the API key instead.
+// The API key request skips the FilterOption and the route permission since it has no claims.
 // Example:
 // iam.Auth(
 //
@@ -118,6 +186,25 @@ func FilterInitializationOptionsFromEnv() *FilterInitializationOptions {
 // )
 func (filter *Filter) Auth(opts ...FilterOption) restful.FilterFunction {
 	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		modes := getRouteAuthModes(req)
+		if hasAuthMode(modes, AuthModeAPIKey) {
+			if apiKey := req.HeaderParameter(filter.apiKeyHeader()); apiKey != "" {
+				if err := filter.validateAPIKey(apiKey); err != nil {
+					logrus.Warn("unauthorized access: ", err)
+					logIfErr(resp.WriteHeaderAndJson(http.StatusUnauthorized, ErrorResponse{
+						ErrorCode:    UnauthorizedAccess,
+						ErrorMessage: ErrorCodeMapping[UnauthorizedAccess],
+					}, restful.MIME_JSON))
+
+					return
+				}
+
+				req.SetAttribute(AuthModeAttribute, AuthModeAPIKey)
+				chain.ProcessFilter(req, resp)
+				return
+			}
+		}
+
 		token, tokenFrom, err := parseAccessToken(req)
 		if err != nil {
 			logrus.Warn("unauthorized access: ", err)
@@ -129,13 +216,17 @@ func (filter *Filter) Auth(opts ...FilterOption) restful.FilterFunction {
 			return
 		}
 
-		claims, err := filter.iamClient.ValidateAndParseClaims(token)
+		claims, iamClient, issuer, err := filter.validateToken(token)
+		if err == nil {
+			err = filter.options.DenyList.check(claims)
+		}
 		if err != nil {
 			logrus.Warn("unauthorized access: ", err)
 			if err.Error() == ErrorCodeMapping[TokenIsExpired] {
 				logIfErr(resp.WriteHeaderAndJson(http.StatusUnauthorized, ErrorResponse{
 					ErrorCode:    TokenIsExpired,
 					ErrorMessage: ErrorCodeMapping[TokenIsExpired],
+					TokenExpired: filter.options.RemediationHints,
 				}, restful.MIME_JSON))
 				return
 			}
@@ -147,6 +238,23 @@ func (filter *Filter) Auth(opts ...FilterOption) restful.FilterFunction {
 		}
 
 		req.SetAttribute(ClaimsAttribute, claims)
+		req.SetAttribute(TokenIssuerAttribute, issuer)
+		countTokenIssuer(issuer)
+
+		mode := tokenAuthMode(claims)
+		if !hasAuthMode(modes, mode) {
+			errorCode := ForbiddenAccess
+			if mode == AuthModeClient {
+				errorCode = TokenIsNotUserToken
+			}
+			logIfErr(resp.WriteHeaderAndJson(http.StatusForbidden, ErrorResponse{
+				ErrorCode:    errorCode,
+				ErrorMessage: "access forbidden: " + ErrorCodeMapping[errorCode],
+			}, restful.MIME_JSON))
+
+			return
+		}
+		req.SetAttribute(AuthModeAttribute, mode)
 
 		if tokenFrom == tokenFromCookie {
 			valid := filter.validateRefererHeader(req, claims)
@@ -171,8 +279,8 @@ func (filter *Filter) Auth(opts ...FilterOption) restful.FilterFunction {
 			}
 		}
 
-		for _, opt := range opts {
-			if err = opt(req, filter.iamClient, claims); err != nil {
+		for _, opt := range withRouteRequirement(req, opts) {
+			if err = opt(req, iamClient, claims); err != nil {
 				if svcErr, ok := err.(restful.ServiceError); ok {
 					logrus.Warn(svcErr.Message)
 
@@ -180,6 +288,9 @@ func (filter *Filter) Auth(opts ...FilterOption) restful.FilterFunction {
 
 					err = json.Unmarshal([]byte(svcErr.Message), &respErr)
 					if err == nil {
+						if !filter.options.RemediationHints {
+							respErr = respErr.withoutRemediationHints()
+						}
 						logIfErr(resp.WriteHeaderAndJson(svcErr.Code, respErr, restful.MIME_JSON))
 					} else {
 						logIfErr(resp.WriteErrorString(svcErr.Code, svcErr.Message))
@@ -218,7 +329,10 @@ func (filter *Filter) PublicAuth(opts ...FilterOption) restful.FilterFunction {
 			return
 		}
 
-		claims, err := filter.iamClient.ValidateAndParseClaims(token)
+		claims, iamClient, issuer, err := filter.validateToken(token)
+		if err == nil {
+			err = filter.options.DenyList.check(claims)
+		}
 		if err != nil {
 			logrus.Warn("unauthorized access for public endpoint: ", err)
 			chain.ProcessFilter(req, resp)
@@ -226,6 +340,8 @@ func (filter *Filter) PublicAuth(opts ...FilterOption) restful.FilterFunction {
 		}
 
 		req.SetAttribute(ClaimsAttribute, claims)
+		req.SetAttribute(TokenIssuerAttribute, issuer)
+		countTokenIssuer(issuer)
 
 		if tokenFrom == tokenFromCookie {
 			valid := filter.validateRefererHeader(req, claims)
@@ -237,7 +353,7 @@ func (filter *Filter) PublicAuth(opts ...FilterOption) restful.FilterFunction {
 		}
 
 		for _, opt := range opts {
-			if err = opt(req, filter.iamClient, claims); err != nil {
+			if err = opt(req, iamClient, claims); err != nil {
 				logrus.Warn(err)
 				req.SetAttribute(ClaimsAttribute, nil)
 				chain.ProcessFilter(req, resp)
@@ -245,10 +361,38 @@ func (filter *Filter) PublicAuth(opts ...FilterOption) restful.FilterFunction {
 			}
 		}
 
+		req.SetAttribute(AuthModeAttribute, tokenAuthMode(claims))
 		chain.ProcessFilter(req, resp)
 	}
 }
 
+// validateAndParseClaims validates the token locally, using the cached result if the local validation is enabled,
+// falling back to the remote token introspection if it's enabled and the local validation error is recoverable.
+// The local validation error is returned if the token can't be validated remotely.
+func (filter *Filter) validateAndParseClaims(token string) (*iam.JWTClaims, error) {
+	if filter.localValidator != nil {
+		if claims, ok := filter.localValidator.get(token); ok {
+			return claims, nil
+		}
+	}
+
+	claims, err := filter.iamClient.ValidateAndParseClaims(token)
+	if err == nil && filter.localValidator != nil {
+		filter.localValidator.store(token, claims)
+	}
+	if err == nil || filter.introspector == nil || !shouldFallback(err) {
+		return claims, err
+	}
+
+	introspectedClaims, introspectionErr := filter.introspector.introspect(token)
+	if introspectionErr != nil {
+		logrus.Warnf("token introspection fallback failed: %v", introspectionErr)
+		return nil, err
+	}
+
+	return introspectedClaims, nil
+}
+
 // RetrieveJWTClaims is a convenience function to retrieve JWT claims
 // from restful.Request.
 // Warning: the claims can be nil if the request wasn't filtered through Auth()
@@ -272,25 +416,42 @@ func WithValidUser() FilterOption {
 // WithPermission filters request with valid permission only
 func WithPermission(permission *iam.Permission) FilterOption {
 	return func(req *restful.Request, iamClient iam.Client, claims *iam.JWTClaims) error {
-		requiredPermissionResources := make(map[string]string)
-		requiredPermissionResources["{namespace}"] = req.PathParameter("namespace")
-		requiredPermissionResources["{userId}"] = req.PathParameter("userId")
-
-		valid, err := iamClient.ValidatePermission(claims, *permission, requiredPermissionResources)
+		valid, err := validatePermission(req, iamClient, claims, permission)
 		if err != nil {
 			return respondError(http.StatusInternalServerError, InternalServerError,
 				"unable to validate permission: "+err.Error())
 		}
 
 		if !valid {
-			return respondError(http.StatusForbidden, InsufficientPermissions,
-				"access forbidden: "+ErrorCodeMapping[InsufficientPermissions])
+			return respondErrorResponse(http.StatusForbidden, ErrorResponse{
+				ErrorCode:          InsufficientPermissions,
+				ErrorMessage:       "access forbidden: " + ErrorCodeMapping[InsufficientPermissions],
+				RequiredPermission: resolvePlaceholders(req, RequirePermission(permission).String()),
+			})
 		}
 
 		return nil
 	}
 }
 
+// resolvePlaceholders resolves the {namespace} and {userId} placeholders of the requirement expression
+func resolvePlaceholders(req *restful.Request, expression string) string {
+	return strings.NewReplacer(
+		"{namespace}", pathParameter(req, "namespace"),
+		"{userId}", pathParameter(req, "userId"),
+	).Replace(expression)
+}
+
+// validatePermission validates the permission, resolving the {namespace} and {userId} resource placeholders
+// from the path parameters
+func validatePermission(req *restful.Request, iamClient iam.Client, claims *iam.JWTClaims, permission *iam.Permission) (bool, error) {
+	requiredPermissionResources := make(map[string]string)
+	requiredPermissionResources["{namespace}"] = pathParameter(req, "namespace")
+	requiredPermissionResources["{userId}"] = pathParameter(req, "userId")
+
+	return iamClient.ValidatePermission(claims, *permission, requiredPermissionResources)
+}
+
 // WithRole filters request with valid role only
 func WithRole(role string) FilterOption {
 	return func(req *restful.Request, iamClient iam.Client, claims *iam.JWTClaims) error {
@@ -345,8 +506,11 @@ func WithValidScope(scope string) FilterOption {
 	return func(req *restful.Request, iamClient iam.Client, claims *iam.JWTClaims) error {
 		err := iamClient.ValidateScope(claims, scope)
 		if err != nil {
-			return respondError(http.StatusForbidden, InsufficientScope,
-				"access forbidden: "+ErrorCodeMapping[InsufficientScope])
+			return respondErrorResponse(http.StatusForbidden, ErrorResponse{
+				ErrorCode:     InsufficientScope,
+				ErrorMessage:  "access forbidden: " + ErrorCodeMapping[InsufficientScope],
+				RequiredScope: scope,
+			})
 		}
 
 		return nil
@@ -484,10 +648,11 @@ func logIfErr(err error) {
 }
 
 func respondError(httpStatus, errorCode int, errorMessage string) restful.ServiceError {
-	messageByte, err := json.Marshal(ErrorResponse{
-		ErrorCode:    errorCode,
-		ErrorMessage: errorMessage,
-	})
+	return respondErrorResponse(httpStatus, ErrorResponse{ErrorCode: errorCode, ErrorMessage: errorMessage})
+}
+
+func respondErrorResponse(httpStatus int, errorResponse ErrorResponse) restful.ServiceError {
+	messageByte, err := json.Marshal(errorResponse)
 	if err != nil {
 		errMsgByte, _ := json.Marshal(ErrorResponse{
 			ErrorCode:    InternalServerError,
@@ -15,7 +15,10 @@
 package iam
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"testing"
@@ -855,3 +858,95 @@ func TestFilterInitializationOptionsFromEnv_SubdomainValidationExcludedNamespace
 	options = FilterInitializationOptionsFromEnv()
 	assert.Empty(t, options.SubdomainValidationExcludedNamespaces)
 }
+
+// nolint:paralleltest
+func TestFilterInitializationOptionsFromEnv_RemediationHints(t *testing.T) {
+	os.Setenv("REMEDIATION_HINTS_ENABLED", "true")
+	defer os.Unsetenv("REMEDIATION_HINTS_ENABLED")
+
+	assert.True(t, FilterInitializationOptionsFromEnv().RemediationHints)
+}
+
+// remediationTestClient rejects the expired token and the scopes other than "read"
+type remediationTestClient struct {
+	*routePermissionTestClient
+}
+
+func (c *remediationTestClient) ValidateAndParseClaims(accessToken string, opts ...iam.Option) (*iam.JWTClaims, error) {
+	if accessToken == "expired" {
+		return nil, errors.New(ErrorCodeMapping[TokenIsExpired])
+	}
+	return c.routePermissionTestClient.ValidateAndParseClaims(accessToken, opts...)
+}
+
+func (c *remediationTestClient) ValidateScope(claims *iam.JWTClaims, scope string, opts ...iam.Option) error {
+	if scope != "read" {
+		return errors.New("invalid scope")
+	}
+	return nil
+}
+
+func serveRemediation(t *testing.T, hints bool, token string, path string) ErrorResponse {
+	t.Helper()
+
+	client := &remediationTestClient{&routePermissionTestClient{
+		MockClient: &iam.MockClient{Healthy: true},
+		resources:  map[string]bool{},
+	}}
+	filter := NewFilterWithOptions(client, &FilterInitializationOptions{RemediationHints: hints})
+
+	ws := new(restful.WebService)
+	ws.Route(ws.GET("/namespaces/{namespace}/users/{userId}").
+		Filter(filter.Auth()).
+		Do(RoutePermission("ADMIN:NAMESPACE:{namespace}:USER:{userId}", iam.ActionRead)).
+		To(func(request *restful.Request, response *restful.Response) {}))
+	ws.Route(ws.GET("/namespaces/{namespace}/items").
+		Filter(filter.Auth(WithPermission(&iam.Permission{Resource: "ADMIN:NAMESPACE:{namespace}:ITEM", Action: iam.ActionRead}))).
+		To(func(request *restful.Request, response *restful.Response) {}))
+	ws.Route(ws.GET("/scoped").
+		Filter(filter.Auth(WithValidScope("write"))).
+		To(func(request *restful.Request, response *restful.Response) {}))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, req)
+
+	var errorResponse ErrorResponse
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &errorResponse))
+	return errorResponse
+}
+
+func TestAuth_RemediationHints(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, ErrorResponse{
+		ErrorCode:          InsufficientPermissions,
+		ErrorMessage:       "access forbidden: " + ErrorCodeMapping[InsufficientPermissions],
+		RequiredPermission: "perm:ADMIN:NAMESPACE:accelbyte:USER:user1:READ",
+	}, serveRemediation(t, true, "user1", "/namespaces/accelbyte/users/user1"))
+
+	assert.Equal(t, "perm:ADMIN:NAMESPACE:accelbyte:ITEM:READ",
+		serveRemediation(t, true, "user1", "/namespaces/accelbyte/items").RequiredPermission)
+
+	assert.Equal(t, "write", serveRemediation(t, true, "user1", "/scoped").RequiredScope)
+
+	expired := serveRemediation(t, true, "expired", "/scoped")
+	assert.Equal(t, TokenIsExpired, expired.ErrorCode)
+	assert.True(t, expired.TokenExpired)
+}
+
+func TestAuth_RemediationHintsDisabled(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, ErrorResponse{
+		ErrorCode:    InsufficientPermissions,
+		ErrorMessage: "access forbidden: " + ErrorCodeMapping[InsufficientPermissions],
+	}, serveRemediation(t, false, "user1", "/namespaces/accelbyte/users/user1"))
+
+	assert.Empty(t, serveRemediation(t, false, "user1", "/scoped").RequiredScope)
+	assert.False(t, serveRemediation(t, false, "expired", "/scoped").TokenExpired)
+}
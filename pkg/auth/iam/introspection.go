@@ -0,0 +1,199 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AccelByte/go-jose/jwt"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/clock"
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultIntrospectionCacheTTL         = time.Minute
+	defaultIntrospectionCacheSize        = 10000
+	defaultIntrospectionFailureThreshold = 5
+	defaultIntrospectionOpenDuration     = 30 * time.Second
+
+	// the local validation error messages of iam-go-sdk which are worth a remote validation
+	errMessageUnknownKeyID = "public key doesn't exist"
+)
+
+var (
+	errIntrospectionCircuitOpen = errors.New("token introspection is temporarily unavailable")
+	errIntrospectionInvalid     = errors.New("token is invalid according to the token introspection")
+)
+
+// IntrospectionFallbackOptions configures the remote token introspection used
+// when the local token validation fails because of unknown signing key ID (e.g. during JWKS rotation)
+// or clock difference (the token is not valid yet).
+type IntrospectionFallbackOptions struct {
+	CacheTTL         time.Duration // How long the introspection result is cached, capped by the token expiry. Default: 1 minute
+	CacheSize        int           // Maximum number of cached introspection results. Default: 10000
+	FailureThreshold int           // Consecutive introspection failures opening the circuit. Default: 5
+	OpenDuration     time.Duration // How long the introspection is skipped after the circuit is open. Default: 30 seconds
+}
+
+type introspectionResult struct {
+	claims    *iam.JWTClaims
+	expiresAt time.Time
+}
+
+// tokenIntrospector validates the token remotely with caching and circuit breaking,
+// so an IAM outage doesn't make every request wait for the remote validation.
+type tokenIntrospector struct {
+	iamClient iam.Client
+	options   IntrospectionFallbackOptions
+	clock     clock.Clock
+
+	mutex               sync.Mutex
+	cache               map[string]introspectionResult
+	consecutiveFailures int
+	openUntil           time.Time
+}
+
+func newTokenIntrospector(iamClient iam.Client, options *IntrospectionFallbackOptions, c clock.Clock) *tokenIntrospector {
+	introspector := &tokenIntrospector{
+		iamClient: iamClient,
+		options:   *options,
+		clock:     clock.OrSystem(c),
+		cache:     map[string]introspectionResult{},
+	}
+	if introspector.options.CacheTTL <= 0 {
+		introspector.options.CacheTTL = defaultIntrospectionCacheTTL
+	}
+	if introspector.options.CacheSize <= 0 {
+		introspector.options.CacheSize = defaultIntrospectionCacheSize
+	}
+	if introspector.options.FailureThreshold <= 0 {
+		introspector.options.FailureThreshold = defaultIntrospectionFailureThreshold
+	}
+	if introspector.options.OpenDuration <= 0 {
+		introspector.options.OpenDuration = defaultIntrospectionOpenDuration
+	}
+	return introspector
+}
+
+// shouldFallback checks whether the local validation error might be resolved by the remote validation
+func shouldFallback(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, errMessageUnknownKeyID) || strings.Contains(message, jwt.ErrNotValidYet.Error())
+}
+
+// introspect validates the token remotely and returns its claims
+func (i *tokenIntrospector) introspect(token string) (*iam.JWTClaims, error) {
+	key := hashToken(token)
+	now := i.clock.Now()
+
+	i.mutex.Lock()
+	if result, ok := i.cache[key]; ok && now.Before(result.expiresAt) {
+		i.mutex.Unlock()
+		if result.claims == nil {
+			return nil, errIntrospectionInvalid
+		}
+		return result.claims, nil
+	}
+	if now.Before(i.openUntil) {
+		i.mutex.Unlock()
+		return nil, errIntrospectionCircuitOpen
+	}
+	i.mutex.Unlock()
+
+	claims, err := parseClaimsWithoutVerification(token)
+	if err != nil {
+		return nil, err
+	}
+
+	valid, err := i.iamClient.ValidateAccessToken(token)
+
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if err != nil {
+		i.consecutiveFailures++
+		if i.consecutiveFailures >= i.options.FailureThreshold {
+			logrus.Warnf("token introspection failed %d times in a row, skipping it for %s",
+				i.consecutiveFailures, i.options.OpenDuration)
+			i.openUntil = now.Add(i.options.OpenDuration)
+			i.consecutiveFailures = 0
+		}
+		return nil, err
+	}
+	i.consecutiveFailures = 0
+
+	if !valid {
+		claims = nil
+	}
+	i.store(key, claims, now)
+
+	if claims == nil {
+		return nil, errIntrospectionInvalid
+	}
+	return claims, nil
+}
+
+func (i *tokenIntrospector) store(key string, claims *iam.JWTClaims, now time.Time) {
+	if len(i.cache) >= i.options.CacheSize {
+		for cachedKey, result := range i.cache {
+			if !now.Before(result.expiresAt) {
+				delete(i.cache, cachedKey)
+			}
+		}
+		if len(i.cache) >= i.options.CacheSize {
+			return
+		}
+	}
+
+	expiresAt := now.Add(i.options.CacheTTL)
+	if claims != nil && claims.Expiry != 0 && claims.Expiry.Time().Before(expiresAt) {
+		expiresAt = claims.Expiry.Time()
+	}
+	i.cache[key] = introspectionResult{claims: claims, expiresAt: expiresAt}
+}
+
+// parseClaimsWithoutVerification decodes the claims of the token without verifying its signature,
+// the token must be validated remotely before the claims are trusted.
+func parseClaimsWithoutVerification(token string) (*iam.JWTClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("unable to parse JWT: invalid format")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, errors.New("unable to parse JWT: " + err.Error())
+	}
+
+	claims := &iam.JWTClaims{}
+	if err = json.Unmarshal(payload, claims); err != nil {
+		return nil, errors.New("unable to parse JWT claims: " + err.Error())
+	}
+
+	return claims, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
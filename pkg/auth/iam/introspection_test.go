@@ -0,0 +1,209 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/clock"
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// introspectionTestClient fails the local validation and validates the token remotely
+type introspectionTestClient struct {
+	*iam.MockClient
+	localErr    error
+	remoteValid bool
+	remoteErr   error
+	remoteCalls int
+}
+
+func (c *introspectionTestClient) ValidateAndParseClaims(accessToken string, opts ...iam.Option) (*iam.JWTClaims, error) {
+	return nil, c.localErr
+}
+
+func (c *introspectionTestClient) ValidateAccessToken(accessToken string, opts ...iam.Option) (bool, error) {
+	c.remoteCalls++
+	return c.remoteValid, c.remoteErr
+}
+
+func newIntrospectionTestClient() *introspectionTestClient {
+	return &introspectionTestClient{
+		MockClient:  &iam.MockClient{Healthy: true},
+		localErr:    errors.New("validateJWT: invalid key: getPublicKey: public key doesn't exist"),
+		remoteValid: true,
+	}
+}
+
+func createUnsignedToken(payload string) string {
+	encoding := base64.RawURLEncoding
+	return encoding.EncodeToString([]byte(`{"alg":"RS256","kid":"rotated"}`)) + "." +
+		encoding.EncodeToString([]byte(payload)) + "." + encoding.EncodeToString([]byte("signature"))
+}
+
+func serveWithAuth(filter *Filter, token string) (*httptest.ResponseRecorder, *iam.JWTClaims) {
+	var claims *iam.JWTClaims
+
+	ws := new(restful.WebService)
+	ws.Filter(filter.Auth())
+	ws.Route(ws.GET("/").To(func(request *restful.Request, response *restful.Response) {
+		claims = RetrieveJWTClaims(request)
+	}))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, req)
+
+	return resp, claims
+}
+
+func TestIntrospectionFallback(t *testing.T) {
+	t.Parallel()
+
+	client := newIntrospectionTestClient()
+	filter := NewFilterWithOptions(client, &FilterInitializationOptions{
+		IntrospectionFallback: &IntrospectionFallbackOptions{},
+	})
+	token := createUnsignedToken(`{"sub":"user1","namespace":"accelbyte","client_id":"client1"}`)
+
+	resp, claims := serveWithAuth(filter, token)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "user1", claims.Subject)
+	assert.Equal(t, "accelbyte", claims.Namespace)
+
+	// the result is cached
+	resp, _ = serveWithAuth(filter, token)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, 1, client.remoteCalls)
+}
+
+func TestIntrospectionFallback_InvalidToken(t *testing.T) {
+	t.Parallel()
+
+	client := newIntrospectionTestClient()
+	client.remoteValid = false
+	filter := NewFilterWithOptions(client, &FilterInitializationOptions{
+		IntrospectionFallback: &IntrospectionFallbackOptions{},
+	})
+
+	resp, _ := serveWithAuth(filter, createUnsignedToken(`{"sub":"user1"}`))
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	// the invalid result is cached too
+	resp, _ = serveWithAuth(filter, createUnsignedToken(`{"sub":"user1"}`))
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Equal(t, 1, client.remoteCalls)
+}
+
+func TestIntrospectionFallback_NotRecoverableError(t *testing.T) {
+	t.Parallel()
+
+	client := newIntrospectionTestClient()
+	client.localErr = errors.New("token has been revoked")
+	filter := NewFilterWithOptions(client, &FilterInitializationOptions{
+		IntrospectionFallback: &IntrospectionFallbackOptions{},
+	})
+
+	resp, _ := serveWithAuth(filter, createUnsignedToken(`{"sub":"user1"}`))
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Equal(t, 0, client.remoteCalls)
+}
+
+func TestIntrospectionFallback_Disabled(t *testing.T) {
+	t.Parallel()
+
+	client := newIntrospectionTestClient()
+	filter := NewFilter(client)
+
+	resp, _ := serveWithAuth(filter, createUnsignedToken(`{"sub":"user1"}`))
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.Equal(t, 0, client.remoteCalls)
+}
+
+func TestTokenIntrospector_CircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	client := newIntrospectionTestClient()
+	client.remoteErr = errors.New("iam is unavailable")
+	fakeClock := clock.NewFake(time.Now())
+	introspector := newTokenIntrospector(client, &IntrospectionFallbackOptions{
+		FailureThreshold: 2,
+		OpenDuration:     time.Minute,
+	}, fakeClock)
+	token := createUnsignedToken(`{"sub":"user1"}`)
+
+	for i := 0; i < 2; i++ {
+		_, err := introspector.introspect(token)
+		assert.Equal(t, client.remoteErr, err)
+	}
+
+	// the circuit is open
+	_, err := introspector.introspect(token)
+	assert.Equal(t, errIntrospectionCircuitOpen, err)
+	assert.Equal(t, 2, client.remoteCalls)
+
+	// the circuit is closed again after the open duration
+	client.remoteErr = nil
+	fakeClock.Advance(time.Minute)
+	claims, err := introspector.introspect(token)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", claims.Subject)
+}
+
+func TestTokenIntrospector_CacheExpiry(t *testing.T) {
+	t.Parallel()
+
+	client := newIntrospectionTestClient()
+	fakeClock := clock.NewFake(time.Unix(1600000000, 0))
+	introspector := newTokenIntrospector(client, &IntrospectionFallbackOptions{CacheTTL: time.Hour}, fakeClock)
+
+	// the cache entry expires with the token
+	token := createUnsignedToken(`{"sub":"user1","exp":1600000060}`)
+	_, err := introspector.introspect(token)
+	assert.NoError(t, err)
+
+	fakeClock.Advance(30 * time.Second)
+	_, err = introspector.introspect(token)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, client.remoteCalls)
+
+	fakeClock.Advance(time.Minute)
+	_, _ = introspector.introspect(token)
+	assert.Equal(t, 2, client.remoteCalls)
+}
+
+func TestParseClaimsWithoutVerification_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := parseClaimsWithoutVerification("invalid")
+	assert.Error(t, err)
+
+	_, err = parseClaimsWithoutVerification("a.!!!.c")
+	assert.Error(t, err)
+
+	_, err = parseClaimsWithoutVerification(createUnsignedToken("not json"))
+	assert.Error(t, err)
+}
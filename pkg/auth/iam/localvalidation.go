@@ -0,0 +1,217 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/clock"
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultLocalValidationCacheTTL      = time.Minute
+	defaultLocalValidationCacheSize     = 10000
+	defaultLocalValidationRetryInterval = 10 * time.Second
+	// the default RevocationListRefreshInterval of iam.Config
+	defaultRevocationListRefreshInterval = 60 * time.Second
+)
+
+// LocalValidationOptions configures the local token validation, where the token is validated against the JWKS
+// and revocation list cached by the IAM client instead of calling the IAM service on every request.
+// The JWKS and revocation list are refreshed in the background by the IAM client every JWKSRefreshInterval
+// and RevocationListRefreshInterval of iam.Config.
+type LocalValidationOptions struct {
+	CacheTTL                      time.Duration // How long a validated token is trusted without validating it again, capped by the token expiry and RevocationListRefreshInterval. Default: 1 minute
+	CacheSize                     int           // Maximum number of cached validated tokens. Default: 10000
+	RetryInterval                 time.Duration // Interval of retrying to start the local validation when the IAM service is unreachable. Default: 10 seconds
+	RevocationListRefreshInterval time.Duration // RevocationListRefreshInterval of iam.Config, so the revoked token isn't trusted longer than the revocation list is stale. Default: 60 seconds
+}
+
+// LocalValidationStats is the state of the local validation cache
+type LocalValidationStats struct {
+	Active bool   // Whether the JWKS and revocation list are loaded
+	Hits   uint64 // Number of tokens found in the cache
+	Misses uint64 // Number of tokens validated by the IAM client
+	Size   int    // Number of cached tokens
+}
+
+type localValidationResult struct {
+	claims    *iam.JWTClaims
+	expiresAt time.Time
+}
+
+// localValidator starts the local validation of the IAM client and caches the validated tokens,
+// so the signature of the same token isn't verified on every request.
+type localValidator struct {
+	// the atomic counters come first to keep them 64-bit aligned on 32-bit platforms
+	hits   uint64
+	misses uint64
+	active int32
+
+	options LocalValidationOptions
+	clock   clock.Clock
+
+	mutex sync.Mutex
+	cache map[string]localValidationResult
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+func newLocalValidator(iamClient iam.Client, options *LocalValidationOptions, c clock.Clock) *localValidator {
+	validator := &localValidator{
+		options: *options,
+		clock:   clock.OrSystem(c),
+		cache:   map[string]localValidationResult{},
+		stop:    make(chan struct{}),
+	}
+	if validator.options.CacheTTL <= 0 {
+		validator.options.CacheTTL = defaultLocalValidationCacheTTL
+	}
+	if validator.options.CacheSize <= 0 {
+		validator.options.CacheSize = defaultLocalValidationCacheSize
+	}
+	if validator.options.RetryInterval <= 0 {
+		validator.options.RetryInterval = defaultLocalValidationRetryInterval
+	}
+	if validator.options.RevocationListRefreshInterval <= 0 {
+		validator.options.RevocationListRefreshInterval = defaultRevocationListRefreshInterval
+	}
+	// the cached token isn't checked against the revocation list, so it's validated again once the list is refreshed
+	if validator.options.CacheTTL > validator.options.RevocationListRefreshInterval {
+		logrus.Warnf("IAM local validation cache TTL %s is capped by the revocation list refresh interval %s",
+			validator.options.CacheTTL, validator.options.RevocationListRefreshInterval)
+		validator.options.CacheTTL = validator.options.RevocationListRefreshInterval
+	}
+
+	if err := iamClient.StartLocalValidation(); err != nil {
+		logrus.Errorf("unable to start IAM local validation, retrying every %s: %v",
+			validator.options.RetryInterval, err)
+		go validator.retryStart(iamClient)
+	} else {
+		atomic.StoreInt32(&validator.active, 1)
+	}
+
+	return validator
+}
+
+// retryStart starts the local validation in the background until it succeeds or the validator is stopped
+func (v *localValidator) retryStart(iamClient iam.Client) {
+	for {
+		select {
+		case <-v.stop:
+			return
+		case <-clock.After(v.clock, v.options.RetryInterval):
+		}
+		// the retry interval may pass together with the stop, the stop wins
+		select {
+		case <-v.stop:
+			return
+		default:
+		}
+
+		if err := iamClient.StartLocalValidation(); err != nil {
+			logrus.Errorf("unable to start IAM local validation: %v", err)
+			continue
+		}
+
+		atomic.StoreInt32(&v.active, 1)
+		logrus.Info("IAM local validation is started")
+		return
+	}
+}
+
+// stopRetry stops retrying to start the local validation
+func (v *localValidator) stopRetry() {
+	v.stopOnce.Do(func() {
+		close(v.stop)
+	})
+}
+
+// get returns the claims of the validated token from the cache
+func (v *localValidator) get(token string) (*iam.JWTClaims, bool) {
+	key := hashToken(token)
+
+	v.mutex.Lock()
+	result, ok := v.cache[key]
+	v.mutex.Unlock()
+
+	if !ok || !v.clock.Now().Before(result.expiresAt) {
+		atomic.AddUint64(&v.misses, 1)
+		return nil, false
+	}
+
+	atomic.AddUint64(&v.hits, 1)
+	return result.claims, true
+}
+
+// store caches the claims of the validated token
+func (v *localValidator) store(token string, claims *iam.JWTClaims) {
+	now := v.clock.Now()
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if len(v.cache) >= v.options.CacheSize {
+		for cachedKey, result := range v.cache {
+			if !now.Before(result.expiresAt) {
+				delete(v.cache, cachedKey)
+			}
+		}
+		if len(v.cache) >= v.options.CacheSize {
+			return
+		}
+	}
+
+	expiresAt := now.Add(v.options.CacheTTL)
+	if claims.Expiry != 0 && claims.Expiry.Time().Before(expiresAt) {
+		expiresAt = claims.Expiry.Time()
+	}
+	v.cache[hashToken(token)] = localValidationResult{claims: claims, expiresAt: expiresAt}
+}
+
+func (v *localValidator) stats() LocalValidationStats {
+	v.mutex.Lock()
+	size := len(v.cache)
+	v.mutex.Unlock()
+
+	return LocalValidationStats{
+		Active: atomic.LoadInt32(&v.active) == 1,
+		Hits:   atomic.LoadUint64(&v.hits),
+		Misses: atomic.LoadUint64(&v.misses),
+		Size:   size,
+	}
+}
+
+// LocalValidationStats returns the state of the local validation cache,
+// or the zero stats when the local validation option isn't enabled
+func (filter *Filter) LocalValidationStats() LocalValidationStats {
+	if filter.localValidator == nil {
+		return LocalValidationStats{}
+	}
+	return filter.localValidator.stats()
+}
+
+// StopLocalValidationRetry stops retrying to start the local validation in the background,
+// e.g. on the shutdown of the service whose IAM service is unreachable. It's safe to call more than once.
+func (filter *Filter) StopLocalValidationRetry() {
+	if filter.localValidator != nil {
+		filter.localValidator.stopRetry()
+	}
+}
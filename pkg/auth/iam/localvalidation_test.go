@@ -0,0 +1,199 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/go-jose/jwt"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/clock"
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+// localValidationTestClient counts the local validations, its local validation can't be started
+// until the failed starts are used up
+type localValidationTestClient struct {
+	*iam.MockClient
+	failedStarts int32
+	starts       int32
+	validations  int32
+	expiry       time.Time
+}
+
+func (c *localValidationTestClient) StartLocalValidation(opts ...iam.Option) error {
+	atomic.AddInt32(&c.starts, 1)
+	if atomic.AddInt32(&c.failedStarts, -1) >= 0 {
+		return errors.New("IAM is unreachable")
+	}
+	return nil
+}
+
+func (c *localValidationTestClient) ValidateAndParseClaims(accessToken string, opts ...iam.Option) (*iam.JWTClaims, error) {
+	atomic.AddInt32(&c.validations, 1)
+	if accessToken == "invalid" {
+		return nil, errors.New("invalid token")
+	}
+	return &iam.JWTClaims{Claims: jwt.Claims{Subject: accessToken, Expiry: jwt.NewNumericDate(c.expiry)}}, nil
+}
+
+func newLocalValidationTestClient() *localValidationTestClient {
+	return &localValidationTestClient{
+		MockClient: &iam.MockClient{Healthy: true},
+		expiry:     time.Now().Add(time.Hour),
+	}
+}
+
+func TestLocalValidation_Cache(t *testing.T) {
+	t.Parallel()
+
+	client := newLocalValidationTestClient()
+	filter := NewFilterWithOptions(client, &FilterInitializationOptions{
+		LocalValidation: &LocalValidationOptions{},
+	})
+	assert.Equal(t, int32(1), client.starts)
+
+	for i := 0; i < 3; i++ {
+		resp, claims := serveWithAuth(filter, "user1")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "user1", claims.Subject)
+	}
+	assert.Equal(t, int32(1), client.validations)
+
+	// the invalid token isn't cached
+	for i := 0; i < 2; i++ {
+		resp, _ := serveWithAuth(filter, "invalid")
+		assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	}
+	assert.Equal(t, int32(3), client.validations)
+
+	assert.Equal(t, LocalValidationStats{Active: true, Hits: 2, Misses: 3, Size: 1}, filter.LocalValidationStats())
+}
+
+func TestLocalValidation_CacheExpiry(t *testing.T) {
+	t.Parallel()
+
+	client := newLocalValidationTestClient()
+	fakeClock := clock.NewFake(time.Now())
+	filter := NewFilterWithOptions(client, &FilterInitializationOptions{
+		LocalValidation: &LocalValidationOptions{CacheTTL: time.Hour, RevocationListRefreshInterval: time.Hour},
+		Clock:           fakeClock,
+	})
+
+	serveWithAuth(filter, "user1")
+
+	// the cached token is validated again after the TTL, so the revoked token is rejected
+	fakeClock.Advance(30 * time.Minute)
+	serveWithAuth(filter, "user1")
+	assert.Equal(t, int32(1), client.validations)
+
+	fakeClock.Advance(31 * time.Minute)
+	serveWithAuth(filter, "user1")
+	assert.Equal(t, int32(2), client.validations)
+
+	// the token expiry caps the TTL
+	client.expiry = fakeClock.Now().Add(time.Minute)
+	filter.localValidator.cache = map[string]localValidationResult{}
+	serveWithAuth(filter, "user2")
+	fakeClock.Advance(2 * time.Minute)
+	serveWithAuth(filter, "user2")
+	assert.Equal(t, int32(4), client.validations)
+}
+
+func TestLocalValidation_CacheTTLCappedByRevocationRefresh(t *testing.T) {
+	t.Parallel()
+
+	client := newLocalValidationTestClient()
+	fakeClock := clock.NewFake(time.Now())
+	filter := NewFilterWithOptions(client, &FilterInitializationOptions{
+		LocalValidation: &LocalValidationOptions{CacheTTL: time.Hour},
+		Clock:           fakeClock,
+	})
+
+	// the revoked token is validated again once the revocation list is refreshed, not after the whole TTL
+	serveWithAuth(filter, "user1")
+	fakeClock.Advance(defaultRevocationListRefreshInterval)
+	serveWithAuth(filter, "user1")
+	assert.Equal(t, int32(2), client.validations)
+}
+
+func TestLocalValidation_CacheSize(t *testing.T) {
+	t.Parallel()
+
+	client := newLocalValidationTestClient()
+	filter := NewFilterWithOptions(client, &FilterInitializationOptions{
+		LocalValidation: &LocalValidationOptions{CacheSize: 1},
+	})
+
+	serveWithAuth(filter, "user1")
+	serveWithAuth(filter, "user2")
+	assert.Equal(t, 1, filter.LocalValidationStats().Size)
+}
+
+func TestLocalValidation_RetryStart(t *testing.T) {
+	t.Parallel()
+
+	client := newLocalValidationTestClient()
+	client.failedStarts = 2
+	fakeClock := clock.NewFake(time.Now())
+	filter := NewFilterWithOptions(client, &FilterInitializationOptions{
+		LocalValidation: &LocalValidationOptions{RetryInterval: time.Minute},
+		Clock:           fakeClock,
+	})
+	assert.False(t, filter.LocalValidationStats().Active)
+
+	// the start is retried every retry interval of the clock
+	for i := 0; i < 2; i++ {
+		assert.Eventually(t, func() bool { return fakeClock.Waiters() == 1 }, time.Second, time.Millisecond)
+		assert.False(t, filter.LocalValidationStats().Active)
+		fakeClock.Advance(time.Minute)
+	}
+
+	assert.Eventually(t, func() bool { return filter.LocalValidationStats().Active }, time.Second, time.Millisecond)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&client.starts))
+	assert.Equal(t, 0, fakeClock.Waiters())
+}
+
+func TestLocalValidation_StopRetry(t *testing.T) {
+	t.Parallel()
+
+	client := newLocalValidationTestClient()
+	client.failedStarts = 1000
+	fakeClock := clock.NewFake(time.Now())
+	filter := NewFilterWithOptions(client, &FilterInitializationOptions{
+		LocalValidation: &LocalValidationOptions{RetryInterval: time.Minute},
+		Clock:           fakeClock,
+	})
+	assert.Eventually(t, func() bool { return fakeClock.Waiters() == 1 }, time.Second, time.Millisecond)
+
+	filter.StopLocalValidationRetry()
+	filter.StopLocalValidationRetry()
+
+	// the stopped retry doesn't start the local validation anymore
+	fakeClock.Advance(time.Hour)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&client.starts))
+	assert.False(t, filter.LocalValidationStats().Active)
+}
+
+func TestLocalValidation_Disabled(t *testing.T) {
+	t.Parallel()
+
+	filter := NewFilterWithOptions(newLocalValidationTestClient(), &FilterInitializationOptions{})
+	assert.Equal(t, LocalValidationStats{}, filter.LocalValidationStats())
+}
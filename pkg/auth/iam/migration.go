@@ -0,0 +1,84 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/AccelByte/iam-go-sdk"
+)
+
+const (
+	// TokenIssuerAttribute is the key for the name of the issuer which accepted the token, stored in the request
+	TokenIssuerAttribute = "TokenIssuer"
+
+	// PrimaryTokenIssuer is the name of the issuer configured by the filter's IAM client
+	PrimaryTokenIssuer = "primary"
+
+	defaultMigrationTokenIssuer = "migration"
+)
+
+// TokenIssuer is an additional IAM configuration accepted by the filter,
+// used to migrate the IAM endpoint or the signing keys without downtime.
+type TokenIssuer struct {
+	Name   string     // Name of the issuer in TokenIssuerAttribute and TokenIssuerCount. Default: "migration"
+	Client iam.Client // IAM client of the issuer, ready to do the local token validation
+}
+
+var tokenIssuerCounts sync.Map
+
+// TokenIssuerCount returns the number of requests accepted by the issuer since the service started,
+// e.g. iam.TokenIssuerCount(iam.PrimaryTokenIssuer). It's used to decide when the migration is completed.
+func TokenIssuerCount(name string) uint64 {
+	if count, ok := tokenIssuerCounts.Load(name); ok {
+		return atomic.LoadUint64(count.(*uint64))
+	}
+	return 0
+}
+
+func countTokenIssuer(name string) {
+	count, _ := tokenIssuerCounts.LoadOrStore(name, new(uint64))
+	atomic.AddUint64(count.(*uint64), 1)
+}
+
+func (issuer *TokenIssuer) name() string {
+	if issuer.Name == "" {
+		return defaultMigrationTokenIssuer
+	}
+	return issuer.Name
+}
+
+// validateToken validates the token by the primary issuer and then the migration issuer (if any),
+// returning the claims along with the IAM client and the name of the issuer which accepted the token.
+// The primary issuer's error is returned if none of them accepts the token.
+func (filter *Filter) validateToken(token string) (*iam.JWTClaims, iam.Client, string, error) {
+	claims, err := filter.validateAndParseClaims(token)
+	if err == nil {
+		return claims, filter.iamClient, PrimaryTokenIssuer, nil
+	}
+
+	migrationIssuer := filter.options.MigrationIssuer
+	if migrationIssuer == nil || migrationIssuer.Client == nil {
+		return nil, nil, "", err
+	}
+
+	migrationClaims, migrationErr := migrationIssuer.Client.ValidateAndParseClaims(token)
+	if migrationErr != nil {
+		return nil, nil, "", err
+	}
+
+	return migrationClaims, migrationIssuer.Client, migrationIssuer.name(), nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AccelByte/go-jose/jwt"
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// issuerTestClient accepts the listed tokens only
+type issuerTestClient struct {
+	*iam.MockClient
+	tokens      map[string]string
+	permissions int
+}
+
+func (c *issuerTestClient) ValidateAndParseClaims(accessToken string, opts ...iam.Option) (*iam.JWTClaims, error) {
+	if subject, ok := c.tokens[accessToken]; ok {
+		return &iam.JWTClaims{Claims: jwt.Claims{Subject: subject}}, nil
+	}
+	return nil, errors.New("validateJWT: invalid key: getPublicKey: public key doesn't exist")
+}
+
+func (c *issuerTestClient) ValidatePermission(claims *iam.JWTClaims, permission iam.Permission,
+	resources map[string]string, opts ...iam.Option) (bool, error) {
+	c.permissions++
+	return true, nil
+}
+
+func serveMigrationRequest(filter *Filter, token string, opts ...FilterOption) (*httptest.ResponseRecorder, interface{}) {
+	var issuer interface{}
+
+	ws := new(restful.WebService)
+	ws.Filter(filter.Auth(opts...))
+	ws.Route(ws.GET("/").To(func(request *restful.Request, response *restful.Response) {
+		issuer = request.Attribute(TokenIssuerAttribute)
+	}))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, req)
+
+	return resp, issuer
+}
+
+// nolint:paralleltest
+func TestMigrationIssuer(t *testing.T) {
+	primaryClient := &issuerTestClient{MockClient: &iam.MockClient{}, tokens: map[string]string{"new-token": "user1"}}
+	legacyClient := &issuerTestClient{MockClient: &iam.MockClient{}, tokens: map[string]string{"old-token": "user2"}}
+
+	filter := NewFilterWithOptions(primaryClient, &FilterInitializationOptions{
+		MigrationIssuer: &TokenIssuer{Name: "legacy", Client: legacyClient},
+	})
+	permission := WithPermission(&iam.Permission{Resource: "NAMESPACE:{namespace}:USER", Action: iam.ActionRead})
+
+	primaryCount := TokenIssuerCount(PrimaryTokenIssuer)
+	legacyCount := TokenIssuerCount("legacy")
+
+	resp, issuer := serveMigrationRequest(filter, "new-token", permission)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, PrimaryTokenIssuer, issuer)
+	assert.Equal(t, 1, primaryClient.permissions)
+
+	// the permission of the legacy token is validated by the legacy IAM
+	resp, issuer = serveMigrationRequest(filter, "old-token", permission)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "legacy", issuer)
+	assert.Equal(t, 1, legacyClient.permissions)
+
+	resp, _ = serveMigrationRequest(filter, "unknown-token")
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	assert.Equal(t, primaryCount+1, TokenIssuerCount(PrimaryTokenIssuer))
+	assert.Equal(t, legacyCount+1, TokenIssuerCount("legacy"))
+}
+
+func TestMigrationIssuer_DefaultName(t *testing.T) {
+	t.Parallel()
+
+	primaryClient := &issuerTestClient{MockClient: &iam.MockClient{}}
+	migrationClient := &issuerTestClient{MockClient: &iam.MockClient{}, tokens: map[string]string{"token": "user1"}}
+
+	filter := NewFilterWithOptions(primaryClient, &FilterInitializationOptions{
+		MigrationIssuer: &TokenIssuer{Client: migrationClient},
+	})
+
+	claims, iamClient, issuer, err := filter.validateToken("token")
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", claims.Subject)
+	assert.Equal(t, migrationClient, iamClient)
+	assert.Equal(t, "migration", issuer)
+}
@@ -0,0 +1,109 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"net/http"
+
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ResourceNamespaceAttribute is the request attribute key of the namespace of the requested resource,
+	// set by NamespaceFilter and printed as the namespace field of the access log
+	ResourceNamespaceAttribute = "IAMResourceNamespace"
+
+	// AnyNamespace is the namespace of the namespace role granted in all namespaces
+	AnyNamespace = "*"
+
+	defaultNamespaceParameter = "namespace"
+)
+
+// NamespaceOptions contains options for NamespaceFilter
+type NamespaceOptions struct {
+	// Parameter is the path parameter of the namespace. Default: namespace
+	Parameter string
+	// CrossNamespaceRoles are the role IDs of the token's roles accessing any namespace, e.g. the platform admin role
+	CrossNamespaceRoles []string
+	// Allow is called when the namespace doesn't match the token's namespace nor its namespace roles,
+	// e.g. to allow the studio namespace accessing its game namespaces. Optional
+	Allow func(claims *iam.JWTClaims, namespace string) bool
+}
+
+// NamespaceFilter extracts the namespace path parameter, stores it in ResourceNamespaceAttribute
+// and rejects the request with 403 when the token can't access the namespace.
+// The token can access its own namespace, the namespaces of its namespace roles (or all namespaces with "*"),
+// and all namespaces if it has one of the CrossNamespaceRoles. It must be registered after the Auth filter,
+// the request without the JWT claims (e.g. the anonymous request of PublicAuth) isn't enforced.
+func NamespaceFilter(options *NamespaceOptions) restful.FilterFunction {
+	if options == nil {
+		options = &NamespaceOptions{}
+	}
+	parameter := options.Parameter
+	if parameter == "" {
+		parameter = defaultNamespaceParameter
+	}
+	crossNamespaceRoles := make(map[string]bool, len(options.CrossNamespaceRoles))
+	for _, roleID := range options.CrossNamespaceRoles {
+		crossNamespaceRoles[roleID] = true
+	}
+
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		namespace := req.PathParameter(parameter)
+		if namespace == "" {
+			chain.ProcessFilter(req, resp)
+			return
+		}
+		req.SetAttribute(ResourceNamespaceAttribute, namespace)
+
+		claims := RetrieveJWTClaims(req)
+		if claims != nil && !canAccessNamespace(claims, namespace, crossNamespaceRoles, options.Allow) {
+			logrus.Warnf("access forbidden: token of namespace %s can't access namespace %s", claims.Namespace, namespace)
+			logIfErr(resp.WriteHeaderAndJson(http.StatusForbidden, ErrorResponse{
+				ErrorCode:    ForbiddenAccess,
+				ErrorMessage: "access forbidden: namespace mismatch",
+			}, restful.MIME_JSON))
+			return
+		}
+
+		chain.ProcessFilter(req, resp)
+	}
+}
+
+// ResourceNamespace returns the namespace of the requested resource set by NamespaceFilter
+func ResourceNamespace(req *restful.Request) string {
+	namespace, _ := req.Attribute(ResourceNamespaceAttribute).(string)
+	return namespace
+}
+
+func canAccessNamespace(claims *iam.JWTClaims, namespace string, crossNamespaceRoles map[string]bool,
+	allow func(claims *iam.JWTClaims, namespace string) bool) bool {
+	if claims.Namespace == namespace {
+		return true
+	}
+	for _, role := range claims.NamespaceRoles {
+		if role.Namespace == namespace || role.Namespace == AnyNamespace {
+			return true
+		}
+	}
+	for _, roleID := range claims.Roles {
+		if crossNamespaceRoles[roleID] {
+			return true
+		}
+	}
+	return allow != nil && allow(claims, namespace)
+}
@@ -0,0 +1,122 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveNamespace(t *testing.T, options *NamespaceOptions, claims *iam.JWTClaims, path string) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+
+	var resourceNamespace string
+	ws := new(restful.WebService)
+	ws.Filter(func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		if claims != nil {
+			req.SetAttribute(ClaimsAttribute, claims)
+		}
+		chain.ProcessFilter(req, resp)
+	})
+	ws.Filter(NamespaceFilter(options))
+	handler := func(request *restful.Request, response *restful.Response) {
+		resourceNamespace = ResourceNamespace(request)
+	}
+	ws.Route(ws.GET("/namespaces/{namespace}/users").To(handler))
+	ws.Route(ws.GET("/games/{game}/users").To(handler))
+	ws.Route(ws.GET("/users").To(handler))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, path, nil))
+	return resp, resourceNamespace
+}
+
+func TestNamespaceFilter(t *testing.T) {
+	t.Parallel()
+
+	studioOf := map[string]string{"game": "studio"}
+	options := &NamespaceOptions{
+		CrossNamespaceRoles: []string{"platform-admin"},
+		Allow: func(claims *iam.JWTClaims, namespace string) bool {
+			return claims.StudioNamespace != "" && studioOf[namespace] == claims.StudioNamespace
+		},
+	}
+
+	testCases := []struct {
+		name   string
+		claims *iam.JWTClaims
+		status int
+	}{
+		{name: "own namespace", claims: &iam.JWTClaims{Namespace: "game"}, status: http.StatusOK},
+		{name: "other namespace", claims: &iam.JWTClaims{Namespace: "other"}, status: http.StatusForbidden},
+		{
+			name:   "namespace role",
+			claims: &iam.JWTClaims{Namespace: "publisher", NamespaceRoles: []iam.NamespaceRole{{RoleID: "r", Namespace: "game"}}},
+			status: http.StatusOK,
+		},
+		{
+			name:   "any namespace role",
+			claims: &iam.JWTClaims{Namespace: "publisher", NamespaceRoles: []iam.NamespaceRole{{RoleID: "r", Namespace: AnyNamespace}}},
+			status: http.StatusOK,
+		},
+		{
+			name:   "namespace role of other namespace",
+			claims: &iam.JWTClaims{Namespace: "publisher", NamespaceRoles: []iam.NamespaceRole{{RoleID: "r", Namespace: "other"}}},
+			status: http.StatusForbidden,
+		},
+		{name: "cross namespace role", claims: &iam.JWTClaims{Namespace: "publisher", Roles: []string{"platform-admin"}}, status: http.StatusOK},
+		{name: "custom allow", claims: &iam.JWTClaims{Namespace: "publisher", StudioNamespace: "studio"}, status: http.StatusOK},
+		{name: "anonymous", status: http.StatusOK},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp, resourceNamespace := serveNamespace(t, options, testCase.claims, "/namespaces/game/users")
+			assert.Equal(t, testCase.status, resp.Code)
+			if testCase.status == http.StatusOK {
+				assert.Equal(t, "game", resourceNamespace)
+				return
+			}
+
+			var errorResponse ErrorResponse
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &errorResponse))
+			assert.Equal(t, ForbiddenAccess, errorResponse.ErrorCode)
+		})
+	}
+}
+
+func TestNamespaceFilter_Parameter(t *testing.T) {
+	t.Parallel()
+
+	resp, _ := serveNamespace(t, &NamespaceOptions{Parameter: "game"}, &iam.JWTClaims{Namespace: "other"}, "/games/game/users")
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+
+	resp, resourceNamespace := serveNamespace(t, nil, &iam.JWTClaims{Namespace: "other"}, "/users")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Empty(t, resourceNamespace)
+}
@@ -0,0 +1,145 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// PreviewTokenHeader is the request header of the access token previewed by PermissionPreviewHandler,
+	// the caller's own token is previewed when it's empty
+	PreviewTokenHeader = "X-Preview-Token"
+
+	// previewPathParametersAttribute is the request attribute of the path parameters resolving
+	// the resource placeholders of the previewed permissions
+	previewPathParametersAttribute = "IAMPreviewPathParameters"
+)
+
+// PermissionPreview is the response of PermissionPreviewHandler
+type PermissionPreview struct {
+	Subject   string                   `json:"subject,omitempty"`
+	ClientID  string                   `json:"clientId,omitempty"`
+	Namespace string                   `json:"namespace,omitempty"`
+	AuthMode  AuthMode                 `json:"authMode"`
+	Routes    []RoutePermissionPreview `json:"routes"`
+}
+
+// RoutePermissionPreview is whether the token satisfies the permission of a route
+type RoutePermissionPreview struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Operation   string `json:"operation,omitempty"`
+	Requirement string `json:"requirement"`
+	Satisfied   bool   `json:"satisfied"`
+	// Reason is the reason the permission isn't satisfied
+	Reason string `json:"reason,omitempty"`
+}
+
+// PermissionPreviewHandler returns the route function listing the routes of the container declaring
+// their permission with RoutePermission or RouteRequirement, and whether the token satisfies each of them.
+// It helps finding out why a request is rejected with 403. The previewed token is read from X-Preview-Token header,
+// or the caller's own token is previewed. The {namespace} and {userId} placeholders are resolved from
+// the query parameters of the same name, defaulting to the namespace and the subject of the token.
+// The route must be protected, e.g.
+//
+//	ws.Route(ws.GET("/debug/permissions").
+//		Filter(filter.Auth(iam.WithPermission(&iamSDK.Permission{Resource: "ADMIN:DEBUG", Action: iamSDK.ActionRead}))).
+//		To(filter.PermissionPreviewHandler(restful.DefaultContainer)))
+func (filter *Filter) PermissionPreviewHandler(container *restful.Container) restful.RouteFunction {
+	return func(req *restful.Request, resp *restful.Response) {
+		token := strings.TrimPrefix(req.HeaderParameter(PreviewTokenHeader), "Bearer ")
+		if token == "" {
+			token, _, _ = parseAccessToken(req)
+		}
+
+		claims, iamClient, _, err := filter.validateToken(token)
+		if err == nil {
+			err = filter.options.DenyList.check(claims)
+		}
+		if err != nil {
+			logrus.Warn("unable to preview permissions: ", err)
+			logIfErr(resp.WriteHeaderAndJson(http.StatusBadRequest, ErrorResponse{
+				ErrorCode:    UnauthorizedAccess,
+				ErrorMessage: "previewed token is invalid: " + err.Error(),
+			}, restful.MIME_JSON))
+			return
+		}
+
+		logIfErr(resp.WriteAsJson(previewPermissions(req, container, iamClient, claims)))
+	}
+}
+
+func previewPermissions(req *restful.Request, container *restful.Container, iamClient iam.Client,
+	claims *iam.JWTClaims) *PermissionPreview {
+	mode := tokenAuthMode(claims)
+	preview := &PermissionPreview{
+		Subject:   claims.Subject,
+		ClientID:  claims.ClientID,
+		Namespace: claims.Namespace,
+		AuthMode:  mode,
+		Routes:    make([]RoutePermissionPreview, 0),
+	}
+
+	pathParameters := map[string]string{"namespace": claims.Namespace, "userId": claims.Subject}
+	for name := range pathParameters {
+		if value := req.QueryParameter(name); value != "" {
+			pathParameters[name] = value
+		}
+	}
+	previewReq := restful.NewRequest(req.Request)
+	previewReq.SetAttribute(previewPathParametersAttribute, pathParameters)
+
+	for _, ws := range container.RegisteredWebServices() {
+		for _, route := range ws.Routes() {
+			requirement := routeRequirement(route.Metadata)
+			if requirement == nil {
+				continue
+			}
+
+			routePreview := RoutePermissionPreview{
+				Method:      route.Method,
+				Path:        route.Path,
+				Operation:   route.Operation,
+				Requirement: requirement.String(),
+			}
+			if !hasAuthMode(routeAuthModes(route.Metadata), mode) {
+				routePreview.Reason = "auth mode " + string(mode) + " is not accepted"
+			} else if satisfied, err := requirement.Evaluate(previewReq, iamClient, claims); err != nil {
+				routePreview.Reason = "unable to validate requirement: " + err.Error()
+			} else if !satisfied {
+				routePreview.Reason = "requirement is not satisfied"
+			} else {
+				routePreview.Satisfied = true
+			}
+			preview.Routes = append(preview.Routes, routePreview)
+		}
+	}
+
+	return preview
+}
+
+// pathParameter returns the path parameter of the request, or the one given to the permission preview
+func pathParameter(req *restful.Request, name string) string {
+	if pathParameters, ok := req.Attribute(previewPathParametersAttribute).(map[string]string); ok {
+		return pathParameters[name]
+	}
+	return req.PathParameter(name)
+}
@@ -0,0 +1,132 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func servePermissionPreview(t *testing.T, path string, previewToken string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	client := &routePermissionTestClient{
+		MockClient: &iam.MockClient{Healthy: true},
+		resources:  map[string]bool{"ADMIN:NAMESPACE:accelbyte:USER:user1": true, "ADMIN:DEBUG": true},
+	}
+	filter := NewFilter(client)
+	container := restful.NewContainer()
+
+	ws := new(restful.WebService)
+	ws.Filter(filter.Auth())
+	ws.Route(ws.GET("/namespaces/{namespace}/users/{userId}").
+		Operation("getUser").
+		Do(RoutePermission("ADMIN:NAMESPACE:{namespace}:USER:{userId}", iam.ActionRead)).
+		To(func(request *restful.Request, response *restful.Response) {}))
+	ws.Route(ws.DELETE("/namespaces/{namespace}/users/{userId}").
+		Do(RoutePermission("ADMIN:NAMESPACE:{namespace}:USER:{userId}", iam.ActionDelete)).
+		Do(RouteAuthModes(AuthModeClient)).
+		To(func(request *restful.Request, response *restful.Response) {}))
+	ws.Route(ws.GET("/namespaces/{namespace}/items").
+		Do(RoutePermission("ADMIN:NAMESPACE:{namespace}:ITEM", iam.ActionRead)).
+		To(func(request *restful.Request, response *restful.Response) {}))
+	ws.Route(ws.GET("/public").
+		To(func(request *restful.Request, response *restful.Response) {}))
+	ws.Route(ws.GET("/debug/permissions").
+		Do(RoutePermission("ADMIN:DEBUG", iam.ActionRead)).
+		To(filter.PermissionPreviewHandler(container)))
+	container.Add(ws)
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer admin")
+	if previewToken != "" {
+		req.Header.Set(PreviewTokenHeader, previewToken)
+	}
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, req)
+
+	return resp
+}
+
+func TestPermissionPreviewHandler(t *testing.T) {
+	t.Parallel()
+
+	resp := servePermissionPreview(t, "/debug/permissions?namespace=accelbyte", "user1")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var preview PermissionPreview
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &preview))
+
+	assert.Equal(t, "user1", preview.Subject)
+	assert.Equal(t, AuthModeUser, preview.AuthMode)
+	assert.Equal(t, []RoutePermissionPreview{
+		{
+			Method:      http.MethodGet,
+			Path:        "/namespaces/{namespace}/users/{userId}",
+			Operation:   "getUser",
+			Requirement: "perm:ADMIN:NAMESPACE:{namespace}:USER:{userId}:READ",
+			Satisfied:   true,
+		},
+		{
+			Method:      http.MethodDelete,
+			Path:        "/namespaces/{namespace}/users/{userId}",
+			Operation:   preview.Routes[1].Operation,
+			Requirement: "perm:ADMIN:NAMESPACE:{namespace}:USER:{userId}:DELETE",
+			Reason:      "auth mode user is not accepted",
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/namespaces/{namespace}/items",
+			Operation:   preview.Routes[2].Operation,
+			Requirement: "perm:ADMIN:NAMESPACE:{namespace}:ITEM:READ",
+			Reason:      "requirement is not satisfied",
+		},
+		{
+			Method:      http.MethodGet,
+			Path:        "/debug/permissions",
+			Operation:   preview.Routes[3].Operation,
+			Requirement: "perm:ADMIN:DEBUG:READ",
+			Satisfied:   true,
+		},
+	}, preview.Routes)
+}
+
+func TestPermissionPreviewHandler_CallerToken(t *testing.T) {
+	t.Parallel()
+
+	resp := servePermissionPreview(t, "/debug/permissions?namespace=accelbyte&userId=user1", "")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var preview PermissionPreview
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &preview))
+
+	assert.Equal(t, "admin", preview.Subject)
+	assert.True(t, preview.Routes[0].Satisfied)
+}
+
+func TestPermissionPreviewHandler_InvalidToken(t *testing.T) {
+	t.Parallel()
+
+	resp := servePermissionPreview(t, "/debug/permissions", iam.MockUnauthorized)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
@@ -0,0 +1,358 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+)
+
+const (
+	rolePrefix       = "role:"
+	permissionPrefix = "perm:"
+)
+
+var actionNames = map[string]int{
+	"CREATE": iam.ActionCreate,
+	"READ":   iam.ActionRead,
+	"UPDATE": iam.ActionUpdate,
+	"DELETE": iam.ActionDelete,
+}
+
+// Requirement is a composable access requirement of a route,
+// e.g. a permission, a role, or a combination of them using And, Or and Not.
+type Requirement interface {
+	// Evaluate checks whether the claims satisfy the requirement
+	Evaluate(req *restful.Request, iamClient iam.Client, claims *iam.JWTClaims) (bool, error)
+	// String returns the requirement in the expression format accepted by ParseRequirement
+	String() string
+}
+
+// RequirePermission creates a requirement of a permission.
+// The {namespace} and {userId} placeholders in the resource are resolved from the path parameters.
+func RequirePermission(permission *iam.Permission) Requirement {
+	return &permissionRequirement{permission: permission}
+}
+
+// RequireRole creates a requirement of a role
+func RequireRole(roleID string) Requirement {
+	return &roleRequirement{roleID: roleID}
+}
+
+// And creates a requirement which is satisfied when all the requirements are satisfied.
+// It's never satisfied without any requirement, so an empty list doesn't grant the access.
+func And(requirements ...Requirement) Requirement {
+	return &compositeRequirement{operator: "AND", requirements: requirements}
+}
+
+// Or creates a requirement which is satisfied when any of the requirements is satisfied.
+// It's never satisfied without any requirement.
+func Or(requirements ...Requirement) Requirement {
+	return &compositeRequirement{operator: "OR", requirements: requirements}
+}
+
+// Not creates a requirement which is satisfied when the requirement is not satisfied
+func Not(requirement Requirement) Requirement {
+	return &notRequirement{requirement: requirement}
+}
+
+// WithRequirement filters request satisfying the composite requirement only
+// Example:
+//
+//	iam.WithRequirement(iam.And(
+//		iam.RequirePermission(&iamSDK.Permission{Resource: "ADMIN:NAMESPACE:{namespace}:USER", Action: iamSDK.ActionRead}),
+//		iam.Or(iam.RequireRole(supportRoleID), iam.RequireRole(auditorRoleID)),
+//	))
+func WithRequirement(requirement Requirement) FilterOption {
+	return func(req *restful.Request, iamClient iam.Client, claims *iam.JWTClaims) error {
+		valid, err := requirement.Evaluate(req, iamClient, claims)
+		if err != nil {
+			return respondError(http.StatusInternalServerError, InternalServerError,
+				"unable to validate requirement: "+err.Error())
+		}
+
+		if !valid {
+			return respondErrorResponse(http.StatusForbidden, ErrorResponse{
+				ErrorCode:          InsufficientPermissions,
+				ErrorMessage:       "access forbidden: " + ErrorCodeMapping[InsufficientPermissions],
+				RequiredPermission: resolvePlaceholders(req, requirement.String()),
+			})
+		}
+
+		return nil
+	}
+}
+
+// WithRequirementExpression filters request satisfying the requirement expression only.
+// It panics if the expression is invalid, since the filter is constructed when the routes are registered.
+// See ParseRequirement for the expression format.
+func WithRequirementExpression(expression string, operands map[string]Requirement) FilterOption {
+	requirement, err := ParseRequirement(expression, operands)
+	if err != nil {
+		panic(err)
+	}
+	return WithRequirement(requirement)
+}
+
+type permissionRequirement struct {
+	permission *iam.Permission
+}
+
+func (r *permissionRequirement) Evaluate(req *restful.Request, iamClient iam.Client, claims *iam.JWTClaims) (bool, error) {
+	return validatePermission(req, iamClient, claims, r.permission)
+}
+
+func (r *permissionRequirement) String() string {
+	actions := make([]string, 0)
+	for _, name := range []string{"CREATE", "READ", "UPDATE", "DELETE"} {
+		if r.permission.Action&actionNames[name] != 0 {
+			actions = append(actions, name)
+		}
+	}
+	return permissionPrefix + r.permission.Resource + ":" + strings.Join(actions, "|")
+}
+
+type roleRequirement struct {
+	roleID string
+}
+
+func (r *roleRequirement) Evaluate(req *restful.Request, iamClient iam.Client, claims *iam.JWTClaims) (bool, error) {
+	return iamClient.ValidateRole(r.roleID, claims)
+}
+
+func (r *roleRequirement) String() string {
+	return rolePrefix + r.roleID
+}
+
+type compositeRequirement struct {
+	operator     string
+	requirements []Requirement
+}
+
+func (r *compositeRequirement) Evaluate(req *restful.Request, iamClient iam.Client, claims *iam.JWTClaims) (bool, error) {
+	// fail closed, e.g. And(requirements...) with a list built at runtime that ended up empty
+	if len(r.requirements) == 0 {
+		return false, nil
+	}
+
+	for _, requirement := range r.requirements {
+		valid, err := requirement.Evaluate(req, iamClient, claims)
+		if err != nil {
+			return false, err
+		}
+		// short-circuit the evaluation, so the remaining requirements don't hit IAM
+		if r.operator == "AND" && !valid {
+			return false, nil
+		}
+		if r.operator == "OR" && valid {
+			return true, nil
+		}
+	}
+	return r.operator == "AND", nil
+}
+
+func (r *compositeRequirement) String() string {
+	terms := make([]string, 0, len(r.requirements))
+	for _, requirement := range r.requirements {
+		term := requirement.String()
+		if _, ok := requirement.(*compositeRequirement); ok {
+			term = "(" + term + ")"
+		}
+		terms = append(terms, term)
+	}
+	return strings.Join(terms, " "+r.operator+" ")
+}
+
+type notRequirement struct {
+	requirement Requirement
+}
+
+func (r *notRequirement) Evaluate(req *restful.Request, iamClient iam.Client, claims *iam.JWTClaims) (bool, error) {
+	valid, err := r.requirement.Evaluate(req, iamClient, claims)
+	if err != nil {
+		return false, err
+	}
+	return !valid, nil
+}
+
+func (r *notRequirement) String() string {
+	if _, ok := r.requirement.(*compositeRequirement); ok {
+		return "NOT (" + r.requirement.String() + ")"
+	}
+	return "NOT " + r.requirement.String()
+}
+
+// ParseRequirement parses the requirement expression, e.g. "PERM_A AND (PERM_B OR ROLE_C)".
+// The operators are AND, OR and NOT (case-insensitive), AND has higher precedence than OR,
+// and the parentheses can be used for grouping.
+// An operand is either:
+//   - a name of the operands map, e.g. "PERM_A"
+//   - a role literal, e.g. "role:2251438839e948d783ec0e5281daf05b"
+//   - a permission literal of resource and actions, e.g. "perm:ADMIN:NAMESPACE:{namespace}:USER:READ|UPDATE"
+func ParseRequirement(expression string, operands map[string]Requirement) (Requirement, error) {
+	parser := &requirementParser{tokens: tokenizeRequirement(expression), operands: operands}
+	if len(parser.tokens) == 0 {
+		return nil, fmt.Errorf("invalid requirement expression: empty expression")
+	}
+
+	requirement, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos < len(parser.tokens) {
+		return nil, fmt.Errorf("invalid requirement expression: unexpected %q", parser.tokens[parser.pos])
+	}
+
+	return requirement, nil
+}
+
+func tokenizeRequirement(expression string) []string {
+	expression = strings.ReplaceAll(expression, "(", " ( ")
+	expression = strings.ReplaceAll(expression, ")", " ) ")
+	return strings.Fields(expression)
+}
+
+type requirementParser struct {
+	tokens   []string
+	pos      int
+	operands map[string]Requirement
+}
+
+func (p *requirementParser) peek() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *requirementParser) parseOr() (Requirement, error) {
+	requirements, err := p.parseList("OR", p.parseAnd)
+	if err != nil {
+		return nil, err
+	}
+	if len(requirements) == 1 {
+		return requirements[0], nil
+	}
+	return Or(requirements...), nil
+}
+
+func (p *requirementParser) parseAnd() (Requirement, error) {
+	requirements, err := p.parseList("AND", p.parseUnary)
+	if err != nil {
+		return nil, err
+	}
+	if len(requirements) == 1 {
+		return requirements[0], nil
+	}
+	return And(requirements...), nil
+}
+
+func (p *requirementParser) parseList(operator string, parseTerm func() (Requirement, error)) ([]Requirement, error) {
+	requirements := make([]Requirement, 0)
+	for {
+		requirement, err := parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		requirements = append(requirements, requirement)
+
+		if !strings.EqualFold(p.peek(), operator) {
+			return requirements, nil
+		}
+		p.pos++
+	}
+}
+
+func (p *requirementParser) parseUnary() (Requirement, error) {
+	token := p.peek()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("invalid requirement expression: unexpected end of expression")
+	case strings.EqualFold(token, "NOT"):
+		p.pos++
+		requirement, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not(requirement), nil
+	case token == "(":
+		p.pos++
+		requirement, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("invalid requirement expression: missing closing parenthesis")
+		}
+		p.pos++
+		return requirement, nil
+	case token == ")" || strings.EqualFold(token, "AND") || strings.EqualFold(token, "OR"):
+		return nil, fmt.Errorf("invalid requirement expression: unexpected %q", token)
+	}
+
+	p.pos++
+	return p.parseOperand(token)
+}
+
+func (p *requirementParser) parseOperand(token string) (Requirement, error) {
+	if requirement, ok := p.operands[token]; ok {
+		return requirement, nil
+	}
+
+	if strings.HasPrefix(token, rolePrefix) {
+		roleID := strings.TrimPrefix(token, rolePrefix)
+		if roleID == "" {
+			return nil, fmt.Errorf("invalid requirement expression: empty role in %q", token)
+		}
+		return RequireRole(roleID), nil
+	}
+
+	if strings.HasPrefix(token, permissionPrefix) {
+		permission := strings.TrimPrefix(token, permissionPrefix)
+		separatorIndex := strings.LastIndex(permission, ":")
+		if separatorIndex <= 0 {
+			return nil, fmt.Errorf("invalid requirement expression: missing action in %q", token)
+		}
+		action, err := parseAction(permission[separatorIndex+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid requirement expression: %v in %q", err, token)
+		}
+		return RequirePermission(&iam.Permission{Resource: permission[:separatorIndex], Action: action}), nil
+	}
+
+	return nil, fmt.Errorf("invalid requirement expression: unknown operand %q", token)
+}
+
+// parseAction parses the action names separated by "|" (e.g. "READ|UPDATE") or the action number (e.g. "6")
+func parseAction(s string) (int, error) {
+	if value, err := strconv.Atoi(s); err == nil {
+		return value, nil
+	}
+
+	action := 0
+	for _, name := range strings.Split(s, "|") {
+		value, ok := actionNames[strings.ToUpper(name)]
+		if !ok {
+			return 0, fmt.Errorf("unknown action %s", name)
+		}
+		action |= value
+	}
+	return action, nil
+}
@@ -0,0 +1,206 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// requirementTestClient grants the listed permission resources and roles only
+type requirementTestClient struct {
+	*iam.MockClient
+	resources map[string]bool
+	roles     map[string]bool
+	calls     int
+}
+
+func (c *requirementTestClient) ValidatePermission(claims *iam.JWTClaims, permission iam.Permission,
+	resources map[string]string, opts ...iam.Option) (bool, error) {
+	c.calls++
+	if permission.Resource == "ERROR" {
+		return false, errors.New("iam is unavailable")
+	}
+	return c.resources[permission.Resource], nil
+}
+
+func (c *requirementTestClient) ValidateRole(roleID string, claims *iam.JWTClaims, opts ...iam.Option) (bool, error) {
+	c.calls++
+	return c.roles[roleID], nil
+}
+
+func newRequirementTestClient(resources []string, roles []string) *requirementTestClient {
+	client := &requirementTestClient{
+		MockClient: &iam.MockClient{Healthy: true},
+		resources:  map[string]bool{},
+		roles:      map[string]bool{},
+	}
+	for _, resource := range resources {
+		client.resources[resource] = true
+	}
+	for _, role := range roles {
+		client.roles[role] = true
+	}
+	return client
+}
+
+func TestParseRequirement(t *testing.T) {
+	t.Parallel()
+
+	operands := map[string]Requirement{
+		"PERM_A": RequirePermission(&iam.Permission{Resource: "A", Action: iam.ActionRead}),
+		"PERM_B": RequirePermission(&iam.Permission{Resource: "B", Action: iam.ActionRead | iam.ActionUpdate}),
+		"ROLE_C": RequireRole("C"),
+	}
+
+	testcases := []struct {
+		expression string
+		expected   string
+	}{
+		{"PERM_A", "perm:A:READ"},
+		{"PERM_A AND (PERM_B OR ROLE_C)", "perm:A:READ AND (perm:B:READ|UPDATE OR role:C)"},
+		{"PERM_A and PERM_B or ROLE_C", "(perm:A:READ AND perm:B:READ|UPDATE) OR role:C"},
+		{"NOT ROLE_C AND PERM_A", "NOT role:C AND perm:A:READ"},
+		{"not (PERM_A or ROLE_C)", "NOT (perm:A:READ OR role:C)"},
+		{"perm:NAMESPACE:{namespace}:USER:6 OR role:admin", "perm:NAMESPACE:{namespace}:USER:READ|UPDATE OR role:admin"},
+	}
+
+	for _, testcase := range testcases {
+		requirement, err := ParseRequirement(testcase.expression, operands)
+		assert.NoError(t, err, testcase.expression)
+		assert.Equal(t, testcase.expected, requirement.String(), testcase.expression)
+
+		// the string representation can be parsed back
+		reparsed, err := ParseRequirement(requirement.String(), nil)
+		assert.NoError(t, err, testcase.expression)
+		assert.Equal(t, requirement.String(), reparsed.String(), testcase.expression)
+	}
+}
+
+func TestParseRequirement_Invalid(t *testing.T) {
+	t.Parallel()
+
+	for _, expression := range []string{
+		"",
+		"PERM_A",
+		"role:",
+		"perm:A",
+		"perm:A:WRITE",
+		"role:a AND",
+		"(role:a OR role:b",
+		"role:a role:b",
+		"OR role:a",
+		"role:a)",
+	} {
+		_, err := ParseRequirement(expression, nil)
+		assert.Error(t, err, expression)
+	}
+}
+
+func TestRequirement_Evaluate(t *testing.T) {
+	t.Parallel()
+
+	requirement, err := ParseRequirement("perm:A:READ AND (perm:B:READ OR role:C)", nil)
+	assert.NoError(t, err)
+
+	testcases := []struct {
+		name      string
+		resources []string
+		roles     []string
+		valid     bool
+	}{
+		{"all granted", []string{"A", "B"}, []string{"C"}, true},
+		{"permission and role", []string{"A"}, []string{"C"}, true},
+		{"permissions only", []string{"A", "B"}, nil, true},
+		{"missing the first permission", []string{"B"}, []string{"C"}, false},
+		{"missing the alternatives", []string{"A"}, nil, false},
+	}
+
+	for _, testcase := range testcases {
+		client := newRequirementTestClient(testcase.resources, testcase.roles)
+		valid, err := requirement.Evaluate(&restful.Request{}, client, &iam.JWTClaims{})
+		assert.NoError(t, err, testcase.name)
+		assert.Equal(t, testcase.valid, valid, testcase.name)
+	}
+}
+
+func TestRequirement_EvaluateShortCircuit(t *testing.T) {
+	t.Parallel()
+
+	client := newRequirementTestClient(nil, []string{"admin"})
+
+	valid, err := Or(RequireRole("admin"), RequirePermission(&iam.Permission{Resource: "ERROR"})).
+		Evaluate(&restful.Request{}, client, &iam.JWTClaims{})
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, 1, client.calls)
+
+	valid, err = And(RequireRole("support"), RequirePermission(&iam.Permission{Resource: "ERROR"})).
+		Evaluate(&restful.Request{}, client, &iam.JWTClaims{})
+	assert.NoError(t, err)
+	assert.False(t, valid)
+	assert.Equal(t, 2, client.calls)
+}
+
+func TestRequirement_EvaluateEmpty(t *testing.T) {
+	t.Parallel()
+
+	client := newRequirementTestClient([]string{"A"}, []string{"admin"})
+
+	valid, err := And().Evaluate(&restful.Request{}, client, &iam.JWTClaims{})
+	assert.NoError(t, err)
+	assert.False(t, valid)
+
+	valid, err = Or().Evaluate(&restful.Request{}, client, &iam.JWTClaims{})
+	assert.NoError(t, err)
+	assert.False(t, valid)
+
+	// the empty composite operand of the expression doesn't grant the access either
+	requirement, err := ParseRequirement("EMPTY OR perm:B:READ", map[string]Requirement{"EMPTY": And()})
+	assert.NoError(t, err)
+	valid, err = requirement.Evaluate(&restful.Request{}, client, &iam.JWTClaims{})
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestWithRequirement(t *testing.T) {
+	t.Parallel()
+
+	client := newRequirementTestClient([]string{"A"}, nil)
+	req := restful.NewRequest(httptest.NewRequest(http.MethodGet, "/", nil))
+
+	err := WithRequirementExpression("perm:A:READ OR role:C", nil)(req, client, &iam.JWTClaims{})
+	assert.NoError(t, err)
+
+	err = WithRequirementExpression("perm:A:READ AND role:C", nil)(req, client, &iam.JWTClaims{})
+	svcErr, ok := err.(restful.ServiceError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusForbidden, svcErr.Code)
+
+	err = WithRequirement(RequirePermission(&iam.Permission{Resource: "ERROR"}))(req, client, &iam.JWTClaims{})
+	svcErr, ok = err.(restful.ServiceError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusInternalServerError, svcErr.Code)
+
+	assert.Panics(t, func() {
+		WithRequirementExpression("perm:A:READ AND", nil)
+	})
+}
@@ -0,0 +1,95 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"fmt"
+
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+)
+
+// PermissionMetadata is the route metadata key of the permission required by the route,
+// the value is either a *iam.Permission or a Requirement.
+// It's enforced by the Auth filter in addition to its filter options.
+const PermissionMetadata = "IAMPermission"
+
+// RoutePermission declares the permission required by the route, the {namespace} and {userId} placeholders
+// in the resource are resolved from the path parameters. The request without the permission is rejected with 403.
+// Example:
+//
+//	ws.Route(ws.GET("/namespaces/{namespace}/users/{userId}").
+//		Do(iam.RoutePermission("ADMIN:NAMESPACE:{namespace}:USER:{userId}", iamSDK.ActionRead)).
+//		To(getUser))
+func RoutePermission(resource string, action int) func(*restful.RouteBuilder) {
+	return func(builder *restful.RouteBuilder) {
+		builder.Metadata(PermissionMetadata, &iam.Permission{Resource: resource, Action: action})
+	}
+}
+
+// RouteRequirement declares the composite requirement of the route, e.g. multiple permissions
+func RouteRequirement(requirement Requirement) func(*restful.RouteBuilder) {
+	return func(builder *restful.RouteBuilder) {
+		builder.Metadata(PermissionMetadata, requirement)
+	}
+}
+
+// getRouteRequirement returns the requirement declared in the selected route's metadata, or nil if there is none
+func getRouteRequirement(req *restful.Request) Requirement {
+	route := req.SelectedRoute()
+	if route == nil {
+		return nil
+	}
+	return routeRequirement(route.Metadata())
+}
+
+// routeRequirement returns the requirement declared in the route metadata, or nil if there is none
+func routeRequirement(metadata map[string]interface{}) Requirement {
+	switch value := metadata[PermissionMetadata].(type) {
+	case nil:
+		return nil
+	case *iam.Permission:
+		return RequirePermission(value)
+	case iam.Permission:
+		return RequirePermission(&value)
+	case Requirement:
+		return value
+	default:
+		// fail closed, the misconfigured route must not be accessible
+		return &invalidRequirement{value: value}
+	}
+}
+
+// withRouteRequirement appends the route requirement to the filter options.
+// The options slice is shared by the requests, so it's copied instead of appended in place.
+func withRouteRequirement(req *restful.Request, opts []FilterOption) []FilterOption {
+	requirement := getRouteRequirement(req)
+	if requirement == nil {
+		return opts
+	}
+	return append(opts[:len(opts):len(opts)], WithRequirement(requirement))
+}
+
+type invalidRequirement struct {
+	value interface{}
+}
+
+func (r *invalidRequirement) Evaluate(req *restful.Request, iamClient iam.Client, claims *iam.JWTClaims) (bool, error) {
+	return false, fmt.Errorf("invalid %s route metadata of type %T", PermissionMetadata, r.value)
+}
+
+func (r *invalidRequirement) String() string {
+	return fmt.Sprintf("invalid(%T)", r.value)
+}
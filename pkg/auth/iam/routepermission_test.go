@@ -0,0 +1,107 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iam
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// routePermissionTestClient grants the listed permission resources after resolving the placeholders
+type routePermissionTestClient struct {
+	*iam.MockClient
+	resources map[string]bool
+}
+
+func (c *routePermissionTestClient) ValidatePermission(claims *iam.JWTClaims, permission iam.Permission,
+	resources map[string]string, opts ...iam.Option) (bool, error) {
+	resource := permission.Resource
+	for placeholder, value := range resources {
+		resource = strings.ReplaceAll(resource, placeholder, value)
+	}
+	return c.resources[resource], nil
+}
+
+func serveRoutePermission(t *testing.T, route func(*restful.RouteBuilder), path string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	client := &routePermissionTestClient{
+		MockClient: &iam.MockClient{Healthy: true},
+		resources:  map[string]bool{"ADMIN:NAMESPACE:accelbyte:USER:user1": true},
+	}
+	filter := NewFilter(client)
+
+	ws := new(restful.WebService)
+	ws.Filter(filter.Auth())
+	ws.Route(ws.GET("/namespaces/{namespace}/users/{userId}").
+		Do(route).
+		To(func(request *restful.Request, response *restful.Response) {}))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Authorization", "Bearer token")
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, req)
+
+	return resp
+}
+
+func TestRoutePermission(t *testing.T) {
+	t.Parallel()
+
+	route := RoutePermission("ADMIN:NAMESPACE:{namespace}:USER:{userId}", iam.ActionRead)
+
+	resp := serveRoutePermission(t, route, "/namespaces/accelbyte/users/user1")
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	resp = serveRoutePermission(t, route, "/namespaces/accelbyte/users/user2")
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+
+	errorResponse := ErrorResponse{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &errorResponse))
+	assert.Equal(t, InsufficientPermissions, errorResponse.ErrorCode)
+}
+
+func TestRouteRequirement(t *testing.T) {
+	t.Parallel()
+
+	route := RouteRequirement(Or(
+		RequirePermission(&iam.Permission{Resource: "ADMIN:NAMESPACE:{namespace}:USER", Action: iam.ActionRead}),
+		RequirePermission(&iam.Permission{Resource: "ADMIN:NAMESPACE:{namespace}:USER:{userId}", Action: iam.ActionRead}),
+	))
+
+	assert.Equal(t, http.StatusOK, serveRoutePermission(t, route, "/namespaces/accelbyte/users/user1").Code)
+	assert.Equal(t, http.StatusForbidden, serveRoutePermission(t, route, "/namespaces/other/users/user1").Code)
+}
+
+func TestRoutePermission_InvalidMetadata(t *testing.T) {
+	t.Parallel()
+
+	route := func(builder *restful.RouteBuilder) {
+		builder.Metadata(PermissionMetadata, "ADMIN:NAMESPACE:{namespace}:USER")
+	}
+
+	assert.Equal(t, http.StatusInternalServerError,
+		serveRoutePermission(t, route, "/namespaces/accelbyte/users/user1").Code)
+}
@@ -0,0 +1,287 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/response"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/trace"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// InvalidBatchRequest is the error code when the batch request is invalid
+	InvalidBatchRequest = 20002
+
+	internalServerErrorMessage = "internal server error"
+
+	defaultMaxRequests = 20
+	defaultConcurrency = 4
+
+	fieldBatchSize = "batch_size"
+)
+
+// batchContextKey marks the context of the sub-request, so the batch can't be nested
+type batchContextKey struct{}
+
+// DefaultInheritedHeaders are the headers of the batch request copied into each sub-request
+var DefaultInheritedHeaders = []string{"Authorization", "Cookie", "Accept", "Accept-Language", "User-Agent",
+	trace.TraceIDKey}
+
+// Request is a sub-request of the batch
+type Request struct {
+	// ID is echoed in the response to correlate the responses with the requests
+	ID      string            `json:"id"`
+	Method  string            `json:"method"`
+	Path    string            `json:"path"` // path of the route with the query params, e.g. /users/me?fields=name
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// Response is the response of the sub-request. The JSON body is embedded as is, the other body is sent as string.
+type Response struct {
+	ID      string            `json:"id"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// Requests is the body of the batch request
+type Requests struct {
+	Requests []Request `json:"requests"`
+}
+
+// Responses is the body of the batch response, in the same order as the requests
+type Responses struct {
+	Responses []Response `json:"responses"`
+}
+
+// Options contains options for the batch handler
+type Options struct {
+	// MaxRequests is the maximum number of sub-requests in a batch. Default: 20
+	MaxRequests int
+	// Concurrency is the number of sub-requests dispatched concurrently. Default: 4
+	Concurrency int
+	// InheritedHeaders are the headers of the batch request copied into each sub-request,
+	// unless the sub-request has its own. Default: DefaultInheritedHeaders
+	InheritedHeaders []string
+}
+
+// Handler returns the route function dispatching the sub-requests of the batch through the container,
+// so each sub-request goes through the filters of its route (e.g. auth and access log) as a separate request.
+// The batch responds 200 with the response of each sub-request, even when some of them fail.
+func Handler(container *restful.Container, options Options) restful.RouteFunction {
+	if options.MaxRequests <= 0 {
+		options.MaxRequests = defaultMaxRequests
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = defaultConcurrency
+	}
+	if options.InheritedHeaders == nil {
+		options.InheritedHeaders = DefaultInheritedHeaders
+	}
+
+	return func(req *restful.Request, resp *restful.Response) {
+		batch := Requests{}
+		err := req.ReadEntity(&batch)
+		if err == nil {
+			err = validate(req, batch, options)
+		}
+		if err != nil {
+			response.WriteErrorEnvelope(req, resp, http.StatusBadRequest,
+				response.NewError(InvalidBatchRequest, err.Error(), nil))
+			return
+		}
+
+		log.AdditionalFields(req, map[string]interface{}{fieldBatchSize: len(batch.Requests)})
+
+		responses := Responses{Responses: make([]Response, len(batch.Requests))}
+		slots := make(chan struct{}, options.Concurrency)
+		var wg sync.WaitGroup
+		for i := range batch.Requests {
+			wg.Add(1)
+			slots <- struct{}{}
+			go func(i int) {
+				defer func() {
+					<-slots
+					wg.Done()
+				}()
+				responses.Responses[i] = dispatchRecovered(container, req, batch.Requests[i], options)
+			}(i)
+		}
+		wg.Wait()
+
+		if err = resp.WriteHeaderAndJson(http.StatusOK, responses, restful.MIME_JSON); err != nil {
+			logrus.Error(err)
+		}
+	}
+}
+
+func validate(req *restful.Request, batch Requests, options Options) error {
+	if req.Request.Context().Value(batchContextKey{}) != nil {
+		return errors.New("batch request can't be nested")
+	}
+	if len(batch.Requests) == 0 {
+		return errors.New("batch request is empty")
+	}
+	if len(batch.Requests) > options.MaxRequests {
+		return fmt.Errorf("batch request exceeds the maximum of %d requests", options.MaxRequests)
+	}
+
+	for i, request := range batch.Requests {
+		if request.Method == "" {
+			return fmt.Errorf("method of request %d is empty", i)
+		}
+		target, err := url.Parse(request.Path)
+		if err != nil {
+			return fmt.Errorf("path of request %d is invalid: %v", i, err)
+		}
+		if target.IsAbs() || target.Host != "" || !strings.HasPrefix(target.Path, "/") {
+			return fmt.Errorf("path of request %d must be an absolute path of the service", i)
+		}
+	}
+
+	return nil
+}
+
+// dispatchRecovered dispatches the sub-request, converting its panic into 500 error response of the sub-request,
+// since the sub-request runs in its own goroutine and the panic can't be recovered by the batch request's filters.
+// The panic with a *response.Error value keeps its error code.
+func dispatchRecovered(container *restful.Container, parent *restful.Request, request Request,
+	options Options) (result Response) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+
+		logrus.Errorf("panic recovered on batch sub-request %s %s: %v\n%s", request.Method, request.Path,
+			recovered, debug.Stack())
+
+		errorResponse, ok := recovered.(*response.Error)
+		if !ok {
+			errorResponse = &response.Error{ErrorCode: response.InternalServerError, ErrorMessage: internalServerErrorMessage}
+		}
+		body, err := json.Marshal(errorResponse)
+		if err != nil {
+			logrus.Error(err)
+		}
+		result = Response{
+			ID:      request.ID,
+			Status:  http.StatusInternalServerError,
+			Headers: map[string]string{restful.HEADER_ContentType: restful.MIME_JSON},
+			Body:    body,
+		}
+	}()
+
+	return dispatch(container, parent, request, options)
+}
+
+// dispatch serves the sub-request through the container
+func dispatch(container *restful.Container, parent *restful.Request, request Request, options Options) Response {
+	ctx := context.WithValue(parent.Request.Context(), batchContextKey{}, true)
+	subRequest, err := http.NewRequest(strings.ToUpper(request.Method), request.Path, bytes.NewReader(request.Body))
+	if err != nil {
+		return Response{ID: request.ID, Status: http.StatusBadRequest}
+	}
+	subRequest = subRequest.WithContext(ctx)
+	subRequest.Host = parent.Request.Host
+	subRequest.RemoteAddr = parent.Request.RemoteAddr
+
+	for _, name := range options.InheritedHeaders {
+		if values := parent.Request.Header[http.CanonicalHeaderKey(name)]; len(values) > 0 {
+			subRequest.Header[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	for _, name := range []string{"X-Forwarded-For", "X-Real-Ip"} {
+		if values := parent.Request.Header[name]; len(values) > 0 {
+			subRequest.Header[name] = values
+		}
+	}
+	if len(request.Body) > 0 {
+		subRequest.Header.Set(restful.HEADER_ContentType, restful.MIME_JSON)
+	}
+	for name, value := range request.Headers {
+		subRequest.Header.Set(name, value)
+	}
+
+	recorder := newResponseRecorder()
+	container.ServeHTTP(recorder, subRequest)
+
+	return recorder.response(request.ID)
+}
+
+// responseRecorder records the response of the sub-request
+type responseRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{header: http.Header{}, status: http.StatusOK}
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if r.wroteHeader {
+		return
+	}
+	r.wroteHeader = true
+	r.status = statusCode
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(b)
+}
+
+func (r *responseRecorder) response(id string) Response {
+	result := Response{
+		ID:      id,
+		Status:  r.status,
+		Headers: make(map[string]string, len(r.header)),
+	}
+	for name, values := range r.header {
+		result.Headers[name] = strings.Join(values, ",")
+	}
+
+	if r.body.Len() > 0 {
+		body := bytes.TrimSpace(r.body.Bytes())
+		if strings.Contains(r.header.Get(restful.HEADER_ContentType), "json") && json.Valid(body) {
+			result.Body = body
+		} else {
+			result.Body, _ = json.Marshal(r.body.String())
+		}
+	}
+
+	return result
+}
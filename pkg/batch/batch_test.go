@@ -0,0 +1,188 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package batch
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	Name string `json:"name"`
+}
+
+func createTestContainer(options Options) *restful.Container {
+	container := restful.NewContainer()
+
+	ws := new(restful.WebService)
+	ws.Produces(restful.MIME_JSON)
+	ws.Filter(log.AccessLog)
+	ws.Filter(func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		if req.HeaderParameter("Authorization") != "Bearer token" {
+			resp.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		chain.ProcessFilter(req, resp)
+	})
+	ws.Route(ws.GET("/users/{userId}").
+		To(func(request *restful.Request, response *restful.Response) {
+			_ = response.WriteEntity(user{Name: request.PathParameter("userId")})
+		}))
+	ws.Route(ws.POST("/users").Consumes(restful.MIME_JSON).
+		To(func(request *restful.Request, response *restful.Response) {
+			entity := user{}
+			_ = request.ReadEntity(&entity)
+			_ = response.WriteHeaderAndEntity(http.StatusCreated, entity)
+		}))
+	ws.Route(ws.GET("/text").
+		To(func(request *restful.Request, response *restful.Response) {
+			response.Header().Set("Content-Type", "text/plain")
+			_, _ = response.Write([]byte("hello"))
+		}))
+	ws.Route(ws.GET("/panic").
+		To(func(request *restful.Request, response *restful.Response) {
+			panic("boom")
+		}))
+	ws.Route(ws.POST("/batch").
+		To(Handler(container, options)))
+	container.Add(ws)
+
+	return container
+}
+
+func serveBatch(container *restful.Container, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", restful.MIME_JSON)
+	req.Header.Set("Authorization", "Bearer token")
+
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	container := createTestContainer(Options{})
+
+	resp := serveBatch(container, `{"requests":[
+		{"id":"1","method":"GET","path":"/users/john"},
+		{"id":"2","method":"POST","path":"/users","body":{"name":"jane"}},
+		{"id":"3","method":"GET","path":"/users/john","headers":{"Authorization":"Bearer invalid"}},
+		{"id":"4","method":"GET","path":"/text"},
+		{"id":"5","method":"GET","path":"/unknown"}
+	]}`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	responses := Responses{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &responses))
+	assert.Len(t, responses.Responses, 5)
+
+	assert.Equal(t, "1", responses.Responses[0].ID)
+	assert.Equal(t, http.StatusOK, responses.Responses[0].Status)
+	assert.JSONEq(t, `{"name":"john"}`, string(responses.Responses[0].Body))
+
+	assert.Equal(t, http.StatusCreated, responses.Responses[1].Status)
+	assert.JSONEq(t, `{"name":"jane"}`, string(responses.Responses[1].Body))
+
+	// each sub-request goes through the filters
+	assert.Equal(t, http.StatusUnauthorized, responses.Responses[2].Status)
+
+	assert.Equal(t, http.StatusOK, responses.Responses[3].Status)
+	assert.Equal(t, `"hello"`, string(responses.Responses[3].Body))
+	assert.Equal(t, "text/plain", responses.Responses[3].Headers["Content-Type"])
+
+	assert.Equal(t, http.StatusNotFound, responses.Responses[4].Status)
+}
+
+func TestHandler_Panic(t *testing.T) {
+	t.Parallel()
+
+	container := createTestContainer(Options{})
+	resp := serveBatch(container, `{"requests":[
+		{"id":"1","method":"GET","path":"/panic"},
+		{"id":"2","method":"GET","path":"/users/abc"}
+	]}`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	responses := Responses{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &responses))
+	assert.Len(t, responses.Responses, 2)
+	assert.Equal(t, "1", responses.Responses[0].ID)
+	assert.Equal(t, http.StatusInternalServerError, responses.Responses[0].Status)
+	assert.JSONEq(t, `{"errorCode":20000,"errorMessage":"internal server error"}`, string(responses.Responses[0].Body))
+	assert.Equal(t, http.StatusOK, responses.Responses[1].Status)
+	assert.JSONEq(t, `{"name":"abc"}`, string(responses.Responses[1].Body))
+}
+
+func TestHandler_InvalidBatch(t *testing.T) {
+	t.Parallel()
+
+	container := createTestContainer(Options{MaxRequests: 2})
+
+	testCases := map[string]string{
+		"empty":          `{"requests":[]}`,
+		"too many":       `{"requests":[{"method":"GET","path":"/a"},{"method":"GET","path":"/b"},{"method":"GET","path":"/c"}]}`,
+		"no method":      `{"requests":[{"path":"/users/john"}]}`,
+		"absolute URL":   `{"requests":[{"method":"GET","path":"http://example.com/users/john"}]}`,
+		"relative path":  `{"requests":[{"method":"GET","path":"users/john"}]}`,
+		"nested":         `{"requests":[{"method":"POST","path":"/batch","body":{"requests":[{"method":"GET","path":"/text"}]}}]}`,
+		"invalid entity": `[]`,
+	}
+	for name, body := range testCases {
+		resp := serveBatch(container, body)
+		if name == "nested" {
+			// the nested batch is rejected as the sub-request
+			responses := Responses{}
+			assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &responses), name)
+			assert.Equal(t, http.StatusBadRequest, responses.Responses[0].Status, name)
+			continue
+		}
+		assert.Equal(t, http.StatusBadRequest, resp.Code, name)
+		errorResponse := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &errorResponse), name)
+		assert.Equal(t, float64(InvalidBatchRequest), errorResponse["errorCode"], name)
+	}
+}
+
+// nolint:paralleltest
+func TestHandler_AccessLog(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	log.SetAccessLogOutput(buffer)
+	log.FullAccessLogEnabled = true
+	defer func() {
+		log.SetAccessLogOutput(os.Stdout)
+		log.FullAccessLogEnabled = false
+	}()
+
+	container := createTestContainer(Options{Concurrency: 1})
+	serveBatch(container, `{"requests":[{"id":"1","method":"GET","path":"/users/john"},{"id":"2","method":"GET","path":"/text"}]}`)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[0], `path="/users/john"`)
+	assert.Contains(t, lines[1], `path="/text"`)
+	assert.Contains(t, lines[2], `path="/batch"`)
+	assert.Contains(t, lines[2], fieldBatchSize+"=2")
+}
@@ -0,0 +1,152 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/ratelimit"
+	"github.com/emicklei/go-restful/v3"
+)
+
+// conventional bundle names
+const (
+	Public      = "public"       // endpoints accessible without a user, e.g. the public game data
+	Admin       = "admin"        // endpoints of the admin portal and the admin tools
+	InternalS2S = "internal-s2s" // endpoints called by the other services using their client token
+)
+
+var (
+	mutex   sync.RWMutex
+	bundles = map[string]*Bundle{}
+)
+
+// Options contains the settings composed into a bundle, the unset settings are skipped
+type Options struct {
+	// Auth authenticates the request, e.g. iamFilter.Auth(iam.WithValidUser()) or iamFilter.PublicAuth()
+	Auth restful.FilterFunction
+	// RateLimit limits the request rate after the request is authenticated, so it can be keyed by the client
+	RateLimit *ratelimit.Options
+	// Log sets the masking, data classification and retention of the access log records
+	Log *log.Option
+	// Filters are run after the auth, rate limit and log filters
+	Filters []restful.FilterFunction
+	// Metadata is attached to the routes using the bundle, e.g. iam.PermissionMetadata
+	Metadata map[string]interface{}
+}
+
+// Bundle is a named set of filters and route metadata attached to a WebService or a route with one call,
+// so the routes of the same kind are wired the same way across the services.
+type Bundle struct {
+	name     string
+	filters  []restful.FilterFunction
+	metadata map[string]interface{}
+}
+
+// New creates new Bundle instance, the filters are run in order: log, auth, rate limit, then the additional filters
+func New(name string, options Options) *Bundle {
+	filters := make([]restful.FilterFunction, 0, len(options.Filters)+3)
+	if options.Log != nil {
+		filters = append(filters, log.Attribute(*options.Log))
+	}
+	if options.Auth != nil {
+		filters = append(filters, options.Auth)
+	}
+	if options.RateLimit != nil {
+		filters = append(filters, ratelimit.Filter(options.RateLimit))
+	}
+	filters = append(filters, options.Filters...)
+
+	metadata := make(map[string]interface{}, len(options.Metadata))
+	for key, value := range options.Metadata {
+		metadata[key] = value
+	}
+
+	return &Bundle{name: name, filters: filters, metadata: metadata}
+}
+
+// Name returns the name of the bundle
+func (b *Bundle) Name() string {
+	return b.name
+}
+
+// Apply adds the filters of the bundle into the WebService, so they run for all its routes.
+// The WebService doesn't have metadata, so it panics if the bundle has metadata (e.g. iam.PermissionMetadata)
+// instead of serving the routes without it, use Route to attach such bundle to each route.
+func (b *Bundle) Apply(ws *restful.WebService) *restful.WebService {
+	if len(b.metadata) > 0 {
+		panic("bundle " + b.name + " has route metadata, attach it to each route with Route instead of Apply")
+	}
+	for _, filter := range b.filters {
+		ws.Filter(filter)
+	}
+	return ws
+}
+
+// Route attaches the filters and the metadata of the bundle to the route. Example:
+//
+//	ws.Route(ws.GET("/namespaces/{namespace}/items").
+//		Do(bundle.MustGet(bundle.Public).Route).
+//		To(listItems))
+func (b *Bundle) Route(builder *restful.RouteBuilder) {
+	for _, filter := range b.filters {
+		builder.Filter(filter)
+	}
+	for key, value := range b.metadata {
+		builder.Metadata(key, value)
+	}
+}
+
+// Register registers the bundle by its name, the bundle with the same name replaces the registered one
+func Register(bundle *Bundle) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	bundles[bundle.name] = bundle
+}
+
+// Get returns the registered bundle by its name
+func Get(name string) (*Bundle, bool) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	bundle, ok := bundles[name]
+	return bundle, ok
+}
+
+// MustGet returns the registered bundle by its name, it panics if the bundle isn't registered,
+// so the misconfigured route fails when the service starts instead of being served without its filters.
+func MustGet(name string) *Bundle {
+	bundle, ok := Get(name)
+	if !ok {
+		panic("bundle " + name + " is not registered")
+	}
+	return bundle
+}
+
+// Names returns the names of the registered bundles, sorted
+func Names() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	names := make([]string, 0, len(bundles))
+	for name := range bundles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,137 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bundle
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/ratelimit"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingFilter appends its name into the calls attribute of the request
+func recordingFilter(name string) restful.FilterFunction {
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		calls, _ := req.Attribute("calls").([]string)
+		req.SetAttribute("calls", append(calls, name))
+		chain.ProcessFilter(req, resp)
+	}
+}
+
+func TestBundle_Route(t *testing.T) {
+	t.Parallel()
+
+	b := New("test", Options{
+		Auth:     recordingFilter("auth"),
+		Log:      &log.Option{MaskedRequestFields: "password"},
+		Filters:  []restful.FilterFunction{recordingFilter("custom")},
+		Metadata: map[string]interface{}{"Key": "value"},
+	})
+	assert.Equal(t, "test", b.Name())
+
+	var calls []string
+	var masked interface{}
+	var metadata map[string]interface{}
+
+	ws := new(restful.WebService)
+	ws.Route(ws.GET("/bundled").
+		Do(b.Route).
+		To(func(req *restful.Request, resp *restful.Response) {
+			calls, _ = req.Attribute("calls").([]string)
+			masked = req.Attribute(log.MaskedRequestFieldsAttribute)
+			metadata = req.SelectedRoute().Metadata()
+		}))
+	ws.Route(ws.GET("/plain").
+		To(func(req *restful.Request, resp *restful.Response) {
+			calls, _ = req.Attribute("calls").([]string)
+		}))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	container.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/bundled", nil))
+	assert.Equal(t, []string{"auth", "custom"}, calls)
+	assert.Equal(t, "password", masked)
+	assert.Equal(t, "value", metadata["Key"])
+
+	container.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/plain", nil))
+	assert.Empty(t, calls)
+}
+
+func TestBundle_Apply(t *testing.T) {
+	t.Parallel()
+
+	b := New("limited", Options{
+		Auth:      recordingFilter("auth"),
+		RateLimit: &ratelimit.Options{Limit: ratelimit.PerMinute(1), KeyFunc: ratelimit.BySourceIP},
+	})
+
+	var calls []string
+	ws := b.Apply(new(restful.WebService))
+	ws.Route(ws.GET("/items").
+		To(func(req *restful.Request, resp *restful.Response) {
+			calls, _ = req.Attribute("calls").([]string)
+		}))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/items", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, []string{"auth"}, calls)
+
+	resp = httptest.NewRecorder()
+	container.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/items", nil))
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+}
+
+func TestBundle_ApplyWithMetadata(t *testing.T) {
+	t.Parallel()
+
+	b := New("permission", Options{
+		Auth:     recordingFilter("auth"),
+		Metadata: map[string]interface{}{"Key": "value"},
+	})
+
+	// the metadata can't be attached to a WebService, so it mustn't be silently dropped
+	assert.Panics(t, func() { b.Apply(new(restful.WebService)) })
+}
+
+// nolint:paralleltest
+func TestRegister(t *testing.T) {
+	defer func() {
+		bundles = map[string]*Bundle{}
+	}()
+
+	public := New(Public, Options{})
+	Register(public)
+	Register(New(Admin, Options{}))
+
+	b, ok := Get(Public)
+	assert.True(t, ok)
+	assert.Same(t, public, b)
+	assert.Same(t, public, MustGet(Public))
+
+	_, ok = Get(InternalS2S)
+	assert.False(t, ok)
+	assert.Panics(t, func() { MustGet(InternalS2S) })
+
+	assert.Equal(t, []string{Admin, Public}, Names())
+}
@@ -0,0 +1,367 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/clock"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// response headers of the cache
+	HeaderETag         = "ETag"
+	HeaderIfNoneMatch  = "If-None-Match"
+	HeaderCacheControl = "Cache-Control"
+	HeaderAge          = "Age"
+
+	defaultTTL         = time.Minute
+	defaultMaxBodySize = 1 << 20 // 1MB
+)
+
+var (
+	hits   uint64
+	misses uint64
+)
+
+// KeyFunc returns the cache key of the request
+type KeyFunc func(req *restful.Request) string
+
+// ByPathQueryNamespace keys the requests by the path, the query and the namespace of the IAM claims,
+// so the namespaces never share the cached responses. The IAM filter must run before the cache filter.
+func ByPathQueryNamespace(req *restful.Request) string {
+	namespace := ""
+	if claims := iam.RetrieveJWTClaims(req); claims != nil {
+		namespace = claims.Namespace
+	}
+	// the encoded query is sorted by the key, so the order of the query parameters doesn't matter
+	return req.Request.URL.Path + "?" + req.Request.URL.Query().Encode() + "#" + namespace
+}
+
+// Options contains options for the cache filter
+type Options struct {
+	// TTL is how long the response is cached, the max-age or s-maxage of the response takes precedence.
+	// Default: 1 minute
+	TTL time.Duration
+	// MaxBodySize is the size of the largest response body to be cached in bytes. Default: 1MB
+	MaxBodySize int
+	// KeyFunc keys the cached responses. Default: ByPathQueryNamespace
+	KeyFunc KeyFunc
+	// Store keeps the cached responses. Default: NewMemoryStore(0)
+	Store Store
+	// Clock is the time source of the stored time and the Age header, also used by the default store.
+	// Default: the system clock
+	Clock clock.Clock
+}
+
+// Stats is the number of the cached responses served since the service started
+type Stats struct {
+	Hits   uint64 `json:"hits"`   // Number of the GET requests served from the cache
+	Misses uint64 `json:"misses"` // Number of the GET requests served by the handler
+}
+
+// GetStats returns the number of the cache hits and misses since the service started
+func GetStats() Stats {
+	return Stats{
+		Hits:   atomic.LoadUint64(&hits),
+		Misses: atomic.LoadUint64(&misses),
+	}
+}
+
+// Filter caches the 200 responses of the GET requests and responds with 304 Not Modified
+// when the If-None-Match header of the request matches the ETag of the response.
+// The response with no-store or private Cache-Control, or with Set-Cookie header, is not cached.
+// The request with no-cache Cache-Control bypasses the cached response.
+// The store error doesn't fail the request, it's served by the handler instead.
+func Filter(options *Options) restful.FilterFunction {
+	if options == nil {
+		options = &Options{}
+	}
+	ttl := options.TTL
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	maxBodySize := options.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = defaultMaxBodySize
+	}
+	keyFunc := options.KeyFunc
+	if keyFunc == nil {
+		keyFunc = ByPathQueryNamespace
+	}
+	clk := clock.OrSystem(options.Clock)
+	store := options.Store
+	if store == nil {
+		memoryStore := NewMemoryStore(0)
+		memoryStore.SetClock(clk)
+		store = memoryStore
+	}
+
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		if req.Request.Method != http.MethodGet {
+			chain.ProcessFilter(req, resp)
+			return
+		}
+
+		requestCacheControl := parseCacheControl(req.Request.Header.Get(HeaderCacheControl))
+		if requestCacheControl.noStore {
+			chain.ProcessFilter(req, resp)
+			return
+		}
+
+		key := keyFunc(req)
+		if !requestCacheControl.noCache {
+			entry, ok, err := store.Get(req.Request.Context(), key)
+			if err != nil {
+				logrus.Errorf("unable to get cached response of %s: %v", key, err)
+			} else if ok {
+				atomic.AddUint64(&hits, 1)
+				log.SetCacheStatus(req, log.CacheHit)
+				log.SetCachedResponseBody(req, entry.MaskedBody)
+				writeEntry(req, resp, entry, clk.Now())
+				return
+			}
+		}
+
+		atomic.AddUint64(&misses, 1)
+		log.SetCacheStatus(req, log.CacheMiss)
+
+		original := resp.ResponseWriter
+		writer := &bufferingWriter{ResponseWriter: original, statusCode: http.StatusOK, maxBodySize: maxBodySize}
+		resp.ResponseWriter = writer
+		// restored on panic too, so the recovery handler can write its response
+		defer func() {
+			resp.ResponseWriter = original
+		}()
+		chain.ProcessFilter(req, resp)
+		resp.ResponseWriter = original
+
+		if writer.passThrough {
+			return
+		}
+
+		entry := &Entry{
+			StatusCode: writer.statusCode,
+			Header:     writer.Header().Clone(),
+			Body:       writer.body.Bytes(),
+			ETag:       writer.Header().Get(HeaderETag),
+			StoredAt:   clk.Now(),
+		}
+		if entry.ETag == "" {
+			entry.ETag = generateETag(entry.Body)
+			writer.Header().Set(HeaderETag, entry.ETag)
+		}
+
+		if entryTTL, ok := cacheableTTL(entry, ttl); ok {
+			entry.Header.Set(HeaderETag, entry.ETag)
+			entry.MaskedBody = log.MaskResponseBody(req, entry.Header.Get(restful.HEADER_ContentType), string(entry.Body))
+			if err := store.Set(req.Request.Context(), key, entry, entryTTL); err != nil {
+				logrus.Errorf("unable to cache response of %s: %v", key, err)
+			}
+		}
+
+		if matchETag(req.Request.Header.Get(HeaderIfNoneMatch), entry.ETag) {
+			resp.WriteHeader(http.StatusNotModified)
+			return
+		}
+		resp.WriteHeader(entry.StatusCode)
+		// the body is already counted in the content length of the response when the handler wrote it
+		if _, err := original.Write(entry.Body); err != nil {
+			logrus.Errorf("unable to write response: %v", err)
+		}
+	}
+}
+
+// writeEntry responds with the cached response, or 304 Not Modified if the client has the same response
+func writeEntry(req *restful.Request, resp *restful.Response, entry *Entry, now time.Time) {
+	header := resp.Header()
+	for name, values := range entry.Header {
+		header[name] = append([]string(nil), values...)
+	}
+	header.Set(HeaderAge, strconv.Itoa(int(now.Sub(entry.StoredAt).Seconds())))
+
+	if matchETag(req.Request.Header.Get(HeaderIfNoneMatch), entry.ETag) {
+		resp.WriteHeader(http.StatusNotModified)
+		return
+	}
+	resp.WriteHeader(entry.StatusCode)
+	if _, err := resp.Write(entry.Body); err != nil {
+		logrus.Errorf("unable to write cached response: %v", err)
+	}
+}
+
+// cacheableTTL returns how long the response is cached, or false if it must not be cached
+func cacheableTTL(entry *Entry, ttl time.Duration) (time.Duration, bool) {
+	if entry.StatusCode != http.StatusOK || entry.Header.Get("Set-Cookie") != "" {
+		return 0, false
+	}
+
+	cacheControl := parseCacheControl(entry.Header.Get(HeaderCacheControl))
+	if cacheControl.noStore || cacheControl.private {
+		return 0, false
+	}
+	if cacheControl.maxAge >= 0 {
+		ttl = time.Duration(cacheControl.maxAge) * time.Second
+	}
+
+	return ttl, ttl > 0
+}
+
+// generateETag generates a strong ETag from the response body
+func generateETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// matchETag checks the If-None-Match header against the ETag using the weak comparison
+func matchETag(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheControl is the parsed Cache-Control header
+type cacheControl struct {
+	noStore bool
+	noCache bool
+	private bool
+	maxAge  int // -1 if not set, s-maxage takes precedence over max-age
+}
+
+func parseCacheControl(header string) cacheControl {
+	result := cacheControl{maxAge: -1}
+	sharedMaxAge := -1
+	for _, directive := range strings.Split(header, ",") {
+		name, value := strings.TrimSpace(directive), ""
+		if index := strings.Index(name, "="); index != -1 {
+			name, value = name[:index], strings.Trim(name[index+1:], `"`)
+		}
+		switch strings.ToLower(name) {
+		case "no-store":
+			result.noStore = true
+		case "no-cache":
+			result.noCache = true
+		case "private":
+			result.private = true
+		case "max-age":
+			if seconds, err := strconv.Atoi(value); err == nil {
+				result.maxAge = seconds
+			}
+		case "s-maxage":
+			if seconds, err := strconv.Atoi(value); err == nil {
+				sharedMaxAge = seconds
+			}
+		}
+	}
+	if sharedMaxAge >= 0 {
+		result.maxAge = sharedMaxAge
+	}
+	return result
+}
+
+// bufferingWriter decorates http.ResponseWriter to hold the 200 response until the handler is done,
+// so the ETag can be generated from the whole body. The other responses, the body larger than maxBodySize
+// and the flushed response are passed through.
+type bufferingWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	body        bytes.Buffer
+	maxBodySize int
+	wroteHeader bool
+	passThrough bool
+}
+
+func (w *bufferingWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.statusCode = statusCode
+	if statusCode != http.StatusOK {
+		w.passThrough = true
+		w.ResponseWriter.WriteHeader(statusCode)
+	}
+}
+
+func (w *bufferingWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.passThrough {
+		return w.ResponseWriter.Write(b)
+	}
+	if w.body.Len()+len(b) > w.maxBodySize {
+		if err := w.release(); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(b)
+	}
+	return w.body.Write(b)
+}
+
+// release writes the buffered response and passes through the rest of the response
+func (w *bufferingWriter) release() error {
+	w.passThrough = true
+	w.ResponseWriter.WriteHeader(w.statusCode)
+	if w.body.Len() == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(w.body.Bytes())
+	w.body.Reset()
+	return err
+}
+
+// Flush implements http.Flusher, the flushed response is streamed instead of cached
+func (w *bufferingWriter) Flush() {
+	if !w.passThrough {
+		if err := w.release(); err != nil {
+			logrus.Errorf("unable to write response: %v", err)
+		}
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker
+func (w *bufferingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the underlying http.ResponseWriter doesn't implement http.Hijacker")
+	}
+	w.passThrough = true
+	return hijacker.Hijack()
+}
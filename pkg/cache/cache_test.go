@@ -0,0 +1,262 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/clock"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	iamSDK "github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingStore struct{}
+
+func (failingStore) Get(context.Context, string) (*Entry, bool, error) {
+	return nil, false, errors.New("connection refused")
+}
+
+func (failingStore) Set(context.Context, string, *Entry, time.Duration) error {
+	return errors.New("connection refused")
+}
+
+// testService serves the cached routes and counts the handler calls
+type testService struct {
+	container  *restful.Container
+	calls      int
+	lastStatus string
+}
+
+func newTestService(options *Options, route restful.RouteFunction) *testService {
+	service := &testService{}
+
+	ws := new(restful.WebService)
+	ws.Filter(func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		if namespace := req.Request.Header.Get("Namespace"); namespace != "" {
+			req.SetAttribute(iam.ClaimsAttribute, &iamSDK.JWTClaims{Namespace: namespace})
+		}
+		chain.ProcessFilter(req, resp)
+		service.lastStatus, _ = req.Attribute(log.CacheStatusAttribute).(string)
+	})
+	ws.Filter(Filter(options))
+	handler := func(req *restful.Request, resp *restful.Response) {
+		service.calls++
+		route(req, resp)
+	}
+	ws.Route(ws.GET("/items").To(handler))
+	ws.Route(ws.POST("/items").To(handler))
+
+	service.container = restful.NewContainer()
+	service.container.Add(ws)
+
+	return service
+}
+
+func (s *testService) serve(req *http.Request) *httptest.ResponseRecorder {
+	resp := httptest.NewRecorder()
+	s.container.ServeHTTP(resp, req)
+	return resp
+}
+
+func writeItems(req *restful.Request, resp *restful.Response) {
+	_ = resp.WriteAsJson([]string{"sword", "shield"})
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(nil, writeItems)
+	before := GetStats()
+
+	resp := service.serve(httptest.NewRequest(http.MethodGet, "/items?b=2&a=1", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, log.CacheMiss, service.lastStatus)
+	etag := resp.Header().Get(HeaderETag)
+	assert.NotEmpty(t, etag)
+	body := resp.Body.String()
+
+	// the order of the query parameters doesn't matter
+	resp = service.serve(httptest.NewRequest(http.MethodGet, "/items?a=1&b=2", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, log.CacheHit, service.lastStatus)
+	assert.Equal(t, body, resp.Body.String())
+	assert.Equal(t, etag, resp.Header().Get(HeaderETag))
+	assert.Equal(t, restful.MIME_JSON, resp.Header().Get(restful.HEADER_ContentType))
+	assert.Equal(t, "0", resp.Header().Get(HeaderAge))
+	assert.Equal(t, 1, service.calls)
+
+	stats := GetStats()
+	assert.True(t, stats.Hits >= before.Hits+1)
+	assert.True(t, stats.Misses >= before.Misses+1)
+}
+
+func TestFilter_NotModified(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(nil, writeItems)
+
+	req := httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set(HeaderIfNoneMatch, `"stale"`)
+	resp := service.serve(req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	etag := resp.Header().Get(HeaderETag)
+
+	req = httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set(HeaderIfNoneMatch, `"stale", W/`+etag)
+	resp = service.serve(req)
+	assert.Equal(t, http.StatusNotModified, resp.Code)
+	assert.Empty(t, resp.Body.String())
+	assert.Equal(t, etag, resp.Header().Get(HeaderETag))
+	assert.Equal(t, 1, service.calls)
+
+	// the ETag is also checked when the response isn't cached yet
+	service = newTestService(&Options{Store: failingStore{}}, writeItems)
+	req = httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set(HeaderIfNoneMatch, etag)
+	resp = service.serve(req)
+	assert.Equal(t, http.StatusNotModified, resp.Code)
+	assert.Equal(t, 1, service.calls)
+}
+
+func TestFilter_Namespace(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(nil, func(req *restful.Request, resp *restful.Response) {
+		_, _ = resp.Write([]byte(req.Request.Header.Get("Namespace")))
+	})
+
+	for _, namespace := range []string{"ns1", "ns2", "ns1"} {
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.Header.Set("Namespace", namespace)
+		resp := service.serve(req)
+		assert.Equal(t, namespace, resp.Body.String())
+	}
+	assert.Equal(t, 2, service.calls)
+}
+
+func TestFilter_NotCached(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name   string
+		method string
+		header http.Header
+		route  restful.RouteFunction
+	}{
+		{name: "POST", method: http.MethodPost, route: writeItems},
+		{name: "request no-store", method: http.MethodGet, header: http.Header{HeaderCacheControl: {"no-store"}},
+			route: writeItems},
+		{name: "request no-cache", method: http.MethodGet, header: http.Header{HeaderCacheControl: {"no-cache"}},
+			route: writeItems},
+		{name: "response private", method: http.MethodGet, route: func(req *restful.Request, resp *restful.Response) {
+			resp.Header().Set(HeaderCacheControl, "private, max-age=60")
+			writeItems(req, resp)
+		}},
+		{name: "response max-age=0", method: http.MethodGet, route: func(req *restful.Request, resp *restful.Response) {
+			resp.Header().Set(HeaderCacheControl, "max-age=0")
+			writeItems(req, resp)
+		}},
+		{name: "response cookie", method: http.MethodGet, route: func(req *restful.Request, resp *restful.Response) {
+			resp.Header().Set("Set-Cookie", "session=abc")
+			writeItems(req, resp)
+		}},
+		{name: "not found", method: http.MethodGet, route: func(req *restful.Request, resp *restful.Response) {
+			resp.WriteErrorString(http.StatusNotFound, "not found")
+		}},
+		{name: "large body", method: http.MethodGet, route: func(req *restful.Request, resp *restful.Response) {
+			_, _ = resp.Write([]byte(strings.Repeat("a", 20)))
+			_, _ = resp.Write([]byte(strings.Repeat("b", 20)))
+		}},
+		{name: "flushed", method: http.MethodGet, route: func(req *restful.Request, resp *restful.Response) {
+			_, _ = resp.Write([]byte("event"))
+			resp.Flush()
+		}},
+	}
+
+	for _, testCase := range testCases {
+		service := newTestService(&Options{MaxBodySize: 32}, testCase.route)
+
+		var body string
+		for i := 0; i < 2; i++ {
+			req := httptest.NewRequest(testCase.method, "/items", nil)
+			for name, values := range testCase.header {
+				req.Header[name] = values
+			}
+			resp := service.serve(req)
+			if i > 0 {
+				assert.Equal(t, body, resp.Body.String(), testCase.name)
+			}
+			body = resp.Body.String()
+		}
+		assert.Equal(t, 2, service.calls, testCase.name)
+	}
+}
+
+func TestFilter_StoreError(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(&Options{Store: failingStore{}}, writeItems)
+
+	for i := 0; i < 2; i++ {
+		resp := service.serve(httptest.NewRequest(http.MethodGet, "/items", nil))
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.JSONEq(t, `["sword","shield"]`, resp.Body.String())
+	}
+	assert.Equal(t, 2, service.calls)
+}
+
+func TestFilter_MaxAge(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clock.NewFake(time.Now())
+
+	service := newTestService(&Options{Clock: fakeClock, TTL: time.Hour}, func(req *restful.Request, resp *restful.Response) {
+		resp.Header().Set(HeaderCacheControl, "public, max-age=600, s-maxage=30")
+		writeItems(req, resp)
+	})
+
+	service.serve(httptest.NewRequest(http.MethodGet, "/items", nil))
+	service.serve(httptest.NewRequest(http.MethodGet, "/items", nil))
+	assert.Equal(t, 1, service.calls)
+
+	fakeClock.Advance(20 * time.Second)
+	resp := service.serve(httptest.NewRequest(http.MethodGet, "/items", nil))
+	assert.Equal(t, "20", resp.Header().Get(HeaderAge))
+	assert.Equal(t, 1, service.calls)
+
+	fakeClock.Advance(11 * time.Second)
+	service.serve(httptest.NewRequest(http.MethodGet, "/items", nil))
+	assert.Equal(t, 2, service.calls)
+}
+
+func TestMatchETag(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, matchETag(`"a"`, `"a"`))
+	assert.True(t, matchETag(`W/"a"`, `"a"`))
+	assert.True(t, matchETag(`"b", "a"`, `"a"`))
+	assert.True(t, matchETag(`*`, `"a"`))
+	assert.False(t, matchETag(`"b"`, `"a"`))
+	assert.False(t, matchETag("", `"a"`))
+}
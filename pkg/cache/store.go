@@ -0,0 +1,155 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/clock"
+)
+
+const defaultMaxSize = 64 << 20 // 64MB
+
+// Entry is a cached response
+type Entry struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	ETag       string
+	// MaskedBody is the response body masked the same way as the access log, printed in the access log of the hit
+	MaskedBody string
+	StoredAt   time.Time
+}
+
+// size returns the approximate memory size of the entry
+func (e *Entry) size() int {
+	size := len(e.Body) + len(e.MaskedBody) + len(e.ETag)
+	for name, values := range e.Header {
+		size += len(name)
+		for _, value := range values {
+			size += len(value)
+		}
+	}
+	return size
+}
+
+// Store keeps the cached responses, the returned entry must not be modified.
+// Implement this interface to share the cache between the replicas of the service, e.g. using Redis.
+type Store interface {
+	Get(ctx context.Context, key string) (*Entry, bool, error)
+	Set(ctx context.Context, key string, entry *Entry, ttl time.Duration) error
+}
+
+type memoryItem struct {
+	key       string
+	entry     *Entry
+	size      int
+	expiresAt time.Time
+}
+
+// MemoryStore keeps the cached responses in memory, so each replica of the service has its own cache.
+// The least recently used entries are evicted when the cache runs out of its size.
+type MemoryStore struct {
+	maxSize int
+	clock   clock.Clock
+
+	mutex   sync.Mutex
+	size    int
+	items   map[string]*list.Element
+	recency *list.List
+}
+
+// NewMemoryStore creates new MemoryStore instance holding up to maxSize bytes of the responses. Default maxSize: 64MB
+func NewMemoryStore(maxSize int) *MemoryStore {
+	if maxSize <= 0 {
+		maxSize = defaultMaxSize
+	}
+	return &MemoryStore{
+		maxSize: maxSize,
+		clock:   clock.System(),
+		items:   map[string]*list.Element{},
+		recency: list.New(),
+	}
+}
+
+// SetClock sets the time source of the entry expiry, e.g. a fake clock in the tests. Nil restores the system clock.
+func (s *MemoryStore) SetClock(c clock.Clock) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.clock = clock.OrSystem(c)
+}
+
+// Get returns the entry of the key if it's not expired yet
+func (s *MemoryStore) Get(_ context.Context, key string) (*Entry, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	element, ok := s.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	item := element.Value.(*memoryItem)
+	if !s.clock.Now().Before(item.expiresAt) {
+		s.remove(element)
+		return nil, false, nil
+	}
+	s.recency.MoveToFront(element)
+
+	return item.entry, true, nil
+}
+
+// Set stores the entry of the key for the ttl, the entry larger than the store is not stored
+func (s *MemoryStore) Set(_ context.Context, key string, entry *Entry, ttl time.Duration) error {
+	size := len(key) + entry.size()
+	if size > s.maxSize {
+		return nil
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if element, ok := s.items[key]; ok {
+		s.remove(element)
+	}
+
+	s.items[key] = s.recency.PushFront(&memoryItem{key: key, entry: entry, size: size, expiresAt: s.clock.Now().Add(ttl)})
+	s.size += size
+
+	for s.size > s.maxSize {
+		s.remove(s.recency.Back())
+	}
+
+	return nil
+}
+
+// Size returns the approximate memory size of the stored entries in bytes
+func (s *MemoryStore) Size() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.size
+}
+
+func (s *MemoryStore) remove(element *list.Element) {
+	item := s.recency.Remove(element).(*memoryItem)
+	delete(s.items, item.key)
+	s.size -= item.size
+}
@@ -0,0 +1,77 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore(0)
+	fakeClock := clock.NewFake(time.Now())
+	store.SetClock(fakeClock)
+	ctx := context.Background()
+
+	_, ok, err := store.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, store.Set(ctx, "key", &Entry{Body: []byte("body")}, time.Minute))
+	entry, ok, err := store.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "body", string(entry.Body))
+	assert.Equal(t, len("key")+len("body"), store.Size())
+
+	fakeClock.Advance(time.Minute)
+	_, ok, _ = store.Get(ctx, "key")
+	assert.False(t, ok)
+	assert.Equal(t, 0, store.Size())
+}
+
+func TestMemoryStore_Eviction(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore(30)
+	ctx := context.Background()
+	body := []byte(strings.Repeat("a", 9))
+
+	assert.NoError(t, store.Set(ctx, "k1", &Entry{Body: body}, time.Minute))
+	assert.NoError(t, store.Set(ctx, "k2", &Entry{Body: body}, time.Minute))
+	_, _, _ = store.Get(ctx, "k1")
+	assert.NoError(t, store.Set(ctx, "k3", &Entry{Body: body}, time.Minute))
+
+	// k2 is the least recently used
+	_, ok, _ := store.Get(ctx, "k2")
+	assert.False(t, ok)
+	_, ok, _ = store.Get(ctx, "k1")
+	assert.True(t, ok)
+	_, ok, _ = store.Get(ctx, "k3")
+	assert.True(t, ok)
+	assert.Equal(t, 22, store.Size())
+
+	// the entry larger than the store is not stored
+	assert.NoError(t, store.Set(ctx, "k4", &Entry{Body: []byte(strings.Repeat("a", 40))}, time.Minute))
+	_, ok, _ = store.Get(ctx, "k4")
+	assert.False(t, ok)
+}
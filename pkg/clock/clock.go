@@ -0,0 +1,147 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the time source of the filters, so the time dependent behavior (e.g. TTL, rate limit, token cache)
+// can be tested deterministically using Fake instead of sleeping
+type Clock interface {
+	Now() time.Time
+}
+
+// Waiter is implemented by the clock which can also wait on its time, e.g. to drive the background retries
+// from Fake in the tests
+type Waiter interface {
+	After(d time.Duration) <-chan time.Time
+}
+
+// After waits for the duration on the clock, or on the system time if the clock doesn't implement Waiter
+func After(clock Clock, d time.Duration) <-chan time.Time {
+	if waiter, ok := clock.(Waiter); ok {
+		return waiter.After(d)
+	}
+	return time.After(d)
+}
+
+// Func is an adapter to use the function as Clock
+type Func func() time.Time
+
+// Now calls f()
+func (f Func) Now() time.Time {
+	return f()
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// System returns the clock reading the system time
+func System() Clock {
+	return systemClock{}
+}
+
+// OrSystem returns the clock, or the system clock if it's nil
+func OrSystem(clock Clock) Clock {
+	if clock == nil {
+		return systemClock{}
+	}
+	return clock
+}
+
+// Fake is the clock which time only moves when it's set or advanced, it's safe for concurrent use
+type Fake struct {
+	mutex   sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+// fakeWaiter receives the time once the clock reaches the deadline
+type fakeWaiter struct {
+	deadline time.Time
+	c        chan time.Time
+}
+
+// NewFake creates new Fake instance starting at the given time
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now returns the current time of the clock
+func (c *Fake) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+// Advance moves the clock forward by the duration
+func (c *Fake) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(d)
+	c.notify()
+}
+
+// Set moves the clock to the given time
+func (c *Fake) Set(now time.Time) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = now
+	c.notify()
+}
+
+// After returns the channel receiving the time once the clock is advanced by the duration
+func (c *Fake) After(d time.Duration) <-chan time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	waiter := fakeWaiter{deadline: c.now.Add(d), c: make(chan time.Time, 1)}
+	c.waiters = append(c.waiters, waiter)
+	c.notify()
+	return waiter.c
+}
+
+// Waiters returns the number of the pending After calls, e.g. to advance the clock once the background goroutine waits
+func (c *Fake) Waiters() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.waiters)
+}
+
+// notify sends the time to the waiters reaching their deadline, the mutex must be held
+func (c *Fake) notify() {
+	pending := c.waiters[:0]
+	for _, waiter := range c.waiters {
+		if waiter.deadline.After(c.now) {
+			pending = append(pending, waiter)
+			continue
+		}
+		waiter.c <- c.now
+	}
+	c.waiters = pending
+}
@@ -0,0 +1,84 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystem(t *testing.T) {
+	t.Parallel()
+
+	before := time.Now()
+	now := System().Now()
+	assert.False(t, now.Before(before))
+	assert.False(t, OrSystem(nil).Now().Before(now))
+}
+
+func TestFake(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := NewFake(start)
+	assert.Equal(t, fake, OrSystem(fake))
+	assert.Equal(t, start, fake.Now())
+
+	fake.Advance(time.Minute)
+	assert.Equal(t, start.Add(time.Minute), fake.Now())
+
+	fake.Set(start)
+	assert.Equal(t, start, fake.Now())
+}
+
+func TestFake_After(t *testing.T) {
+	t.Parallel()
+
+	fake := NewFake(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	minute := After(fake, time.Minute)
+	hour := After(fake, time.Hour)
+	assert.Equal(t, 2, fake.Waiters())
+
+	fake.Advance(59 * time.Second)
+	assert.Len(t, minute, 0)
+
+	fake.Advance(time.Second)
+	assert.Equal(t, fake.Now(), <-minute)
+	assert.Len(t, hour, 0)
+	assert.Equal(t, 1, fake.Waiters())
+
+	fake.Set(fake.Now().Add(time.Hour))
+	assert.Equal(t, fake.Now(), <-hour)
+	assert.Equal(t, 0, fake.Waiters())
+
+	// the elapsed duration is received right away
+	assert.Equal(t, fake.Now(), <-After(fake, 0))
+}
+
+func TestSystem_After(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, (<-After(System(), time.Millisecond)).IsZero())
+	assert.False(t, (<-After(Func(time.Now), time.Millisecond)).IsZero())
+}
+
+func TestFunc(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, now, Func(func() time.Time { return now }).Now())
+}
@@ -0,0 +1,225 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"mime"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/emicklei/go-restful/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// media types of the built-in decoders
+const (
+	MIMEJSON = "application/json"
+	MIMEYAML = "application/yaml"
+	MIMEForm = "application/x-www-form-urlencoded"
+)
+
+// ErrUnsupportedContentType is returned when there is no decoder registered for the content type
+var ErrUnsupportedContentType = errors.New("unsupported content type")
+
+// Decoder decodes the body of a content type into the value, e.g. a pointer to a struct or *interface{}.
+// The context is the context of the request the body belongs to.
+type Decoder interface {
+	Decode(ctx context.Context, body []byte, v interface{}) error
+}
+
+// DecoderFunc is an adapter to use the function as Decoder
+type DecoderFunc func(ctx context.Context, body []byte, v interface{}) error
+
+// Decode calls f(ctx, body, v)
+func (f DecoderFunc) Decode(ctx context.Context, body []byte, v interface{}) error {
+	return f(ctx, body, v)
+}
+
+var (
+	// JSON decodes the JSON body, the numbers decoded into interface{} are json.Number
+	JSON Decoder = DecoderFunc(decodeJSON)
+	// YAML decodes the YAML body, the value is decoded using its yaml tags
+	YAML Decoder = DecoderFunc(decodeYAML)
+	// Form decodes the URL encoded form body into *interface{}, *map[string]interface{} or the value with json tags.
+	// The single value parameter is decoded as a string, the repeated parameter as an array of strings.
+	Form Decoder = DecoderFunc(decodeForm)
+)
+
+var (
+	registryMutex sync.RWMutex
+	registry      = map[string]Decoder{
+		MIMEJSON: JSON,
+	}
+)
+
+// Register registers the decoder of the media type (e.g. "application/yaml"),
+// used by the access log and the request binding. Nil decoder removes the media type.
+// Only the JSON decoder is registered by default.
+func Register(mediaType string, decoder Decoder) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+
+	mediaType = strings.ToLower(mediaType)
+	if decoder == nil {
+		delete(registry, mediaType)
+		return
+	}
+	registry[mediaType] = decoder
+}
+
+// Lookup returns the decoder of the content type. The parameters of the content type (e.g. charset) are ignored,
+// and the structured syntax suffix falls back to its base type, e.g. "application/problem+json" is decoded as JSON.
+func Lookup(contentType string) (Decoder, bool) {
+	_, decoder, ok := lookup(contentType)
+	return decoder, ok
+}
+
+// Supported checks whether there is a decoder registered for the content type
+func Supported(contentType string) bool {
+	_, ok := Lookup(contentType)
+	return ok
+}
+
+func lookup(contentType string) (string, Decoder, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", nil, false
+	}
+
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	if decoder, ok := registry[mediaType]; ok {
+		return mediaType, decoder, true
+	}
+	if suffixIndex := strings.LastIndex(mediaType, "+"); suffixIndex != -1 {
+		baseType := "application/" + mediaType[suffixIndex+1:]
+		if decoder, ok := registry[baseType]; ok {
+			return baseType, decoder, true
+		}
+	}
+	return "", nil, false
+}
+
+// Decode decodes the body of the content type using its registered decoder
+func Decode(ctx context.Context, contentType string, body []byte, v interface{}) error {
+	decoder, ok := Lookup(contentType)
+	if !ok {
+		return ErrUnsupportedContentType
+	}
+	return decoder.Decode(ctx, body, v)
+}
+
+// ToJSON converts the body of the content type into JSON, e.g. to be logged and masked as JSON.
+// The JSON body is returned as is, the other body is decoded into interface{} and encoded as JSON.
+func ToJSON(ctx context.Context, contentType string, body []byte) ([]byte, error) {
+	mediaType, decoder, ok := lookup(contentType)
+	if !ok {
+		return nil, ErrUnsupportedContentType
+	}
+	if mediaType == MIMEJSON {
+		return body, nil
+	}
+
+	var value interface{}
+	if err := decoder.Decode(ctx, body, &value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// Bind reads the request body and decodes it into the value according to the Content-Type header,
+// the request without Content-Type is decoded as JSON. The body is converted into JSON first (see ToJSON),
+// so the json tags of the value apply to any content type. The body is set back, so it can be read again.
+func Bind(req *restful.Request, v interface{}) error {
+	body, err := ReadBody(req)
+	if err != nil {
+		return err
+	}
+
+	contentType := req.Request.Header.Get(restful.HEADER_ContentType)
+	if contentType != "" {
+		body, err = ToJSON(req.Request.Context(), contentType, body)
+		if err != nil {
+			return err
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// ReadBody reads the request body and sets it back, so the handler can read it
+func ReadBody(req *restful.Request) ([]byte, error) {
+	if req.Request.Body == nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(req.Request.Body)
+	_ = req.Request.Body.Close()
+	req.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, err
+}
+
+func decodeJSON(_ context.Context, body []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+func decodeYAML(_ context.Context, body []byte, v interface{}) error {
+	return yaml.Unmarshal(body, v)
+}
+
+func decodeForm(_ context.Context, body []byte, v interface{}) error {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return err
+	}
+
+	form := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if len(value) == 1 {
+			form[key] = value[0]
+			continue
+		}
+		items := make([]interface{}, len(value))
+		for i, item := range value {
+			items[i] = item
+		}
+		form[key] = items
+	}
+
+	switch target := v.(type) {
+	case *interface{}:
+		*target = form
+		return nil
+	case *map[string]interface{}:
+		*target = form
+		return nil
+	}
+
+	encoded, err := json.Marshal(form)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, v)
+}
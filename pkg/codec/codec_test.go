@@ -0,0 +1,138 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codec
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, Supported("application/json"))
+	assert.True(t, Supported("application/json; charset=utf-8"))
+	assert.True(t, Supported("application/problem+json"))
+	assert.False(t, Supported("text/plain"))
+	assert.False(t, Supported(""))
+	assert.False(t, Supported("invalid;;"))
+}
+
+// nolint:paralleltest
+func TestRegister(t *testing.T) {
+	assert.False(t, Supported("application/yaml"))
+
+	Register("Application/YAML", YAML)
+	assert.True(t, Supported("application/yaml"))
+	assert.True(t, Supported("application/merge-patch+yaml"))
+
+	Register("application/yaml", nil)
+	assert.False(t, Supported("application/yaml"))
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	var value interface{}
+	assert.NoError(t, Decode(context.Background(), "application/json", []byte(`{"a":1}`), &value))
+	assert.Equal(t, map[string]interface{}{"a": json.Number("1")}, value)
+
+	assert.Equal(t, ErrUnsupportedContentType, Decode(context.Background(), "text/plain", []byte("a"), &value))
+}
+
+func TestToJSON(t *testing.T) {
+	t.Parallel()
+
+	// the JSON body is kept as is
+	body, err := ToJSON(context.Background(), "application/json", []byte(`{"b":1, "a":2}`))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"b":1, "a":2}`, string(body))
+
+	_, err = ToJSON(context.Background(), "text/plain", []byte("a"))
+	assert.Equal(t, ErrUnsupportedContentType, err)
+}
+
+func TestYAMLAndFormDecoder(t *testing.T) {
+	t.Parallel()
+
+	var value interface{}
+	assert.NoError(t, YAML.Decode(context.Background(), []byte("name: foo\ntags: [a, b]\n"), &value))
+	encoded, err := json.Marshal(value)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"foo","tags":["a","b"]}`, string(encoded))
+
+	assert.NoError(t, Form.Decode(context.Background(), []byte("name=foo&tag=a&tag=b"), &value))
+	assert.Equal(t, map[string]interface{}{"name": "foo", "tag": []interface{}{"a", "b"}}, value)
+
+	var form struct {
+		Name string   `json:"name"`
+		Tag  []string `json:"tag"`
+	}
+	assert.NoError(t, Form.Decode(context.Background(), []byte("name=foo&tag=a&tag=b"), &form))
+	assert.Equal(t, "foo", form.Name)
+	assert.Equal(t, []string{"a", "b"}, form.Tag)
+
+	assert.Error(t, Form.Decode(context.Background(), []byte("%zz"), &value))
+}
+
+// nolint:paralleltest
+func TestBind(t *testing.T) {
+	Register(MIMEYAML, YAML)
+	defer Register(MIMEYAML, nil)
+
+	type user struct {
+		UserName string `json:"userName"`
+		Age      int    `json:"age"`
+	}
+
+	bind := func(contentType string, body string) (user, *restful.Request, error) {
+		httpRequest := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+		if contentType != "" {
+			httpRequest.Header.Set("Content-Type", contentType)
+		}
+		req := restful.NewRequest(httpRequest)
+		value := user{}
+		err := Bind(req, &value)
+		return value, req, err
+	}
+
+	value, req, err := bind("application/json", `{"userName":"foo","age":20}`)
+	assert.NoError(t, err)
+	assert.Equal(t, user{UserName: "foo", Age: 20}, value)
+
+	// the body can be read again
+	body, err := ReadBody(req)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"userName":"foo","age":20}`, string(body))
+
+	// the json tags apply to the YAML body
+	value, _, err = bind("application/yaml", "userName: foo\nage: 20\n")
+	assert.NoError(t, err)
+	assert.Equal(t, user{UserName: "foo", Age: 20}, value)
+
+	value, _, err = bind("", `{"userName":"foo"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", value.UserName)
+
+	_, _, err = bind("text/plain", "foo")
+	assert.Equal(t, ErrUnsupportedContentType, err)
+}
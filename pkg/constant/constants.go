@@ -15,7 +15,8 @@
 package constant
 
 const (
-	ContentType = "Content-Type"
-	Referer     = "Referer"
-	UserAgent   = "User-Agent"
+	ContentType     = "Content-Type"
+	ContentEncoding = "Content-Encoding"
+	Referer         = "Referer"
+	UserAgent       = "User-Agent"
 )
@@ -19,6 +19,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/emicklei/go-restful/v3"
 	"github.com/sirupsen/logrus"
@@ -38,20 +39,25 @@ type CrossOriginResourceSharing struct {
 	AllowedMethods []string // list of allowed Methods
 	MaxAge         int      // number of seconds that indicates how long the results of a preflight request can be cached.
 	CookiesAllowed bool
-	Container      *restful.Container
+	Container      *restful.Container // container of the routes, required to apply the route policy to the preflight request
 }
 
 const (
 	AllowedDomainsRegexPrefix = "re:"
 )
 
-// Filter is a filter function that implements the CORS flow
+// patternCache keeps the compiled patterns of the allowed domains
+var patternCache sync.Map
+
+// Filter is a filter function that implements the CORS flow.
+// The route can override the configuration using PolicyMetadata route metadata.
 func (c CrossOriginResourceSharing) Filter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
 	origin := req.Request.Header.Get(restful.HEADER_Origin)
 	if len(origin) == 0 {
 		chain.ProcessFilter(req, resp)
 		return
 	}
+	c = c.resolvePolicy(req)
 	if !c.isOriginAllowed(origin) { // check whether this origin is allowed
 		logrus.Debugf("HTTP Origin:%s is not part of %v", origin, c.AllowedDomains)
 		chain.ProcessFilter(req, resp)
@@ -116,6 +122,8 @@ func (c *CrossOriginResourceSharing) doPreflightRequest(req *restful.Request, re
 func (c CrossOriginResourceSharing) setOptionsHeaders(req *restful.Request, resp *restful.Response) {
 	origin := req.Request.Header.Get(restful.HEADER_Origin)
 	resp.AddHeader(restful.HEADER_AccessControlAllowOrigin, origin)
+	// the allowed origin is echoed, so the cached response must vary by the origin
+	resp.AddHeader("Vary", restful.HEADER_Origin)
 
 	// some reference said that "Access-Control-Expose-Headers" should only be set for Actual request's response header (not Preflight request),
 	// but we're keep it here to follow the current implementation from go-restful.
@@ -149,6 +157,8 @@ func (c CrossOriginResourceSharing) isOriginAllowed(origin string) bool {
 			if pattern.MatchString(origin) {
 				return true
 			}
+		} else if strings.Contains(domain, "*") && getWildcardPattern(domain).MatchString(origin) {
+			return true
 		}
 	}
 
@@ -176,9 +186,37 @@ func (c CrossOriginResourceSharing) isValidAccessControlRequestHeader(header str
 }
 
 func getPattern(str string) (*regexp.Regexp, error) {
+	if pattern, ok := patternCache.Load(str); ok {
+		return pattern.(*regexp.Regexp), nil
+	}
+
 	split := strings.Split(str, AllowedDomainsRegexPrefix)
 	if len(split) < 2 {
 		return nil, errors.New("pattern not found")
 	}
-	return regexp.Compile(split[1])
+	pattern, err := regexp.Compile(split[1])
+	if err != nil {
+		return nil, err
+	}
+
+	patternCache.Store(str, pattern)
+	return pattern, nil
+}
+
+// getWildcardPattern converts the domain with wildcards to the regular expression,
+// e.g. "https://*.example.io" matches "https://api.example.io" and "https://dev.api.example.io".
+// The wildcard doesn't match the scheme or port separators and the path.
+func getWildcardPattern(domain string) *regexp.Regexp {
+	if pattern, ok := patternCache.Load(domain); ok {
+		return pattern.(*regexp.Regexp)
+	}
+
+	parts := strings.Split(domain, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	pattern := regexp.MustCompile("^" + strings.Join(parts, "[^/:]+") + "$")
+
+	patternCache.Store(domain, pattern)
+	return pattern
 }
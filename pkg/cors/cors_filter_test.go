@@ -333,3 +333,17 @@ func TestFilter_PreflightRequest(t *testing.T) {
 	assert.Equal(t, "https://www.example.io", resp4.Header().Get("Access-Control-Allow-Origin"))
 	assert.Equal(t, "true", resp4.Header().Get("Access-Control-Allow-Credentials"))
 }
+
+func TestIsOriginAllowed_Wildcard(t *testing.T) {
+	cors := CrossOriginResourceSharing{
+		AllowedDomains: []string{"https://*.example.io", "http://localhost:*"},
+	}
+	assert.True(t, cors.isOriginAllowed("https://www.example.io"))
+	assert.True(t, cors.isOriginAllowed("https://dev.api.example.io"))
+	assert.True(t, cors.isOriginAllowed("http://localhost:3000"))
+	assert.False(t, cors.isOriginAllowed("https://example.io"))
+	assert.False(t, cors.isOriginAllowed("https://www.example.io.evil.com"))
+	assert.False(t, cors.isOriginAllowed("https://evil.com/.example.io"))
+	assert.False(t, cors.isOriginAllowed("http://www.example.io"))
+	assert.False(t, cors.isOriginAllowed("http://localhost"))
+}
@@ -0,0 +1,97 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cors
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/envdoc"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultCrossOriginResourceSharing is the CORS configuration from the environment variables:
+// CORS_ALLOWED_DOMAINS, CORS_ALLOWED_METHODS, CORS_ALLOWED_HEADERS, CORS_EXPOSE_HEADERS, CORS_MAX_AGE
+// and CORS_COOKIES_ALLOWED. The lists are comma separated.
+var DefaultCrossOriginResourceSharing CrossOriginResourceSharing
+
+func init() {
+	envdoc.Register(
+		envdoc.Variable{Name: "CORS_ALLOWED_DOMAINS", Package: "cors", Type: envdoc.TypeList,
+			Description: "Allowed domains, every origin is allowed when it's empty"},
+		envdoc.Variable{Name: "CORS_ALLOWED_METHODS", Package: "cors", Type: envdoc.TypeList,
+			Default: "GET,HEAD,POST,PUT,PATCH,DELETE", Description: "Allowed methods"},
+		envdoc.Variable{Name: "CORS_ALLOWED_HEADERS", Package: "cors", Type: envdoc.TypeList,
+			Default: "Accept,Authorization,Content-Type", Description: "Allowed headers"},
+		envdoc.Variable{Name: "CORS_EXPOSE_HEADERS", Package: "cors", Type: envdoc.TypeList,
+			Description: "Exposed headers"},
+		envdoc.Variable{Name: "CORS_MAX_AGE", Package: "cors", Type: envdoc.TypeInteger, Default: "0",
+			Description: "Seconds the preflight response is cached"},
+		envdoc.Variable{Name: "CORS_COOKIES_ALLOWED", Package: "cors", Type: envdoc.TypeBoolean, Default: "false",
+			Description: "Whether the credentials are allowed"},
+	)
+
+	DefaultCrossOriginResourceSharing = loadEnv()
+}
+
+func loadEnv() CrossOriginResourceSharing {
+	cors := CrossOriginResourceSharing{
+		AllowedMethods: []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type"},
+	}
+
+	if s, exists := os.LookupEnv("CORS_ALLOWED_DOMAINS"); exists && s != "" {
+		cors.AllowedDomains = splitList(s)
+	}
+
+	if s, exists := os.LookupEnv("CORS_ALLOWED_METHODS"); exists && s != "" {
+		cors.AllowedMethods = splitList(strings.ToUpper(s))
+	}
+
+	if s, exists := os.LookupEnv("CORS_ALLOWED_HEADERS"); exists && s != "" {
+		cors.AllowedHeaders = splitList(s)
+	}
+
+	if s, exists := os.LookupEnv("CORS_EXPOSE_HEADERS"); exists && s != "" {
+		cors.ExposeHeaders = splitList(s)
+	}
+
+	if s, exists := os.LookupEnv("CORS_MAX_AGE"); exists {
+		value, err := strconv.Atoi(s)
+		if err != nil {
+			logrus.Errorf("Parse CORS_MAX_AGE env error: %v", err)
+		}
+		cors.MaxAge = value
+	}
+
+	if s, exists := os.LookupEnv("CORS_COOKIES_ALLOWED"); exists {
+		value, err := strconv.ParseBool(s)
+		if err != nil {
+			logrus.Errorf("Parse CORS_COOKIES_ALLOWED env error: %v", err)
+		}
+		cors.CookiesAllowed = value
+	}
+
+	return cors
+}
+
+func splitList(s string) []string {
+	values := strings.Split(s, ",")
+	for i, value := range values {
+		values[i] = strings.TrimSpace(value)
+	}
+	return values
+}
@@ -0,0 +1,57 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cors
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadEnv(t *testing.T) {
+	env := map[string]string{
+		"CORS_ALLOWED_DOMAINS": "https://*.example.io, re:^https://example\\.(io|com)$",
+		"CORS_ALLOWED_METHODS": "get,post",
+		"CORS_ALLOWED_HEADERS": "Content-Type,Device-Id",
+		"CORS_EXPOSE_HEADERS":  "X-Total-Count",
+		"CORS_MAX_AGE":         "600",
+		"CORS_COOKIES_ALLOWED": "true",
+	}
+	for key, value := range env {
+		os.Setenv(key, value)
+	}
+	defer func() {
+		for key := range env {
+			os.Unsetenv(key)
+		}
+	}()
+
+	cors := loadEnv()
+	assert.Equal(t, []string{"https://*.example.io", "re:^https://example\\.(io|com)$"}, cors.AllowedDomains)
+	assert.Equal(t, []string{"GET", "POST"}, cors.AllowedMethods)
+	assert.Equal(t, []string{"Content-Type", "Device-Id"}, cors.AllowedHeaders)
+	assert.Equal(t, []string{"X-Total-Count"}, cors.ExposeHeaders)
+	assert.Equal(t, 600, cors.MaxAge)
+	assert.True(t, cors.CookiesAllowed)
+	assert.True(t, cors.isOriginAllowed("https://example.com"))
+}
+
+func TestLoadEnv_Default(t *testing.T) {
+	cors := loadEnv()
+	assert.Empty(t, cors.AllowedDomains)
+	assert.Equal(t, []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"}, cors.AllowedMethods)
+	assert.False(t, cors.CookiesAllowed)
+}
@@ -0,0 +1,128 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cors
+
+import (
+	"strings"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+// PolicyMetadata is the route metadata key to override the CORS configuration of the route, the value is Policy
+const PolicyMetadata = "CORSPolicy"
+
+// Policy is the CORS configuration of the route, the empty fields keep the configuration of the filter
+type Policy struct {
+	AllowedDomains []string // list of allowed values for Http Origin, supports wildcard and "re:" regular expression
+	AllowedMethods []string // list of allowed Methods
+	AllowedHeaders []string // list of allowed Headers
+	ExposeHeaders  []string // list of exposed Headers
+	MaxAge         int      // number of seconds the result of the preflight request can be cached
+	CookiesAllowed *bool    // whether the credentials are allowed
+}
+
+// resolvePolicy returns the configuration overridden by the policy of the requested route.
+// The preflight request doesn't match any route, so its route is looked up from the Container
+// using the Access-Control-Request-Method header.
+func (c CrossOriginResourceSharing) resolvePolicy(req *restful.Request) CrossOriginResourceSharing {
+	var metadata map[string]interface{}
+	if route := req.SelectedRoute(); route != nil {
+		metadata = route.Metadata()
+	} else if c.Container != nil && req.Request.URL != nil && c.isPreflightRequest(req) {
+		method := req.Request.Header.Get(restful.HEADER_AccessControlRequestMethod)
+		if route, ok := findRoute(c.Container, method, req.Request.URL.Path); ok {
+			metadata = route.Metadata
+		}
+	}
+
+	policy, ok := metadata[PolicyMetadata].(Policy)
+	if !ok {
+		return c
+	}
+
+	if len(policy.AllowedDomains) > 0 {
+		c.AllowedDomains = policy.AllowedDomains
+	}
+	if len(policy.AllowedMethods) > 0 {
+		c.AllowedMethods = policy.AllowedMethods
+	}
+	if len(policy.AllowedHeaders) > 0 {
+		c.AllowedHeaders = policy.AllowedHeaders
+	}
+	if len(policy.ExposeHeaders) > 0 {
+		c.ExposeHeaders = policy.ExposeHeaders
+	}
+	if policy.MaxAge > 0 {
+		c.MaxAge = policy.MaxAge
+	}
+	if policy.CookiesAllowed != nil {
+		c.CookiesAllowed = *policy.CookiesAllowed
+	}
+
+	return c
+}
+
+// findRoute returns the route of the method matching the path, preferring the route with more literal segments,
+// e.g. /users/me over /users/{userId}
+func findRoute(container *restful.Container, method, path string) (restful.Route, bool) {
+	pathTokens := tokenizePath(path)
+
+	var found restful.Route
+	bestScore := -1
+	for _, ws := range container.RegisteredWebServices() {
+		for _, route := range ws.Routes() {
+			if route.Method != method {
+				continue
+			}
+			if score, ok := matchPath(tokenizePath(route.Path), pathTokens); ok && score > bestScore {
+				found = route
+				bestScore = score
+			}
+		}
+	}
+
+	return found, bestScore >= 0
+}
+
+// matchPath returns whether the path matches the route path, and the number of the matched literal segments
+func matchPath(routeTokens, pathTokens []string) (int, bool) {
+	score := 0
+	for i, routeToken := range routeTokens {
+		if strings.HasPrefix(routeToken, "{") && strings.HasSuffix(routeToken, ":*}") {
+			// the wildcard parameter matches the rest of the path
+			return score, true
+		}
+		if i >= len(pathTokens) {
+			return 0, false
+		}
+		if strings.HasPrefix(routeToken, "{") {
+			continue
+		}
+		if routeToken != pathTokens[i] {
+			return 0, false
+		}
+		score++
+	}
+
+	return score, len(routeTokens) == len(pathTokens)
+}
+
+func tokenizePath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
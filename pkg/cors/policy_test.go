@@ -0,0 +1,136 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func createPolicyTestContainer() *restful.Container {
+	cookiesAllowed := false
+
+	ws := new(restful.WebService)
+	ws.Path("/users")
+	ws.Route(ws.GET("/{userId}").
+		To(func(request *restful.Request, response *restful.Response) {}))
+	ws.Route(ws.DELETE("/{userId}").
+		Metadata(PolicyMetadata, Policy{
+			AllowedDomains: []string{"https://admin.example.io"},
+			AllowedMethods: []string{"DELETE"},
+			AllowedHeaders: []string{"Authorization"},
+			MaxAge:         60,
+			CookiesAllowed: &cookiesAllowed,
+		}).
+		To(func(request *restful.Request, response *restful.Response) {}))
+	ws.Route(ws.GET("/me").
+		Metadata(PolicyMetadata, Policy{ExposeHeaders: []string{"X-Total-Count"}}).
+		To(func(request *restful.Request, response *restful.Response) {}))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+	container.Filter(CrossOriginResourceSharing{
+		AllowedDomains: []string{"https://*.example.io"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type"},
+		CookiesAllowed: true,
+		MaxAge:         3600,
+		Container:      container,
+	}.Filter)
+
+	return container
+}
+
+func serveCORS(container *restful.Container, method, path, origin, requestMethod, requestHeaders string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Origin", origin)
+	if requestMethod != "" {
+		req.Header.Set("Access-Control-Request-Method", requestMethod)
+	}
+	if requestHeaders != "" {
+		req.Header.Set("Access-Control-Request-Headers", requestHeaders)
+	}
+
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestPolicy_Preflight(t *testing.T) {
+	container := createPolicyTestContainer()
+
+	// the route without policy uses the filter configuration
+	resp := serveCORS(container, http.MethodOptions, "/users/abc", "https://www.example.io", "GET", "Content-Type")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "https://www.example.io", resp.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET,POST", resp.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "3600", resp.Header().Get("Access-Control-Max-Age"))
+	assert.Equal(t, "true", resp.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "Origin", resp.Header().Get("Vary"))
+
+	// the route policy overrides the filter configuration
+	resp = serveCORS(container, http.MethodOptions, "/users/abc", "https://admin.example.io", "DELETE", "Authorization")
+	assert.Equal(t, "https://admin.example.io", resp.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "DELETE", resp.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Authorization", resp.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "60", resp.Header().Get("Access-Control-Max-Age"))
+	assert.Empty(t, resp.Header().Get("Access-Control-Allow-Credentials"))
+
+	// the origin isn't allowed by the route policy
+	resp = serveCORS(container, http.MethodOptions, "/users/abc", "https://www.example.io", "DELETE", "")
+	assert.Empty(t, resp.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, resp.Header().Get("Access-Control-Allow-Methods"))
+
+	// the header isn't allowed by the route policy
+	resp = serveCORS(container, http.MethodOptions, "/users/abc", "https://admin.example.io", "DELETE", "Content-Type")
+	assert.Empty(t, resp.Header().Get("Access-Control-Allow-Methods"))
+}
+
+func TestPolicy_ActualRequest(t *testing.T) {
+	container := createPolicyTestContainer()
+
+	resp := serveCORS(container, http.MethodGet, "/users/me", "https://www.example.io", "", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "https://www.example.io", resp.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "X-Total-Count", resp.Header().Get("Access-Control-Expose-Headers"))
+
+	resp = serveCORS(container, http.MethodGet, "/users/abc", "https://www.example.io", "", "")
+	assert.Empty(t, resp.Header().Get("Access-Control-Expose-Headers"))
+
+	resp = serveCORS(container, http.MethodDelete, "/users/abc", "https://www.example.io", "", "")
+	assert.Empty(t, resp.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestFindRoute(t *testing.T) {
+	container := createPolicyTestContainer()
+
+	route, ok := findRoute(container, http.MethodGet, "/users/me")
+	assert.True(t, ok)
+	assert.Equal(t, "/users/me", route.Path)
+
+	route, ok = findRoute(container, http.MethodGet, "/users/abc")
+	assert.True(t, ok)
+	assert.Equal(t, "/users/{userId}", route.Path)
+
+	_, ok = findRoute(container, http.MethodPost, "/users/abc")
+	assert.False(t, ok)
+
+	_, ok = findRoute(container, http.MethodGet, "/users/abc/roles")
+	assert.False(t, ok)
+}
@@ -0,0 +1,140 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envdoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Type is the data type of environment variable value
+type Type string
+
+const (
+	TypeString  Type = "string"
+	TypeInteger Type = "integer"
+	TypeBoolean Type = "boolean"
+	// TypeList is a comma-separated list of strings
+	TypeList Type = "list"
+)
+
+// Variable describes an environment variable read by a package
+type Variable struct {
+	Name        string `json:"name"`
+	Package     string `json:"package"`
+	Type        Type   `json:"type"`
+	Default     string `json:"default,omitempty"`
+	Description string `json:"description"`
+}
+
+var (
+	mutex     sync.RWMutex
+	variables = make(map[string]Variable)
+)
+
+// Register registers the environment variables read by a package, usually in its init function.
+// The variable with the same name replaces the registered one.
+func Register(vars ...Variable) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for _, variable := range vars {
+		variables[variable.Name] = variable
+	}
+}
+
+// Variables returns the registered environment variables, sorted by the package and the name.
+// Only the variables of the imported packages are registered.
+func Variables() []Variable {
+	mutex.RLock()
+	result := make([]Variable, 0, len(variables))
+	for _, variable := range variables {
+		result = append(result, variable)
+	}
+	mutex.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Package != result[j].Package {
+			return result[i].Package < result[j].Package
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// Validate checks the value of the registered environment variables which are set against their type
+func Validate() []error {
+	errs := make([]error, 0)
+	for _, variable := range Variables() {
+		value, exists := os.LookupEnv(variable.Name)
+		if !exists {
+			continue
+		}
+		if err := validateValue(variable.Type, value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s env value %q: %w", variable.Name, value, err))
+		}
+	}
+	return errs
+}
+
+func validateValue(variableType Type, value string) error {
+	switch variableType {
+	case TypeInteger:
+		_, err := strconv.ParseInt(value, 0, 64)
+		return err
+	case TypeBoolean:
+		_, err := strconv.ParseBool(value)
+		return err
+	default:
+		return nil
+	}
+}
+
+// JSON renders the registered environment variables as JSON array
+func JSON() ([]byte, error) {
+	return json.MarshalIndent(Variables(), "", "  ")
+}
+
+// Markdown renders the registered environment variables as Markdown tables grouped by the package
+func Markdown() string {
+	builder := &strings.Builder{}
+	currentPackage := ""
+	for _, variable := range Variables() {
+		if variable.Package != currentPackage || builder.Len() == 0 {
+			if builder.Len() > 0 {
+				builder.WriteString("\n")
+			}
+			currentPackage = variable.Package
+			fmt.Fprintf(builder, "### %s\n\n", currentPackage)
+			builder.WriteString("| Name | Type | Default | Description |\n")
+			builder.WriteString("|------|------|---------|-------------|\n")
+		}
+		fmt.Fprintf(builder, "| `%s` | %s | %s | %s |\n",
+			variable.Name, variable.Type, markdownCell(variable.Default), markdownCell(variable.Description))
+	}
+	return builder.String()
+}
+
+func markdownCell(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return strings.ReplaceAll(value, "|", "\\|")
+}
@@ -0,0 +1,80 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package envdoc
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func resetVariables() {
+	mutex.Lock()
+	variables = make(map[string]Variable)
+	mutex.Unlock()
+}
+
+// nolint:paralleltest
+func TestVariables(t *testing.T) {
+	resetVariables()
+	Register(
+		Variable{Name: "TEST_B_ENABLED", Package: "b", Type: TypeBoolean, Default: "false", Description: "enable b"},
+		Variable{Name: "TEST_A_SIZE", Package: "a", Type: TypeInteger, Default: "10", Description: "size of a"},
+		Variable{Name: "TEST_A_NAMES", Package: "a", Type: TypeList, Description: "names | aliases of a"},
+	)
+
+	names := make([]string, 0)
+	for _, variable := range Variables() {
+		names = append(names, variable.Name)
+	}
+	assert.Equal(t, []string{"TEST_A_NAMES", "TEST_A_SIZE", "TEST_B_ENABLED"}, names)
+
+	result, err := JSON()
+	assert.NoError(t, err)
+	var decoded []Variable
+	assert.NoError(t, json.Unmarshal(result, &decoded))
+	assert.Equal(t, Variables(), decoded)
+
+	expected := "### a\n\n" +
+		"| Name | Type | Default | Description |\n" +
+		"|------|------|---------|-------------|\n" +
+		"| `TEST_A_NAMES` | list | - | names \\| aliases of a |\n" +
+		"| `TEST_A_SIZE` | integer | 10 | size of a |\n" +
+		"\n### b\n\n" +
+		"| Name | Type | Default | Description |\n" +
+		"|------|------|---------|-------------|\n" +
+		"| `TEST_B_ENABLED` | boolean | false | enable b |\n"
+	assert.Equal(t, expected, Markdown())
+}
+
+// nolint:paralleltest
+func TestValidate(t *testing.T) {
+	resetVariables()
+	Register(
+		Variable{Name: "TEST_VALIDATE_ENABLED", Package: "validate", Type: TypeBoolean},
+		Variable{Name: "TEST_VALIDATE_SIZE", Package: "validate", Type: TypeInteger},
+	)
+	defer os.Unsetenv("TEST_VALIDATE_ENABLED")
+	defer os.Unsetenv("TEST_VALIDATE_SIZE")
+
+	os.Setenv("TEST_VALIDATE_ENABLED", "true")
+	os.Setenv("TEST_VALIDATE_SIZE", "10KB")
+
+	errs := Validate()
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "TEST_VALIDATE_SIZE")
+}
@@ -0,0 +1,61 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	iamSDK "github.com/AccelByte/iam-go-sdk"
+)
+
+// Checker checks whether a dependency of the service is healthy
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc is an adapter to use the function as Checker
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f(ctx)
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Pinger is implemented by *sql.DB and the clients of the other databases
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// PingChecker checks the database connectivity, e.g. health.PingChecker(db) where db is *sql.DB
+func PingChecker(pinger Pinger) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		if err := pinger.PingContext(ctx); err != nil {
+			return fmt.Errorf("ping failed: %v", err)
+		}
+		return nil
+	})
+}
+
+// IAMChecker checks the IAM connectivity using the health check of the IAM client
+func IAMChecker(client iamSDK.Client) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		if !client.HealthCheck() {
+			return errors.New("IAM client is unhealthy")
+		}
+		return nil
+	})
+}
@@ -0,0 +1,47 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	iamSDK "github.com/AccelByte/iam-go-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPinger struct {
+	err error
+}
+
+func (p testPinger) PingContext(context.Context) error {
+	return p.err
+}
+
+func TestPingChecker(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, PingChecker(testPinger{}).Check(context.Background()))
+	assert.EqualError(t, PingChecker(testPinger{err: errors.New("connection refused")}).Check(context.Background()),
+		"ping failed: connection refused")
+}
+
+func TestIAMChecker(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, IAMChecker(&iamSDK.MockClient{Healthy: true}).Check(context.Background()))
+	assert.Error(t, IAMChecker(&iamSDK.MockClient{Healthy: false}).Check(context.Background()))
+}
@@ -0,0 +1,299 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/plugins"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/response"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// StatusUp is the status of the healthy service or check
+	StatusUp = "UP"
+	// StatusDown is the status of the unhealthy service or check
+	StatusDown = "DOWN"
+
+	// ServiceShuttingDown is the error code when the request is rejected because the service is shutting down
+	ServiceShuttingDown = response.ServiceShuttingDown
+
+	defaultCheckTimeout  = 5 * time.Second
+	drainPollingInterval = 10 * time.Millisecond
+
+	fieldDraining = "draining"
+)
+
+var errCheckPanicked = errors.New("check panicked")
+
+// Options contains options for Health
+type Options struct {
+	// CheckTimeout is the maximum duration of each check. Default: 5 seconds
+	CheckTimeout time.Duration
+}
+
+// CheckResult is the result of a single check
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the result of the liveness or readiness checks
+type Report struct {
+	Status   string                 `json:"status"`
+	Draining bool                   `json:"draining,omitempty"`
+	Checks   map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type namedChecker struct {
+	name    string
+	checker Checker
+}
+
+// Health serves the liveness and readiness endpoints and drains the requests when the service is shutting down
+type Health struct {
+	// the atomic counters come first to keep them 64-bit aligned on 32-bit platforms
+	inFlight int64
+	draining int32
+
+	checkTimeout time.Duration
+
+	mutex           sync.RWMutex
+	livenessChecks  []namedChecker
+	readinessChecks []namedChecker
+}
+
+// New creates new Health instance
+func New(options Options) *Health {
+	checkTimeout := options.CheckTimeout
+	if checkTimeout <= 0 {
+		checkTimeout = defaultCheckTimeout
+	}
+	return &Health{checkTimeout: checkTimeout}
+}
+
+// AddLivenessCheck adds the check of the liveness endpoint. The liveness check should only fail
+// when the service can't recover without being restarted, the unavailable dependencies belong to the readiness checks.
+func (h *Health) AddLivenessCheck(name string, checker Checker) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.livenessChecks = append(h.livenessChecks, namedChecker{name: name, checker: checker})
+}
+
+// AddReadinessCheck adds the check of the readiness endpoint, e.g. the database or IAM connectivity
+func (h *Health) AddReadinessCheck(name string, checker Checker) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.readinessChecks = append(h.readinessChecks, namedChecker{name: name, checker: checker})
+}
+
+// Liveness runs the liveness checks
+func (h *Health) Liveness(ctx context.Context) Report {
+	h.mutex.RLock()
+	checks := h.livenessChecks
+	h.mutex.RUnlock()
+
+	return h.run(ctx, checks)
+}
+
+// Readiness runs the readiness checks, the service isn't ready while it's draining
+func (h *Health) Readiness(ctx context.Context) Report {
+	h.mutex.RLock()
+	checks := h.readinessChecks
+	h.mutex.RUnlock()
+
+	report := h.run(ctx, checks)
+	if h.Draining() {
+		report.Status = StatusDown
+		report.Draining = true
+	}
+	return report
+}
+
+// run runs the checks concurrently, each of them is given up to checkTimeout
+func (h *Health) run(ctx context.Context, checks []namedChecker) Report {
+	report := Report{Status: StatusUp}
+	if len(checks) == 0 {
+		return report
+	}
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = h.check(ctx, checks[i].checker)
+		}(i)
+	}
+	wg.Wait()
+
+	report.Checks = make(map[string]CheckResult, len(checks))
+	for i, check := range checks {
+		report.Checks[check.name] = results[i]
+		if results[i].Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+func (h *Health) check(ctx context.Context, checker Checker) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.checkTimeout)
+	defer cancel()
+
+	// the checker ignoring the context is given up after the timeout
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				logrus.Errorf("health check panicked: %v", r)
+				done <- errCheckPanicked
+			}
+		}()
+		done <- checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		return CheckResult{Status: StatusDown, Error: err.Error()}
+	}
+	return CheckResult{Status: StatusUp}
+}
+
+// LivenessHandler returns http.Handler of the liveness endpoint, it responds 200 or 503 with the Report in JSON format
+func (h *Health) LivenessHandler() http.Handler {
+	return reportHandler(h.Liveness)
+}
+
+// ReadinessHandler returns http.Handler of the readiness endpoint, it responds 200 or 503 with the Report in JSON format
+func (h *Health) ReadinessHandler() http.Handler {
+	return reportHandler(h.Readiness)
+}
+
+func reportHandler(run func(ctx context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := run(r.Context())
+
+		status := http.StatusOK
+		if report.Status != StatusUp {
+			status = http.StatusServiceUnavailable
+			logrus.Warnf("%s is %s: %v", r.URL.Path, report.Status, failedChecks(report))
+		}
+
+		w.Header().Set("Content-Type", restful.MIME_JSON)
+		w.WriteHeader(status)
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			logrus.Error(err)
+		}
+	})
+}
+
+func failedChecks(report Report) []string {
+	failed := make([]string, 0)
+	for name, result := range report.Checks {
+		if result.Status != StatusUp {
+			failed = append(failed, name+": "+result.Error)
+		}
+	}
+	sort.Strings(failed)
+	if report.Draining {
+		failed = append(failed, fieldDraining)
+	}
+	return failed
+}
+
+// Filter counts the in-flight requests. While the service is draining, the new request is rejected
+// with 503 error response and "Connection: close" header, so the client retries on another instance.
+func (h *Health) Filter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	// counted before checking the flag, so Drain never misses a request that has passed the check
+	atomic.AddInt64(&h.inFlight, 1)
+	if h.Draining() {
+		atomic.AddInt64(&h.inFlight, -1)
+		log.AdditionalFields(req, map[string]interface{}{fieldDraining: true})
+		resp.Header().Set("Connection", "close")
+		response.WriteErrorEnvelope(req, resp, http.StatusServiceUnavailable,
+			&response.Error{ErrorCode: ServiceShuttingDown, ErrorMessage: "service is shutting down"})
+		return
+	}
+	defer atomic.AddInt64(&h.inFlight, -1)
+
+	chain.ProcessFilter(req, resp)
+}
+
+// Draining checks whether the service is draining
+func (h *Health) Draining() bool {
+	return atomic.LoadInt32(&h.draining) == 1
+}
+
+// InFlight returns the number of requests being served by the Filter
+func (h *Health) InFlight() int {
+	return int(atomic.LoadInt64(&h.inFlight))
+}
+
+// Drain flips the readiness to failing and rejects the new requests,
+// then waits until the in-flight requests finish or the context is done.
+func (h *Health) Drain(ctx context.Context) error {
+	if atomic.CompareAndSwapInt32(&h.draining, 0, 1) {
+		logrus.Infof("draining %d in-flight requests", h.InFlight())
+	}
+
+	ticker := time.NewTicker(drainPollingInterval)
+	defer ticker.Stop()
+
+	for {
+		if h.InFlight() == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Shutdown drains the requests, shuts down the server, writes the access log summary if it's enabled,
+// then flushes the registered plugins.Flusher, so the logs, metrics and traces of the last requests aren't lost.
+// Call it on SIGTERM, e.g.
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+//	defer cancel()
+//	err := h.Shutdown(ctx, server)
+func (h *Health) Shutdown(ctx context.Context, server *http.Server) error {
+	if err := h.Drain(ctx); err != nil {
+		logrus.Warnf("unable to drain in-flight requests: %v", err)
+	}
+	if err := server.Shutdown(ctx); err != nil {
+		return err
+	}
+	log.WriteAccessLogSummary()
+	return plugins.ForceFlush(ctx)
+}
@@ -0,0 +1,210 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/response"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func healthy(context.Context) error {
+	return nil
+}
+
+func serveReport(t *testing.T, handler http.Handler) (int, Report) {
+	t.Helper()
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report Report
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &report))
+	return resp.Code, report
+}
+
+func TestReadinessHandler(t *testing.T) {
+	t.Parallel()
+
+	h := New(Options{})
+	h.AddReadinessCheck("db", CheckerFunc(healthy))
+	h.AddReadinessCheck("iam", CheckerFunc(func(context.Context) error { return errors.New("unreachable") }))
+
+	status, report := serveReport(t, h.ReadinessHandler())
+
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, Report{
+		Status: StatusDown,
+		Checks: map[string]CheckResult{
+			"db":  {Status: StatusUp},
+			"iam": {Status: StatusDown, Error: "unreachable"},
+		},
+	}, report)
+}
+
+func TestLivenessHandlerIgnoresReadinessChecks(t *testing.T) {
+	t.Parallel()
+
+	h := New(Options{})
+	h.AddReadinessCheck("iam", CheckerFunc(func(context.Context) error { return errors.New("unreachable") }))
+
+	status, report := serveReport(t, h.LivenessHandler())
+
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, Report{Status: StatusUp}, report)
+}
+
+func TestCheckTimeout(t *testing.T) {
+	t.Parallel()
+
+	h := New(Options{CheckTimeout: 10 * time.Millisecond})
+	block := make(chan struct{})
+	defer close(block)
+	h.AddLivenessCheck("stuck", CheckerFunc(func(context.Context) error {
+		<-block
+		return nil
+	}))
+
+	report := h.Liveness(context.Background())
+
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["stuck"].Error)
+}
+
+func TestCheckPanic(t *testing.T) {
+	t.Parallel()
+
+	h := New(Options{})
+	h.AddLivenessCheck("broken", CheckerFunc(func(context.Context) error { panic("boom") }))
+
+	report := h.Liveness(context.Background())
+
+	assert.Equal(t, CheckResult{Status: StatusDown, Error: "check panicked"}, report.Checks["broken"])
+}
+
+func TestDrainRejectsNewRequestsAndWaitsInFlight(t *testing.T) {
+	t.Parallel()
+
+	h := New(Options{})
+	h.AddReadinessCheck("db", CheckerFunc(healthy))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	ws := new(restful.WebService)
+	ws.Filter(h.Filter)
+	ws.Route(ws.GET("/slow").To(func(req *restful.Request, resp *restful.Response) {
+		close(started)
+		<-release
+		resp.WriteHeader(http.StatusOK)
+	}))
+	ws.Route(ws.GET("/fast").To(func(req *restful.Request, resp *restful.Response) {
+		resp.WriteHeader(http.StatusOK)
+	}))
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	slowResp := httptest.NewRecorder()
+	slowDone := make(chan struct{})
+	go func() {
+		defer close(slowDone)
+		container.ServeHTTP(slowResp, httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+	<-started
+
+	drained := make(chan error, 1)
+	go func() {
+		drained <- h.Drain(context.Background())
+	}()
+	assert.Eventually(t, h.Draining, time.Second, time.Millisecond)
+
+	// the new request is rejected
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Equal(t, "close", resp.Header().Get("Connection"))
+	var errorResponse response.Error
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &errorResponse))
+	assert.Equal(t, ServiceShuttingDown, errorResponse.ErrorCode)
+
+	// the readiness fails
+	status, report := serveReport(t, h.ReadinessHandler())
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.True(t, report.Draining)
+
+	// the in-flight request finishes
+	select {
+	case <-drained:
+		t.Fatal("drained before the in-flight request finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-slowDone
+	assert.Equal(t, http.StatusOK, slowResp.Code)
+	assert.NoError(t, <-drained)
+	assert.Equal(t, 0, h.InFlight())
+}
+
+func TestDrainTimeout(t *testing.T) {
+	t.Parallel()
+
+	h := New(Options{})
+	h.inFlight = 1
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, h.Drain(ctx))
+}
+
+func TestShutdown(t *testing.T) {
+	t.Parallel()
+
+	h := New(Options{})
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	assert.NoError(t, h.Shutdown(context.Background(), server.Config))
+	assert.True(t, h.Draining())
+}
+
+// nolint:paralleltest
+func TestShutdown_AccessLogSummary(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	log.SetAccessLogOutput(buffer)
+	log.FullAccessLogSummaryEnabled = true
+	defer func() {
+		log.SetAccessLogOutput(os.Stdout)
+		log.FullAccessLogSummaryEnabled = false
+	}()
+
+	h := New(Options{})
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	assert.NoError(t, h.Shutdown(context.Background(), server.Config))
+	assert.Contains(t, buffer.String(), "log_type=access_summary")
+}
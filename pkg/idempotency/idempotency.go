@@ -0,0 +1,300 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/clock"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/response"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// HeaderIdempotencyKey is the request header of the client-generated idempotency key
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed is the response header set to "true" when the stored response is replayed
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	// fieldIdempotentReplayed is the access log field of the replayed response
+	fieldIdempotentReplayed = "idempotent_replayed"
+
+	maxKeyLength              = 255
+	defaultTTL                = 24 * time.Hour
+	defaultMaxBodySize        = 1 << 20 // 1MB
+	defaultMaxRequestBodySize = 1 << 20 // 1MB
+	// inProgressRetryAfter is the Retry-After in seconds of the duplicate request while the first one is processed
+	inProgressRetryAfter = 1
+)
+
+// errRequestBodyTooLarge is returned when the body of the request with the idempotency key exceeds MaxRequestBodySize
+var errRequestBodyTooLarge = errors.New("request body exceeds the maximum size of the idempotent request")
+
+// KeyFunc returns the store key of the request with the idempotency key
+type KeyFunc func(req *restful.Request, idempotencyKey string) string
+
+// BySubject scopes the idempotency key by the method, path and the subject (user or client) of the access token,
+// so the same key sent by the different callers or to the different endpoints never replays the other's response
+func BySubject(req *restful.Request, idempotencyKey string) string {
+	subject := ""
+	if claims := iam.RetrieveJWTClaims(req); claims != nil {
+		subject = claims.Subject
+		if subject == "" {
+			subject = claims.ClientID
+		}
+	}
+	return req.Request.Method + " " + req.Request.URL.Path + "#" + subject + "#" + idempotencyKey
+}
+
+// Options contains options for the idempotency filter
+type Options struct {
+	// TTL is how long the response is replayed for the duplicate requests. Default: 24 hours
+	TTL time.Duration
+	// Methods are the HTTP methods honoring the idempotency key. Default: POST and PATCH
+	Methods []string
+	// MaxBodySize is the size of the largest response body to be stored in bytes,
+	// the larger response is not stored, so the duplicate request is processed again. Default: 1MB
+	MaxBodySize int
+	// MaxRequestBodySize is the size of the largest request body with the idempotency key in bytes,
+	// the body is buffered to be hashed, so the larger request is rejected with 413. Default: 1MB
+	MaxRequestBodySize int64
+	// KeyFunc keys the stored responses. Default: BySubject
+	KeyFunc KeyFunc
+	// Store keeps the stored responses. Default: NewMemoryStore()
+	Store Store
+	// Clock is the time source of the stored time, also used by the default store. Default: the system clock
+	Clock clock.Clock
+}
+
+// Filter is a filter that honors the Idempotency-Key header of the mutating requests.
+// The first response of the key is stored and replayed for the duplicate requests within the TTL,
+// the duplicate request with a different payload, or sent while the first one is still processed, is rejected with 409.
+// The 5xx responses are not stored, so the failed request can be retried with the same key.
+// It should be added after the IAM filter, so the key is scoped by the caller.
+func Filter(options *Options) restful.FilterFunction {
+	if options == nil {
+		options = &Options{}
+	}
+	ttl := options.TTL
+	if ttl <= 0 {
+		ttl = defaultTTL
+	}
+	methods := options.Methods
+	if len(methods) == 0 {
+		methods = []string{http.MethodPost, http.MethodPatch}
+	}
+	maxBodySize := options.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = defaultMaxBodySize
+	}
+	maxRequestBodySize := options.MaxRequestBodySize
+	if maxRequestBodySize <= 0 {
+		maxRequestBodySize = defaultMaxRequestBodySize
+	}
+	keyFunc := options.KeyFunc
+	if keyFunc == nil {
+		keyFunc = BySubject
+	}
+	clk := clock.OrSystem(options.Clock)
+	store := options.Store
+	if store == nil {
+		memoryStore := NewMemoryStore()
+		memoryStore.SetClock(clk)
+		store = memoryStore
+	}
+
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		idempotencyKey := req.Request.Header.Get(HeaderIdempotencyKey)
+		if idempotencyKey == "" || !containsMethod(methods, req.Request.Method) {
+			chain.ProcessFilter(req, resp)
+			return
+		}
+
+		if len(idempotencyKey) > maxKeyLength {
+			response.WriteErrorEnvelope(req, resp, http.StatusBadRequest, &response.Error{
+				ErrorCode:    iam.ValidationError,
+				ErrorMessage: "invalid request: " + HeaderIdempotencyKey + " header is longer than " + strconv.Itoa(maxKeyLength) + " characters",
+			})
+			return
+		}
+
+		requestHash, err := hashRequest(req, maxRequestBodySize)
+		if err == errRequestBodyTooLarge {
+			response.WriteErrorEnvelope(req, resp, http.StatusRequestEntityTooLarge, &response.Error{
+				ErrorCode:    iam.ValidationError,
+				ErrorMessage: err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			logrus.Errorf("unable to read request body: %v", err)
+			response.WriteErrorEnvelope(req, resp, http.StatusBadRequest, &response.Error{
+				ErrorCode:    iam.ValidationError,
+				ErrorMessage: "invalid request: unable to read request body",
+			})
+			return
+		}
+
+		key := keyFunc(req, idempotencyKey)
+		ctx := req.Request.Context()
+		entry, reserved, err := store.Reserve(ctx, key, &Entry{RequestHash: requestHash}, ttl)
+		if err != nil {
+			// the request is still processed, only without the idempotency guarantee
+			logrus.Errorf("unable to reserve idempotency key %s: %v", key, err)
+			chain.ProcessFilter(req, resp)
+			return
+		}
+
+		if !reserved {
+			switch {
+			case entry.RequestHash != requestHash:
+				response.WriteErrorEnvelope(req, resp, http.StatusConflict, &response.Error{
+					ErrorCode:    iam.ValidationError,
+					ErrorMessage: "conflict: " + HeaderIdempotencyKey + " is already used by a request with a different payload",
+				})
+			case !entry.Completed:
+				resp.Header().Set("Retry-After", strconv.Itoa(inProgressRetryAfter))
+				response.WriteErrorEnvelope(req, resp, http.StatusConflict, &response.Error{
+					ErrorCode:    iam.ValidationError,
+					ErrorMessage: "conflict: a request with the same " + HeaderIdempotencyKey + " is still being processed",
+				})
+			default:
+				replay(req, resp, entry)
+			}
+			return
+		}
+
+		// the headers set by the outer filters (e.g. the request ID) are not replayed
+		outerHeader := resp.Header().Clone()
+		original := resp.ResponseWriter
+		interceptor := log.NewResponseWriterInterceptor(original, maxBodySize)
+		resp.ResponseWriter = interceptor
+		completed := false
+		// restored and released on panic too, the reservation is removed so the request can be retried
+		defer func() {
+			resp.ResponseWriter = original
+			interceptor.Release()
+			if !completed {
+				if err := store.Delete(ctx, key); err != nil {
+					logrus.Errorf("unable to delete idempotency key %s: %v", key, err)
+				}
+			}
+		}()
+
+		chain.ProcessFilter(req, resp)
+
+		statusCode := interceptor.StatusCode()
+		if statusCode == 0 {
+			statusCode = http.StatusOK
+		}
+		if statusCode >= http.StatusInternalServerError || interceptor.Truncated() {
+			return
+		}
+
+		completed = true
+		entry = &Entry{
+			RequestHash: requestHash,
+			Completed:   true,
+			StatusCode:  statusCode,
+			Header:      handlerHeader(outerHeader, original.Header()),
+			Body:        append([]byte(nil), interceptor.Body()...),
+			StoredAt:    clk.Now(),
+		}
+		if err := store.Set(ctx, key, entry, ttl); err != nil {
+			logrus.Errorf("unable to store response of idempotency key %s: %v", key, err)
+		}
+	}
+}
+
+// replay writes the stored response of the first request
+func replay(req *restful.Request, resp *restful.Response, entry *Entry) {
+	header := resp.Header()
+	for name, values := range entry.Header {
+		header[name] = append([]string(nil), values...)
+	}
+	header.Set(HeaderIdempotentReplayed, "true")
+	log.AdditionalFields(req, map[string]interface{}{fieldIdempotentReplayed: true})
+
+	resp.WriteHeader(entry.StatusCode)
+	if _, err := resp.Write(entry.Body); err != nil {
+		logrus.Errorf("unable to write replayed response: %v", err)
+	}
+}
+
+// handlerHeader returns the response headers set or changed after the outer headers were captured
+func handlerHeader(outer http.Header, header http.Header) http.Header {
+	result := http.Header{}
+	for name, values := range header {
+		if previous, ok := outer[name]; ok && equalValues(previous, values) {
+			continue
+		}
+		result[name] = append([]string(nil), values...)
+	}
+	return result
+}
+
+func equalValues(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// hashRequest returns the hash of the method, URL and body of the request, the body is set back into the request.
+// The body is hashed while it's read, up to maxBodySize bytes.
+func hashRequest(req *restful.Request, maxBodySize int64) (string, error) {
+	hash := sha256.New()
+	hash.Write([]byte(req.Request.Method + " " + req.Request.URL.RequestURI() + "\n"))
+
+	if req.Request.Body != nil && req.Request.Body != http.NoBody {
+		body := new(bytes.Buffer)
+		n, err := io.Copy(io.MultiWriter(hash, body), io.LimitReader(req.Request.Body, maxBodySize+1))
+		if err != nil {
+			return "", err
+		}
+		if n > maxBodySize {
+			return "", errRequestBodyTooLarge
+		}
+		_ = req.Request.Body.Close()
+		req.Request.Body = ioutil.NopCloser(body)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,244 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package idempotency
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/go-jose/jwt"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/clock"
+	iamSDK "github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type testServer struct {
+	container *restful.Container
+	calls     int32
+}
+
+func newTestServer(options *Options, handler restful.RouteFunction) *testServer {
+	server := &testServer{container: restful.NewContainer()}
+
+	ws := new(restful.WebService)
+	ws.Filter(func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		resp.Header().Set("X-Request-Id", req.Request.Header.Get("X-Request-Id"))
+		if subject := req.Request.Header.Get("X-Subject"); subject != "" {
+			req.SetAttribute(iam.ClaimsAttribute, &iamSDK.JWTClaims{Claims: jwt.Claims{Subject: subject}})
+		}
+		chain.ProcessFilter(req, resp)
+	})
+	ws.Filter(Filter(options))
+	ws.Route(ws.POST("/orders").To(func(req *restful.Request, resp *restful.Response) {
+		atomic.AddInt32(&server.calls, 1)
+		handler(req, resp)
+	}))
+	ws.Route(ws.PUT("/orders").To(func(req *restful.Request, resp *restful.Response) {
+		atomic.AddInt32(&server.calls, 1)
+		handler(req, resp)
+	}))
+	server.container.Add(ws)
+
+	return server
+}
+
+func (s *testServer) serve(method string, key string, body string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/orders", strings.NewReader(body))
+	req.Header.Set("Content-Type", restful.MIME_JSON)
+	if key != "" {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	resp := httptest.NewRecorder()
+	s.container.ServeHTTP(resp, req)
+	return resp
+}
+
+func createOrder(req *restful.Request, resp *restful.Response) {
+	body, _ := ioutil.ReadAll(req.Request.Body)
+	resp.Header().Set("Location", "/orders/1")
+	resp.Header().Set("Content-Type", restful.MIME_JSON)
+	resp.WriteHeader(http.StatusCreated)
+	_, _ = resp.Write(body)
+}
+
+func TestFilter_Replay(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(nil, createOrder)
+
+	first := server.serve(http.MethodPost, "key-1", `{"item":"sword"}`, map[string]string{"X-Request-Id": "r1"})
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, `{"item":"sword"}`, first.Body.String())
+	assert.Empty(t, first.Header().Get(HeaderIdempotentReplayed))
+
+	duplicate := server.serve(http.MethodPost, "key-1", `{"item":"sword"}`, map[string]string{"X-Request-Id": "r2"})
+	assert.Equal(t, http.StatusCreated, duplicate.Code)
+	assert.Equal(t, `{"item":"sword"}`, duplicate.Body.String())
+	assert.Equal(t, "/orders/1", duplicate.Header().Get("Location"))
+	assert.Equal(t, "true", duplicate.Header().Get(HeaderIdempotentReplayed))
+	// the header of the outer filter isn't replayed
+	assert.Equal(t, "r2", duplicate.Header().Get("X-Request-Id"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.calls))
+
+	// a different key, or no key
+	assert.Equal(t, http.StatusCreated, server.serve(http.MethodPost, "key-2", `{"item":"sword"}`, nil).Code)
+	assert.Equal(t, http.StatusCreated, server.serve(http.MethodPost, "", `{"item":"sword"}`, nil).Code)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&server.calls))
+
+	// the same key of the other subject
+	assert.Equal(t, http.StatusCreated, server.serve(http.MethodPost, "key-1", `{"item":"sword"}`,
+		map[string]string{"X-Subject": "user-2"}).Code)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&server.calls))
+
+	// the method not honoring the key
+	server.serve(http.MethodPut, "key-3", `{"item":"sword"}`, nil)
+	server.serve(http.MethodPut, "key-3", `{"item":"sword"}`, nil)
+	assert.Equal(t, int32(6), atomic.LoadInt32(&server.calls))
+}
+
+func TestFilter_ConflictingPayload(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(nil, createOrder)
+
+	assert.Equal(t, http.StatusCreated, server.serve(http.MethodPost, "key-1", `{"item":"sword"}`, nil).Code)
+
+	resp := server.serve(http.MethodPost, "key-1", `{"item":"shield"}`, nil)
+	assert.Equal(t, http.StatusConflict, resp.Code)
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+	assert.Equal(t, float64(iam.ValidationError), body["errorCode"])
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.calls))
+}
+
+func TestFilter_InProgress(t *testing.T) {
+	t.Parallel()
+
+	var server *testServer
+	var inProgress *httptest.ResponseRecorder
+	server = newTestServer(nil, func(req *restful.Request, resp *restful.Response) {
+		if inProgress == nil {
+			inProgress = server.serve(http.MethodPost, "key-1", ``, nil)
+		}
+		resp.WriteHeader(http.StatusNoContent)
+	})
+
+	assert.Equal(t, http.StatusNoContent, server.serve(http.MethodPost, "key-1", ``, nil).Code)
+	if assert.NotNil(t, inProgress) {
+		assert.Equal(t, http.StatusConflict, inProgress.Code)
+		assert.Equal(t, "1", inProgress.Header().Get("Retry-After"))
+	}
+
+	resp := server.serve(http.MethodPost, "key-1", ``, nil)
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Equal(t, "true", resp.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.calls))
+}
+
+func TestFilter_NotStored(t *testing.T) {
+	t.Parallel()
+
+	status := http.StatusServiceUnavailable
+	store := NewMemoryStore()
+	server := newTestServer(&Options{Store: store, MaxBodySize: 8}, func(req *restful.Request, resp *restful.Response) {
+		resp.WriteHeader(status)
+		_, _ = resp.Write(bytes.Repeat([]byte("a"), 4))
+	})
+
+	// 5xx response can be retried with the same key
+	assert.Equal(t, http.StatusServiceUnavailable, server.serve(http.MethodPost, "key-1", ``, nil).Code)
+	assert.Equal(t, 0, store.Len())
+	status = http.StatusOK
+	assert.Equal(t, http.StatusOK, server.serve(http.MethodPost, "key-1", ``, nil).Code)
+	assert.Equal(t, 1, store.Len())
+
+	// the response larger than MaxBodySize
+	server = newTestServer(&Options{Store: store, MaxBodySize: 2}, func(req *restful.Request, resp *restful.Response) {
+		_, _ = resp.Write(bytes.Repeat([]byte("a"), 4))
+	})
+	assert.Equal(t, "aaaa", server.serve(http.MethodPost, "key-2", ``, nil).Body.String())
+	assert.Equal(t, "aaaa", server.serve(http.MethodPost, "key-2", ``, nil).Body.String())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&server.calls))
+}
+
+func TestFilter_Panic(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	server := newTestServer(&Options{Store: store}, func(req *restful.Request, resp *restful.Response) {
+		panic("unexpected")
+	})
+
+	assert.Panics(t, func() {
+		server.serve(http.MethodPost, "key-1", ``, nil)
+	})
+	assert.Equal(t, 0, store.Len())
+}
+
+func TestFilter_TTL(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clock.NewFake(time.Now())
+	server := newTestServer(&Options{TTL: time.Hour, Clock: fakeClock}, createOrder)
+
+	server.serve(http.MethodPost, "key-1", `{"item":"sword"}`, nil)
+	fakeClock.Advance(59 * time.Minute)
+	assert.Equal(t, "true", server.serve(http.MethodPost, "key-1", `{"item":"sword"}`, nil).Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&server.calls))
+
+	// the key can be reused after the TTL
+	fakeClock.Advance(time.Minute)
+	resp := server.serve(http.MethodPost, "key-1", `{"item":"shield"}`, nil)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Empty(t, resp.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&server.calls))
+}
+
+func TestFilter_InvalidKey(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(nil, createOrder)
+
+	resp := server.serve(http.MethodPost, strings.Repeat("k", maxKeyLength+1), `{}`, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&server.calls))
+}
+
+func TestFilter_RequestBodyTooLarge(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(&Options{MaxRequestBodySize: 8}, createOrder)
+
+	resp := server.serve(http.MethodPost, "key-1", `{"item":"shield"}`, nil)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&server.calls))
+
+	// the body within the limit is passed to the handler as is
+	resp = server.serve(http.MethodPost, "key-2", `{"a":1}`, nil)
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(t, `{"a":1}`, resp.Body.String())
+}
@@ -0,0 +1,137 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/clock"
+)
+
+const sweepInterval = time.Minute
+
+// Entry is the response of the first request with the idempotency key
+type Entry struct {
+	// RequestHash is the hash of the method, URL and body of the first request,
+	// the duplicate request with a different hash is rejected
+	RequestHash string
+	// Completed is false while the first request is still being processed
+	Completed  bool
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	StoredAt   time.Time
+}
+
+// Store keeps the responses of the idempotent requests, e.g. in a shared cache for the replicas of the service
+type Store interface {
+	// Reserve stores the entry if the key doesn't exist yet and returns true,
+	// otherwise it returns the existing entry and false. It must be atomic across the concurrent requests.
+	Reserve(ctx context.Context, key string, entry *Entry, ttl time.Duration) (*Entry, bool, error)
+	// Set replaces the entry of the key, e.g. when the first request is completed
+	Set(ctx context.Context, key string, entry *Entry, ttl time.Duration) error
+	// Delete removes the entry of the key, so the request can be retried
+	Delete(ctx context.Context, key string) error
+}
+
+type memoryItem struct {
+	entry     *Entry
+	expiresAt time.Time
+}
+
+// MemoryStore is the in-memory Store, the entries are not shared between the replicas of the service
+type MemoryStore struct {
+	clock clock.Clock
+
+	mutex     sync.Mutex
+	items     map[string]memoryItem
+	lastSweep time.Time
+}
+
+// NewMemoryStore creates new MemoryStore instance
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		clock: clock.System(),
+		items: map[string]memoryItem{},
+	}
+}
+
+// SetClock sets the time source of the entry expiry, e.g. a fake clock in the tests. Nil restores the system clock.
+func (s *MemoryStore) SetClock(c clock.Clock) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.clock = clock.OrSystem(c)
+}
+
+// Reserve implements Store
+func (s *MemoryStore) Reserve(_ context.Context, key string, entry *Entry, ttl time.Duration) (*Entry, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.clock.Now()
+	s.sweep(now)
+
+	if item, ok := s.items[key]; ok && now.Before(item.expiresAt) {
+		return item.entry, false, nil
+	}
+	s.items[key] = memoryItem{entry: entry, expiresAt: now.Add(ttl)}
+
+	return entry, true, nil
+}
+
+// Set implements Store
+func (s *MemoryStore) Set(_ context.Context, key string, entry *Entry, ttl time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.items[key] = memoryItem{entry: entry, expiresAt: s.clock.Now().Add(ttl)}
+
+	return nil
+}
+
+// Delete implements Store
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.items, key)
+
+	return nil
+}
+
+// Len returns the number of the stored entries, including the expired ones which are not swept yet
+func (s *MemoryStore) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.items)
+}
+
+// sweep removes the expired entries at most once per sweepInterval
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, item := range s.items {
+		if !now.Before(item.expiresAt) {
+			delete(s.items, key)
+		}
+	}
+}
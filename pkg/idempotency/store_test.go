@@ -0,0 +1,76 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	fakeClock := clock.NewFake(time.Now())
+	store.SetClock(fakeClock)
+	ctx := context.Background()
+
+	entry, reserved, err := store.Reserve(ctx, "key", &Entry{RequestHash: "first"}, time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+	assert.Equal(t, "first", entry.RequestHash)
+
+	entry, reserved, err = store.Reserve(ctx, "key", &Entry{RequestHash: "second"}, time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, "first", entry.RequestHash)
+	assert.False(t, entry.Completed)
+
+	assert.NoError(t, store.Set(ctx, "key", &Entry{RequestHash: "first", Completed: true}, time.Minute))
+	entry, reserved, _ = store.Reserve(ctx, "key", &Entry{RequestHash: "second"}, time.Minute)
+	assert.False(t, reserved)
+	assert.True(t, entry.Completed)
+
+	assert.NoError(t, store.Delete(ctx, "key"))
+	_, reserved, _ = store.Reserve(ctx, "key", &Entry{RequestHash: "second"}, time.Minute)
+	assert.True(t, reserved)
+
+	// expired
+	fakeClock.Advance(time.Minute)
+	entry, reserved, _ = store.Reserve(ctx, "key", &Entry{RequestHash: "third"}, time.Minute)
+	assert.True(t, reserved)
+	assert.Equal(t, "third", entry.RequestHash)
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryStore()
+	fakeClock := clock.NewFake(time.Now())
+	store.SetClock(fakeClock)
+	ctx := context.Background()
+
+	_, _, _ = store.Reserve(ctx, "k1", &Entry{}, time.Second)
+	_, _, _ = store.Reserve(ctx, "k2", &Entry{}, time.Hour)
+	assert.Equal(t, 2, store.Len())
+
+	fakeClock.Advance(sweepInterval)
+	_, _, _ = store.Reserve(ctx, "k3", &Entry{}, time.Hour)
+	assert.Equal(t, 2, store.Len())
+}
@@ -0,0 +1,162 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package killswitch
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/envdoc"
+	"github.com/sirupsen/logrus"
+)
+
+// the features which can be disabled at runtime
+const (
+	// Masking disables the field masking of the request and response bodies in the access log,
+	// the bodies aren't logged at all instead of being logged unmasked
+	Masking = "masking"
+	// BodyCapture disables capturing the request and response bodies for the access log and the audit log
+	BodyCapture = "body_capture"
+	// Metrics disables the metrics filter
+	Metrics = "metrics"
+	// Audit disables the audit filter
+	Audit = "audit"
+
+	killSwitchesEnv = "KILL_SWITCHES"
+)
+
+var (
+	// Features are the features known by the admin handler
+	Features = []string{Masking, BodyCapture, Metrics, Audit}
+
+	// disabled holds map[string]bool, replaced on every change so the filters read it without locking
+	disabled atomic.Value
+	mutex    sync.Mutex
+)
+
+func init() {
+	disabled.Store(map[string]bool{})
+
+	envdoc.Register(envdoc.Variable{Name: killSwitchesEnv, Package: "killswitch", Type: envdoc.TypeList,
+		Description: "Features disabled on startup: masking, body_capture, metrics or audit"})
+
+	if s, exists := os.LookupEnv(killSwitchesEnv); exists && s != "" {
+		for _, feature := range strings.Split(s, ",") {
+			if feature = strings.TrimSpace(feature); feature != "" {
+				Disable(feature)
+			}
+		}
+	}
+}
+
+// IsDisabled checks whether the feature is disabled
+func IsDisabled(feature string) bool {
+	return disabled.Load().(map[string]bool)[feature]
+}
+
+// Disable disables the feature until it's enabled again
+func Disable(feature string) {
+	set(feature, true)
+}
+
+// Enable enables the disabled feature
+func Enable(feature string) {
+	set(feature, false)
+}
+
+func set(feature string, value bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	current := disabled.Load().(map[string]bool)
+	if current[feature] == value {
+		return
+	}
+
+	updated := make(map[string]bool, len(current)+1)
+	for key, val := range current {
+		updated[key] = val
+	}
+	if value {
+		updated[feature] = true
+		logrus.Warnf("kill switch: %s is disabled", feature)
+	} else {
+		delete(updated, feature)
+		logrus.Infof("kill switch: %s is enabled", feature)
+	}
+	disabled.Store(updated)
+}
+
+// States returns whether each of the known features and the other disabled features is disabled
+func States() map[string]bool {
+	states := make(map[string]bool)
+	for _, feature := range Features {
+		states[feature] = false
+	}
+	for feature := range disabled.Load().(map[string]bool) {
+		states[feature] = true
+	}
+	return states
+}
+
+// Handler returns the admin http.Handler of the kill switches, it must be protected or served on the admin port.
+// GET responds the states in JSON, e.g. {"masking": false, "metrics": true},
+// POST with feature and disabled query params changes the state, e.g. POST ?feature=metrics&disabled=true
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			feature := r.URL.Query().Get("feature")
+			value, err := strconv.ParseBool(r.URL.Query().Get("disabled"))
+			if !isKnownFeature(feature) || err != nil {
+				http.Error(w, "feature must be one of "+strings.Join(sortedFeatures(), ", ")+
+					" and disabled must be a boolean", http.StatusBadRequest)
+				return
+			}
+			set(feature, value)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(States()); err != nil {
+			logrus.Errorf("unable to write kill switch states: %v", err)
+		}
+	})
+}
+
+func isKnownFeature(feature string) bool {
+	for _, known := range Features {
+		if known == feature {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedFeatures() []string {
+	features := append([]string{}, Features...)
+	sort.Strings(features)
+	return features
+}
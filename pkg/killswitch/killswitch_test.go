@@ -0,0 +1,69 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package killswitch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// nolint:paralleltest
+func TestDisable(t *testing.T) {
+	defer Enable(Metrics)
+
+	assert.False(t, IsDisabled(Metrics))
+
+	Disable(Metrics)
+	assert.True(t, IsDisabled(Metrics))
+	assert.False(t, IsDisabled(Masking))
+	assert.Equal(t, map[string]bool{Masking: false, BodyCapture: false, Metrics: true, Audit: false}, States())
+
+	Enable(Metrics)
+	assert.False(t, IsDisabled(Metrics))
+}
+
+// nolint:paralleltest
+func TestHandler(t *testing.T) {
+	defer Enable(BodyCapture)
+
+	handler := Handler()
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/?feature=body_capture&disabled=true", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.True(t, IsDisabled(BodyCapture))
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/", nil))
+	states := map[string]bool{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &states))
+	assert.True(t, states[BodyCapture])
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/?feature=unknown&disabled=true", nil))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/?feature=metrics&disabled=maybe", nil))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodDelete, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+}
@@ -0,0 +1,198 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/constant"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/killswitch"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/trace"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// AuditMetadata is the route metadata key to override whether the route is audited with a bool value,
+	// e.g. to audit a sensitive read, or to skip a noisy mutating route
+	AuditMetadata = "Audit"
+	// ActionMetadata is the route metadata key of the audit action name. Default: the route operation id
+	ActionMetadata = "AuditAction"
+
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	// ResultScheduled is the result of the destructive request accepted within its undo window, see pkg/undo
+	ResultScheduled = "scheduled"
+	// ResultUndone is the result of the scheduled request undone before it's finalized
+	ResultUndone = "undone"
+	// ResultAccepted is the result of the request whose job is accepted to run in the background, see pkg/async
+	ResultAccepted = "accepted"
+)
+
+// Actor is the caller of the audited request
+type Actor struct {
+	UserID   string `json:"userId,omitempty"`
+	ClientID string `json:"clientId,omitempty"`
+}
+
+// Event is the audit record of a mutating request
+type Event struct {
+	Time      time.Time `json:"time"`
+	TraceID   string    `json:"traceId,omitempty"`
+	Action    string    `json:"action"`
+	Method    string    `json:"method"`
+	Route     string    `json:"route"`
+	Namespace string    `json:"namespace,omitempty"`
+	Actor     Actor     `json:"actor"`
+	// Target is the target resource identified by the path params, e.g. {"namespace": "ab", "userId": "1"}
+	Target map[string]string `json:"target,omitempty"`
+	Result string            `json:"result"`
+	Status int               `json:"status"`
+	// ErrorCode is the error code of the failed request or job
+	ErrorCode int `json:"errorCode,omitempty"`
+	// JobID is the ID of the background job of the request, see pkg/async
+	JobID string `json:"jobId,omitempty"`
+	// ChangedFields are the top-level fields of the JSON request body, sorted
+	ChangedFields []string `json:"changedFields,omitempty"`
+	// RequestBody is the request body with the masked fields of the endpoint
+	RequestBody string `json:"requestBody,omitempty"`
+}
+
+// Options contains options for the audit filter
+type Options struct {
+	// Publisher receives the audit events. Default: LogPublisher
+	Publisher Publisher
+	// Methods are the audited HTTP methods. Default: POST, PUT, PATCH and DELETE
+	Methods []string
+	// RequestBodyEnabled includes the masked request body in the event. Default: false, only the changed fields
+	RequestBodyEnabled bool
+}
+
+var defaultMethods = []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// Filter publishes an audit event for each request of the audited routes, after the request is processed.
+// The request body is captured once and shared with the AccessLog filter.
+// The actor is read from the JWT claims after the request is processed, so it can be registered before the auth filter.
+func Filter(options *Options) restful.FilterFunction {
+	if options == nil {
+		options = &Options{}
+	}
+	publisher := options.Publisher
+	if publisher == nil {
+		publisher = LogPublisher()
+	}
+	methods := make(map[string]bool)
+	methodList := options.Methods
+	if len(methodList) == 0 {
+		methodList = defaultMethods
+	}
+	for _, method := range methodList {
+		methods[strings.ToUpper(method)] = true
+	}
+
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		route := req.SelectedRoute()
+		if route == nil || !isAudited(route, methods[req.Request.Method]) || killswitch.IsDisabled(killswitch.Audit) {
+			chain.ProcessFilter(req, resp)
+			return
+		}
+
+		body := log.CaptureRequestBody(req)
+
+		chain.ProcessFilter(req, resp)
+
+		event := newEvent(req, resp, body, options.RequestBodyEnabled)
+		if err := publisher.Publish(event); err != nil {
+			logrus.Errorf("unable to publish audit event of %s %s: %v", event.Method, event.Route, err)
+		}
+	}
+}
+
+func isAudited(route restful.RouteReader, defaultValue bool) bool {
+	if audited, ok := route.Metadata()[AuditMetadata].(bool); ok {
+		return audited
+	}
+	return defaultValue
+}
+
+func newEvent(req *restful.Request, resp *restful.Response, body string, includeBody bool) *Event {
+	event := NewEvent(req, resp.StatusCode())
+
+	if body != "" {
+		if strings.Contains(req.HeaderParameter(constant.ContentType), "json") {
+			event.ChangedFields = jsonFields(body)
+		}
+		if includeBody {
+			event.RequestBody = log.MaskRequestBody(req, body)
+		}
+	}
+
+	return event
+}
+
+// NewEvent creates the audit event of the request processed with the status, without the request body,
+// e.g. for the events published outside of the audit filter. The result is failure when the status is 400 or above.
+func NewEvent(req *restful.Request, status int) *Event {
+	event := &Event{
+		Time:   time.Now().UTC(),
+		Method: req.Request.Method,
+		Route:  req.SelectedRoutePath(),
+		Target: req.PathParameters(),
+		Status: status,
+		Result: ResultSuccess,
+	}
+	if route := req.SelectedRoute(); route != nil {
+		event.Action = route.Operation()
+		if action, ok := route.Metadata()[ActionMetadata].(string); ok && action != "" {
+			event.Action = action
+		}
+	}
+	if event.Status >= http.StatusBadRequest {
+		event.Result = ResultFailure
+	}
+	if traceID, ok := req.Attribute(trace.TraceIDKey).(string); ok {
+		event.TraceID = traceID
+	}
+	if claims := iam.RetrieveJWTClaims(req); claims != nil {
+		event.Actor = Actor{UserID: claims.Subject, ClientID: claims.ClientID}
+		event.Namespace = claims.Namespace
+	}
+	if namespace := req.PathParameter("namespace"); namespace != "" {
+		event.Namespace = namespace
+	}
+
+	return event
+}
+
+// jsonFields returns the sorted top-level fields of the JSON object
+func jsonFields(body string) []string {
+	object := make(map[string]json.RawMessage)
+	if err := json.Unmarshal([]byte(body), &object); err != nil {
+		return nil
+	}
+
+	fields := make([]string, 0, len(object))
+	for field := range object {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,179 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AccelByte/go-jose/jwt"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/killswitch"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	iamSDK "github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPublisher struct {
+	events []*Event
+}
+
+func (p *testPublisher) Publish(event *Event) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+func createTestContainer(options *Options, handlerBody *string) *restful.Container {
+	withClaims := func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		req.SetAttribute(iam.ClaimsAttribute, &iamSDK.JWTClaims{
+			Claims:    jwt.Claims{Subject: "admin1"},
+			ClientID:  "client1",
+			Namespace: "accelbyte",
+		})
+		chain.ProcessFilter(req, resp)
+	}
+
+	ws := new(restful.WebService)
+	ws.Filter(Filter(options))
+	ws.Filter(withClaims)
+	ws.Route(ws.PUT("/namespaces/{namespace}/users/{userId}").
+		Operation("updateUser").
+		To(func(request *restful.Request, response *restful.Response) {
+			request.SetAttribute(log.MaskedRequestFieldsAttribute, "password")
+			body, _ := ioutil.ReadAll(request.Request.Body)
+			*handlerBody = string(body)
+		}))
+	ws.Route(ws.DELETE("/namespaces/{namespace}/users/{userId}").
+		Operation("deleteUser").
+		Metadata(ActionMetadata, "user.delete").
+		To(func(request *restful.Request, response *restful.Response) {
+			response.WriteHeader(http.StatusNotFound)
+		}))
+	ws.Route(ws.GET("/namespaces/{namespace}/users/{userId}").
+		To(func(request *restful.Request, response *restful.Response) {}))
+	ws.Route(ws.GET("/namespaces/{namespace}/users/{userId}/secrets").
+		Metadata(AuditMetadata, true).
+		To(func(request *restful.Request, response *restful.Response) {}))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+	return container
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	publisher := &testPublisher{}
+	var handlerBody string
+	container := createTestContainer(&Options{Publisher: publisher, RequestBodyEnabled: true}, &handlerBody)
+
+	body := `{"displayName":"john","password":"secret"}`
+	req := httptest.NewRequest(http.MethodPut, "/namespaces/accelbyte/users/user1", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	container.ServeHTTP(httptest.NewRecorder(), req)
+
+	// the handler still reads the full body
+	assert.Equal(t, body, handlerBody)
+
+	assert.Len(t, publisher.events, 1)
+	event := publisher.events[0]
+	assert.Equal(t, "updateUser", event.Action)
+	assert.Equal(t, http.MethodPut, event.Method)
+	assert.Equal(t, "/namespaces/{namespace}/users/{userId}", event.Route)
+	assert.Equal(t, "accelbyte", event.Namespace)
+	assert.Equal(t, Actor{UserID: "admin1", ClientID: "client1"}, event.Actor)
+	assert.Equal(t, map[string]string{"namespace": "accelbyte", "userId": "user1"}, event.Target)
+	assert.Equal(t, ResultSuccess, event.Result)
+	assert.Equal(t, http.StatusOK, event.Status)
+	assert.Equal(t, []string{"displayName", "password"}, event.ChangedFields)
+	assert.NotContains(t, event.RequestBody, "secret")
+	assert.Contains(t, event.RequestBody, "john")
+}
+
+func TestFilter_FailureAndAction(t *testing.T) {
+	t.Parallel()
+
+	publisher := &testPublisher{}
+	var handlerBody string
+	container := createTestContainer(&Options{Publisher: publisher}, &handlerBody)
+
+	container.ServeHTTP(httptest.NewRecorder(),
+		httptest.NewRequest(http.MethodDelete, "/namespaces/accelbyte/users/user1", nil))
+
+	assert.Len(t, publisher.events, 1)
+	assert.Equal(t, "user.delete", publisher.events[0].Action)
+	assert.Equal(t, ResultFailure, publisher.events[0].Result)
+	assert.Equal(t, http.StatusNotFound, publisher.events[0].Status)
+	assert.Empty(t, publisher.events[0].RequestBody)
+}
+
+func TestFilter_AuditMetadata(t *testing.T) {
+	t.Parallel()
+
+	publisher := &testPublisher{}
+	var handlerBody string
+	container := createTestContainer(&Options{Publisher: publisher}, &handlerBody)
+
+	container.ServeHTTP(httptest.NewRecorder(),
+		httptest.NewRequest(http.MethodGet, "/namespaces/accelbyte/users/user1", nil))
+	assert.Len(t, publisher.events, 0)
+
+	container.ServeHTTP(httptest.NewRecorder(),
+		httptest.NewRequest(http.MethodGet, "/namespaces/accelbyte/users/user1/secrets", nil))
+	assert.Len(t, publisher.events, 1)
+}
+
+// nolint:paralleltest
+func TestFilter_KillSwitch(t *testing.T) {
+	defer killswitch.Enable(killswitch.Audit)
+
+	publisher := &testPublisher{}
+	var handlerBody string
+	container := createTestContainer(&Options{Publisher: publisher}, &handlerBody)
+
+	killswitch.Disable(killswitch.Audit)
+	container.ServeHTTP(httptest.NewRecorder(),
+		httptest.NewRequest(http.MethodDelete, "/namespaces/accelbyte/users/user1", nil))
+	assert.Len(t, publisher.events, 0)
+}
+
+func TestNewEvent(t *testing.T) {
+	t.Parallel()
+
+	var event *Event
+	ws := new(restful.WebService)
+	ws.Route(ws.DELETE("/namespaces/{namespace}/users/{userId}").
+		Metadata(ActionMetadata, "user.delete").
+		To(func(request *restful.Request, response *restful.Response) {
+			request.SetAttribute(iam.ClaimsAttribute, &iamSDK.JWTClaims{Claims: jwt.Claims{Subject: "admin1"}})
+			event = NewEvent(request, http.StatusAccepted)
+		}))
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	container.ServeHTTP(httptest.NewRecorder(),
+		httptest.NewRequest(http.MethodDelete, "/namespaces/accelbyte/users/user1", nil))
+
+	assert.Equal(t, "user.delete", event.Action)
+	assert.Equal(t, "/namespaces/{namespace}/users/{userId}", event.Route)
+	assert.Equal(t, "accelbyte", event.Namespace)
+	assert.Equal(t, Actor{UserID: "admin1"}, event.Actor)
+	assert.Equal(t, map[string]string{"namespace": "accelbyte", "userId": "user1"}, event.Target)
+	assert.Equal(t, ResultSuccess, event.Result)
+}
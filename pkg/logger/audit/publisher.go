@@ -0,0 +1,97 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+const logTypeAudit = "audit"
+
+// Publisher publishes the audit events, e.g. into Kafka, an HTTP collector or a file
+type Publisher interface {
+	Publish(event *Event) error
+}
+
+// PublisherFunc adapts a function into Publisher, e.g. a Kafka producer
+type PublisherFunc func(event *Event) error
+
+// Publish calls the function
+func (f PublisherFunc) Publish(event *Event) error {
+	return f(event)
+}
+
+// LogPublisher returns the publisher writing the audit events into logrus standard logger
+// with log_type=audit field, so they can be routed separately from the application logs
+func LogPublisher() Publisher {
+	return PublisherFunc(func(event *Event) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		logrus.WithField("log_type", logTypeAudit).Info(string(data))
+		return nil
+	})
+}
+
+// WriterPublisher returns the publisher writing the audit events as JSON lines into the writer, e.g. a file
+func WriterPublisher(writer io.Writer) Publisher {
+	mutex := &sync.Mutex{}
+	return PublisherFunc(func(event *Event) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		_, err = writer.Write(append(data, '\n'))
+		return err
+	})
+}
+
+// HTTPPublisher returns the publisher posting the audit events as JSON into the URL.
+// The client should have a timeout, since the event is published before the filter returns. Default: http.DefaultClient
+func HTTPPublisher(url string, client *http.Client) Publisher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return PublisherFunc(func(event *Event) error {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+		if resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("audit collector responded %d", resp.StatusCode)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,68 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterPublisher(t *testing.T) {
+	t.Parallel()
+
+	buffer := new(bytes.Buffer)
+	publisher := WriterPublisher(buffer)
+
+	assert.NoError(t, publisher.Publish(&Event{Action: "createUser", Result: ResultSuccess}))
+	assert.NoError(t, publisher.Publish(&Event{Action: "deleteUser", Result: ResultFailure}))
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	event := Event{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &event))
+	assert.Equal(t, "deleteUser", event.Action)
+}
+
+func TestHTTPPublisher(t *testing.T) {
+	t.Parallel()
+
+	received := make(chan Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		event := Event{}
+		_ = json.Unmarshal(body, &event)
+		received <- event
+		if event.Action == "rejected" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	publisher := HTTPPublisher(server.URL, nil)
+
+	assert.NoError(t, publisher.Publish(&Event{Action: "createUser"}))
+	assert.Equal(t, "createUser", (<-received).Action)
+
+	assert.Error(t, publisher.Publish(&Event{Action: "rejected"}))
+	<-received
+}
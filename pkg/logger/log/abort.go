@@ -0,0 +1,54 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	fieldAborted      = "aborted"
+	fieldBytesWritten = "bytes_written"
+)
+
+var abortedRequestCount uint64
+
+// AbortedRequestCount returns the number of requests abandoned by the client
+// (the client disconnected before the response was completed) since the service started.
+func AbortedRequestCount() uint64 {
+	return atomic.LoadUint64(&abortedRequestCount)
+}
+
+// isRequestAborted checks whether the client has disconnected.
+// The request context is canceled by net/http when the client connection is closed.
+func isRequestAborted(req *restful.Request) bool {
+	return req.Request.Context().Err() == context.Canceled
+}
+
+// addAbortFields marks the record as aborted along with the response bytes written so far
+func addAbortFields(req *restful.Request, respWriter *ResponseWriterInterceptor, fields logrus.Fields) {
+	if !isRequestAborted(req) {
+		return
+	}
+
+	atomic.AddUint64(&abortedRequestCount, 1)
+
+	fields[fieldAborted] = true
+	fields[fieldBytesWritten] = respWriter.BytesWritten()
+}
@@ -0,0 +1,62 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// nolint:paralleltest
+func TestAccessLog_ClientDisconnected(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/download").
+		To(func(request *restful.Request, response *restful.Response) {
+			_, _ = response.Write([]byte("partial"))
+			// client hangs up in the middle of the response
+			cancel()
+		}))
+
+	countBefore := AbortedRequestCount()
+	req := httptest.NewRequest(http.MethodGet, "/download", nil).WithContext(ctx)
+	fields, _ := serveWithAccessLog(t, ws, req)
+
+	assert.Equal(t, true, fields[fieldAborted])
+	assert.Equal(t, float64(len("partial")), fields[fieldBytesWritten])
+	assert.Equal(t, countBefore+1, AbortedRequestCount())
+}
+
+// nolint:paralleltest
+func TestAccessLog_ClientNotDisconnected(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/download").
+		To(func(request *restful.Request, response *restful.Response) {
+			_, _ = response.Write([]byte("complete"))
+		}))
+
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/download", nil))
+
+	assert.Nil(t, fields[fieldAborted])
+	assert.Nil(t, fields[fieldBytesWritten])
+}
@@ -15,8 +15,8 @@
 package log
 
 import (
-	"bytes"
-	"io/ioutil"
+	"context"
+	"io"
 	"net/http"
 	"os"
 	"strconv"
@@ -24,7 +24,9 @@ import (
 	"time"
 
 	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/codec"
 	"github.com/AccelByte/go-restful-plugins/v4/pkg/constant"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/outbound"
 	"github.com/AccelByte/go-restful-plugins/v4/pkg/trace"
 	"github.com/AccelByte/go-restful-plugins/v4/pkg/util"
 	publicsourceip "github.com/AccelByte/public-source-ip"
@@ -38,24 +40,32 @@ var (
 	FullAccessLogMaxBodySize           int
 	FullAccessLogRequestBodyEnabled    bool
 	FullAccessLogResponseBodyEnabled   bool
+	FullAccessLogFormat                string
+	// FullAccessLogJSONStringValues prints the numeric and boolean fields as strings in json format,
+	// for the consumers expecting the stringly typed values
+	FullAccessLogJSONStringValues bool
 
 	fullAccessLogLogger *logrus.Logger
 )
 
 const (
-	fullAccessLogFormat = `time=%s log_type=access method=%s path="%s" status=%d duration=%d length=%d source_ip=%s user_agent="%s" referer="%s" trace_id=%s namespace=%s user_id=%s client_id=%s request_content_type="%s" request_body=AB[%s]AB response_content_type="%s" response_body=AB[%s]AB operation="%s"`
+	fullAccessLogFormat = `time=%v log_type=access method=%s path="%s" status=%d duration=%d length=%d source_ip=%s user_agent="%s" referer="%s" trace_id=%s namespace=%s user_id=%s client_id=%s request_content_type="%s" request_body=AB[%s]AB response_content_type="%s" response_body=AB[%s]AB operation="%s"`
 )
 
-// fullAccessLogFormatter represent logrus.Formatter,
-// this is used to print the custom format for access log.
-type fullAccessLogFormatter struct {
-}
+func init() {
+	FullAccessLogSupportedContentTypes = []string{"application/json", "application/xml", "application/x-www-form-urlencoded", "text/plain", "text/html"}
+	FullAccessLogMaxBodySize = 10 << 10 // 10KB
+	FullAccessLogRequestBodyEnabled = true
+	FullAccessLogResponseBodyEnabled = true
+	FullAccessLogFormat = AccessLogFormatText
 
-func (f *fullAccessLogFormatter) Format(entry *logrus.Entry) ([]byte, error) {
-	return []byte(entry.Message + "\n"), nil
-}
+	// the profile only selects the defaults, the specific environment variables below take precedence
+	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_PROFILE"); exists && s != "" {
+		if err := ApplyProfile(s); err != nil {
+			logrus.Errorf("Parse FULL_ACCESS_LOG_PROFILE env error: %v", err)
+		}
+	}
 
-func init() {
 	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_ENABLED"); exists {
 		value, err := strconv.ParseBool(s)
 		if err != nil {
@@ -66,20 +76,16 @@ func init() {
 
 	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_SUPPORTED_CONTENT_TYPES"); exists {
 		FullAccessLogSupportedContentTypes = strings.Split(s, ",")
-	} else {
-		FullAccessLogSupportedContentTypes = []string{"application/json", "application/xml", "application/x-www-form-urlencoded", "text/plain", "text/html"}
 	}
 
-	FullAccessLogMaxBodySize = 10 << 10 // 10KB
 	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_MAX_BODY_SIZE"); exists {
 		value, err := strconv.ParseInt(s, 0, 64)
 		if err != nil {
 			logrus.Errorf("Parse FULL_ACCESS_LOG_MAX_BODY_SIZE env error: %v", err)
 		}
-		FullAccessLogMaxBodySize = int(value)
+		FullAccessLogMaxBodySize = nonNegativeBodySize(int(value))
 	}
 
-	FullAccessLogRequestBodyEnabled = true
 	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_REQUEST_BODY_ENABLED"); exists {
 		value, err := strconv.ParseBool(s)
 		if err != nil {
@@ -88,7 +94,10 @@ func init() {
 		FullAccessLogRequestBodyEnabled = value
 	}
 
-	FullAccessLogResponseBodyEnabled = true
+	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_BODY_TRUNCATION"); exists {
+		parseBodyTruncations(s)
+	}
+
 	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_RESPONSE_BODY_ENABLED"); exists {
 		value, err := strconv.ParseBool(s)
 		if err != nil {
@@ -96,87 +105,247 @@ func init() {
 		}
 		FullAccessLogResponseBodyEnabled = value
 	}
+
+	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_FORMAT"); exists {
+		if strings.EqualFold(s, AccessLogFormatJSON) || strings.EqualFold(s, AccessLogFormatText) {
+			FullAccessLogFormat = strings.ToLower(s)
+		} else {
+			logrus.Errorf("Parse FULL_ACCESS_LOG_FORMAT env error: unsupported format %s", s)
+		}
+	}
+
+	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_FIELDS"); exists && s != "" {
+		FullAccessLogFields = strings.Split(s, ",")
+	}
+
+	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_JSON_STRING_VALUES"); exists {
+		value, err := strconv.ParseBool(s)
+		if err != nil {
+			logrus.Errorf("Parse FULL_ACCESS_LOG_JSON_STRING_VALUES env error: %v", err)
+		}
+		FullAccessLogJSONStringValues = value
+	}
+
+	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_TIME_FORMAT"); exists && s != "" {
+		FullAccessLogTimeFormat = s
+	}
+
+	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_DURATION_UNIT"); exists && s != "" {
+		if unit, ok := parseDurationUnit(s); ok {
+			FullAccessLogDurationUnit = unit
+		} else {
+			logrus.Errorf("Parse FULL_ACCESS_LOG_DURATION_UNIT env error: unsupported unit %s", s)
+		}
+	}
+
+	FullAccessLogDefaultRetention = os.Getenv("FULL_ACCESS_LOG_DEFAULT_RETENTION")
+	FullAccessLogPIIRetention = os.Getenv("FULL_ACCESS_LOG_PII_RETENTION")
+
+	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_HEADERS"); exists && s != "" {
+		FullAccessLogHeaders = strings.Split(s, ",")
+	}
+
+	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_RESPONSE_HEADERS"); exists && s != "" {
+		FullAccessLogResponseHeaders = strings.Split(s, ",")
+	}
+
+	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_SENSITIVE_HEADERS"); exists && s != "" {
+		FullAccessLogSensitiveHeaders = strings.Split(s, ",")
+	}
+
+	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_DEBUG_CLIENT_IDS"); exists && s != "" {
+		FullAccessLogDebugClientIDs = strings.Split(s, ",")
+	}
+
+	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_BODY_READ_DIAGNOSTICS_ENABLED"); exists {
+		value, err := strconv.ParseBool(s)
+		if err != nil {
+			logrus.Errorf("Parse FULL_ACCESS_LOG_BODY_READ_DIAGNOSTICS_ENABLED env error: %v", err)
+		}
+		FullAccessLogBodyReadDiagnosticsEnabled = value
+	}
+
+	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_MASKING_CONFIG_FILE"); exists && s != "" {
+		if err := LoadMaskingConfig(s); err != nil {
+			logrus.Errorf("Load FULL_ACCESS_LOG_MASKING_CONFIG_FILE env error: %v", err)
+		}
+	} else if s, exists := os.LookupEnv("FULL_ACCESS_LOG_MASKING_CONFIG"); exists && s != "" {
+		config, err := parseMaskingConfig([]byte(s), ".json")
+		if err != nil {
+			logrus.Errorf("Parse FULL_ACCESS_LOG_MASKING_CONFIG env error: %v", err)
+		} else {
+			SetMaskingConfig(config)
+		}
+	}
+
+	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_EXCLUDED_PATHS"); exists {
+		ExcludePaths(strings.Split(s, ",")...)
+	}
+
+	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_SAMPLE_RATES"); exists {
+		parseSampleRates(s)
+	}
+
+	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_VERIFY_FORMAT"); exists && s != "" {
+		VerifyAccessLogFormat(&FormatVerificationOptions{Candidate: NewAccessLogFormatter(s)})
+	}
+
+	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_SLOW_THRESHOLD_MS"); exists {
+		value, err := strconv.ParseInt(s, 0, 64)
+		if err != nil {
+			logrus.Errorf("Parse FULL_ACCESS_LOG_SLOW_THRESHOLD_MS env error: %v", err)
+		}
+		FullAccessLogSlowThreshold = time.Duration(value) * time.Millisecond
+	}
+
+	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_SLOW_THRESHOLDS"); exists {
+		parseSlowThresholds(s)
+	}
+
+	if s, exists := os.LookupEnv("FULL_ACCESS_LOG_SUMMARY_ENABLED"); exists {
+		value, err := strconv.ParseBool(s)
+		if err != nil {
+			logrus.Errorf("Parse FULL_ACCESS_LOG_SUMMARY_ENABLED env error: %v", err)
+		}
+		FullAccessLogSummaryEnabled = value
+	}
 }
 
 // AccessLog is a filter that will log incoming request into the Access Log format
 func AccessLog(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
-	// initialize custom logger for full access log
-	if fullAccessLogLogger == nil {
-		fullAccessLogLogger = &logrus.Logger{
-			Out:       os.Stdout,
-			Level:     logrus.GetLevel(),
-			Formatter: &fullAccessLogFormatter{},
+	// the request is stored in its context before anything else, so FromContext works on the excluded requests
+	withRequestContext(req)
+
+	// skip the excluded or unsampled request before capturing anything
+	if !shouldLog(req) {
+		if !FullAccessLogSummaryEnabled {
+			chain.ProcessFilter(req, resp)
+			return
 		}
+		start := accessLogClock.Now()
+		chain.ProcessFilter(req, resp)
+		recordAccessLogSummary(resp.StatusCode(), accessLogClock.Now().Sub(start))
+		return
 	}
 
-	start := time.Now()
+	// initialize custom logger for full access log
+	logger := getFullAccessLogLogger()
+
+	start := accessLogClock.Now()
 
 	sourceIP := publicsourceip.PublicIP(&http.Request{Header: req.Request.Header})
 	referer := req.HeaderParameter(constant.Referer)
 	userAgent := req.HeaderParameter(constant.UserAgent)
 	requestContentType := req.HeaderParameter(constant.ContentType)
 	requestBody := "-"
-
-	if FullAccessLogEnabled {
-		if FullAccessLogRequestBodyEnabled {
-			requestBody = getRequestBody(req, requestContentType)
-		}
+	// the kill switches are read once, so the body captured before the chain is masked after the chain
+	requestBodyEnabled := FullAccessLogEnabled && isFieldSelected(fieldRequestBody) &&
+		routeBodyCaptureEnabled(req, RequestBodyMetadata, FullAccessLogRequestBodyEnabled) && bodyCaptureEnabled()
+	responseBodyEnabled := FullAccessLogEnabled && isFieldSelected(fieldResponseBody) &&
+		routeBodyCaptureEnabled(req, ResponseBodyMetadata, FullAccessLogResponseBodyEnabled) && bodyCaptureEnabled()
+	// the bodies of the debug access log are captured before the client is known,
+	// and dropped after the chain if the client isn't allowed to enable it
+	debugRequested := isDebugLogRequested(req)
+	debugBodyEnabled := debugRequested && bodyCaptureEnabled()
+	// the upgraded connection (e.g. WebSocket) has no body to capture,
+	// it's logged when the connection is established and closed instead
+	upgrade := isUpgradeRequest(req.Request)
+	if upgrade {
+		requestBodyEnabled = false
+		responseBodyEnabled = false
+		debugBodyEnabled = false
 	}
 
-	// decorate the original http.ResponseWriter with ResponseWriterInterceptor so we can intercept to get the response bytes
-	respWriterInterceptor := &ResponseWriterInterceptor{ResponseWriter: resp.ResponseWriter}
-	resp.ResponseWriter = respWriterInterceptor
+	if requestBodyEnabled || debugBodyEnabled {
+		requestBody = CaptureRequestBody(req)
+	}
 
-	chain.ProcessFilter(req, resp)
+	startTimings(req)
 
-	var tokenNamespace, tokenUserID, tokenClientID string
-	if val := req.Attribute(NamespaceAttribute); val != nil {
-		tokenNamespace = val.(string)
+	var bodyTracker *requestBodyTracker
+	if FullAccessLogBodyReadDiagnosticsEnabled {
+		bodyTracker = trackRequestBody(req)
 	}
-	if val := req.Attribute(UserIDAttribute); val != nil {
-		tokenUserID = val.(string)
+
+	// decorate the original http.ResponseWriter with ResponseWriterInterceptor so we can intercept to get the response bytes
+	originalWriter := resp.ResponseWriter
+	respWriterInterceptor := &ResponseWriterInterceptor{
+		ResponseWriter: originalWriter,
+		skipCapture:    !responseBodyEnabled && !debugBodyEnabled,
 	}
-	if val := req.Attribute(ClientIDAttribute); val != nil {
-		tokenClientID = val.(string)
+	defer respWriterInterceptor.Release()
+	if upgrade {
+		upgraded := &upgradeLog{logger: logger, req: req, start: start}
+		respWriterInterceptor.onHijack = upgraded.hijack
 	}
-	if jwtClaims := iam.RetrieveJWTClaims(req); jwtClaims != nil {
-		// if tokenNamespace, tokenUserID or tokenClientID is empty,
-		// fallback get from jwt claims
-		if tokenNamespace == "" {
-			tokenNamespace = jwtClaims.Namespace
-		}
-		if tokenUserID == "" {
-			tokenUserID = jwtClaims.Subject
-		}
-		if tokenClientID == "" {
-			tokenClientID = jwtClaims.ClientID
+	resp.ResponseWriter = respWriterInterceptor
+
+	// the panic is recovered here and propagated after the record is written,
+	// so the panicking request still has a complete access log record
+	panicked := processFilterChain(req, resp, chain)
+
+	// restore the original http.ResponseWriter for the outer filters and the recovery handler
+	resp.ResponseWriter = originalWriter
+
+	// the upgraded connection is already logged by upgradeLog
+	if upgrade && respWriterInterceptor.Hijacked() {
+		if panicked != nil {
+			panic(panicked.value)
 		}
+		return
 	}
 
+	tokenNamespace, tokenUserID, tokenClientID, jwtClaims := getRequestIdentity(req)
+
+	debug := debugRequested && isDebugLogAllowed(req, tokenClientID)
+	if debug && debugBodyEnabled {
+		requestBodyEnabled = true
+		responseBodyEnabled = true
+	} else if !requestBodyEnabled {
+		requestBody = "-"
+	}
+
+	masked := resolveMasking(req)
+
 	requestUri := req.Request.URL.RequestURI()
 	// mask sensitive field(s)
-	if maskedQueryParams := req.Attribute(MaskedQueryParamsAttribute); maskedQueryParams != nil {
-		requestUri = MaskQueryParams(requestUri, maskedQueryParams.(string))
+	if masked.queryParams != "" {
+		requestUri = MaskQueryParams(requestUri, masked.queryParams)
 	}
 
 	responseContentType := respWriterInterceptor.Header().Get(constant.ContentType)
+	cacheStatus := getCacheStatus(req)
 	responseBody := "-"
+	responseTruncated := false
+	responseBodyTruncated := false
+	responseBodySize := 0
 
-	if FullAccessLogEnabled {
-		if FullAccessLogRequestBodyEnabled {
+	if requestBodyEnabled || responseBodyEnabled {
+		if requestBodyEnabled {
 			// mask sensitive field(s)
 			// notes: we masked the request body after calling chain.ProcessFilter first,
 			//        since the MaskedRequestFields attribute is initialized in the inner filter.
-			if maskedRequestFields := req.Attribute(MaskedRequestFieldsAttribute); maskedRequestFields != nil && requestBody != "" {
-				requestBody = MaskFields(requestContentType, requestBody, maskedRequestFields.(string))
+			if masked.requestFields != "" && requestBody != "" {
+				requestBody = MaskFields(requestContentType, requestBody, masked.requestFields)
 			}
 		}
 
-		if FullAccessLogResponseBodyEnabled {
-			responseBody = getResponseBody(respWriterInterceptor, responseContentType)
+		if cachedBody, ok := getCachedResponseBody(req, cacheStatus); ok && responseBodyEnabled {
+			// the cached response body is already masked when it was stored
+			responseBody = ""
+			if isSupportedContentType(responseContentType) {
+				responseBody, responseBodyTruncated = formatBody(req.Request.Context(), []byte(cachedBody), responseContentType)
+				responseBodySize = len(cachedBody)
+			}
+		} else if responseBodyEnabled {
+			responseBody, responseBodyTruncated = getResponseBody(req.Request.Context(), respWriterInterceptor, responseContentType)
+			responseBodySize = respWriterInterceptor.BytesWritten()
+			if respWriterInterceptor.Truncated() {
+				responseTruncated = true
+			}
 			// mask sensitive field(s)
-			if maskedResponseFields := req.Attribute(MaskedResponseFieldsAttribute); maskedResponseFields != nil && responseBody != "" {
-				responseBody = MaskFields(responseContentType, responseBody, maskedResponseFields.(string))
+			if masked.responseFields != "" && responseBody != "" {
+				responseBody = MaskFields(responseContentType, responseBody, masked.responseFields)
 			}
 		}
 	}
@@ -188,32 +357,113 @@ func AccessLog(req *restful.Request, resp *restful.Response, chain *restful.Filt
 		operation = selectedRoute.Operation()
 	}
 
-	traceID := req.Attribute(trace.TraceIDKey)
-	if traceID == nil {
-		traceID = ""
-	}
-	duration := time.Since(start)
-
-	fullAccessLogLogger.Infof(fullAccessLogFormat,
-		time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
-		req.Request.Method,
-		requestUri,
-		resp.StatusCode(),
-		duration.Milliseconds(),
-		resp.ContentLength(),
-		sourceIP,
-		userAgent,
-		referer,
-		traceID,
-		tokenNamespace,
-		tokenUserID,
-		tokenClientID,
-		requestContentType,
-		requestBody,
-		responseContentType,
-		responseBody,
-		operation,
-	)
+	traceID, _ := req.Attribute(trace.TraceIDKey).(string)
+	journeyID := trace.GetJourneyID(req)
+	consumerID := trace.GetConsumerID(req)
+	end := accessLogClock.Now()
+
+	entry := &AccessLogEntry{
+		Time:                end,
+		Method:              req.Request.Method,
+		Path:                requestUri,
+		Status:              resp.StatusCode(),
+		Duration:            end.Sub(start),
+		Length:              resp.ContentLength(),
+		SourceIP:            sourceIP,
+		UserAgent:           userAgent,
+		Referer:             referer,
+		TraceID:             traceID,
+		Namespace:           tokenNamespace,
+		UserID:              tokenUserID,
+		ClientID:            tokenClientID,
+		Claims:              jwtClaims,
+		RequestContentType:  requestContentType,
+		RequestBody:         requestBody,
+		ResponseContentType: responseContentType,
+		ResponseBody:        responseBody,
+		Operation:           operation,
+		Extras:              map[string]interface{}{},
+		Request:             req,
+	}
+	fields := entry.Extras
+	if journeyID != "" {
+		fields[fieldJourneyID] = journeyID
+	}
+	if consumerID != "" {
+		fields[fieldConsumerID] = consumerID
+	}
+	addGameClientFields(trace.GetGameClient(req), fields)
+	if requestID := trace.GetRequestID(req); requestID != "" {
+		fields[fieldRequestID] = requestID
+	}
+	if responseTruncated {
+		fields[fieldResponseTruncated] = true
+	}
+	if requestBodyEnabled {
+		addRequestBodyTruncationFields(req, fields)
+	}
+	if responseBodyTruncated {
+		fields[fieldResponseBodyTruncated] = true
+		fields[fieldResponseBodySize] = responseBodySize
+	}
+	if tokenIssuer, ok := req.Attribute(iam.TokenIssuerAttribute).(string); ok && tokenIssuer != "" {
+		fields[fieldTokenIssuer] = tokenIssuer
+	}
+	if authMode := iam.GetAuthMode(req); authMode != "" {
+		fields[fieldAuthMode] = string(authMode)
+	}
+	if cacheStatus != "" {
+		fields[fieldCache] = cacheStatus
+	}
+	if errorCode, ok := req.Attribute(ErrorCodeAttribute).(int); ok {
+		fields[fieldErrorCode] = errorCode
+	}
+	if dependencies := outbound.GetDependencies(req); len(dependencies) > 0 {
+		fields[fieldDependencies] = dependencies.String()
+		if throttling := dependencies.Throttling(); throttling != "" {
+			fields[fieldUpstreamThrottled] = throttling
+		}
+	}
+	addRedirectFields(resp.StatusCode(), respWriterInterceptor.Header(), masked.queryParams, fields)
+	addClassificationFields(req, masked, fields)
+	addHeaderFields(req.Request.Header, respWriterInterceptor.Header(), masked.headers, fields)
+	if debug {
+		addDebugFields(req.Request.Header, respWriterInterceptor.Header(), masked.headers, fields)
+	}
+	addAbortFields(req, respWriterInterceptor, fields)
+	addBodyReadFields(req, bodyTracker, fields)
+	addPanicFields(req, panicked, respWriterInterceptor, entry)
+
+	if additionalFields, ok := req.Attribute(AdditionalFieldsAttribute).(map[string]interface{}); ok {
+		for key, value := range additionalFields {
+			if _, exists := fields[key]; !exists && !fullAccessLogFormatFields[key] {
+				fields[key] = value
+			}
+		}
+	}
+
+	writeAccessLogEntry(logger, entry)
+	verifyAccessLogFormat(entry)
+	logSlowRequest(entry)
+	publishAccessLogEntry(*entry)
+	recordAccessLogSummary(entry.Status, entry.Duration)
+
+	if panicked != nil {
+		panic(panicked.value)
+	}
+}
+
+// addGameClientFields adds the game client headers sent by the AccelByte game client SDK
+func addGameClientFields(gameClient trace.GameClient, fields map[string]interface{}) {
+	if gameClient.FlightID != "" {
+		fields[fieldFlightID] = gameClient.FlightID
+	}
+	if gameClient.Version != "" {
+		fields[fieldGameClientVersion] = gameClient.Version
+	}
+	if gameClient.Platform != "" {
+		fields[fieldPlatform] = gameClient.Platform
+	}
 }
 
 // getRequestBody will get the request body from Request object
@@ -222,55 +472,104 @@ func getRequestBody(req *restful.Request, contentType string) string {
 		return ""
 	}
 
-	bodyBytes, err := ioutil.ReadAll(req.Request.Body)
+	// read at most FullAccessLogMaxBodySize+1 bytes to know whether the body is too large,
+	// the rest of the body is left unread in the original reader
+	buffer := getBodyBuffer()
+	_, err := buffer.ReadFrom(io.LimitReader(req.Request.Body, int64(FullAccessLogMaxBodySize)+1))
 	if err != nil {
 		logrus.Errorf("failed to read request body: %v", err.Error())
 	}
-	if len(bodyBytes) != 0 {
-		// set the original bytes back into request body reader
-		req.Request.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
+	if buffer.Len() == 0 {
+		putBodyBuffer(buffer)
+		return ""
+	}
 
-		if len(bodyBytes) > FullAccessLogMaxBodySize {
-			return "data too large"
-		}
+	bodyString, truncated := formatBody(req.Request.Context(), buffer.Bytes(), contentType)
+	if truncated {
+		req.SetAttribute(requestBodyTruncatedAttribute, req.Request.ContentLength)
+	}
 
-		if strings.Contains(contentType, "application/json") {
-			return util.MinifyJSON(bodyBytes)
-		}
+	// set the read bytes back in front of the original request body reader
+	req.Request.Body = &replayBody{buffer: buffer, rest: req.Request.Body}
 
-		bodyString := string(bodyBytes)
-		bodyString = strings.ReplaceAll(bodyString, "\n", "\\n")
-		bodyString = strings.ReplaceAll(bodyString, "\r", "\\r")
-		return bodyString
-	}
-	return ""
+	return bodyString
 }
 
-// getResponseBody will get the response body from ResponseWriterInterceptor object
-func getResponseBody(respWriter *ResponseWriterInterceptor, contentType string) string {
+// getResponseBody will get the response body from ResponseWriterInterceptor object,
+// along with whether the body is truncated, see TruncateBody
+func getResponseBody(ctx context.Context, respWriter *ResponseWriterInterceptor, contentType string) (string, bool) {
 	if contentType == "" || !isSupportedContentType(contentType) {
-		return ""
+		return "", false
 	}
 
-	if len(respWriter.data) > FullAccessLogMaxBodySize {
-		return "data too large"
+	body := respWriter.Body()
+	contentEncoding := respWriter.Header().Get(constant.ContentEncoding)
+	if contentEncoding != "" && respWriter.truncated {
+		// the truncated compressed body can't be decoded
+		return "data too large", false
 	}
+	if respWriter.truncated {
+		// the captured body is cut at the maximum body size, so it's formatted as the body exceeding it
+		if _, ok := bodyTruncationSize(contentType); !ok {
+			return "data too large", false
+		}
+		truncated, _ := truncateBody(body, contentType)
+		return formatBodyLine(ctx, truncated, contentType), true
+	}
+
+	if contentEncoding != "" {
+		decoded, err := decodeBody(body, contentEncoding, FullAccessLogMaxBodySize)
+		if err == errDecodedBodyTooLarge {
+			return "data too large", false
+		}
+		if err != nil {
+			logrus.Errorf("failed to decode %s response body: %v", contentEncoding, err)
+			return "", false
+		}
+		body = decoded
+	}
+
+	return formatBody(ctx, body, contentType)
+}
 
+// formatBody formats the captured body bytes into a single line string.
+// The body exceeding FullAccessLogMaxBodySize is truncated if it's enabled for the content type, see TruncateBody,
+// otherwise it's replaced with "data too large".
+func formatBody(ctx context.Context, body []byte, contentType string) (string, bool) {
+	if len(body) > FullAccessLogMaxBodySize {
+		truncated, ok := truncateBody(body, contentType)
+		if !ok {
+			return "data too large", false
+		}
+		return formatBodyLine(ctx, truncated, contentType), true
+	}
+
+	return formatBodyLine(ctx, body, contentType), false
+}
+
+// formatBodyLine formats the body bytes into a single line string regardless of its size.
+// The body of the other content type with a registered decoder (see codec.Register) is logged as JSON,
+// so it's masked as JSON.
+func formatBodyLine(ctx context.Context, body []byte, contentType string) string {
 	if strings.Contains(contentType, "application/json") {
-		return util.MinifyJSON(respWriter.data)
+		return EscapeLogValue(util.MinifyJSON(body))
+	}
+	if codec.Supported(contentType) {
+		if converted, err := codec.ToJSON(ctx, contentType, body); err == nil {
+			return EscapeLogValue(util.MinifyJSON(converted))
+		}
 	}
 
-	bodyString := string(respWriter.data)
-	bodyString = strings.ReplaceAll(bodyString, "\n", "\\n")
-	bodyString = strings.ReplaceAll(bodyString, "\r", "\\r")
-	return bodyString
+	return EscapeLogValue(string(body))
 }
 
+// isSupportedContentType checks whether the body of the content type is logged:
+// one of FullAccessLogSupportedContentTypes, or the content type with a registered decoder (see codec.Register)
 func isSupportedContentType(contentType string) bool {
 	for _, v := range FullAccessLogSupportedContentTypes {
 		if strings.Contains(contentType, v) {
 			return true
 		}
 	}
-	return false
+	return codec.Supported(contentType)
 }
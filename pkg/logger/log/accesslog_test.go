@@ -15,13 +15,20 @@
 package log
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/codec"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/killswitch"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/outbound"
 	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -51,23 +58,23 @@ func TestGetRequestBody(t *testing.T) {
 func TestGetResponseBody(t *testing.T) {
 	t.Parallel()
 
-	responseBody1 := getResponseBody(createDummyResponse("", ""), "")
+	responseBody1, _ := getResponseBody(context.Background(), createDummyResponse("", ""), "")
 	assert.Equal(t, "", responseBody1)
 
-	responseBody2 := getResponseBody(createDummyResponse("{\"foo\":\"bar\"}", "application/json"), "application/json")
+	responseBody2, _ := getResponseBody(context.Background(), createDummyResponse("{\"foo\":\"bar\"}", "application/json"), "application/json")
 	assert.Equal(t, "{\"foo\":\"bar\"}", responseBody2)
 
 	// uncompleted json
-	responseBody3 := getResponseBody(createDummyResponse("{\"foo\":\"bar\"", "application/json"), "application/json")
+	responseBody3, _ := getResponseBody(context.Background(), createDummyResponse("{\"foo\":\"bar\"", "application/json"), "application/json")
 	assert.Equal(t, "{\"foo\":\"bar\"", responseBody3)
 
-	responseBody4 := getResponseBody(createDummyResponse("foo=bar&foo2=bar2", "application/x-www-form-urlencoded"), "application/x-www-form-urlencoded")
+	responseBody4, _ := getResponseBody(context.Background(), createDummyResponse("foo=bar&foo2=bar2", "application/x-www-form-urlencoded"), "application/x-www-form-urlencoded")
 	assert.Equal(t, "foo=bar&foo2=bar2", responseBody4)
 
-	responseBody5 := getResponseBody(createDummyResponse("test test test", "text/plain"), "text/plain")
+	responseBody5, _ := getResponseBody(context.Background(), createDummyResponse("test test test", "text/plain"), "text/plain")
 	assert.Equal(t, "test test test", responseBody5)
 
-	responseBody6 := getResponseBody(createDummyResponse("test test test", "unidentified-type"), "unidentified-type")
+	responseBody6, _ := getResponseBody(context.Background(), createDummyResponse("test test test", "unidentified-type"), "unidentified-type")
 	assert.Equal(t, "", responseBody6)
 }
 
@@ -109,7 +116,7 @@ test test test test test test test test test test test test test test test test
 test test test test test test test test test test test test test test test test test test test test test test test test test test test test test test test 
 test test test test test test test test`
 
-	responseBody := getResponseBody(createDummyResponse(largeData, "text/plain"), "text/plain")
+	responseBody, _ := getResponseBody(context.Background(), createDummyResponse(largeData, "text/plain"), "text/plain")
 	assert.Equal(t, "data too large", responseBody)
 }
 
@@ -134,6 +141,158 @@ func createDummyResponse(content string, contentType string) *ResponseWriterInte
 
 	return &ResponseWriterInterceptor{
 		ResponseWriter: response.ResponseWriter,
-		data:           []byte(content),
+		buffer:         bytes.NewBufferString(content),
 	}
 }
+
+// serveWithAccessLog serves the request into the web service and returns the printed access log fields.
+// The full access log logger is replaced during the call, so the caller test can't run in parallel.
+func serveWithAccessLog(t *testing.T, ws *restful.WebService, req *http.Request) (map[string]interface{}, *httptest.ResponseRecorder) {
+	t.Helper()
+
+	buffer := new(bytes.Buffer)
+	fullAccessLogLogger = &logrus.Logger{
+		Out:       buffer,
+		Level:     logrus.InfoLevel,
+		Formatter: &fullAccessLogJSONFormatter{},
+	}
+	defer func() {
+		fullAccessLogLogger = nil
+	}()
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, req)
+
+	fields := map[string]interface{}{}
+	if buffer.Len() > 0 {
+		assert.NoError(t, json.Unmarshal(buffer.Bytes(), &fields))
+	}
+	return fields, resp
+}
+
+// nolint:paralleltest
+func TestAccessLog_RegisteredContentType(t *testing.T) {
+	FullAccessLogEnabled = true
+	codec.Register("application/x-test-yaml", codec.YAML)
+	defer func() {
+		FullAccessLogEnabled = false
+		codec.Register("application/x-test-yaml", nil)
+	}()
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.POST("/users").
+		Filter(Attribute(Option{MaskedRequestFields: "password"})).
+		To(func(request *restful.Request, response *restful.Response) {}))
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader("name: foo\npassword: secret\n"))
+	req.Header.Set("Content-Type", "application/x-test-yaml")
+	fields, _ := serveWithAccessLog(t, ws, req)
+
+	// the body is logged and masked as JSON
+	assert.Equal(t, `{"name":"foo","password":"******"}`, fields[fieldRequestBody])
+}
+
+// nolint:paralleltest
+func TestAccessLog_KillSwitch(t *testing.T) {
+	FullAccessLogEnabled = true
+	FullAccessLogMaxBodySize = 10 << 10
+	defer func() {
+		FullAccessLogEnabled = false
+		killswitch.Enable(killswitch.Masking)
+		killswitch.Enable(killswitch.BodyCapture)
+	}()
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.POST("/users").
+		Filter(Attribute(Option{MaskedRequestFields: "password"})).
+		To(func(request *restful.Request, response *restful.Response) {
+			_ = response.WriteAsJson(map[string]string{"name": "foo"})
+		}))
+
+	serve := func() map[string]interface{} {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"password":"secret","name":"foo"}`))
+		req.Header.Set("Content-Type", "application/json")
+		fields, _ := serveWithAccessLog(t, ws, req)
+		return fields
+	}
+
+	assert.Equal(t, `{"password":"******","name":"foo"}`, serve()[fieldRequestBody])
+
+	// the body isn't logged at all instead of being logged unmasked
+	killswitch.Disable(killswitch.Masking)
+	fields := serve()
+	assert.Equal(t, "-", fields[fieldRequestBody])
+	assert.Equal(t, "-", fields[fieldResponseBody])
+
+	killswitch.Enable(killswitch.Masking)
+	killswitch.Disable(killswitch.BodyCapture)
+	fields = serve()
+	assert.Equal(t, "-", fields[fieldRequestBody])
+	assert.Equal(t, "-", fields[fieldResponseBody])
+}
+
+// nolint:paralleltest
+func TestAccessLog_Dependencies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := outbound.NewClient("platform", server.Client())
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Filter(outbound.Filter())
+	ws.Route(ws.GET("/users").
+		To(func(request *restful.Request, response *restful.Response) {
+			outgoing, _ := http.NewRequestWithContext(request.Request.Context(), http.MethodGet, server.URL, nil)
+			if resp, err := client.Do(outgoing); err == nil {
+				_ = resp.Body.Close()
+			}
+		}))
+
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.Regexp(t, `^platform:1:\d+:1$`, fields[fieldDependencies])
+	assert.Nil(t, fields[fieldUpstreamThrottled])
+
+	// no downstream call
+	ws = new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Filter(outbound.Filter())
+	ws.Route(ws.GET("/users").
+		To(func(request *restful.Request, response *restful.Response) {}))
+
+	fields, _ = serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.Nil(t, fields[fieldDependencies])
+}
+
+// nolint:paralleltest
+func TestAccessLog_UpstreamThrottled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := outbound.NewClient("platform", server.Client())
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Filter(outbound.Filter())
+	ws.Route(ws.GET("/users").
+		To(func(request *restful.Request, response *restful.Response) {
+			outgoing, _ := http.NewRequestWithContext(request.Request.Context(), http.MethodGet, server.URL, nil)
+			if resp, err := client.Do(outgoing); err == nil {
+				_ = resp.Body.Close()
+			}
+		}))
+
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.Regexp(t, `^platform:1:\d+:0$`, fields[fieldDependencies])
+	assert.Equal(t, "platform:1:30000", fields[fieldUpstreamThrottled])
+}
@@ -0,0 +1,73 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// maxPooledBodyBufferSize is the maximum capacity of the buffer kept in the pool,
+// so a huge FULL_ACCESS_LOG_MAX_BODY_SIZE doesn't pin the memory after the request is completed.
+const maxPooledBodyBufferSize = 1 << 20
+
+var bodyBufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// getBodyBuffer returns an empty buffer from the pool
+func getBodyBuffer() *bytes.Buffer {
+	buffer := bodyBufferPool.Get().(*bytes.Buffer)
+	buffer.Reset()
+	return buffer
+}
+
+// putBodyBuffer puts the buffer back into the pool
+func putBodyBuffer(buffer *bytes.Buffer) {
+	if buffer == nil || buffer.Cap() > maxPooledBodyBufferSize {
+		return
+	}
+	bodyBufferPool.Put(buffer)
+}
+
+// replayBody is the request body that replays the bytes already read by the access log
+// before continuing with the rest of the original body.
+// The buffer is put back into the pool once it is fully replayed or the body is closed.
+type replayBody struct {
+	buffer *bytes.Buffer
+	rest   io.ReadCloser
+}
+
+func (b *replayBody) Read(p []byte) (int, error) {
+	if b.buffer != nil {
+		if b.buffer.Len() > 0 {
+			return b.buffer.Read(p)
+		}
+		putBodyBuffer(b.buffer)
+		b.buffer = nil
+	}
+	return b.rest.Read(p)
+}
+
+func (b *replayBody) Close() error {
+	if b.buffer != nil {
+		putBodyBuffer(b.buffer)
+		b.buffer = nil
+	}
+	return b.rest.Close()
+}
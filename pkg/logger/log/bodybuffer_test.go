@@ -0,0 +1,106 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type closeTracker struct {
+	*strings.Reader
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestReplayBody(t *testing.T) {
+	t.Parallel()
+
+	rest := &closeTracker{Reader: strings.NewReader(" world")}
+	body := &replayBody{buffer: bytes.NewBufferString("hello"), rest: rest}
+
+	content, err := ioutil.ReadAll(body)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello world", string(content))
+	assert.Nil(t, body.buffer)
+
+	assert.NoError(t, body.Close())
+	assert.True(t, rest.closed)
+}
+
+func TestPutBodyBuffer_SkipLargeBuffer(t *testing.T) {
+	t.Parallel()
+
+	// shouldn't panic for nil or oversized buffer
+	putBodyBuffer(nil)
+	putBodyBuffer(bytes.NewBuffer(make([]byte, 0, maxPooledBodyBufferSize+1)))
+
+	buffer := getBodyBuffer()
+	assert.Equal(t, 0, buffer.Len())
+	putBodyBuffer(buffer)
+}
+
+// nolint:paralleltest
+func TestGetRequestBody_LargeBodyIsFullyReplayed(t *testing.T) {
+	FullAccessLogMaxBodySize = 8
+
+	content := strings.Repeat("0123456789", 100)
+	req := createDummyRequest(content, "text/plain")
+
+	assert.Equal(t, "data too large", getRequestBody(req, "text/plain"))
+
+	// the handler still reads the whole body
+	replayed, err := ioutil.ReadAll(req.Request.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(replayed))
+}
+
+// nolint:paralleltest
+func TestAccessLog_SkipResponseCaptureWhenBodyLoggingDisabled(t *testing.T) {
+	FullAccessLogEnabled = true
+	FullAccessLogResponseBodyEnabled = false
+	defer func() {
+		FullAccessLogEnabled = false
+		FullAccessLogResponseBodyEnabled = true
+	}()
+
+	var interceptor *ResponseWriterInterceptor
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/foo").
+		To(func(request *restful.Request, response *restful.Response) {
+			interceptor = response.ResponseWriter.(*ResponseWriterInterceptor)
+			response.Header().Set("Content-Type", "text/plain")
+			_, _ = response.Write([]byte("bar"))
+			assert.Nil(t, interceptor.Body())
+		}))
+
+	fields, resp := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/foo", nil))
+	assert.Equal(t, "bar", resp.Body.String())
+	assert.Equal(t, "-", fields[fieldResponseBody])
+	assert.Equal(t, 3, interceptor.BytesWritten())
+}
@@ -0,0 +1,97 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	requestBodyTrackerAttribute = "LogRequestBodyTracker"
+
+	fieldRequestBodyRewinds       = "request_body_rewinds"
+	fieldRequestBodyReadsAfterEOF = "request_body_reads_after_eof"
+)
+
+var FullAccessLogBodyReadDiagnosticsEnabled bool
+
+// requestBodyTracker decorates the request body to detect the body being read more than once.
+// A read after the body is fully consumed returns nothing, which usually shows up as an empty body bug.
+type requestBodyTracker struct {
+	io.ReadCloser
+	eof           bool
+	readsAfterEOF int
+	rewinds       int
+}
+
+func (t *requestBodyTracker) Read(p []byte) (int, error) {
+	if t.eof {
+		t.readsAfterEOF++
+	}
+
+	n, err := t.ReadCloser.Read(p)
+	if err == io.EOF {
+		t.eof = true
+	}
+	return n, err
+}
+
+// trackRequestBody decorates the request body with requestBodyTracker
+func trackRequestBody(req *restful.Request) *requestBodyTracker {
+	tracker := &requestBodyTracker{ReadCloser: req.Request.Body}
+	req.Request.Body = tracker
+	req.SetAttribute(requestBodyTrackerAttribute, tracker)
+	return tracker
+}
+
+// RewindRequestBody sets the already read body bytes back into the request body reader,
+// so the next filter or handler can read it again.
+// The rewind is counted in the access log when the body read diagnostics is enabled.
+func RewindRequestBody(req *restful.Request, body []byte) {
+	if tracker, ok := req.Attribute(requestBodyTrackerAttribute).(*requestBodyTracker); ok && req.Request.Body == tracker {
+		tracker.ReadCloser = ioutil.NopCloser(bytes.NewBuffer(body))
+		tracker.eof = false
+		tracker.rewinds++
+		return
+	}
+	req.Request.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+}
+
+// addBodyReadFields adds the body read diagnostics into the fields
+func addBodyReadFields(req *restful.Request, tracker *requestBodyTracker, fields logrus.Fields) {
+	if tracker == nil {
+		return
+	}
+
+	rewinds := tracker.rewinds
+	if req.Request.Body != tracker {
+		// the body was replaced without using RewindRequestBody
+		rewinds++
+	}
+
+	if tracker.readsAfterEOF > 0 {
+		logrus.Warnf("request body of %s %s was read %d time(s) after it was fully consumed",
+			req.Request.Method, req.Request.URL.Path, tracker.readsAfterEOF)
+		fields[fieldRequestBodyReadsAfterEOF] = tracker.readsAfterEOF
+	}
+	if rewinds > 0 {
+		fields[fieldRequestBodyRewinds] = rewinds
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// nolint:paralleltest
+func TestAccessLog_BodyReadAfterConsumed(t *testing.T) {
+	FullAccessLogBodyReadDiagnosticsEnabled = true
+	defer func() {
+		FullAccessLogBodyReadDiagnosticsEnabled = false
+	}()
+
+	var secondRead []byte
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.POST("/user").
+		Filter(func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+			_, _ = ioutil.ReadAll(req.Request.Body)
+			chain.ProcessFilter(req, resp)
+		}).
+		To(func(request *restful.Request, response *restful.Response) {
+			secondRead, _ = ioutil.ReadAll(request.Request.Body)
+		}))
+
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodPost, "/user", strings.NewReader("foo")))
+
+	assert.Empty(t, secondRead)
+	assert.Equal(t, float64(1), fields[fieldRequestBodyReadsAfterEOF])
+	assert.Nil(t, fields[fieldRequestBodyRewinds])
+}
+
+// nolint:paralleltest
+func TestAccessLog_BodyRewind(t *testing.T) {
+	FullAccessLogBodyReadDiagnosticsEnabled = true
+	defer func() {
+		FullAccessLogBodyReadDiagnosticsEnabled = false
+	}()
+
+	var secondRead []byte
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.POST("/user").
+		Filter(func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+			body, _ := ioutil.ReadAll(req.Request.Body)
+			RewindRequestBody(req, body)
+			chain.ProcessFilter(req, resp)
+		}).
+		Filter(func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+			body, _ := ioutil.ReadAll(req.Request.Body)
+			req.Request.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+			chain.ProcessFilter(req, resp)
+		}).
+		To(func(request *restful.Request, response *restful.Response) {
+			secondRead, _ = ioutil.ReadAll(request.Request.Body)
+		}))
+
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodPost, "/user", strings.NewReader("foo")))
+
+	assert.Equal(t, "foo", string(secondRead))
+	assert.Nil(t, fields[fieldRequestBodyReadsAfterEOF])
+	assert.Equal(t, float64(2), fields[fieldRequestBodyRewinds])
+}
+
+// nolint:paralleltest
+func TestAccessLog_BodyReadDiagnosticsDisabled(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.POST("/user").
+		To(func(request *restful.Request, response *restful.Response) {
+			_, _ = ioutil.ReadAll(request.Request.Body)
+			_, _ = ioutil.ReadAll(request.Request.Body)
+		}))
+
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodPost, "/user", strings.NewReader("foo")))
+
+	assert.Nil(t, fields[fieldRequestBodyReadsAfterEOF])
+}
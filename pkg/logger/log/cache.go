@@ -0,0 +1,74 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/killswitch"
+	"github.com/emicklei/go-restful/v3"
+)
+
+const (
+	// CacheStatusAttribute is the key for the cache status of the response, stored in the request
+	CacheStatusAttribute = "LogCacheStatus"
+	// CachedResponseBodyAttribute is the key for the masked response body stored in the cache, stored in the request
+	CachedResponseBodyAttribute = "LogCachedResponseBody"
+)
+
+// cache status of the response
+const (
+	CacheHit   = "hit"   // the response is served from the cache
+	CacheMiss  = "miss"  // the response is served by the handler and may be stored into the cache
+	CacheStale = "stale" // the expired response is served from the cache, e.g. while it's being revalidated
+)
+
+// SetCacheStatus marks the response served by the caching filter, printed as cache field in the access log
+func SetCacheStatus(req *restful.Request, status string) {
+	req.SetAttribute(CacheStatusAttribute, status)
+}
+
+// SetCachedResponseBody sets the masked response body stored in the cache along with the response.
+// When the response is served from the cache (hit or stale), it's printed as response_body field
+// without masking the response body again.
+func SetCachedResponseBody(req *restful.Request, maskedBody string) {
+	req.SetAttribute(CachedResponseBodyAttribute, maskedBody)
+}
+
+// MaskResponseBody masks the response fields of the endpoint the same way as the access log,
+// used by the caching filter to store the masked response body in the cache.
+// The body is dropped if the masking is disabled by the kill switch.
+func MaskResponseBody(req *restful.Request, contentType string, body string) string {
+	if killswitch.IsDisabled(killswitch.Masking) {
+		return ""
+	}
+	masked := resolveMasking(req)
+	if masked.responseFields == "" || body == "" {
+		return body
+	}
+	return MaskFields(contentType, body, masked.responseFields)
+}
+
+func getCacheStatus(req *restful.Request) string {
+	status, _ := req.Attribute(CacheStatusAttribute).(string)
+	return status
+}
+
+// getCachedResponseBody returns the masked response body if the response is served from the cache
+func getCachedResponseBody(req *restful.Request, cacheStatus string) (string, bool) {
+	if cacheStatus != CacheHit && cacheStatus != CacheStale {
+		return "", false
+	}
+	body, ok := req.Attribute(CachedResponseBodyAttribute).(string)
+	return body, ok
+}
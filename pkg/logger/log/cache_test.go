@@ -0,0 +1,79 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// cachingFilter simulates the caching filter storing the masked response body along with the response
+func cachingFilter(cache map[string]string) restful.FilterFunction {
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		if body, ok := cache[req.Request.URL.Path]; ok {
+			SetCacheStatus(req, CacheHit)
+			SetCachedResponseBody(req, MaskResponseBody(req, restful.MIME_JSON, body))
+			_ = resp.WriteHeaderAndJson(http.StatusOK, body, restful.MIME_JSON)
+			return
+		}
+
+		SetCacheStatus(req, CacheMiss)
+		chain.ProcessFilter(req, resp)
+	}
+}
+
+// nolint:paralleltest
+func TestAccessLog_CacheStatus(t *testing.T) {
+	FullAccessLogEnabled = true
+	FullAccessLogMaxBodySize = 10 << 10
+	defer func() {
+		FullAccessLogEnabled = false
+	}()
+
+	cache := map[string]string{"/cached": `{"name":"john","token":"secret"}`}
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Filter(Attribute(Option{MaskedResponseFields: "token"}))
+	ws.Filter(cachingFilter(cache))
+	ws.Route(ws.GET("/cached").To(func(request *restful.Request, response *restful.Response) {}))
+	ws.Route(ws.GET("/uncached").
+		To(func(request *restful.Request, response *restful.Response) {
+			_ = response.WriteAsJson(map[string]string{"name": "john", "token": "secret"})
+		}))
+
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/cached", nil))
+	assert.Equal(t, CacheHit, fields[fieldCache])
+	assert.Equal(t, `{"name":"john","token":"******"}`, fields[fieldResponseBody])
+
+	fields, _ = serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/uncached", nil))
+	assert.Equal(t, CacheMiss, fields[fieldCache])
+	assert.NotContains(t, fields[fieldResponseBody], "secret")
+}
+
+func TestAccessLog_WithoutCacheStatus(t *testing.T) {
+	t.Parallel()
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/user").To(func(request *restful.Request, response *restful.Response) {}))
+
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/user", nil))
+	assert.NotContains(t, fields, fieldCache)
+}
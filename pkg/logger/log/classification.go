@@ -0,0 +1,79 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DataClassificationMetadata is the route metadata key of the record's data classification
+	DataClassificationMetadata = "LogDataClassification"
+	// RetentionMetadata is the route metadata key of the record's retention hint
+	RetentionMetadata = "LogRetention"
+
+	// DataClassificationPII marks the record as containing personally identifiable information
+	DataClassificationPII = "pii"
+
+	fieldDataClassification = "data_classification"
+	fieldPII                = "pii"
+	fieldRetention          = "retention"
+)
+
+var (
+	FullAccessLogDefaultRetention string
+	FullAccessLogPIIRetention     string
+)
+
+// addClassificationFields adds the data classification and retention tags of the request into the fields.
+// The value is taken from the request attribute, then the route metadata.
+// A request with masked field(s) is considered to contain PII.
+func addClassificationFields(req *restful.Request, masked masking, fields logrus.Fields) {
+	classification := getTag(req, DataClassificationAttribute, DataClassificationMetadata)
+	retention := getTag(req, RetentionAttribute, RetentionMetadata)
+
+	pii := classification == DataClassificationPII || !masked.isEmpty()
+
+	if retention == "" {
+		if pii && FullAccessLogPIIRetention != "" {
+			retention = FullAccessLogPIIRetention
+		} else {
+			retention = FullAccessLogDefaultRetention
+		}
+	}
+
+	if classification != "" {
+		fields[fieldDataClassification] = classification
+	}
+	if pii {
+		fields[fieldPII] = true
+	}
+	if retention != "" {
+		fields[fieldRetention] = retention
+	}
+}
+
+func getTag(req *restful.Request, attribute string, metadata string) string {
+	if val, ok := req.Attribute(attribute).(string); ok && val != "" {
+		return val
+	}
+	if route := req.SelectedRoute(); route != nil {
+		if val, ok := route.Metadata()[metadata].(string); ok {
+			return val
+		}
+	}
+	return ""
+}
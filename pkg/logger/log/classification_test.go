@@ -0,0 +1,83 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// nolint:paralleltest
+func TestAccessLog_ClassificationFromRouteMetadata(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/user/{id}").
+		Metadata(DataClassificationMetadata, "internal").
+		Metadata(RetentionMetadata, "90d").
+		To(func(request *restful.Request, response *restful.Response) {}))
+
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/user/abc", nil))
+
+	assert.Equal(t, "internal", fields[fieldDataClassification])
+	assert.Equal(t, "90d", fields[fieldRetention])
+	assert.Nil(t, fields[fieldPII])
+}
+
+// nolint:paralleltest
+func TestAccessLog_ClassificationFromMaskingConfig(t *testing.T) {
+	FullAccessLogPIIRetention = "30d"
+	FullAccessLogDefaultRetention = "180d"
+	defer func() {
+		FullAccessLogPIIRetention = ""
+		FullAccessLogDefaultRetention = ""
+	}()
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/user/{id}").
+		Filter(Attribute(Option{MaskedResponseFields: "email"})).
+		To(func(request *restful.Request, response *restful.Response) {}))
+	ws.Route(ws.GET("/game/{id}").
+		To(func(request *restful.Request, response *restful.Response) {}))
+
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/user/abc", nil))
+	assert.Equal(t, true, fields[fieldPII])
+	assert.Equal(t, "30d", fields[fieldRetention])
+	assert.Nil(t, fields[fieldDataClassification])
+
+	fields, _ = serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/game/abc", nil))
+	assert.Nil(t, fields[fieldPII])
+	assert.Equal(t, "180d", fields[fieldRetention])
+}
+
+// nolint:paralleltest
+func TestAccessLog_ClassificationFromAttributeOverridesMetadata(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/user/{id}").
+		Metadata(DataClassificationMetadata, "internal").
+		Filter(Attribute(Option{DataClassification: DataClassificationPII, Retention: "7d"})).
+		To(func(request *restful.Request, response *restful.Response) {}))
+
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/user/abc", nil))
+
+	assert.Equal(t, DataClassificationPII, fields[fieldDataClassification])
+	assert.Equal(t, true, fields[fieldPII])
+	assert.Equal(t, "7d", fields[fieldRetention])
+}
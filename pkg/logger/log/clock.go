@@ -0,0 +1,91 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"strings"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/clock"
+)
+
+const (
+	// TimeFormatRFC3339Nano prints the time field in RFC3339 format with nanoseconds, e.g. 2022-01-01T00:00:00.123456789Z
+	TimeFormatRFC3339Nano = "rfc3339nano"
+	// TimeFormatEpochMillis prints the time field as the number of milliseconds since the Unix epoch
+	TimeFormatEpochMillis = "epoch_millis"
+
+	// DurationUnitMillisecond prints the duration field in milliseconds
+	DurationUnitMillisecond = "ms"
+	// DurationUnitMicrosecond prints the duration field in microseconds
+	DurationUnitMicrosecond = "us"
+
+	defaultTimeLayout = "2006-01-02T15:04:05.000Z"
+)
+
+var (
+	// FullAccessLogTimeFormat is the format of the time field: TimeFormatRFC3339Nano, TimeFormatEpochMillis
+	// or a Go time layout. Default: 2006-01-02T15:04:05.000Z
+	FullAccessLogTimeFormat string
+	// FullAccessLogDurationUnit is the unit of the duration field: DurationUnitMillisecond or DurationUnitMicrosecond.
+	// Default: DurationUnitMillisecond
+	FullAccessLogDurationUnit = DurationUnitMillisecond
+
+	accessLogClock = clock.System()
+)
+
+// Clock is the time source of the access log
+type Clock = clock.Clock
+
+// SetClock sets the time source of the access log, e.g. a fixed clock to produce deterministic records in the tests.
+// Nil restores the system clock.
+func SetClock(c Clock) {
+	accessLogClock = clock.OrSystem(c)
+}
+
+// parseDurationUnit parses the duration unit, "µs" is accepted as DurationUnitMicrosecond
+func parseDurationUnit(s string) (string, bool) {
+	switch strings.ToLower(s) {
+	case DurationUnitMillisecond:
+		return DurationUnitMillisecond, true
+	case DurationUnitMicrosecond, "µs":
+		return DurationUnitMicrosecond, true
+	default:
+		return "", false
+	}
+}
+
+// formatTime formats the time field in UTC using FullAccessLogTimeFormat
+func formatTime(t time.Time) interface{} {
+	t = t.UTC()
+	switch strings.ToLower(FullAccessLogTimeFormat) {
+	case "":
+		return t.Format(defaultTimeLayout)
+	case TimeFormatRFC3339Nano:
+		return t.Format(time.RFC3339Nano)
+	case TimeFormatEpochMillis:
+		return t.UnixNano() / int64(time.Millisecond)
+	default:
+		return t.Format(FullAccessLogTimeFormat)
+	}
+}
+
+// formatDuration converts the duration field into FullAccessLogDurationUnit
+func formatDuration(d time.Duration) int64 {
+	if FullAccessLogDurationUnit == DurationUnitMicrosecond {
+		return d.Microseconds()
+	}
+	return d.Milliseconds()
+}
@@ -0,0 +1,138 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// steppingClock returns the given time and moves it forward by the step on every call
+type steppingClock struct {
+	now  time.Time
+	step time.Duration
+}
+
+func (c *steppingClock) Now() time.Time {
+	now := c.now
+	c.now = c.now.Add(c.step)
+	return now
+}
+
+// nolint:paralleltest
+func TestFormatTime(t *testing.T) {
+	defer func() {
+		FullAccessLogTimeFormat = ""
+	}()
+
+	now := time.Date(2022, 1, 2, 3, 4, 5, 6007008, time.FixedZone("UTC+7", 7*60*60))
+
+	testCases := []struct {
+		format   string
+		expected interface{}
+	}{
+		{format: "", expected: "2022-01-01T20:04:05.006Z"},
+		{format: TimeFormatRFC3339Nano, expected: "2022-01-01T20:04:05.006007008Z"},
+		{format: "EPOCH_MILLIS", expected: int64(1641067445006)},
+		{format: "2006-01-02 15:04:05", expected: "2022-01-01 20:04:05"},
+	}
+
+	for _, testCase := range testCases {
+		FullAccessLogTimeFormat = testCase.format
+		assert.Equal(t, testCase.expected, formatTime(now), testCase.format)
+	}
+}
+
+// nolint:paralleltest
+func TestFormatDuration(t *testing.T) {
+	defer func() {
+		FullAccessLogDurationUnit = DurationUnitMillisecond
+	}()
+
+	FullAccessLogDurationUnit = DurationUnitMillisecond
+	assert.Equal(t, int64(1500), formatDuration(1500500*time.Microsecond))
+
+	FullAccessLogDurationUnit = DurationUnitMicrosecond
+	assert.Equal(t, int64(1500500), formatDuration(1500500*time.Microsecond))
+}
+
+func TestParseDurationUnit(t *testing.T) {
+	t.Parallel()
+
+	unit, ok := parseDurationUnit("MS")
+	assert.True(t, ok)
+	assert.Equal(t, DurationUnitMillisecond, unit)
+
+	unit, ok = parseDurationUnit("µs")
+	assert.True(t, ok)
+	assert.Equal(t, DurationUnitMicrosecond, unit)
+
+	_, ok = parseDurationUnit("s")
+	assert.False(t, ok)
+}
+
+// nolint:paralleltest
+func TestAccessLog_Clock(t *testing.T) {
+	FullAccessLogTimeFormat = TimeFormatEpochMillis
+	FullAccessLogDurationUnit = DurationUnitMicrosecond
+	SetClock(&steppingClock{now: time.Unix(1641067445, 0), step: 250 * time.Microsecond})
+	defer func() {
+		FullAccessLogTimeFormat = ""
+		FullAccessLogDurationUnit = DurationUnitMillisecond
+		SetClock(nil)
+	}()
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/clock").
+		To(func(request *restful.Request, response *restful.Response) {
+			response.WriteHeader(http.StatusOK)
+		}))
+
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/clock", nil))
+
+	assert.Equal(t, float64(1641067445000), fields[fieldTime])
+	assert.Equal(t, float64(250), fields[fieldDuration])
+}
+
+// nolint:paralleltest
+func TestAccessLogFields_TimeFormat(t *testing.T) {
+	FullAccessLogTimeFormat = TimeFormatEpochMillis
+	FullAccessLogDurationUnit = DurationUnitMicrosecond
+	defer func() {
+		FullAccessLogTimeFormat = ""
+		FullAccessLogDurationUnit = DurationUnitMillisecond
+	}()
+
+	for _, field := range AccessLogFields() {
+		switch field.Name {
+		case fieldTime:
+			assert.Equal(t, FieldTypeInteger, field.Type)
+		case fieldDuration:
+			assert.Equal(t, "Duration of the request in microseconds", field.Description)
+		}
+	}
+
+	for _, field := range accessLogFields {
+		if field.Name == fieldTime {
+			assert.Equal(t, FieldTypeString, field.Type)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	iamSDK "github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DebugLogHeader is the request header enabling the debug access log of the request, e.g. "X-Ab-Debug-Log: true"
+	DebugLogHeader = "X-Ab-Debug-Log"
+
+	fieldDebug = "debug"
+)
+
+var (
+	// FullAccessLogDebugClientIDs is the allowlist of client IDs allowed to enable the debug access log
+	FullAccessLogDebugClientIDs []string
+	// DebugLogAuthorizer authorizes the request to enable the debug access log, in addition to the allowlisted client IDs,
+	// e.g. using DebugLogPermission. It's called after the request is processed, so the token claims are available.
+	DebugLogAuthorizer func(req *restful.Request) bool
+)
+
+// DebugLogPermission returns the DebugLogAuthorizer allowing the token with the permission,
+// resolving the {namespace} and {userId} resource placeholders from the path parameters.
+func DebugLogPermission(client iamSDK.Client, permission iamSDK.Permission) func(req *restful.Request) bool {
+	return func(req *restful.Request) bool {
+		claims := iam.RetrieveJWTClaims(req)
+		if claims == nil {
+			return false
+		}
+
+		valid, err := client.ValidatePermission(claims, permission, map[string]string{
+			"{namespace}": req.PathParameter("namespace"),
+			"{userId}":    req.PathParameter("userId"),
+		})
+		if err != nil {
+			logrus.Errorf("unable to validate debug log permission: %v", err)
+			return false
+		}
+		return valid
+	}
+}
+
+// isDebugLogRequested checks whether the request asks for the debug access log,
+// the header is ignored if nobody is allowed to enable it
+func isDebugLogRequested(req *restful.Request) bool {
+	if len(FullAccessLogDebugClientIDs) == 0 && DebugLogAuthorizer == nil {
+		return false
+	}
+	requested, _ := strconv.ParseBool(req.HeaderParameter(DebugLogHeader))
+	return requested
+}
+
+// isDebugLogAllowed checks whether the client of the request is allowed to enable the debug access log
+func isDebugLogAllowed(req *restful.Request, clientID string) bool {
+	if clientID != "" {
+		for _, allowedClientID := range FullAccessLogDebugClientIDs {
+			if strings.TrimSpace(allowedClientID) == clientID {
+				return true
+			}
+		}
+	}
+	return DebugLogAuthorizer != nil && DebugLogAuthorizer(req)
+}
+
+// addDebugFields adds all request and response headers into the fields, masking the sensitive headers
+func addDebugFields(requestHeader http.Header, responseHeader http.Header, maskedHeaders string, fields logrus.Fields) {
+	fields[fieldDebug] = true
+	for name := range requestHeader {
+		addHeaderField(requestHeaderFieldPrefix, requestHeader, name, maskedHeaders, fields)
+	}
+	for name := range responseHeader {
+		addHeaderField(responseHeaderFieldPrefix, responseHeader, name, maskedHeaders, fields)
+	}
+}
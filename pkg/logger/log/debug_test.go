@@ -0,0 +1,128 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	iamSDK "github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func createDebugLogWebService(claims *iamSDK.JWTClaims) *restful.WebService {
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.POST("/namespaces/{namespace}/users").
+		Filter(func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+			req.SetAttribute(iam.ClaimsAttribute, claims)
+			chain.ProcessFilter(req, resp)
+		}).
+		Filter(Attribute(Option{MaskedRequestFields: "password"})).
+		To(func(request *restful.Request, response *restful.Response) {
+			response.AddHeader("X-Cache", "miss")
+			_ = response.WriteHeaderAndJson(http.StatusCreated, map[string]string{"id": "1"}, restful.MIME_JSON)
+		}))
+	return ws
+}
+
+func createDebugLogRequest(debug string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/namespaces/abc/users",
+		strings.NewReader(`{"name":"john","password":"secret"}`))
+	req.Header.Set("Content-Type", restful.MIME_JSON)
+	req.Header.Set("Authorization", "Bearer token")
+	if debug != "" {
+		req.Header.Set(DebugLogHeader, debug)
+	}
+	return req
+}
+
+// nolint:paralleltest
+func TestAccessLog_DebugClientID(t *testing.T) {
+	FullAccessLogDebugClientIDs = []string{"admin-client"}
+	defer func() {
+		FullAccessLogDebugClientIDs = nil
+	}()
+
+	ws := createDebugLogWebService(&iamSDK.JWTClaims{ClientID: "admin-client"})
+	fields, _ := serveWithAccessLog(t, ws, createDebugLogRequest("true"))
+
+	assert.Equal(t, true, fields[fieldDebug])
+	assert.Equal(t, `{"name":"john","password":"`+MaskedValue+`"}`, fields[fieldRequestBody])
+	assert.Contains(t, fields[fieldResponseBody], `"id":"1"`)
+	assert.Equal(t, MaskedValue, fields["request_header_authorization"])
+	assert.Equal(t, "true", fields["request_header_x_ab_debug_log"])
+	assert.Equal(t, "miss", fields["response_header_x_cache"])
+
+	// the debug access log isn't requested
+	fields, _ = serveWithAccessLog(t, ws, createDebugLogRequest(""))
+	assert.Nil(t, fields[fieldDebug])
+	assert.Equal(t, "-", fields[fieldRequestBody])
+	assert.Equal(t, "-", fields[fieldResponseBody])
+}
+
+// nolint:paralleltest
+func TestAccessLog_DebugNotAllowed(t *testing.T) {
+	FullAccessLogDebugClientIDs = []string{"admin-client"}
+	defer func() {
+		FullAccessLogDebugClientIDs = nil
+	}()
+
+	ws := createDebugLogWebService(&iamSDK.JWTClaims{ClientID: "game-client"})
+	fields, _ := serveWithAccessLog(t, ws, createDebugLogRequest("true"))
+
+	assert.Nil(t, fields[fieldDebug])
+	assert.Equal(t, "-", fields[fieldRequestBody])
+	assert.Equal(t, "-", fields[fieldResponseBody])
+	assert.Nil(t, fields["request_header_authorization"])
+}
+
+// nolint:paralleltest
+func TestAccessLog_DebugNotConfigured(t *testing.T) {
+	ws := createDebugLogWebService(&iamSDK.JWTClaims{ClientID: "admin-client"})
+	fields, _ := serveWithAccessLog(t, ws, createDebugLogRequest("true"))
+
+	assert.Nil(t, fields[fieldDebug])
+	assert.Equal(t, "-", fields[fieldRequestBody])
+}
+
+// nolint:paralleltest
+func TestAccessLog_DebugPermission(t *testing.T) {
+	DebugLogAuthorizer = DebugLogPermission(&iamSDK.MockClient{}, iamSDK.Permission{
+		Resource: "ADMIN:NAMESPACE:{namespace}:DEBUGLOG",
+		Action:   iamSDK.ActionRead,
+	})
+	defer func() {
+		DebugLogAuthorizer = nil
+	}()
+
+	ws := createDebugLogWebService(&iamSDK.JWTClaims{
+		Permissions: []iamSDK.Permission{{Resource: "ADMIN:NAMESPACE:abc:DEBUGLOG", Action: iamSDK.ActionRead}},
+	})
+	fields, _ := serveWithAccessLog(t, ws, createDebugLogRequest("true"))
+	assert.Equal(t, true, fields[fieldDebug])
+	assert.NotEqual(t, "-", fields[fieldRequestBody])
+
+	ws = createDebugLogWebService(&iamSDK.JWTClaims{
+		Permissions: []iamSDK.Permission{{Resource: iamSDK.MockForbidden}},
+	})
+	fields, _ = serveWithAccessLog(t, ws, createDebugLogRequest("true"))
+	assert.Nil(t, fields[fieldDebug])
+	assert.Equal(t, "-", fields[fieldRequestBody])
+}
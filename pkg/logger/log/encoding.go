@@ -0,0 +1,95 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+var errDecodedBodyTooLarge = errors.New("decoded body exceeds the maximum body size")
+
+// decodeBody decompresses the gzip or deflate encoded body, up to maxSize of decompressed bytes.
+// The body is returned as is if it isn't actually compressed, e.g. the handler writes the plain body
+// and it is compressed by go-restful after passing through the access log filter.
+func decodeBody(body []byte, contentEncoding string, maxSize int) ([]byte, error) {
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case encodingGzip:
+		if !isGzip(body) {
+			return body, nil
+		}
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		return readDecoded(reader, maxSize)
+	case encodingDeflate:
+		return decodeDeflate(body, maxSize)
+	default:
+		return body, nil
+	}
+}
+
+// decodeDeflate decompresses the zlib wrapped or the raw deflate stream,
+// the body which is neither of them is returned as is
+func decodeDeflate(body []byte, maxSize int) ([]byte, error) {
+	if isZlib(body) {
+		if reader, err := zlib.NewReader(bytes.NewReader(body)); err == nil {
+			decoded, err := readDecoded(reader, maxSize)
+			if err == nil || err == errDecodedBodyTooLarge {
+				return decoded, err
+			}
+		}
+	}
+
+	// some clients and servers send the raw deflate stream without zlib wrapper
+	decoded, err := readDecoded(flate.NewReader(bytes.NewReader(body)), maxSize)
+	if err == nil || err == errDecodedBodyTooLarge {
+		return decoded, err
+	}
+	return body, nil
+}
+
+// readDecoded reads the decompressed bytes up to maxSize
+func readDecoded(reader io.Reader, maxSize int) ([]byte, error) {
+	decoded, err := ioutil.ReadAll(io.LimitReader(reader, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded) > maxSize {
+		return nil, errDecodedBodyTooLarge
+	}
+	return decoded, nil
+}
+
+func isGzip(body []byte) bool {
+	return len(body) >= 2 && body[0] == 0x1f && body[1] == 0x8b
+}
+
+// isZlib checks the zlib header: deflate compression method and valid header checksum
+func isZlib(body []byte) bool {
+	return len(body) >= 2 && body[0]&0x0f == 8 && (uint16(body[0])<<8|uint16(body[1]))%31 == 0
+}
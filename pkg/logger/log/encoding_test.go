@@ -0,0 +1,108 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func compress(t *testing.T, encoding string, content string) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	var writer io.WriteCloser
+	switch encoding {
+	case "gzip":
+		writer = gzip.NewWriter(&buffer)
+	case "zlib":
+		writer = zlib.NewWriter(&buffer)
+	default:
+		var err error
+		writer, err = flate.NewWriter(&buffer, flate.DefaultCompression)
+		assert.NoError(t, err)
+	}
+	_, err := writer.Write([]byte(content))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	return buffer.Bytes()
+}
+
+func TestDecodeBody(t *testing.T) {
+	t.Parallel()
+
+	content := `{"foo":"bar"}`
+
+	decoded, err := decodeBody(compress(t, "gzip", content), "gzip", 1024)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(decoded))
+
+	decoded, err = decodeBody(compress(t, "zlib", content), "deflate", 1024)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(decoded))
+
+	decoded, err = decodeBody(compress(t, "flate", content), "Deflate", 1024)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(decoded))
+
+	// the body isn't compressed yet
+	decoded, err = decodeBody([]byte(content), "gzip", 1024)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(decoded))
+
+	// the deflate body isn't compressed yet, e.g. compressed by go-restful outside the filter chain
+	for _, plain := range []string{content, "hello world", "", "x"} {
+		decoded, err = decodeBody([]byte(plain), "deflate", 1024)
+		assert.NoError(t, err)
+		assert.Equal(t, plain, string(decoded))
+	}
+
+	// unsupported encoding
+	decoded, err = decodeBody([]byte(content), "br", 1024)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(decoded))
+}
+
+func TestDecodeBody_TooLarge(t *testing.T) {
+	t.Parallel()
+
+	// highly compressible content expands beyond the limit
+	_, err := decodeBody(compress(t, "gzip", strings.Repeat("a", 4096)), "gzip", 1024)
+	assert.Equal(t, errDecodedBodyTooLarge, err)
+}
+
+// nolint:paralleltest
+func TestGetResponseBody_Compressed(t *testing.T) {
+	FullAccessLogMaxBodySize = 1024
+
+	response := createDummyResponse(string(compress(t, "gzip", `{"foo": "bar"}`)), "application/json")
+	response.Header().Set("Content-Encoding", "gzip")
+	body, _ := getResponseBody(context.Background(), response, "application/json")
+	assert.Equal(t, `{"foo":"bar"}`, body)
+
+	response = createDummyResponse(string(compress(t, "gzip", strings.Repeat("a", 4096))), "text/plain")
+	response.Header().Set("Content-Encoding", "gzip")
+	body, _ = getResponseBody(context.Background(), response, "text/plain")
+	assert.Equal(t, "data too large", body)
+}
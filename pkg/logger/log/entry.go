@@ -0,0 +1,148 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"sync"
+	"time"
+
+	iamSDK "github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+// AccessLogEntry is the typed access log record built by the AccessLog filter
+type AccessLogEntry struct {
+	Time                time.Time
+	Method              string
+	Path                string
+	Status              int
+	Duration            time.Duration
+	Length              int
+	SourceIP            string
+	UserAgent           string
+	Referer             string
+	TraceID             string
+	Namespace           string
+	UserID              string
+	ClientID            string
+	Claims              *iamSDK.JWTClaims
+	RequestContentType  string
+	RequestBody         string
+	ResponseContentType string
+	ResponseBody        string
+	Operation           string
+
+	// Extras are the optional fields, e.g. journey_id, the headers and the custom fields,
+	// the formatter can add its own fields here
+	Extras map[string]interface{}
+
+	// Request is the logged request, e.g. to read the attributes in the formatter.
+	// It's only set while the entry is formatted, the access log hooks receive the entry without it.
+	Request *restful.Request
+}
+
+// Fields returns the access log fields of the entry, the fields of Extras never override the typed fields
+func (e *AccessLogEntry) Fields() logrus.Fields {
+	fields := make(logrus.Fields, len(e.Extras)+len(fullAccessLogFormatFields))
+	for key, value := range e.Extras {
+		fields[key] = value
+	}
+
+	fields[fieldTime] = formatTime(e.Time)
+	fields[fieldLogType] = logTypeAccess
+	fields[fieldMethod] = e.Method
+	fields[fieldPath] = e.Path
+	fields[fieldStatus] = e.Status
+	fields[fieldDuration] = formatDuration(e.Duration)
+	fields[fieldLength] = e.Length
+	fields[fieldSourceIP] = e.SourceIP
+	fields[fieldUserAgent] = e.UserAgent
+	fields[fieldReferer] = e.Referer
+	fields[fieldTraceID] = e.TraceID
+	fields[fieldNamespace] = e.Namespace
+	fields[fieldUserID] = e.UserID
+	fields[fieldClientID] = e.ClientID
+	fields[fieldRequestContentType] = e.RequestContentType
+	fields[fieldRequestBody] = e.RequestBody
+	fields[fieldResponseContentType] = e.ResponseContentType
+	fields[fieldResponseBody] = e.ResponseBody
+	fields[fieldOperation] = e.Operation
+
+	return fields
+}
+
+// AccessLogFormatter formats the access log entry into the record written into the access log output
+type AccessLogFormatter interface {
+	Format(entry *AccessLogEntry) ([]byte, error)
+}
+
+// AccessLogFormatterFunc is an adapter to use the function as AccessLogFormatter
+type AccessLogFormatterFunc func(entry *AccessLogEntry) ([]byte, error)
+
+// Format calls f(entry)
+func (f AccessLogFormatterFunc) Format(entry *AccessLogEntry) ([]byte, error) {
+	return f(entry)
+}
+
+var (
+	accessLogEntryFormatter AccessLogFormatter
+	accessLogWriteMutex     sync.Mutex
+)
+
+// SetAccessLogEntryFormatter sets the formatter of the typed access log entry, e.g. to add the custom fields.
+// Nil restores the default formatter, see DefaultAccessLogFormatter.
+func SetAccessLogEntryFormatter(formatter AccessLogFormatter) {
+	accessLogEntryFormatter = formatter
+}
+
+// DefaultAccessLogFormatter returns the formatter printing the entry using the configured format,
+// or the formatter set by SetAccessLogFormatter. It can be wrapped by the custom AccessLogFormatter.
+func DefaultAccessLogFormatter() AccessLogFormatter {
+	return AccessLogFormatterFunc(func(entry *AccessLogEntry) ([]byte, error) {
+		logger := getFullAccessLogLogger()
+		return logger.Formatter.Format(&logrus.Entry{
+			Logger: logger,
+			Data:   selectFields(entry.Fields()),
+			Time:   entry.Time,
+			Level:  logrus.InfoLevel,
+		})
+	})
+}
+
+// writeAccessLogEntry formats the entry and writes it into the access log output
+func writeAccessLogEntry(logger *logrus.Logger, entry *AccessLogEntry) {
+	formatter := accessLogEntryFormatter
+	if formatter == nil {
+		logger.WithFields(selectFields(entry.Fields())).Info()
+		return
+	}
+
+	if !logger.IsLevelEnabled(logrus.InfoLevel) {
+		return
+	}
+
+	record, err := formatter.Format(entry)
+	if err != nil {
+		logrus.Errorf("failed to format access log entry: %v", err)
+		return
+	}
+
+	accessLogWriteMutex.Lock()
+	defer accessLogWriteMutex.Unlock()
+	if _, err = logger.Out.Write(record); err != nil {
+		logrus.Errorf("failed to write access log entry: %v", err)
+	}
+}
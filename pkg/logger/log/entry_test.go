@@ -0,0 +1,106 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/go-jose/jwt"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	iamSDK "github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessLogEntry_Fields(t *testing.T) {
+	t.Parallel()
+
+	entry := &AccessLogEntry{
+		Time:     time.Date(2022, 1, 2, 3, 4, 5, 6000000, time.UTC),
+		Method:   http.MethodGet,
+		Path:     "/users",
+		Status:   http.StatusOK,
+		Duration: 1500 * time.Millisecond,
+		Extras: map[string]interface{}{
+			fieldJourneyID: "journey",
+			fieldStatus:    http.StatusTeapot,
+		},
+	}
+
+	fields := entry.Fields()
+	assert.Equal(t, "2022-01-02T03:04:05.006Z", fields[fieldTime])
+	assert.Equal(t, logTypeAccess, fields[fieldLogType])
+	assert.Equal(t, http.MethodGet, fields[fieldMethod])
+	assert.Equal(t, "/users", fields[fieldPath])
+	assert.Equal(t, http.StatusOK, fields[fieldStatus])
+	assert.Equal(t, int64(1500), fields[fieldDuration])
+	assert.Equal(t, "journey", fields[fieldJourneyID])
+	assert.Equal(t, "", fields[fieldTraceID])
+}
+
+// nolint:paralleltest
+func TestAccessLog_EntryFormatter(t *testing.T) {
+	var captured *AccessLogEntry
+	SetAccessLogEntryFormatter(AccessLogFormatterFunc(func(entry *AccessLogEntry) ([]byte, error) {
+		captured = entry
+		entry.Extras["match_id"] = entry.Request.Attribute("MatchID")
+		return DefaultAccessLogFormatter().Format(entry)
+	}))
+	defer SetAccessLogEntryFormatter(nil)
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/matches/{id}").
+		To(func(request *restful.Request, response *restful.Response) {
+			request.SetAttribute("MatchID", request.PathParameter("id"))
+			request.SetAttribute(iam.ClaimsAttribute, &iamSDK.JWTClaims{Claims: jwt.Claims{Subject: "user1"}})
+			response.WriteHeader(http.StatusNoContent)
+		}))
+
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/matches/m1", nil))
+
+	assert.Equal(t, "m1", fields["match_id"])
+	assert.Equal(t, "/matches/m1", fields[fieldPath])
+	assert.Equal(t, float64(http.StatusNoContent), fields[fieldStatus])
+
+	if assert.NotNil(t, captured) {
+		assert.Equal(t, http.MethodGet, captured.Method)
+		assert.Equal(t, http.StatusNoContent, captured.Status)
+		assert.Equal(t, "user1", captured.UserID)
+		assert.Equal(t, "user1", captured.Claims.Subject)
+	}
+}
+
+// nolint:paralleltest
+func TestAccessLog_EntryFormatterError(t *testing.T) {
+	SetAccessLogEntryFormatter(AccessLogFormatterFunc(func(entry *AccessLogEntry) ([]byte, error) {
+		return nil, errors.New("unexpected")
+	}))
+	defer SetAccessLogEntryFormatter(nil)
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/users").
+		To(func(request *restful.Request, response *restful.Response) {}))
+
+	fields, resp := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	assert.Empty(t, fields)
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
@@ -0,0 +1,81 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/envdoc"
+)
+
+const envPackage = "logger/log"
+
+func init() {
+	envdoc.Register(
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_PROFILE", Package: envPackage, Type: envdoc.TypeString,
+			Description: "Preset profile selecting the defaults: dev, staging, prod or compliance"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_ENABLED", Package: envPackage, Type: envdoc.TypeBoolean, Default: "false",
+			Description: "Capture the request and response bodies in the access log"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_SUPPORTED_CONTENT_TYPES", Package: envPackage, Type: envdoc.TypeList,
+			Default:     "application/json,application/xml,application/x-www-form-urlencoded,text/plain,text/html",
+			Description: "Content types of the captured bodies"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_MAX_BODY_SIZE", Package: envPackage, Type: envdoc.TypeInteger, Default: "10240",
+			Description: "Maximum size of the captured body in bytes"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_BODY_TRUNCATION", Package: envPackage, Type: envdoc.TypeList,
+			Description: "Content types whose body exceeding the maximum body size is truncated instead of dropped, with the optional size, e.g. application/json:2048,text/plain"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_REQUEST_BODY_ENABLED", Package: envPackage, Type: envdoc.TypeBoolean, Default: "true",
+			Description: "Capture the request body in full access log mode"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_RESPONSE_BODY_ENABLED", Package: envPackage, Type: envdoc.TypeBoolean, Default: "true",
+			Description: "Capture the response body in full access log mode"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_FORMAT", Package: envPackage, Type: envdoc.TypeString, Default: AccessLogFormatText,
+			Description: "Output format of the access log: text or json"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_FIELDS", Package: envPackage, Type: envdoc.TypeList,
+			Description: "Fields emitted in the access log, all fields when empty"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_JSON_STRING_VALUES", Package: envPackage, Type: envdoc.TypeBoolean, Default: "false",
+			Description: "Print the numeric and boolean fields as strings in json format"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_VERIFY_FORMAT", Package: envPackage, Type: envdoc.TypeString,
+			Description: "Candidate format (text or json) verified side by side with the current format"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_TIME_FORMAT", Package: envPackage, Type: envdoc.TypeString,
+			Default: defaultTimeLayout, Description: "Format of the time field: rfc3339nano, epoch_millis or a Go time layout"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_DURATION_UNIT", Package: envPackage, Type: envdoc.TypeString,
+			Default: DurationUnitMillisecond, Description: "Unit of the duration field: ms or us"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_DEFAULT_RETENTION", Package: envPackage, Type: envdoc.TypeString,
+			Description: "Retention hint of the record that doesn't declare its retention"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_PII_RETENTION", Package: envPackage, Type: envdoc.TypeString,
+			Description: "Retention hint of the record that contains PII and doesn't declare its retention"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_HEADERS", Package: envPackage, Type: envdoc.TypeList,
+			Description: "Request and response headers printed in the access log"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_RESPONSE_HEADERS", Package: envPackage, Type: envdoc.TypeList,
+			Description: "Response only headers printed in the access log, e.g. Cache-Control,ETag,X-Cache"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_SENSITIVE_HEADERS", Package: envPackage, Type: envdoc.TypeList,
+			Description: "Headers which value is always masked"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_DEBUG_CLIENT_IDS", Package: envPackage, Type: envdoc.TypeList,
+			Description: "Client IDs allowed to enable the debug access log of the request using X-Ab-Debug-Log header"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_BODY_READ_DIAGNOSTICS_ENABLED", Package: envPackage, Type: envdoc.TypeBoolean,
+			Default: "false", Description: "Record the request body reads after EOF and the body rewinds"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_MASKING_CONFIG_FILE", Package: envPackage, Type: envdoc.TypeString,
+			Description: "Path of the masking configuration file (.yaml, .yml or .json)"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_MASKING_CONFIG", Package: envPackage, Type: envdoc.TypeString,
+			Description: "Masking configuration in JSON format, used when the masking configuration file is not set"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_EXCLUDED_PATHS", Package: envPackage, Type: envdoc.TypeList,
+			Description: "Paths excluded from the access log, a path ending with * excludes the prefix"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_SAMPLE_RATES", Package: envPackage, Type: envdoc.TypeList,
+			Description: "Sampling rates of the route operation ids, e.g. getUser:0.1"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_SLOW_THRESHOLD_MS", Package: envPackage, Type: envdoc.TypeInteger, Default: "0",
+			Description: "Duration in milliseconds after which the request is written into the slow log, 0 disables it"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_SLOW_THRESHOLDS", Package: envPackage, Type: envdoc.TypeList,
+			Description: "Slow log thresholds in milliseconds of the route operation ids, e.g. getUser:500"},
+		envdoc.Variable{Name: "FULL_ACCESS_LOG_SUMMARY_ENABLED", Package: envPackage, Type: envdoc.TypeBoolean, Default: "false",
+			Description: "Write the summary of the requests since the service started on the graceful shutdown"},
+	)
+}
@@ -0,0 +1,96 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sinkFailures is the total number of the access log records failed to be written into the primary sinks
+var sinkFailures uint64
+
+// FallbackWriter is an io.Writer that writes into the primary writer,
+// and into the secondary writer when the primary fails (e.g. disk full, broker down or broken stdout redirection),
+// so the access records aren't silently lost. The access log output is wrapped with it automatically.
+type FallbackWriter struct {
+	failures  uint64
+	failing   int32
+	primary   io.Writer
+	secondary io.Writer
+}
+
+// NewFallbackWriter creates new FallbackWriter instance. Default secondary: os.Stderr
+func NewFallbackWriter(primary io.Writer, secondary io.Writer) *FallbackWriter {
+	if secondary == nil {
+		secondary = os.Stderr
+	}
+	return &FallbackWriter{primary: primary, secondary: secondary}
+}
+
+// Write writes the record into the primary writer, or into the secondary writer if the primary fails.
+// The first failure and the recovery of the primary writer are logged.
+func (w *FallbackWriter) Write(p []byte) (int, error) {
+	n, err := w.primary.Write(p)
+	if err == nil {
+		if atomic.CompareAndSwapInt32(&w.failing, 1, 0) {
+			logrus.Warnf("access log sink recovered after %d failed records", atomic.LoadUint64(&w.failures))
+		}
+		return n, nil
+	}
+
+	atomic.AddUint64(&w.failures, 1)
+	atomic.AddUint64(&sinkFailures, 1)
+	if atomic.CompareAndSwapInt32(&w.failing, 0, 1) {
+		logrus.Errorf("access log sink failed, falling back to the secondary sink: %v", err)
+	}
+
+	return w.secondary.Write(p)
+}
+
+// Failures returns the number of the records failed to be written into the primary writer
+func (w *FallbackWriter) Failures() uint64 {
+	return atomic.LoadUint64(&w.failures)
+}
+
+// Close closes the primary writer if it's an io.Closer, e.g. an AsyncWriter
+func (w *FallbackWriter) Close() error {
+	if closer, ok := w.primary.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// ForceFlush flushes the primary writer if it's buffered, e.g. an AsyncWriter
+func (w *FallbackWriter) ForceFlush(ctx context.Context) error {
+	return flushWriter(ctx, w.primary)
+}
+
+// AccessLogSinkFailures returns the total number of the access log records failed to be written into the primary sinks
+func AccessLogSinkFailures() uint64 {
+	return atomic.LoadUint64(&sinkFailures)
+}
+
+// withFallback wraps the access log output with FallbackWriter writing into os.Stderr
+func withFallback(out io.Writer) io.Writer {
+	if _, ok := out.(*FallbackWriter); ok || out == os.Stderr {
+		return out
+	}
+	return NewFallbackWriter(out, os.Stderr)
+}
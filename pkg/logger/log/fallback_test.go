@@ -0,0 +1,94 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// toggleWriter fails while it's broken
+type toggleWriter struct {
+	mutex  sync.Mutex
+	broken bool
+	buffer bytes.Buffer
+}
+
+func (w *toggleWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.broken {
+		return 0, errors.New("broken pipe")
+	}
+	return w.buffer.Write(p)
+}
+
+func TestFallbackWriter(t *testing.T) {
+	t.Parallel()
+
+	primary := &toggleWriter{}
+	secondary := new(bytes.Buffer)
+	writer := NewFallbackWriter(primary, secondary)
+
+	_, err := writer.Write([]byte("first\n"))
+	assert.NoError(t, err)
+
+	primary.broken = true
+	_, err = writer.Write([]byte("second\n"))
+	assert.NoError(t, err)
+	_, err = writer.Write([]byte("third\n"))
+	assert.NoError(t, err)
+
+	primary.broken = false
+	_, err = writer.Write([]byte("fourth\n"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "first\nfourth\n", primary.buffer.String())
+	assert.Equal(t, "second\nthird\n", secondary.String())
+	assert.Equal(t, uint64(2), writer.Failures())
+	assert.True(t, AccessLogSinkFailures() >= 2)
+}
+
+// nolint:paralleltest
+func TestSetAccessLogOutput_Fallback(t *testing.T) {
+	primary := &toggleWriter{broken: true}
+	SetAccessLogOutput(primary)
+	defer SetAccessLogOutput(os.Stdout)
+
+	_, ok := fullAccessLogOutput.(*FallbackWriter)
+	assert.True(t, ok)
+
+	// the access log request still succeeds while the sink is failing
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/user").To(func(request *restful.Request, response *restful.Response) {}))
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	failures := AccessLogSinkFailures()
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/user", nil))
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, failures+1, AccessLogSinkFailures())
+}
@@ -0,0 +1,78 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// RequestBodyMetadata is the route metadata key enabling or disabling the request body capture of the route
+	RequestBodyMetadata = "LogRequestBody"
+	// ResponseBodyMetadata is the route metadata key enabling or disabling the response body capture of the route
+	ResponseBodyMetadata = "LogResponseBody"
+)
+
+// FullAccessLogFields are the fields emitted in the access log, empty means all fields.
+// The time and log_type fields are always emitted.
+var FullAccessLogFields []string
+
+// RouteBodyCapture returns the route builder function toggling the request and response body capture of the route,
+// overriding FullAccessLogRequestBodyEnabled and FullAccessLogResponseBodyEnabled.
+// It takes effect when the AccessLog filter is added to the web service or the route, not to the container.
+func RouteBodyCapture(request bool, response bool) func(*restful.RouteBuilder) {
+	return func(builder *restful.RouteBuilder) {
+		builder.Metadata(RequestBodyMetadata, request)
+		builder.Metadata(ResponseBodyMetadata, response)
+	}
+}
+
+// routeBodyCaptureEnabled returns the body capture toggle of the selected route, or the default if it's not set
+func routeBodyCaptureEnabled(req *restful.Request, metadata string, defaultValue bool) bool {
+	if route := req.SelectedRoute(); route != nil {
+		if enabled, ok := route.Metadata()[metadata].(bool); ok {
+			return enabled
+		}
+	}
+	return defaultValue
+}
+
+// isFieldSelected checks whether the field is emitted in the access log
+func isFieldSelected(field string) bool {
+	if len(FullAccessLogFields) == 0 || field == fieldTime || field == fieldLogType {
+		return true
+	}
+	for _, selected := range FullAccessLogFields {
+		if selected == field {
+			return true
+		}
+	}
+	return false
+}
+
+// selectFields returns the fields emitted in the access log, the fields are returned as is when there is no selection
+func selectFields(fields logrus.Fields) logrus.Fields {
+	if len(FullAccessLogFields) == 0 {
+		return fields
+	}
+	selected := make(logrus.Fields, len(FullAccessLogFields)+2)
+	for _, field := range append([]string{fieldTime, fieldLogType}, FullAccessLogFields...) {
+		if value, ok := fields[field]; ok {
+			selected[field] = value
+		}
+	}
+	return selected
+}
@@ -0,0 +1,150 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// nolint:paralleltest
+func TestSelectFields(t *testing.T) {
+	fields := logrus.Fields{
+		fieldTime:      "2022-01-01T00:00:00.000Z",
+		fieldLogType:   logTypeAccess,
+		fieldMethod:    http.MethodGet,
+		fieldReferer:   "https://example.com",
+		fieldRequestID: "abc",
+	}
+	assert.Equal(t, fields, selectFields(fields))
+	assert.True(t, isFieldSelected(fieldReferer))
+
+	FullAccessLogFields = []string{fieldMethod, fieldRequestID, fieldStatus}
+	defer func() {
+		FullAccessLogFields = nil
+	}()
+
+	assert.Equal(t, logrus.Fields{
+		fieldTime:      "2022-01-01T00:00:00.000Z",
+		fieldLogType:   logTypeAccess,
+		fieldMethod:    http.MethodGet,
+		fieldRequestID: "abc",
+	}, selectFields(fields))
+	assert.True(t, isFieldSelected(fieldTime))
+	assert.True(t, isFieldSelected(fieldMethod))
+	assert.False(t, isFieldSelected(fieldReferer))
+}
+
+func TestFullAccessLogFormatter_SelectedFields(t *testing.T) {
+	t.Parallel()
+
+	formatter := &fullAccessLogFormatter{}
+	record, err := formatter.Format(&logrus.Entry{Data: logrus.Fields{
+		fieldTime:        "2022-01-01T00:00:00.000Z",
+		fieldLogType:     logTypeAccess,
+		fieldMethod:      http.MethodPost,
+		fieldPath:        "/users",
+		fieldStatus:      http.StatusCreated,
+		fieldRequestBody: `{"name":"foo"}`,
+		fieldOperation:   "createUser",
+		fieldRequestID:   "abc",
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, `time=2022-01-01T00:00:00.000Z log_type=access method=POST path="/users" status=201 `+
+		`request_body=AB[{"name":"foo"}]AB operation="createUser" request_id=abc`+"\n", string(record))
+}
+
+// nolint:paralleltest
+func TestAccessLog_FieldSelection(t *testing.T) {
+	FullAccessLogEnabled = true
+	FullAccessLogFields = []string{fieldMethod, fieldStatus, fieldResponseBody}
+	defer func() {
+		FullAccessLogEnabled = false
+		FullAccessLogFields = nil
+	}()
+
+	var requestBody string
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.POST("/users").
+		Consumes(restful.MIME_JSON).
+		To(func(request *restful.Request, response *restful.Response) {
+			body, _ := ioutil.ReadAll(request.Request.Body)
+			requestBody = string(body)
+			_ = response.WriteHeaderAndJson(http.StatusCreated, map[string]string{"id": "1"}, restful.MIME_JSON)
+		}))
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"foo"}`))
+	req.Header.Set("Content-Type", restful.MIME_JSON)
+	req.Header.Set("Referer", "https://example.com")
+	fields, _ := serveWithAccessLog(t, ws, req)
+
+	assert.Equal(t, `{"name":"foo"}`, requestBody)
+	assert.Len(t, fields, 5)
+	assert.NotNil(t, fields[fieldTime])
+	assert.Equal(t, logTypeAccess, fields[fieldLogType])
+	assert.Equal(t, http.MethodPost, fields[fieldMethod])
+	assert.Equal(t, float64(http.StatusCreated), fields[fieldStatus])
+	assert.Contains(t, fields[fieldResponseBody], `"id":"1"`)
+
+	names := make([]string, 0)
+	for _, field := range AccessLogFields() {
+		names = append(names, field.Name)
+	}
+	assert.Equal(t, []string{fieldTime, fieldLogType, fieldMethod, fieldStatus, fieldResponseBody}, names)
+}
+
+// nolint:paralleltest
+func TestRouteBodyCapture(t *testing.T) {
+	FullAccessLogEnabled = true
+	defer func() {
+		FullAccessLogEnabled = false
+	}()
+
+	handler := func(request *restful.Request, response *restful.Response) {
+		_, _ = ioutil.ReadAll(request.Request.Body)
+		_ = response.WriteHeaderAndJson(http.StatusOK, map[string]string{"id": "1"}, restful.MIME_JSON)
+	}
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.POST("/avatar").Do(RouteBodyCapture(false, true)).To(handler))
+	ws.Route(ws.POST("/users").To(handler))
+
+	serve := func(path string) map[string]interface{} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"name":"foo"}`))
+		req.Header.Set("Content-Type", restful.MIME_JSON)
+		fields, _ := serveWithAccessLog(t, ws, req)
+		return fields
+	}
+
+	fields := serve("/avatar")
+	assert.Equal(t, "-", fields[fieldRequestBody])
+	assert.Contains(t, fields[fieldResponseBody], `"id":"1"`)
+
+	fields = serve("/users")
+	assert.Equal(t, `{"name":"foo"}`, fields[fieldRequestBody])
+	assert.Contains(t, fields[fieldResponseBody], `"id":"1"`)
+
+	FullAccessLogEnabled = false
+	fields = serve("/avatar")
+	assert.Equal(t, "-", fields[fieldResponseBody])
+}
@@ -0,0 +1,66 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/plugins"
+)
+
+const flushPollInterval = time.Millisecond
+
+func init() {
+	plugins.RegisterFlusher("logger/log", plugins.FlusherFunc(ForceFlush))
+}
+
+// ForceFlush waits until the queued access log entries are passed to the hooks
+// and the buffered access log and slow log outputs (e.g. an AsyncWriter) are written, or the context is done.
+// It's registered into plugins.ForceFlush.
+func ForceFlush(ctx context.Context) error {
+	if err := waitUntil(ctx, func() bool { return atomic.LoadInt64(&accessLogHookPending) == 0 }); err != nil {
+		return err
+	}
+	if err := flushWriter(ctx, fullAccessLogOutput); err != nil {
+		return err
+	}
+	return flushWriter(ctx, slowLogOutput)
+}
+
+// flushWriter flushes the writer if it's buffered
+func flushWriter(ctx context.Context, writer io.Writer) error {
+	if flusher, ok := writer.(plugins.Flusher); ok {
+		return flusher.ForceFlush(ctx)
+	}
+	return nil
+}
+
+// waitUntil polls the condition until it's met, or the context is done
+func waitUntil(ctx context.Context, condition func() bool) error {
+	ticker := time.NewTicker(flushPollInterval)
+	defer ticker.Stop()
+
+	for !condition() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
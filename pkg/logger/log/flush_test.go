@@ -0,0 +1,74 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsyncWriter_ForceFlush(t *testing.T) {
+	t.Parallel()
+
+	out := &blockingWriter{release: make(chan struct{})}
+	writer := NewAsyncWriter(NewFallbackWriter(out, nil), nil)
+	defer writer.Close() // nolint:errcheck
+
+	_, _ = writer.Write([]byte("first\n"))
+	_, _ = writer.Write([]byte("second\n"))
+
+	// the records are still blocked in the writer
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, writer.ForceFlush(ctx))
+
+	close(out.release)
+	assert.NoError(t, writer.ForceFlush(context.Background()))
+	assert.Equal(t, "first\nsecond\n", out.out.String())
+}
+
+// nolint:paralleltest
+func TestForceFlush(t *testing.T) {
+	defer resetAccessLogHooks()
+
+	out := &safeBuffer{}
+	writer := NewAsyncWriter(out, nil)
+	SetAccessLogOutput(writer)
+	defer func() {
+		SetAccessLogOutput(os.Stdout)
+		_ = writer.Close()
+	}()
+
+	var hooked int32
+	RegisterAccessLogHook(func(entry AccessLogEntry) {
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&hooked, 1)
+	})
+
+	_, _ = fullAccessLogOutput.Write([]byte("record\n"))
+	publishAccessLogEntry(AccessLogEntry{Method: http.MethodGet})
+
+	// the access log flusher is registered into plugins.ForceFlush
+	assert.NoError(t, plugins.ForceFlush(context.Background()))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hooked))
+	assert.Equal(t, "record\n", out.String())
+}
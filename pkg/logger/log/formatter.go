@@ -0,0 +1,371 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// AccessLogFormatText prints the access log as a single key=value line
+	AccessLogFormatText = "text"
+	// AccessLogFormatJSON prints the access log as a single JSON object
+	AccessLogFormatJSON = "json"
+)
+
+// access log field keys
+const (
+	fieldTime                = "time"
+	fieldLogType             = "log_type"
+	fieldMethod              = "method"
+	fieldPath                = "path"
+	fieldStatus              = "status"
+	fieldDuration            = "duration"
+	fieldLength              = "length"
+	fieldSourceIP            = "source_ip"
+	fieldUserAgent           = "user_agent"
+	fieldReferer             = "referer"
+	fieldTraceID             = "trace_id"
+	fieldNamespace           = "namespace"
+	fieldUserID              = "user_id"
+	fieldClientID            = "client_id"
+	fieldRequestContentType  = "request_content_type"
+	fieldRequestBody         = "request_body"
+	fieldResponseContentType = "response_content_type"
+	fieldResponseBody        = "response_body"
+	fieldOperation           = "operation"
+	fieldJourneyID           = "journey_id"
+	fieldConsumerID          = "consumer_id"
+	fieldFlightID            = "flight_id"
+	fieldGameClientVersion   = "game_client_version"
+	fieldPlatform            = "platform"
+	fieldRequestID           = "request_id"
+	fieldResponseTruncated   = "response_truncated"
+	fieldTokenIssuer         = "token_issuer"
+	fieldAuthMode            = "auth_mode"
+	fieldCache               = "cache"
+	fieldErrorCode           = "error_code"
+	fieldDependencies        = "dependencies"
+	fieldUpstreamThrottled   = "upstream_throttled"
+
+	logTypeAccess = "access"
+)
+
+// fullAccessLogFormatFields are the fields printed positionally by fullAccessLogFormat,
+// other fields will be appended at the end of the line.
+var fullAccessLogFormatFields = map[string]bool{
+	fieldTime:                true,
+	fieldLogType:             true,
+	fieldMethod:              true,
+	fieldPath:                true,
+	fieldStatus:              true,
+	fieldDuration:            true,
+	fieldLength:              true,
+	fieldSourceIP:            true,
+	fieldUserAgent:           true,
+	fieldReferer:             true,
+	fieldTraceID:             true,
+	fieldNamespace:           true,
+	fieldUserID:              true,
+	fieldClientID:            true,
+	fieldRequestContentType:  true,
+	fieldRequestBody:         true,
+	fieldResponseContentType: true,
+	fieldResponseBody:        true,
+	fieldOperation:           true,
+}
+
+// fullAccessLogFormatLayout is the layout of the positional fields of fullAccessLogFormat,
+// used to print the fields left by the field selection in the same format
+var fullAccessLogFormatLayout = []struct {
+	field  string
+	format string
+}{
+	{fieldTime, "%v"},
+	{fieldLogType, "%v"},
+	{fieldMethod, "%v"},
+	{fieldPath, `"%v"`},
+	{fieldStatus, "%v"},
+	{fieldDuration, "%v"},
+	{fieldLength, "%v"},
+	{fieldSourceIP, "%v"},
+	{fieldUserAgent, `"%v"`},
+	{fieldReferer, `"%v"`},
+	{fieldTraceID, "%v"},
+	{fieldNamespace, "%v"},
+	{fieldUserID, "%v"},
+	{fieldClientID, "%v"},
+	{fieldRequestContentType, `"%v"`},
+	{fieldRequestBody, "AB[%v]AB"},
+	{fieldResponseContentType, `"%v"`},
+	{fieldResponseBody, "AB[%v]AB"},
+	{fieldOperation, `"%v"`},
+}
+
+var fullAccessLogCustomFormatter logrus.Formatter
+
+var (
+	// bodyDelimiterPattern matches the closing body delimiter with any backslashes after its bracket, e.g. `]AB` or `]\AB`
+	bodyDelimiterPattern = regexp.MustCompile(`\](\\*)AB`)
+	// escapedBodyDelimiterPattern matches the body delimiter escaped by escapeBodyValue
+	escapedBodyDelimiterPattern = regexp.MustCompile(`\]\\(\\*)AB`)
+)
+
+// fullAccessLogFormatter represent logrus.Formatter,
+// this is used to print the custom format for access log.
+type fullAccessLogFormatter struct {
+}
+
+func (f *fullAccessLogFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	var builder strings.Builder
+	builder.WriteString(formatPositionalFields(entry.Data))
+
+	// append the additional fields in a deterministic order
+	keys := make([]string, 0)
+	for key := range entry.Data {
+		if !fullAccessLogFormatFields[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		builder.WriteString(" ")
+		builder.WriteString(key)
+		builder.WriteString("=")
+		builder.WriteString(formatFieldValue(entry.Data[key]))
+	}
+	builder.WriteString("\n")
+
+	return []byte(builder.String()), nil
+}
+
+// formatPositionalFields formats the positional fields using fullAccessLogFormat,
+// or only the present ones in the same layout when some of them are trimmed by the field selection
+func formatPositionalFields(data logrus.Fields) string {
+	for _, layout := range fullAccessLogFormatLayout {
+		if _, ok := data[layout.field]; !ok {
+			return formatSelectedPositionalFields(data)
+		}
+	}
+
+	return fmt.Sprintf(fullAccessLogFormat,
+		escapePositionalValue(data[fieldTime]),
+		escapePositionalValue(data[fieldMethod]),
+		escapePositionalValue(data[fieldPath]),
+		escapePositionalValue(data[fieldStatus]),
+		escapePositionalValue(data[fieldDuration]),
+		escapePositionalValue(data[fieldLength]),
+		escapePositionalValue(data[fieldSourceIP]),
+		escapePositionalValue(data[fieldUserAgent]),
+		escapePositionalValue(data[fieldReferer]),
+		escapePositionalValue(data[fieldTraceID]),
+		escapePositionalValue(data[fieldNamespace]),
+		escapePositionalValue(data[fieldUserID]),
+		escapePositionalValue(data[fieldClientID]),
+		escapePositionalValue(data[fieldRequestContentType]),
+		escapeBodyValue(escapePositionalValue(data[fieldRequestBody])),
+		escapePositionalValue(data[fieldResponseContentType]),
+		escapeBodyValue(escapePositionalValue(data[fieldResponseBody])),
+		escapePositionalValue(data[fieldOperation]),
+	)
+}
+
+// formatSelectedPositionalFields formats the present positional fields in the layout of fullAccessLogFormat
+func formatSelectedPositionalFields(data logrus.Fields) string {
+	var builder strings.Builder
+	for _, layout := range fullAccessLogFormatLayout {
+		value, ok := data[layout.field]
+		if !ok {
+			continue
+		}
+		if builder.Len() > 0 {
+			builder.WriteString(" ")
+		}
+		value = escapePositionalValue(value)
+		if layout.field == fieldRequestBody || layout.field == fieldResponseBody {
+			value = escapeBodyValue(value)
+		}
+		builder.WriteString(layout.field)
+		builder.WriteString("=")
+		builder.WriteString(fmt.Sprintf(layout.format, value))
+	}
+	return builder.String()
+}
+
+// formatFieldValue formats the additional field value,
+// string value is quoted if it is empty or contains space, quote, equal sign, control character or invalid UTF-8.
+func formatFieldValue(value interface{}) string {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Sprintf("%v", value)
+	}
+	if s == "" || strings.ContainsAny(s, " \"=") || needsEscape(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// escapePositionalValue escapes the string value of the positional field, the quotes are kept as is
+func escapePositionalValue(value interface{}) interface{} {
+	if s, ok := value.(string); ok {
+		return EscapeLogValue(s)
+	}
+	return value
+}
+
+// escapeBodyValue adds a backslash into the closing "]AB" delimiter in the body value, and into the already
+// escaped one, so the body can't close its AB[...]AB wrapper early and forge the following fields.
+// It's reverted by unescapeBodyValue.
+func escapeBodyValue(value interface{}) interface{} {
+	if s, ok := value.(string); ok && strings.Contains(s, "AB") {
+		return bodyDelimiterPattern.ReplaceAllString(s, `]\${1}AB`)
+	}
+	return value
+}
+
+// unescapeBodyValue removes the backslash added by escapeBodyValue
+func unescapeBodyValue(s string) string {
+	if !strings.Contains(s, "AB") {
+		return s
+	}
+	return escapedBodyDelimiterPattern.ReplaceAllString(s, `]${1}AB`)
+}
+
+// EscapeLogValue escapes the control characters of the value written into a line based log,
+// so the value can't break the line or forge another record, e.g. a new line is escaped as "\\n".
+// The invalid UTF-8 bytes are replaced with U+FFFD, other characters are kept as is.
+func EscapeLogValue(s string) string {
+	if !needsEscape(s) {
+		return s
+	}
+
+	var builder strings.Builder
+	builder.Grow(len(s))
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch {
+		case r == utf8.RuneError && size == 1:
+			builder.WriteRune(utf8.RuneError)
+		case r == '\n':
+			builder.WriteString(`\n`)
+		case r == '\r':
+			builder.WriteString(`\r`)
+		case r == '\t':
+			builder.WriteString(`\t`)
+		case unicode.IsControl(r):
+			builder.WriteString(fmt.Sprintf(`\u%04x`, r))
+		default:
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// needsEscape checks whether the value contains control character or invalid UTF-8
+func needsEscape(s string) bool {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if (r == utf8.RuneError && size == 1) || unicode.IsControl(r) {
+			return true
+		}
+		i += size
+	}
+	return false
+}
+
+// fullAccessLogJSONFormatter represent logrus.Formatter,
+// this is used to print the access log fields as a JSON object.
+// The body fields are kept as raw strings, the numeric and boolean fields are printed as JSON numbers and booleans
+// unless FullAccessLogJSONStringValues is enabled.
+type fullAccessLogJSONFormatter struct {
+}
+
+func (f *fullAccessLogJSONFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	data := entry.Data
+	if FullAccessLogJSONStringValues {
+		data = stringifyFieldValues(data)
+	}
+
+	buffer := new(bytes.Buffer)
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(data); err != nil {
+		return nil, fmt.Errorf("failed to marshal access log fields to JSON: %v", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// stringifyFieldValues returns the copy of the fields with the numeric and boolean values formatted as strings
+func stringifyFieldValues(fields logrus.Fields) logrus.Fields {
+	stringified := make(logrus.Fields, len(fields))
+	for key, value := range fields {
+		switch v := value.(type) {
+		case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			stringified[key] = fmt.Sprintf("%v", v)
+		default:
+			stringified[key] = value
+		}
+	}
+	return stringified
+}
+
+// SetAccessLogFormatter overrides the formatter used to print the access log.
+// The formatter receives the access log fields in logrus.Entry.Data.
+func SetAccessLogFormatter(formatter logrus.Formatter) {
+	fullAccessLogCustomFormatter = formatter
+	if fullAccessLogLogger != nil && formatter != nil {
+		fullAccessLogLogger.Formatter = formatter
+	}
+}
+
+// newFullAccessLogFormatter returns the formatter based on the configured FullAccessLogFormat
+func newFullAccessLogFormatter() logrus.Formatter {
+	if fullAccessLogCustomFormatter != nil {
+		return fullAccessLogCustomFormatter
+	}
+	if strings.EqualFold(FullAccessLogFormat, AccessLogFormatJSON) {
+		return &fullAccessLogJSONFormatter{}
+	}
+	return &fullAccessLogFormatter{}
+}
+
+// getFullAccessLogLogger initialize the custom logger for full access log if not yet initialized
+func getFullAccessLogLogger() *logrus.Logger {
+	if fullAccessLogLogger == nil {
+		out := fullAccessLogOutput
+		if out == nil {
+			out = withFallback(os.Stdout)
+		}
+		fullAccessLogLogger = &logrus.Logger{
+			Out:       out,
+			Level:     logrus.GetLevel(),
+			Formatter: newFullAccessLogFormatter(),
+		}
+	}
+	return fullAccessLogLogger
+}
@@ -0,0 +1,159 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func createDummyEntry() *logrus.Entry {
+	return &logrus.Entry{
+		Data: logrus.Fields{
+			fieldTime:                "2022-01-01T00:00:00.000Z",
+			fieldLogType:             logTypeAccess,
+			fieldMethod:              "POST",
+			fieldPath:                "/user?foo=bar",
+			fieldStatus:              200,
+			fieldDuration:            int64(12),
+			fieldLength:              34,
+			fieldSourceIP:            "8.8.8.8",
+			fieldUserAgent:           "curl",
+			fieldReferer:             "",
+			fieldTraceID:             "abc",
+			fieldNamespace:           "ns",
+			fieldUserID:              "user",
+			fieldClientID:            "client",
+			fieldRequestContentType:  "application/json",
+			fieldRequestBody:         "{\"foo\":\"<bar>\"}",
+			fieldResponseContentType: "application/json",
+			fieldResponseBody:        "{\"id\":1}",
+			fieldOperation:           "createUser",
+		},
+	}
+}
+
+func TestFullAccessLogFormatter(t *testing.T) {
+	t.Parallel()
+
+	formatter := &fullAccessLogFormatter{}
+	result, err := formatter.Format(createDummyEntry())
+
+	assert.NoError(t, err)
+	assert.Equal(t, `time=2022-01-01T00:00:00.000Z log_type=access method=POST path="/user?foo=bar" status=200 duration=12 length=34 source_ip=8.8.8.8 user_agent="curl" referer="" trace_id=abc namespace=ns user_id=user client_id=client request_content_type="application/json" request_body=AB[{"foo":"<bar>"}]AB response_content_type="application/json" response_body=AB[{"id":1}]AB operation="createUser"`+"\n",
+		string(result))
+}
+
+func TestFullAccessLogFormatter_AdditionalFields(t *testing.T) {
+	t.Parallel()
+
+	entry := createDummyEntry()
+	entry.Data["retention"] = "30d"
+	entry.Data["pii"] = true
+	entry.Data["data_classification"] = "personal data"
+
+	formatter := &fullAccessLogFormatter{}
+	result, err := formatter.Format(entry)
+
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(result), ` operation="createUser" data_classification="personal data" pii=true retention=30d`+"\n"))
+}
+
+func TestFullAccessLogJSONFormatter(t *testing.T) {
+	t.Parallel()
+
+	formatter := &fullAccessLogJSONFormatter{}
+	result, err := formatter.Format(createDummyEntry())
+	assert.NoError(t, err)
+	assert.Equal(t, byte('\n'), result[len(result)-1])
+	assert.Contains(t, string(result), `"request_body":"{\"foo\":\"<bar>\"}"`)
+
+	var fields map[string]interface{}
+	assert.NoError(t, json.Unmarshal(result, &fields))
+	assert.Equal(t, "access", fields[fieldLogType])
+	assert.Equal(t, "POST", fields[fieldMethod])
+	assert.Equal(t, float64(200), fields[fieldStatus])
+	assert.Equal(t, float64(12), fields[fieldDuration])
+	assert.Equal(t, "{\"id\":1}", fields[fieldResponseBody])
+	assert.Equal(t, "createUser", fields[fieldOperation])
+}
+
+// nolint:paralleltest
+func TestFullAccessLogJSONFormatter_StringValues(t *testing.T) {
+	FullAccessLogJSONStringValues = true
+	defer func() {
+		FullAccessLogJSONStringValues = false
+	}()
+
+	entry := createDummyEntry()
+	entry.Data[fieldPII] = true
+	result, err := (&fullAccessLogJSONFormatter{}).Format(entry)
+	assert.NoError(t, err)
+
+	var fields map[string]interface{}
+	assert.NoError(t, json.Unmarshal(result, &fields))
+	assert.Equal(t, "200", fields[fieldStatus])
+	assert.Equal(t, "12", fields[fieldDuration])
+	assert.Equal(t, "true", fields[fieldPII])
+	assert.Equal(t, "POST", fields[fieldMethod])
+
+	// the entry isn't modified
+	assert.Equal(t, 200, entry.Data[fieldStatus])
+}
+
+// nolint:paralleltest
+func TestNewFullAccessLogFormatter(t *testing.T) {
+	defer func() {
+		FullAccessLogFormat = AccessLogFormatText
+		fullAccessLogCustomFormatter = nil
+		fullAccessLogLogger = nil
+	}()
+
+	FullAccessLogFormat = AccessLogFormatText
+	assert.IsType(t, &fullAccessLogFormatter{}, newFullAccessLogFormatter())
+
+	FullAccessLogFormat = AccessLogFormatJSON
+	assert.IsType(t, &fullAccessLogJSONFormatter{}, newFullAccessLogFormatter())
+
+	SetAccessLogFormatter(&logrus.TextFormatter{})
+	assert.IsType(t, &logrus.TextFormatter{}, newFullAccessLogFormatter())
+}
+
+func TestEscapeLogValue(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "foo bar", EscapeLogValue("foo bar"))
+	assert.Equal(t, `a\nb\rc\td`, EscapeLogValue("a\nb\rc\td"))
+	assert.Equal(t, `\u001b[31m\u007f`, EscapeLogValue("\x1b[31m\x7f"))
+	assert.Equal(t, "a\uFFFDb", EscapeLogValue("a\xffb"))
+}
+
+func TestFullAccessLogFormatter_ForgedRecord(t *testing.T) {
+	t.Parallel()
+
+	entry := &logrus.Entry{Data: logrus.Fields{
+		fieldTime:   "2022-01-01T00:00:00Z",
+		fieldMethod: "GET",
+		"custom":    "foo\ntime=2022 log_type=access",
+	}}
+	line, err := (&fullAccessLogFormatter{}).Format(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(line), "\n"))
+	assert.Contains(t, string(line), `custom="foo\ntime=2022 log_type=access"`)
+}
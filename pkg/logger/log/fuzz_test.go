@@ -0,0 +1,110 @@
+//go:build go1.18
+// +build go1.18
+
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func FuzzEscapeLogValue(f *testing.F) {
+	f.Add("foo bar")
+	f.Add("line\nforged\r\n")
+	f.Add("\x00\x1b\x7f\xc2\x85\xff")
+
+	f.Fuzz(func(t *testing.T, value string) {
+		escaped := EscapeLogValue(value)
+		if !utf8.ValidString(escaped) {
+			t.Fatalf("escaped value %q is not valid UTF-8", escaped)
+		}
+		for _, r := range escaped {
+			if unicode.IsControl(r) {
+				t.Fatalf("escaped value %q contains control character", escaped)
+			}
+		}
+
+		formatted := formatFieldValue(value)
+		if strings.ContainsAny(formatted, "\n\r") {
+			t.Fatalf("formatted value %q contains new line", formatted)
+		}
+	})
+}
+
+func FuzzMaskJSONFields(f *testing.F) {
+	f.Add(`{"data":{"password":"secret"}}`, "data.password")
+	f.Add(`[{"a":[1,{"b":null}]}]`, "**.b")
+	f.Add(`{"\ud800":"x","a":"\"}`, "/a/*")
+	f.Add(`{"a":1}}`, "[*].a")
+
+	f.Fuzz(func(t *testing.T, content, field string) {
+		masked, err := MaskJSONFields(content, field)
+		if err != nil {
+			assert.Equal(t, content, masked)
+		}
+	})
+}
+
+func FuzzMaskFields(f *testing.F) {
+	f.Add("application/json", `{"password":"secret"}`, "password", "secret")
+	f.Add("application/json", `{"a":[{"b":{"password":1}}]}`, "a[*].b.password,password", "s\"e\\c")
+	f.Add("application/x-www-form-urlencoded", "password=secret&user=foo", "password,user", "")
+	f.Add("text/plain", `{"password" :"secret`, "password,(", "\u2028")
+	f.Add("", strings.Repeat("[", 1024), "**.password", "x")
+
+	f.Fuzz(func(t *testing.T, contentType, content, fields, secret string) {
+		// the adversarial content must not panic the filter
+		MaskFields(contentType, content, fields)
+
+		// the secret must not be leaked however it's encoded
+		encoded, err := json.Marshal(secret)
+		if err != nil {
+			return
+		}
+		inputAndExpected := [][]string{
+			{`{"password":` + string(encoded) + `}`, `{"password":"******"}`},
+			{"{ \"password\"\t:\n" + string(encoded) + " }", "{ \"password\"\t:\n\"******\" }"},
+			{`[{"data":{"password":` + string(encoded) + `}}]`, `[{"data":{"password":"******"}}]`},
+		}
+		for _, val := range inputAndExpected {
+			assert.Equal(t, val[1], MaskFields("application/json", val[0], "password"))
+		}
+	})
+}
+
+func FuzzMaskQueryParams(f *testing.F) {
+	f.Add("https://example.net?password=secret", "secret")
+	f.Add("https://example.net?pass%77ord=secret&%=&&=", "a&b=c")
+	f.Add("?password", "%zz")
+
+	f.Fuzz(func(t *testing.T, uri, secret string) {
+		// the adversarial uri must not panic the filter
+		MaskQueryParams(uri, "password,token")
+
+		masked := MaskQueryParams("https://example.net?a=b&password="+url.QueryEscape(secret), "password")
+		assert.Equal(t, "https://example.net?a=b&password=******", masked)
+
+		masked = MaskQueryParams("https://example.net?"+url.QueryEscape("password")+"="+url.QueryEscape(secret), "password")
+		assert.Equal(t, "https://example.net?password=******", masked)
+	})
+}
@@ -0,0 +1,96 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	requestHeaderFieldPrefix  = "request_header_"
+	responseHeaderFieldPrefix = "response_header_"
+)
+
+var (
+	// FullAccessLogHeaders is the allowlist of request and response headers printed in the access log
+	FullAccessLogHeaders []string
+	// FullAccessLogResponseHeaders is the allowlist of response only headers printed in the access log,
+	// e.g. Cache-Control, ETag, Content-Encoding and X-Cache to debug the CDN and caching behavior
+	FullAccessLogResponseHeaders []string
+	// FullAccessLogSensitiveHeaders is the list of headers which value is always masked,
+	// in addition to the Authorization, Proxy-Authorization, Cookie and Set-Cookie headers
+	FullAccessLogSensitiveHeaders []string
+
+	defaultSensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+)
+
+// addHeaderFields adds the allowlisted headers of the request and the response into the fields,
+// masking the sensitive headers and the headers masked for the endpoint.
+func addHeaderFields(requestHeader http.Header, responseHeader http.Header, maskedHeaders string, fields logrus.Fields) {
+	for _, name := range FullAccessLogHeaders {
+		addHeaderField(requestHeaderFieldPrefix, requestHeader, name, maskedHeaders, fields)
+		addHeaderField(responseHeaderFieldPrefix, responseHeader, name, maskedHeaders, fields)
+	}
+	for _, name := range FullAccessLogResponseHeaders {
+		addHeaderField(responseHeaderFieldPrefix, responseHeader, name, maskedHeaders, fields)
+	}
+}
+
+func addHeaderField(prefix string, header http.Header, name string, maskedHeaders string, fields logrus.Fields) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return
+	}
+
+	if values := header[http.CanonicalHeaderKey(name)]; len(values) > 0 {
+		fields[headerFieldName(prefix, name)] = headerValue(values, isSensitiveHeader(name, maskedHeaders))
+	}
+}
+
+func isSensitiveHeader(name string, maskedHeaders string) bool {
+	for _, sensitiveHeader := range defaultSensitiveHeaders {
+		if strings.EqualFold(name, sensitiveHeader) {
+			return true
+		}
+	}
+	for _, sensitiveHeader := range FullAccessLogSensitiveHeaders {
+		if strings.EqualFold(name, strings.TrimSpace(sensitiveHeader)) {
+			return true
+		}
+	}
+	if maskedHeaders != "" {
+		for _, maskedHeader := range strings.Split(maskedHeaders, ",") {
+			if strings.EqualFold(name, maskedHeader) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func headerValue(values []string, sensitive bool) string {
+	if sensitive {
+		return MaskedValue
+	}
+	return strings.Join(values, ",")
+}
+
+// headerFieldName converts the header name into field name, e.g. "X-Ab-Platform" into "request_header_x_ab_platform"
+func headerFieldName(prefix string, name string) string {
+	return prefix + strings.ReplaceAll(strings.ToLower(name), "-", "_")
+}
@@ -0,0 +1,110 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeaderFieldName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "request_header_x_ab_platform", headerFieldName(requestHeaderFieldPrefix, "X-Ab-Platform"))
+	assert.Equal(t, "response_header_etag", headerFieldName(responseHeaderFieldPrefix, "ETag"))
+}
+
+// nolint:paralleltest
+func TestAccessLog_Headers(t *testing.T) {
+	FullAccessLogHeaders = []string{"x-ab-platform", "Accept-Language", "Authorization", "X-Api-Key", "X-Secret", "X-Request-Count", "Set-Cookie"}
+	FullAccessLogSensitiveHeaders = []string{"X-Secret"}
+	defer func() {
+		FullAccessLogHeaders = nil
+		FullAccessLogSensitiveHeaders = nil
+	}()
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/user").
+		Filter(Attribute(Option{MaskedHeaders: "X-Api-Key"})).
+		To(func(request *restful.Request, response *restful.Response) {
+			response.AddHeader("X-Request-Count", "1")
+			response.AddHeader("Set-Cookie", "session=abc")
+		}))
+
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set("X-Ab-Platform", "ios")
+	req.Header.Add("Accept-Language", "en")
+	req.Header.Add("Accept-Language", "id")
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("X-Api-Key", "key")
+	req.Header.Set("X-Secret", "secret")
+	req.Header.Set("X-Not-Allowed", "value")
+	fields, _ := serveWithAccessLog(t, ws, req)
+
+	assert.Equal(t, "ios", fields["request_header_x_ab_platform"])
+	assert.Equal(t, "en,id", fields["request_header_accept_language"])
+	assert.Equal(t, MaskedValue, fields["request_header_authorization"])
+	assert.Equal(t, MaskedValue, fields["request_header_x_api_key"])
+	assert.Equal(t, MaskedValue, fields["request_header_x_secret"])
+	assert.Nil(t, fields["request_header_x_not_allowed"])
+	assert.Equal(t, "1", fields["response_header_x_request_count"])
+	assert.Equal(t, MaskedValue, fields["response_header_set_cookie"])
+}
+
+// nolint:paralleltest
+func TestAccessLog_ResponseHeaders(t *testing.T) {
+	FullAccessLogResponseHeaders = []string{"Cache-Control", "ETag", "X-Cache", "Set-Cookie"}
+	defer func() {
+		FullAccessLogResponseHeaders = nil
+	}()
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/user").
+		To(func(request *restful.Request, response *restful.Response) {
+			response.AddHeader("Cache-Control", "max-age=60")
+			response.AddHeader("ETag", `"abc"`)
+			response.AddHeader("Set-Cookie", "session=abc")
+		}))
+
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set("Cache-Control", "no-cache")
+	fields, _ := serveWithAccessLog(t, ws, req)
+
+	assert.Equal(t, "max-age=60", fields["response_header_cache_control"])
+	assert.Equal(t, `"abc"`, fields["response_header_etag"])
+	assert.Equal(t, MaskedValue, fields["response_header_set_cookie"])
+	assert.Nil(t, fields["response_header_x_cache"])
+	assert.Nil(t, fields["request_header_cache_control"])
+}
+
+// nolint:paralleltest
+func TestAccessLog_HeadersNotConfigured(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/user").
+		To(func(request *restful.Request, response *restful.Response) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set("X-Ab-Platform", "ios")
+	fields, _ := serveWithAccessLog(t, ws, req)
+
+	assert.Nil(t, fields["request_header_x_ab_platform"])
+}
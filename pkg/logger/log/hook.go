@@ -0,0 +1,96 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+const accessLogHookQueueSize = 1024
+
+// AccessLogHook receives the access log entries, e.g. to publish them into a message bus for real-time analytics
+type AccessLogHook func(entry AccessLogEntry)
+
+var (
+	accessLogHookDropped uint64
+	accessLogHookPending int64
+	accessLogHooks       atomic.Value // []AccessLogHook
+	accessLogHookMutex   sync.Mutex
+	accessLogHookQueue   chan AccessLogEntry
+)
+
+// RegisterAccessLogHook registers the hook invoked asynchronously after each access log record is written.
+// The entries are queued in a bounded queue and passed to the hooks one by one from a background goroutine,
+// so a slow hook never blocks the request. The entry is dropped when the queue is full, see AccessLogHookDropped.
+func RegisterAccessLogHook(hook AccessLogHook) {
+	accessLogHookMutex.Lock()
+	defer accessLogHookMutex.Unlock()
+
+	hooks, _ := accessLogHooks.Load().([]AccessLogHook)
+	updated := make([]AccessLogHook, 0, len(hooks)+1)
+	updated = append(updated, hooks...)
+	accessLogHooks.Store(append(updated, hook))
+
+	if accessLogHookQueue == nil {
+		accessLogHookQueue = make(chan AccessLogEntry, accessLogHookQueueSize)
+		go runAccessLogHooks(accessLogHookQueue)
+	}
+}
+
+// AccessLogHookDropped returns the number of the access log entries dropped because the hook queue was full
+func AccessLogHookDropped() uint64 {
+	return atomic.LoadUint64(&accessLogHookDropped)
+}
+
+// publishAccessLogEntry queues the entry for the hooks without blocking
+func publishAccessLogEntry(entry AccessLogEntry) {
+	if hooks, _ := accessLogHooks.Load().([]AccessLogHook); len(hooks) == 0 {
+		return
+	}
+
+	// the request must not be used after the filter returns
+	entry.Request = nil
+	atomic.AddInt64(&accessLogHookPending, 1)
+	select {
+	case accessLogHookQueue <- entry:
+	default:
+		atomic.AddInt64(&accessLogHookPending, -1)
+		atomic.AddUint64(&accessLogHookDropped, 1)
+	}
+}
+
+func runAccessLogHooks(queue chan AccessLogEntry) {
+	for entry := range queue {
+		hooks, _ := accessLogHooks.Load().([]AccessLogHook)
+		for _, hook := range hooks {
+			callAccessLogHook(hook, entry)
+		}
+		atomic.AddInt64(&accessLogHookPending, -1)
+	}
+}
+
+// callAccessLogHook calls the hook, the panic of a hook must not stop the other hooks
+func callAccessLogHook(hook AccessLogHook, entry AccessLogEntry) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			logrus.Errorf("access log hook panicked: %v", recovered)
+		}
+	}()
+
+	hook(entry)
+}
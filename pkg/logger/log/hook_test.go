@@ -0,0 +1,100 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// resetAccessLogHooks removes the registered hooks, the dispatcher goroutine keeps running on the queue
+func resetAccessLogHooks() {
+	accessLogHookMutex.Lock()
+	defer accessLogHookMutex.Unlock()
+	accessLogHooks.Store([]AccessLogHook(nil))
+}
+
+// nolint:paralleltest
+func TestRegisterAccessLogHook(t *testing.T) {
+	defer resetAccessLogHooks()
+
+	entries := make(chan AccessLogEntry, 1)
+	RegisterAccessLogHook(func(entry AccessLogEntry) {
+		panic("unexpected")
+	})
+	RegisterAccessLogHook(func(entry AccessLogEntry) {
+		entries <- entry
+	})
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/namespaces/{namespace}/users").
+		To(func(request *restful.Request, response *restful.Response) {
+			response.WriteHeader(http.StatusOK)
+		}))
+
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/namespaces/abc/users", nil))
+
+	select {
+	case entry := <-entries:
+		assert.Equal(t, "GET", entry.Method)
+		assert.Equal(t, "/namespaces/abc/users", entry.Path)
+		assert.Equal(t, http.StatusOK, entry.Status)
+		assert.Equal(t, fields[fieldPath], entry.Fields()[fieldPath])
+		assert.Nil(t, entry.Request)
+	case <-time.After(time.Second):
+		t.Fatal("access log hook isn't invoked")
+	}
+}
+
+// nolint:paralleltest
+func TestRegisterAccessLogHook_QueueFull(t *testing.T) {
+	defer resetAccessLogHooks()
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	RegisterAccessLogHook(func(entry AccessLogEntry) {
+		started <- struct{}{}
+		<-release
+	})
+
+	publishAccessLogEntry(AccessLogEntry{Method: http.MethodGet})
+	<-started
+
+	dropped := AccessLogHookDropped()
+	for i := 0; i < accessLogHookQueueSize+10; i++ {
+		publishAccessLogEntry(AccessLogEntry{Method: http.MethodGet})
+	}
+	assert.Equal(t, dropped+10, AccessLogHookDropped())
+
+	// unblock the dispatcher without invoking the hook for the queued entries
+	resetAccessLogHooks()
+	close(release)
+}
+
+// nolint:paralleltest
+func TestPublishAccessLogEntry_NoHook(t *testing.T) {
+	dropped := AccessLogHookDropped()
+	for i := 0; i < accessLogHookQueueSize+10; i++ {
+		publishAccessLogEntry(AccessLogEntry{Method: http.MethodGet})
+	}
+	assert.Equal(t, dropped, atomic.LoadUint64(&accessLogHookDropped))
+}
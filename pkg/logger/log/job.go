@@ -0,0 +1,59 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"time"
+)
+
+const (
+	fieldJobID = "job_id"
+
+	logTypeJob = "job"
+)
+
+// JobRecord is the completion of a background job of a request, e.g. an async job accepted with 202
+type JobRecord struct {
+	JobID     string
+	Operation string
+	// Status is the final status of the job, e.g. succeeded or failed
+	Status    string
+	Duration  time.Duration
+	TraceID   string
+	UserID    string
+	ClientID  string
+	ErrorCode int
+}
+
+// WriteJobLog writes the completion of the job into the access log output as a record with job log type,
+// so the outcome of the work done after the request is completed is queryable like the request itself.
+// The error_code field is only written when it's not zero.
+func WriteJobLog(record JobRecord) {
+	fields := map[string]interface{}{
+		fieldTime:      formatTime(accessLogClock.Now()),
+		fieldLogType:   logTypeJob,
+		fieldJobID:     record.JobID,
+		fieldOperation: record.Operation,
+		fieldStatus:    record.Status,
+		fieldDuration:  formatDuration(record.Duration),
+		fieldTraceID:   record.TraceID,
+		fieldUserID:    record.UserID,
+		fieldClientID:  record.ClientID,
+	}
+	if record.ErrorCode != 0 {
+		fields[fieldErrorCode] = record.ErrorCode
+	}
+	getFullAccessLogLogger().WithFields(fields).Info()
+}
@@ -0,0 +1,71 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/clock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// nolint:paralleltest
+func TestWriteJobLog(t *testing.T) {
+	SetClock(clock.NewFake(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)))
+	buffer := new(bytes.Buffer)
+	fullAccessLogLogger = &logrus.Logger{
+		Out:       buffer,
+		Level:     logrus.InfoLevel,
+		Formatter: &fullAccessLogJSONFormatter{},
+	}
+	defer func() {
+		SetClock(nil)
+		fullAccessLogLogger = nil
+	}()
+
+	WriteJobLog(JobRecord{
+		JobID:     "abc",
+		Operation: "exportUsers",
+		Status:    "failed",
+		Duration:  1500 * time.Millisecond,
+		TraceID:   "trace",
+		UserID:    "user",
+		ErrorCode: 20000,
+	})
+
+	fields := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &fields))
+	assert.Equal(t, logTypeJob, fields[fieldLogType])
+	assert.Equal(t, "2022-01-01T00:00:00.000Z", fields[fieldTime])
+	assert.Equal(t, "abc", fields[fieldJobID])
+	assert.Equal(t, "exportUsers", fields[fieldOperation])
+	assert.Equal(t, "failed", fields[fieldStatus])
+	assert.Equal(t, float64(1500), fields[fieldDuration])
+	assert.Equal(t, "trace", fields[fieldTraceID])
+	assert.Equal(t, "user", fields[fieldUserID])
+	assert.Equal(t, float64(20000), fields[fieldErrorCode])
+
+	// the error code of the succeeded job is omitted
+	buffer.Reset()
+	WriteJobLog(JobRecord{JobID: "def", Status: "succeeded"})
+	fields = map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &fields))
+	assert.Equal(t, "succeeded", fields[fieldStatus])
+	assert.NotContains(t, fields, fieldErrorCode)
+}
@@ -22,9 +22,15 @@ const (
 	MaskedQueryParamsAttribute    = "MaskedQueryParams"
 	MaskedRequestFieldsAttribute  = "MaskedRequestFields"
 	MaskedResponseFieldsAttribute = "MaskedResponseFields"
+	MaskedHeadersAttribute        = "MaskedHeaders"
 	UserIDAttribute               = "LogUserId"
 	ClientIDAttribute             = "LogClientId"
 	NamespaceAttribute            = "LogNamespace"
+	DataClassificationAttribute   = "LogDataClassification"
+	RetentionAttribute            = "LogRetention"
+	AdditionalFieldsAttribute     = "LogAdditionalFields"
+	ErrorCodeAttribute            = "LogErrorCode"
+	AddedMaskingAttribute         = "LogAddedMasking"
 )
 
 // Option contains attribute options for log functionality
@@ -35,6 +41,12 @@ type Option struct {
 	MaskedRequestFields string
 	// Field that need to masked in response body, separated with comma
 	MaskedResponseFields string
+	// Header that need to masked, separated with comma
+	MaskedHeaders string
+	// Data classification of the endpoint's record, e.g. "pii", "internal", "public"
+	DataClassification string
+	// Retention hint of the endpoint's record for downstream log pipelines, e.g. "30d"
+	Retention string
 }
 
 // Attribute filter is used to define the log attribute for the endpoint.
@@ -49,6 +61,51 @@ func Attribute(option Option) restful.FilterFunction {
 		if option.MaskedResponseFields != "" {
 			req.SetAttribute(MaskedResponseFieldsAttribute, option.MaskedResponseFields)
 		}
+		if option.MaskedHeaders != "" {
+			req.SetAttribute(MaskedHeadersAttribute, option.MaskedHeaders)
+		}
+		if option.DataClassification != "" {
+			req.SetAttribute(DataClassificationAttribute, option.DataClassification)
+		}
+		if option.Retention != "" {
+			req.SetAttribute(RetentionAttribute, option.Retention)
+		}
 		chain.ProcessFilter(req, resp)
 	}
 }
+
+// AdditionalFields adds custom field(s) into the access log record of the request.
+// The field with the same key as the standard access log field is ignored.
+func AdditionalFields(req *restful.Request, fields map[string]interface{}) {
+	additionalFields, ok := req.Attribute(AdditionalFieldsAttribute).(map[string]interface{})
+	if !ok {
+		additionalFields = make(map[string]interface{})
+		req.SetAttribute(AdditionalFieldsAttribute, additionalFields)
+	}
+	for key, value := range fields {
+		additionalFields[key] = value
+	}
+}
+
+// AddMasking adds the masked field(s) of the request at response time, e.g. by the handler when the sensitivity
+// of the fields depends on the data. Only the Masked* fields of the option are used.
+// The added field(s) are merged with the field(s) of the Attribute filter and the central masking configuration
+// before the access log record is written: the route's field(s) first, then the configuration's,
+// then the added ones in the order they're added, without duplicates.
+// The merged field(s) are always masked, a field masked by any of them can't be unmasked.
+func AddMasking(req *restful.Request, option Option) {
+	added, ok := req.Attribute(AddedMaskingAttribute).(*masking)
+	if !ok {
+		added = &masking{}
+		req.SetAttribute(AddedMaskingAttribute, added)
+	}
+	added.queryParams = joinFields(added.queryParams, splitFields(option.MaskedQueryParams))
+	added.requestFields = joinFields(added.requestFields, splitFields(option.MaskedRequestFields))
+	added.responseFields = joinFields(added.responseFields, splitFields(option.MaskedResponseFields))
+	added.headers = joinFields(added.headers, splitFields(option.MaskedHeaders))
+}
+
+// SetErrorCode sets the error code of the error response, printed as error_code field in the access log
+func SetErrorCode(req *restful.Request, errorCode int) {
+	req.SetAttribute(ErrorCodeAttribute, errorCode)
+}
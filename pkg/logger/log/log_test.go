@@ -0,0 +1,150 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/trace"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// nolint:paralleltest
+func TestAccessLog_AdditionalFields(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/match/{id}").
+		To(func(request *restful.Request, response *restful.Response) {
+			AdditionalFields(request, map[string]interface{}{"match_id": request.PathParameter("id")})
+			AdditionalFields(request, map[string]interface{}{"region": "us", fieldMethod: "PUT"})
+		}))
+
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/match/abc", nil))
+
+	assert.Equal(t, "abc", fields["match_id"])
+	assert.Equal(t, "us", fields["region"])
+	assert.Equal(t, "GET", fields[fieldMethod])
+}
+
+// nolint:paralleltest
+func TestAccessLog_JourneyID(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Filter(trace.JourneyFilter())
+	ws.Route(ws.GET("/user").
+		To(func(request *restful.Request, response *restful.Response) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set(trace.JourneyIDKey, "login-flow-1")
+	fields, _ := serveWithAccessLog(t, ws, req)
+
+	assert.Equal(t, "login-flow-1", fields[fieldJourneyID])
+}
+
+// nolint:paralleltest
+func TestAccessLog_RequestID(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Filter(trace.RequestIDFilter(nil))
+	ws.Route(ws.GET("/user").
+		To(func(request *restful.Request, response *restful.Response) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set(trace.RequestIDKey, "abc-123")
+	fields, resp := serveWithAccessLog(t, ws, req)
+
+	assert.Equal(t, "abc-123", fields[fieldRequestID])
+	assert.Equal(t, "abc-123", resp.Header().Get(trace.RequestIDKey))
+}
+
+// nolint:paralleltest
+func TestAccessLog_ConsumerID(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/user").
+		To(func(request *restful.Request, response *restful.Response) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set(trace.ConsumerIDKey, "mobile-app")
+	fields, _ := serveWithAccessLog(t, ws, req)
+
+	assert.Equal(t, "mobile-app", fields[fieldConsumerID])
+}
+
+// nolint:paralleltest
+func TestAccessLog_GameClient(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/user").
+		To(func(request *restful.Request, response *restful.Response) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set(trace.FlightIDKey, "3f2c9a7e1b4d4c8e9f0a1b2c3d4e5f60")
+	req.Header.Set(trace.GameClientVersionKey, "1.2.3")
+	req.Header.Set(trace.PlatformKey, "invalid platform")
+	fields, _ := serveWithAccessLog(t, ws, req)
+
+	assert.Equal(t, "3f2c9a7e1b4d4c8e9f0a1b2c3d4e5f60", fields[fieldFlightID])
+	assert.Equal(t, "1.2.3", fields[fieldGameClientVersion])
+	assert.NotContains(t, fields, fieldPlatform)
+}
+
+// nolint:paralleltest
+func TestAccessLog_TokenIssuer(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/user").
+		To(func(request *restful.Request, response *restful.Response) {
+			request.SetAttribute(iam.TokenIssuerAttribute, "legacy")
+		}))
+
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/user", nil))
+
+	assert.Equal(t, "legacy", fields[fieldTokenIssuer])
+}
+
+// nolint:paralleltest
+func TestAccessLog_AuthMode(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/internal/sync").
+		To(func(request *restful.Request, response *restful.Response) {
+			request.SetAttribute(iam.AuthModeAttribute, iam.AuthModeAPIKey)
+		}))
+
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/internal/sync", nil))
+
+	assert.Equal(t, "api_key", fields[fieldAuthMode])
+}
+
+func TestAccessLog_ErrorCode(t *testing.T) {
+	t.Parallel()
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/user").
+		To(func(request *restful.Request, response *restful.Response) {
+			SetErrorCode(request, 20008)
+			response.WriteHeader(http.StatusNotFound)
+		}))
+
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/user", nil))
+
+	assert.Equal(t, float64(20008), fields[fieldErrorCode])
+}
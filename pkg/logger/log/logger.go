@@ -0,0 +1,97 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/trace"
+	iamSDK "github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+type requestContextKey struct{}
+
+// FromRequest returns the logrus entry populated with the trace_id, request_id, namespace, user_id, client_id
+// and operation fields of the request, the same as the access log fields, so the application logs
+// can be correlated with the access log. The empty fields are omitted.
+func FromRequest(req *restful.Request) *logrus.Entry {
+	entry := trace.Logger(req)
+
+	fields := logrus.Fields{}
+	if traceID, ok := req.Attribute(trace.TraceIDKey).(string); ok && traceID != "" {
+		fields[fieldTraceID] = traceID
+	}
+	if requestID := trace.GetRequestID(req); requestID != "" {
+		fields[fieldRequestID] = requestID
+	}
+	namespace, userID, clientID, _ := getRequestIdentity(req)
+	if namespace != "" {
+		fields[fieldNamespace] = namespace
+	}
+	if userID != "" {
+		fields[fieldUserID] = userID
+	}
+	if clientID != "" {
+		fields[fieldClientID] = clientID
+	}
+	if selectedRoute := req.SelectedRoute(); selectedRoute != nil && selectedRoute.Operation() != "" {
+		fields[fieldOperation] = selectedRoute.Operation()
+	}
+
+	return entry.WithFields(fields)
+}
+
+// FromContext returns the logrus entry of the request the context derived from, see FromRequest.
+// The request is stored in the context by the AccessLog filter,
+// otherwise the entry of trace.LoggerFromContext is returned.
+func FromContext(ctx context.Context) *logrus.Entry {
+	if req, ok := ctx.Value(requestContextKey{}).(*restful.Request); ok {
+		return FromRequest(req).WithContext(ctx)
+	}
+	return trace.LoggerFromContext(ctx)
+}
+
+// withRequestContext stores the request in its context, so FromContext can read the request attributes
+func withRequestContext(req *restful.Request) {
+	req.Request = req.Request.WithContext(context.WithValue(req.Request.Context(), requestContextKey{}, req))
+}
+
+// getRequestIdentity returns the namespace, user ID and client ID set by the attributes,
+// falling back to the namespace of the requested resource and the JWT claims of the request
+func getRequestIdentity(req *restful.Request) (namespace, userID, clientID string, claims *iamSDK.JWTClaims) {
+	namespace, _ = req.Attribute(NamespaceAttribute).(string)
+	if namespace == "" {
+		namespace = iam.ResourceNamespace(req)
+	}
+	userID, _ = req.Attribute(UserIDAttribute).(string)
+	clientID, _ = req.Attribute(ClientIDAttribute).(string)
+
+	claims = iam.RetrieveJWTClaims(req)
+	if claims != nil {
+		if namespace == "" {
+			namespace = claims.Namespace
+		}
+		if userID == "" {
+			userID = claims.Subject
+		}
+		if clientID == "" {
+			clientID = claims.ClientID
+		}
+	}
+	return namespace, userID, clientID, claims
+}
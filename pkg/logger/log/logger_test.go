@@ -0,0 +1,96 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AccelByte/go-jose/jwt"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/trace"
+	iamSDK "github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// nolint:paralleltest
+func TestFromRequest(t *testing.T) {
+	var requestEntry, contextEntry *logrus.Entry
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Filter(trace.RequestIDFilter(nil))
+	ws.Route(ws.GET("/namespaces/{namespace}/users").
+		Operation("getUsers").
+		To(func(request *restful.Request, response *restful.Response) {
+			request.SetAttribute(trace.TraceIDKey, "trace-1")
+			request.SetAttribute(iam.ClaimsAttribute, &iamSDK.JWTClaims{
+				Namespace: "abc",
+				ClientID:  "client-1",
+				Claims:    jwt.Claims{Subject: "user-1"},
+			})
+			request.SetAttribute(NamespaceAttribute, "override")
+
+			requestEntry = FromRequest(request)
+			contextEntry = FromContext(request.Request.Context())
+		}))
+
+	req := httptest.NewRequest(http.MethodGet, "/namespaces/abc/users", nil)
+	req.Header.Set(trace.RequestIDKey, "request-1")
+	serveWithAccessLog(t, ws, req)
+
+	expected := logrus.Fields{
+		fieldTraceID:   "trace-1",
+		fieldRequestID: "request-1",
+		fieldNamespace: "override",
+		fieldUserID:    "user-1",
+		fieldClientID:  "client-1",
+		fieldOperation: "getUsers",
+	}
+	if assert.NotNil(t, requestEntry) {
+		assert.Equal(t, expected, requestEntry.Data)
+	}
+	if assert.NotNil(t, contextEntry) {
+		assert.Equal(t, expected, contextEntry.Data)
+		assert.NotNil(t, contextEntry.Context)
+	}
+}
+
+func TestFromRequest_NoAttributes(t *testing.T) {
+	t.Parallel()
+
+	req := restful.NewRequest(httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.Empty(t, FromRequest(req).Data)
+}
+
+func TestFromContext_NoRequest(t *testing.T) {
+	t.Parallel()
+
+	assert.Empty(t, FromContext(context.Background()).Data)
+}
+
+func TestFromRequest_ResourceNamespace(t *testing.T) {
+	t.Parallel()
+
+	req := restful.NewRequest(httptest.NewRequest(http.MethodGet, "/namespaces/game/users", nil))
+	req.SetAttribute(iam.ClaimsAttribute, &iamSDK.JWTClaims{Namespace: "publisher"})
+	req.SetAttribute(iam.ResourceNamespaceAttribute, "game")
+
+	assert.Equal(t, "game", FromRequest(req).Data[fieldNamespace])
+}
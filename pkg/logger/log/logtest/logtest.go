@@ -0,0 +1,174 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logtest provides the helpers to assert on the access log records in the tests.
+package logtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+)
+
+// UpdateGoldenEnv is the environment variable to rewrite the golden files with the actual records,
+// e.g. UPDATE_GOLDEN_FILES=true go test ./...
+const UpdateGoldenEnv = "UPDATE_GOLDEN_FILES"
+
+// DefaultIgnoredFields are the fields which change on every run, they are left out of the golden files
+var DefaultIgnoredFields = []string{"time", "duration"}
+
+// ParseLines parses every non-empty line of the access log output, the test fails on the invalid line
+func ParseLines(t testing.TB, output string) []log.AccessLogEntry {
+	t.Helper()
+
+	entries := make([]log.AccessLogEntry, 0)
+	for index, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entry, err := log.ParseAccessLogLine(line)
+		if err != nil {
+			t.Fatalf("unable to parse access log line %d: %v", index+1, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// ParseLine parses the single access log record, the test fails when the output isn't exactly one record
+func ParseLine(t testing.TB, output string) log.AccessLogEntry {
+	t.Helper()
+
+	entries := ParseLines(t, output)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 access log record, got %d", len(entries))
+	}
+	return entries[0]
+}
+
+// Fields returns the fields of the entry formatted as strings, without the ignored fields,
+// so the entries parsed from text and json formats can be compared with each other
+func Fields(entry log.AccessLogEntry, ignoredFields ...string) map[string]string {
+	ignored := make(map[string]bool, len(ignoredFields))
+	for _, field := range ignoredFields {
+		ignored[field] = true
+	}
+
+	fields := map[string]string{}
+	for key, value := range entry.Fields() {
+		if ignored[key] {
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			fields[key] = v
+		case map[string]interface{}, []interface{}:
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				fields[key] = fmt.Sprintf("%v", v)
+				continue
+			}
+			fields[key] = string(encoded)
+		default:
+			fields[key] = fmt.Sprintf("%v", v)
+		}
+	}
+	return fields
+}
+
+// AssertGolden compares the fields of the entry with the golden file, a JSON object of the expected fields.
+// DefaultIgnoredFields and the given ignored fields aren't compared. The golden file is written with the actual
+// fields when UPDATE_GOLDEN_FILES environment variable is true, or when it doesn't exist yet.
+func AssertGolden(t testing.TB, goldenFile string, entry log.AccessLogEntry, ignoredFields ...string) {
+	t.Helper()
+
+	actual := Fields(entry, append(append([]string{}, DefaultIgnoredFields...), ignoredFields...)...)
+	encoded, err := marshalGolden(actual)
+	if err != nil {
+		t.Fatalf("unable to encode golden fields: %v", err)
+	}
+
+	expectedBytes, err := ioutil.ReadFile(goldenFile)
+	if os.IsNotExist(err) || strings.EqualFold(os.Getenv(UpdateGoldenEnv), "true") {
+		if err = os.MkdirAll(filepath.Dir(goldenFile), 0755); err == nil {
+			err = ioutil.WriteFile(goldenFile, encoded, 0644)
+		}
+		if err != nil {
+			t.Fatalf("unable to write golden file %s: %v", goldenFile, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("unable to read golden file %s: %v", goldenFile, err)
+	}
+
+	expected := map[string]string{}
+	if err = json.Unmarshal(expectedBytes, &expected); err != nil {
+		t.Fatalf("invalid golden file %s: %v", goldenFile, err)
+	}
+
+	if diff := diffFields(expected, actual); diff != "" {
+		t.Errorf("access log record doesn't match golden file %s (set %s=true to update it):\n%s",
+			goldenFile, UpdateGoldenEnv, diff)
+	}
+}
+
+// marshalGolden encodes the fields as an indented JSON object sorted by the field names
+func marshalGolden(fields map[string]string) ([]byte, error) {
+	buffer := new(bytes.Buffer)
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(fields); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// diffFields lists the differences between the expected and the actual fields sorted by the field names
+func diffFields(expected, actual map[string]string) string {
+	keys := make([]string, 0, len(expected)+len(actual))
+	for key := range expected {
+		keys = append(keys, key)
+	}
+	for key := range actual {
+		if _, ok := expected[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	for _, key := range keys {
+		expectedValue, inExpected := expected[key]
+		actualValue, inActual := actual[key]
+		switch {
+		case !inActual:
+			builder.WriteString(fmt.Sprintf("- %s: %q (missing)\n", key, expectedValue))
+		case !inExpected:
+			builder.WriteString(fmt.Sprintf("+ %s: %q (unexpected)\n", key, actualValue))
+		case expectedValue != actualValue:
+			builder.WriteString(fmt.Sprintf("~ %s: expected %q, actual %q\n", key, expectedValue, actualValue))
+		}
+	}
+	return builder.String()
+}
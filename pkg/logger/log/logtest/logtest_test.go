@@ -0,0 +1,132 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logtest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingT records the failures instead of failing the test
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+// nolint:paralleltest
+func TestAssertGolden_AccessLog(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	log.SetAccessLogOutput(buffer)
+	log.FullAccessLogEnabled = true
+	defer func() {
+		log.SetAccessLogOutput(os.Stdout)
+		log.FullAccessLogEnabled = false
+	}()
+
+	ws := new(restful.WebService)
+	ws.Filter(log.AccessLog)
+	ws.Route(ws.POST("/users").Operation("createUser").
+		Filter(log.Attribute(log.Option{MaskedRequestFields: "password"})).
+		To(func(request *restful.Request, response *restful.Response) {
+			_ = response.WriteHeaderAndJson(http.StatusCreated, map[string]string{"id": "abc"}, restful.MIME_JSON)
+		}))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"john","password":"secret"}`))
+	req.Header.Set("Content-Type", "application/json")
+	container.ServeHTTP(httptest.NewRecorder(), req)
+
+	entry := ParseLine(t, buffer.String())
+	assert.Equal(t, http.StatusCreated, entry.Status)
+	assert.Equal(t, `{"name":"john","password":"******"}`, entry.RequestBody)
+
+	AssertGolden(t, filepath.Join("testdata", "create_user.golden.json"), entry, "source_ip")
+}
+
+func TestAssertGolden_Mismatch(t *testing.T) {
+	t.Parallel()
+
+	goldenFile := filepath.Join("testdata", "create_user.golden.json")
+	entry := log.AccessLogEntry{Method: http.MethodPost, Path: "/users", Status: http.StatusOK,
+		Extras: map[string]interface{}{"cache": "hit"}}
+
+	recorder := &recordingT{TB: t}
+	AssertGolden(recorder, goldenFile, entry, "source_ip")
+
+	assert.Len(t, recorder.errors, 1)
+	assert.Contains(t, recorder.errors[0], `~ status: expected "201", actual "200"`)
+	assert.Contains(t, recorder.errors[0], `+ cache: "hit" (unexpected)`)
+	assert.Contains(t, recorder.errors[0], `~ operation: expected "createUser", actual ""`)
+}
+
+func TestAssertGolden_Create(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "logtest")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	goldenFile := filepath.Join(dir, "new", "entry.golden.json")
+	entry := log.AccessLogEntry{Method: http.MethodGet, Path: "/users", Status: http.StatusOK}
+
+	AssertGolden(t, goldenFile, entry)
+	assert.FileExists(t, goldenFile)
+
+	recorder := &recordingT{TB: t}
+	AssertGolden(recorder, goldenFile, entry)
+	assert.Empty(t, recorder.errors)
+}
+
+func TestParseLines(t *testing.T) {
+	t.Parallel()
+
+	entries := ParseLines(t, "method=GET path=\"/a\" status=200\n\n{\"method\":\"POST\",\"path\":\"/b\",\"status\":201}\n")
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "/a", entries[0].Path)
+	assert.Equal(t, http.StatusCreated, entries[1].Status)
+}
+
+func TestFields(t *testing.T) {
+	t.Parallel()
+
+	fields := Fields(log.AccessLogEntry{Status: http.StatusOK, Extras: map[string]interface{}{
+		"retries": int64(2), "tags": []interface{}{"a"}, "debug": true,
+	}}, "time")
+
+	assert.NotContains(t, fields, "time")
+	assert.Equal(t, "200", fields["status"])
+	assert.Equal(t, "2", fields["retries"])
+	assert.Equal(t, `["a"]`, fields["tags"])
+	assert.Equal(t, "true", fields["debug"])
+}
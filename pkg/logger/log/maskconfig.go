@@ -0,0 +1,204 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/emicklei/go-restful/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// MaskingRule defines the field(s) that need to be masked for the matched endpoint.
+// The endpoint is matched by its route operation id, or by its method and path.
+// The path can be the route path template (e.g. "/users/{id}") or a pattern of the request path (e.g. "/users/*").
+type MaskingRule struct {
+	Operation      string   `json:"operation" yaml:"operation"`
+	Method         string   `json:"method" yaml:"method"`
+	Path           string   `json:"path" yaml:"path"`
+	QueryParams    []string `json:"queryParams" yaml:"queryParams"`
+	RequestFields  []string `json:"requestFields" yaml:"requestFields"`
+	ResponseFields []string `json:"responseFields" yaml:"responseFields"`
+	Headers        []string `json:"headers" yaml:"headers"`
+}
+
+// MaskingConfig is the central masking configuration applied by the AccessLog filter,
+// in addition to the field(s) defined by the log.Attribute filter.
+type MaskingConfig struct {
+	Rules []MaskingRule `json:"rules" yaml:"rules"`
+}
+
+var (
+	maskingConfigMutex sync.RWMutex
+	maskingConfig      *MaskingConfig
+)
+
+// masking holds the resolved masked field(s) of a request, separated with comma
+type masking struct {
+	queryParams    string
+	requestFields  string
+	responseFields string
+	headers        string
+}
+
+func (m masking) isEmpty() bool {
+	return m.queryParams == "" && m.requestFields == "" && m.responseFields == "" && m.headers == ""
+}
+
+// SetMaskingConfig sets the central masking configuration
+func SetMaskingConfig(config *MaskingConfig) {
+	maskingConfigMutex.Lock()
+	defer maskingConfigMutex.Unlock()
+
+	maskingConfig = config
+}
+
+// LoadMaskingConfig loads the central masking configuration from a YAML (.yaml, .yml) or JSON file
+func LoadMaskingConfig(filePath string) error {
+	content, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("unable to read masking config file: %v", err)
+	}
+
+	config, err := parseMaskingConfig(content, filepath.Ext(filePath))
+	if err != nil {
+		return err
+	}
+
+	SetMaskingConfig(config)
+
+	return nil
+}
+
+func parseMaskingConfig(content []byte, extension string) (*MaskingConfig, error) {
+	config := &MaskingConfig{}
+
+	switch strings.ToLower(extension) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(content, config); err != nil {
+			return nil, fmt.Errorf("unable to parse masking config: %v", err)
+		}
+	default:
+		if err := json.Unmarshal(content, config); err != nil {
+			return nil, fmt.Errorf("unable to parse masking config: %v", err)
+		}
+	}
+
+	return config, nil
+}
+
+// match checks whether the rule is applied to the request
+func (rule MaskingRule) match(req *restful.Request) bool {
+	if rule.Operation != "" {
+		route := req.SelectedRoute()
+		if route == nil || route.Operation() != rule.Operation {
+			return false
+		}
+	}
+
+	if rule.Method != "" && !strings.EqualFold(rule.Method, req.Request.Method) {
+		return false
+	}
+
+	if rule.Path != "" && rule.Path != req.SelectedRoutePath() {
+		if matched, _ := path.Match(rule.Path, req.Request.URL.Path); !matched {
+			return false
+		}
+	}
+
+	return rule.Operation != "" || rule.Method != "" || rule.Path != ""
+}
+
+// resolveMasking merges the masked field(s) from the request attributes, the central masking configuration
+// and the field(s) added by AddMasking
+func resolveMasking(req *restful.Request) masking {
+	result := masking{}
+
+	if val, ok := req.Attribute(MaskedQueryParamsAttribute).(string); ok {
+		result.queryParams = val
+	}
+	if val, ok := req.Attribute(MaskedRequestFieldsAttribute).(string); ok {
+		result.requestFields = val
+	}
+	if val, ok := req.Attribute(MaskedResponseFieldsAttribute).(string); ok {
+		result.responseFields = val
+	}
+	if val, ok := req.Attribute(MaskedHeadersAttribute).(string); ok {
+		result.headers = val
+	}
+
+	maskingConfigMutex.RLock()
+	if maskingConfig != nil {
+		for _, rule := range maskingConfig.Rules {
+			if !rule.match(req) {
+				continue
+			}
+			result.queryParams = joinFields(result.queryParams, rule.QueryParams)
+			result.requestFields = joinFields(result.requestFields, rule.RequestFields)
+			result.responseFields = joinFields(result.responseFields, rule.ResponseFields)
+			result.headers = joinFields(result.headers, rule.Headers)
+		}
+	}
+	maskingConfigMutex.RUnlock()
+
+	// the field(s) added by the handler are merged last, see AddMasking
+	if added, ok := req.Attribute(AddedMaskingAttribute).(*masking); ok {
+		result.queryParams = joinFields(result.queryParams, splitFields(added.queryParams))
+		result.requestFields = joinFields(result.requestFields, splitFields(added.requestFields))
+		result.responseFields = joinFields(result.responseFields, splitFields(added.responseFields))
+		result.headers = joinFields(result.headers, splitFields(added.headers))
+	}
+
+	return result
+}
+
+// joinFields appends the field names into the comma separated fields, skipping the duplicates
+func joinFields(fields string, fieldNames []string) string {
+	existing := map[string]bool{}
+	if fields != "" {
+		for _, fieldName := range strings.Split(fields, ",") {
+			existing[fieldName] = true
+		}
+	}
+
+	for _, fieldName := range fieldNames {
+		fieldName = strings.TrimSpace(fieldName)
+		if fieldName == "" || existing[fieldName] {
+			continue
+		}
+		existing[fieldName] = true
+		if fields == "" {
+			fields = fieldName
+		} else {
+			fields += "," + fieldName
+		}
+	}
+
+	return fields
+}
+
+// splitFields splits the comma separated fields
+func splitFields(fields string) []string {
+	if fields == "" {
+		return nil
+	}
+	return strings.Split(fields, ",")
+}
@@ -0,0 +1,205 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMaskingConfig(t *testing.T) {
+	t.Parallel()
+
+	expected := &MaskingConfig{
+		Rules: []MaskingRule{
+			{
+				Operation:      "createUser",
+				RequestFields:  []string{"password"},
+				ResponseFields: []string{"email"},
+			},
+			{
+				Method:      "GET",
+				Path:        "/users/*",
+				QueryParams: []string{"token"},
+				Headers:     []string{"X-Api-Key"},
+			},
+		},
+	}
+
+	jsonConfig, err := parseMaskingConfig([]byte(`{"rules":[
+		{"operation":"createUser","requestFields":["password"],"responseFields":["email"]},
+		{"method":"GET","path":"/users/*","queryParams":["token"],"headers":["X-Api-Key"]}
+	]}`), ".json")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, jsonConfig)
+
+	yamlConfig, err := parseMaskingConfig([]byte(`
+rules:
+  - operation: createUser
+    requestFields: [password]
+    responseFields: [email]
+  - method: GET
+    path: /users/*
+    queryParams: [token]
+    headers: [X-Api-Key]
+`), ".yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, yamlConfig)
+
+	_, err = parseMaskingConfig([]byte(`{"rules":`), ".json")
+	assert.Error(t, err)
+}
+
+func TestJoinFields(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "", joinFields("", nil))
+	assert.Equal(t, "foo", joinFields("", []string{"foo"}))
+	assert.Equal(t, "foo,bar", joinFields("foo", []string{"bar", " foo ", ""}))
+}
+
+// nolint:paralleltest
+func TestLoadMaskingConfig(t *testing.T) {
+	defer SetMaskingConfig(nil)
+
+	dir, err := ioutil.TempDir("", "masking")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filePath := filepath.Join(dir, "masking.yml")
+	assert.NoError(t, ioutil.WriteFile(filePath, []byte("rules:\n  - operation: createUser\n    requestFields: [password]\n"), 0600))
+
+	assert.NoError(t, LoadMaskingConfig(filePath))
+	assert.Equal(t, []string{"password"}, maskingConfig.Rules[0].RequestFields)
+
+	assert.Error(t, LoadMaskingConfig(filepath.Join(dir, "not-found.json")))
+}
+
+// nolint:paralleltest
+func TestAccessLog_MaskingConfig(t *testing.T) {
+	FullAccessLogEnabled = true
+	FullAccessLogMaxBodySize = 10 << 10
+	SetMaskingConfig(&MaskingConfig{
+		Rules: []MaskingRule{
+			{Operation: "createUser", RequestFields: []string{"password"}, ResponseFields: []string{"email"}},
+			{Method: "POST", Path: "/users/{id}", QueryParams: []string{"token"}},
+			{Path: "/games/*", RequestFields: []string{"secret"}},
+		},
+	})
+	defer func() {
+		FullAccessLogEnabled = false
+		SetMaskingConfig(nil)
+	}()
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.POST("/users/{id}").Operation("createUser").
+		Filter(Attribute(Option{MaskedRequestFields: "pin"})).
+		To(func(request *restful.Request, response *restful.Response) {
+			_ = response.WriteAsJson(map[string]string{"email": "foo@example.com", "name": "foo"})
+		}))
+	ws.Route(ws.POST("/games/{id}").Operation("createGame").
+		To(func(request *restful.Request, response *restful.Response) {}))
+
+	req := httptest.NewRequest(http.MethodPost, "/users/abc?token=secret-token",
+		strings.NewReader(`{"password":"secret","pin":"1234","name":"foo"}`))
+	req.Header.Set("Content-Type", "application/json")
+	fields, _ := serveWithAccessLog(t, ws, req)
+
+	assert.Equal(t, "/users/abc?token=******", fields[fieldPath])
+	assert.Equal(t, `{"password":"******","pin":"******","name":"foo"}`, fields[fieldRequestBody])
+	assert.Equal(t, `{"email":"******","name":"foo"}`, fields[fieldResponseBody])
+	assert.Equal(t, true, fields[fieldPII])
+
+	req = httptest.NewRequest(http.MethodPost, "/games/abc",
+		strings.NewReader(`{"secret":"secret","password":"secret"}`))
+	req.Header.Set("Content-Type", "application/json")
+	fields, _ = serveWithAccessLog(t, ws, req)
+
+	assert.Equal(t, `{"secret":"******","password":"secret"}`, fields[fieldRequestBody])
+}
+
+// nolint:paralleltest
+func TestAccessLog_AddMasking(t *testing.T) {
+	FullAccessLogEnabled = true
+	FullAccessLogMaxBodySize = 10 << 10
+	SetMaskingConfig(&MaskingConfig{
+		Rules: []MaskingRule{
+			{Operation: "getUser", ResponseFields: []string{"email"}},
+		},
+	})
+	defer func() {
+		FullAccessLogEnabled = false
+		SetMaskingConfig(nil)
+	}()
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/users/{id}").Operation("getUser").
+		Filter(Attribute(Option{MaskedResponseFields: "phone"})).
+		To(func(request *restful.Request, response *restful.Response) {
+			if request.PathParameter("id") == "minor" {
+				AddMasking(request, Option{MaskedResponseFields: "name,email", MaskedQueryParams: "token"})
+				AddMasking(request, Option{MaskedResponseFields: "birthDate"})
+			}
+			_ = response.WriteAsJson(map[string]string{
+				"birthDate": "2010-01-01", "email": "foo@example.com", "name": "foo", "phone": "123",
+			})
+		}))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/minor?token=secret-token", nil)
+	fields, _ := serveWithAccessLog(t, ws, req)
+
+	assert.Equal(t, "/users/minor?token=******", fields[fieldPath])
+	assert.Equal(t, `{"birthDate":"******","email":"******","name":"******","phone":"******"}`,
+		fields[fieldResponseBody])
+
+	req = httptest.NewRequest(http.MethodGet, "/users/adult?token=secret-token", nil)
+	fields, _ = serveWithAccessLog(t, ws, req)
+
+	assert.Equal(t, "/users/adult?token=secret-token", fields[fieldPath])
+	assert.Equal(t, `{"birthDate":"2010-01-01","email":"******","name":"foo","phone":"******"}`,
+		fields[fieldResponseBody])
+}
+
+// nolint:paralleltest
+func TestResolveMasking_AddedMasking(t *testing.T) {
+	SetMaskingConfig(&MaskingConfig{
+		Rules: []MaskingRule{
+			{Method: "GET", RequestFields: []string{"secret", "password"}},
+		},
+	})
+	defer SetMaskingConfig(nil)
+
+	req := restful.NewRequest(httptest.NewRequest(http.MethodGet, "/", nil))
+	req.SetAttribute(MaskedRequestFieldsAttribute, "password,pin")
+	AddMasking(req, Option{MaskedRequestFields: "token,secret", MaskedHeaders: "X-Api-Key"})
+	AddMasking(req, Option{MaskedRequestFields: "pin, nonce"})
+
+	masked := resolveMasking(req)
+	assert.Equal(t, "password,pin,secret,token,nonce", masked.requestFields)
+	assert.Equal(t, "X-Api-Key", masked.headers)
+
+	SetMaskingConfig(nil)
+	assert.Equal(t, "password,pin,token,secret,nonce", resolveMasking(req).requestFields)
+}
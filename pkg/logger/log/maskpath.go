@@ -0,0 +1,348 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	anySegment       = "*"
+	anyIndexSegment  = "[*]"
+	anyDepthSegment  = "**"
+	jsonPointerStart = "/"
+
+	// maxMaskJSONDepth limits the nesting of the masked JSON, so an adversarial body can't exhaust the stack
+	maxMaskJSONDepth = 512
+)
+
+var errInvalidJSON = errors.New("invalid json")
+
+// isFieldPath checks whether the field name is a path of nested field instead of a plain field name,
+// e.g. "data.user.password", "items[*].token", "/data/user/password" or "*.password"
+func isFieldPath(fieldName string) bool {
+	return strings.ContainsAny(fieldName, ".[*") || strings.HasPrefix(fieldName, jsonPointerStart)
+}
+
+// parseFieldPath parses the dotted path (e.g. "items[*].token") or
+// the JSON pointer (e.g. "/items/*/token") into path segments.
+// Array index is represented as "[n]" segment, "[*]" matches any index,
+// "*" matches any key or index, and "**" matches any depth.
+func parseFieldPath(fieldPath string) []string {
+	segments := make([]string, 0)
+
+	if strings.HasPrefix(fieldPath, jsonPointerStart) {
+		for _, segment := range strings.Split(fieldPath[1:], "/") {
+			segment = strings.ReplaceAll(segment, "~1", "/")
+			segment = strings.ReplaceAll(segment, "~0", "~")
+			segments = append(segments, segment)
+		}
+		return segments
+	}
+
+	for _, part := range strings.Split(fieldPath, ".") {
+		for part != "" {
+			bracketIndex := strings.Index(part, "[")
+			if bracketIndex == -1 {
+				segments = append(segments, part)
+				break
+			}
+			if bracketIndex > 0 {
+				segments = append(segments, part[:bracketIndex])
+			}
+			closeIndex := strings.Index(part[bracketIndex:], "]")
+			if closeIndex == -1 {
+				segments = append(segments, part[bracketIndex:])
+				break
+			}
+			segments = append(segments, part[bracketIndex:bracketIndex+closeIndex+1])
+			part = part[bracketIndex+closeIndex+1:]
+		}
+	}
+
+	return segments
+}
+
+// matchFieldPath checks whether the path of a JSON value matches the pattern segments.
+// It's evaluated from the last segment, so the repeated "**" segments can't backtrack exponentially.
+func matchFieldPath(pattern []string, path []string) bool {
+	// matched[j] reports whether the rest of the pattern matches path[j:]
+	matched := make([]bool, len(path)+1)
+	matched[len(path)] = true
+
+	for i := len(pattern) - 1; i >= 0; i-- {
+		next := make([]bool, len(path)+1)
+		for j := len(path); j >= 0; j-- {
+			if pattern[i] == anyDepthSegment {
+				next[j] = matched[j] || (j < len(path) && next[j+1])
+				continue
+			}
+			next[j] = j < len(path) && matchSegment(pattern[i], path[j]) && matched[j+1]
+		}
+		matched = next
+	}
+
+	return matched[0]
+}
+
+func matchSegment(pattern string, segment string) bool {
+	if pattern == anySegment || pattern == segment {
+		return true
+	}
+	if strings.HasPrefix(segment, "[") {
+		// JSON pointer represents the array index without bracket
+		return pattern == anyIndexSegment || "["+pattern+"]" == segment
+	}
+	return false
+}
+
+// MaskJSONFields masks the value of the JSON fields matching the field names or paths, see MaskFields.
+// Unlike MaskFields, it returns an error instead of falling back to the pattern based masking
+// when the content isn't a valid JSON or nests deeper than 512 levels.
+func MaskJSONFields(content string, fields ...string) (string, error) {
+	patterns := make([][]string, 0, len(fields))
+	for _, field := range fields {
+		if isFieldPath(field) {
+			patterns = append(patterns, parseFieldPath(field))
+		} else {
+			patterns = append(patterns, []string{anyDepthSegment, field})
+		}
+	}
+	return maskJSONPaths(content, patterns)
+}
+
+// maskJSONPaths masks the value of JSON fields matching the path patterns,
+// while keeping the rest of the content as is, including the order of the fields.
+func maskJSONPaths(content string, patterns [][]string) (string, error) {
+	masker := &jsonPathMasker{data: content, patterns: patterns}
+	if err := masker.value(nil); err != nil {
+		return content, err
+	}
+	masker.whitespace()
+	if masker.pos != len(masker.data) {
+		return content, errInvalidJSON
+	}
+	return masker.out.String(), nil
+}
+
+// jsonPathMasker is a minimal JSON scanner which copies the content into the output,
+// replacing the value of the matched path with MaskedValue.
+type jsonPathMasker struct {
+	data     string
+	pos      int
+	patterns [][]string
+	depth    int
+	out      strings.Builder
+}
+
+func (m *jsonPathMasker) value(path []string) error {
+	m.whitespace()
+	if m.pos >= len(m.data) {
+		return errInvalidJSON
+	}
+
+	for _, pattern := range m.patterns {
+		if path != nil && matchFieldPath(pattern, path) {
+			start := m.pos
+			if err := m.skipValue(); err != nil {
+				m.pos = start
+				return err
+			}
+			m.out.WriteString(`"` + MaskedValue + `"`)
+			return nil
+		}
+	}
+
+	switch m.data[m.pos] {
+	case '{', '[':
+		m.depth++
+		defer func() { m.depth-- }()
+		if m.depth > maxMaskJSONDepth {
+			return errInvalidJSON
+		}
+		if m.data[m.pos] == '{' {
+			return m.object(path)
+		}
+		return m.array(path)
+	default:
+		start := m.pos
+		if err := m.skipValue(); err != nil {
+			return err
+		}
+		m.out.WriteString(m.data[start:m.pos])
+		return nil
+	}
+}
+
+func (m *jsonPathMasker) object(path []string) error {
+	m.out.WriteByte('{')
+	m.pos++
+
+	m.whitespace()
+	if m.pos < len(m.data) && m.data[m.pos] == '}' {
+		m.out.WriteByte('}')
+		m.pos++
+		return nil
+	}
+
+	for {
+		m.whitespace()
+		start := m.pos
+		if err := m.skipString(); err != nil {
+			return err
+		}
+		rawKey := m.data[start:m.pos]
+		var key string
+		if err := json.Unmarshal([]byte(rawKey), &key); err != nil {
+			return errInvalidJSON
+		}
+		m.out.WriteString(rawKey)
+
+		m.whitespace()
+		if m.pos >= len(m.data) || m.data[m.pos] != ':' {
+			return errInvalidJSON
+		}
+		m.out.WriteByte(':')
+		m.pos++
+
+		if err := m.value(appendSegment(path, key)); err != nil {
+			return err
+		}
+
+		m.whitespace()
+		if m.pos >= len(m.data) {
+			return errInvalidJSON
+		}
+		switch m.data[m.pos] {
+		case ',':
+			m.out.WriteByte(',')
+			m.pos++
+		case '}':
+			m.out.WriteByte('}')
+			m.pos++
+			return nil
+		default:
+			return errInvalidJSON
+		}
+	}
+}
+
+func (m *jsonPathMasker) array(path []string) error {
+	m.out.WriteByte('[')
+	m.pos++
+
+	m.whitespace()
+	if m.pos < len(m.data) && m.data[m.pos] == ']' {
+		m.out.WriteByte(']')
+		m.pos++
+		return nil
+	}
+
+	for index := 0; ; index++ {
+		if err := m.value(appendSegment(path, "["+strconv.Itoa(index)+"]")); err != nil {
+			return err
+		}
+
+		m.whitespace()
+		if m.pos >= len(m.data) {
+			return errInvalidJSON
+		}
+		switch m.data[m.pos] {
+		case ',':
+			m.out.WriteByte(',')
+			m.pos++
+		case ']':
+			m.out.WriteByte(']')
+			m.pos++
+			return nil
+		default:
+			return errInvalidJSON
+		}
+	}
+}
+
+// skipValue moves the position to the end of the current value without writing it
+func (m *jsonPathMasker) skipValue() error {
+	if m.pos >= len(m.data) {
+		return errInvalidJSON
+	}
+
+	switch m.data[m.pos] {
+	case '"':
+		return m.skipString()
+	case '{', '[':
+		depth := 0
+		for m.pos < len(m.data) {
+			switch m.data[m.pos] {
+			case '"':
+				if err := m.skipString(); err != nil {
+					return err
+				}
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+			m.pos++
+			if depth == 0 {
+				return nil
+			}
+		}
+		return errInvalidJSON
+	default:
+		start := m.pos
+		for m.pos < len(m.data) && !strings.ContainsRune(",}] \t\r\n", rune(m.data[m.pos])) {
+			m.pos++
+		}
+		if m.pos == start {
+			return errInvalidJSON
+		}
+		return nil
+	}
+}
+
+func (m *jsonPathMasker) skipString() error {
+	if m.pos >= len(m.data) || m.data[m.pos] != '"' {
+		return errInvalidJSON
+	}
+	for i := m.pos + 1; i < len(m.data); i++ {
+		switch m.data[i] {
+		case '\\':
+			i++
+		case '"':
+			m.pos = i + 1
+			return nil
+		}
+	}
+	return errInvalidJSON
+}
+
+// whitespace copies the whitespace into the output
+func (m *jsonPathMasker) whitespace() {
+	for m.pos < len(m.data) && strings.ContainsRune(" \t\r\n", rune(m.data[m.pos])) {
+		m.out.WriteByte(m.data[m.pos])
+		m.pos++
+	}
+}
+
+func appendSegment(path []string, segment string) []string {
+	result := make([]string, len(path), len(path)+1)
+	copy(result, path)
+	return append(result, segment)
+}
@@ -0,0 +1,139 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFieldPath(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"data", "user", "password"}, parseFieldPath("data.user.password"))
+	assert.Equal(t, []string{"items", "[*]", "token"}, parseFieldPath("items[*].token"))
+	assert.Equal(t, []string{"matrix", "[0]", "[1]"}, parseFieldPath("matrix[0][1]"))
+	assert.Equal(t, []string{"[*]", "token"}, parseFieldPath("[*].token"))
+	assert.Equal(t, []string{"**", "token"}, parseFieldPath("**.token"))
+	assert.Equal(t, []string{"data", "a/b", "0"}, parseFieldPath("/data/a~1b/0"))
+}
+
+func TestMatchFieldPath(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, matchFieldPath([]string{"data", "password"}, []string{"data", "password"}))
+	assert.False(t, matchFieldPath([]string{"data", "password"}, []string{"password"}))
+	assert.False(t, matchFieldPath([]string{"data", "password"}, []string{"data", "user", "password"}))
+	assert.True(t, matchFieldPath([]string{"items", "[*]", "token"}, []string{"items", "[3]", "token"}))
+	assert.True(t, matchFieldPath([]string{"items", "[3]", "token"}, []string{"items", "[3]", "token"}))
+	assert.False(t, matchFieldPath([]string{"items", "[2]", "token"}, []string{"items", "[3]", "token"}))
+	assert.True(t, matchFieldPath([]string{"items", "3", "token"}, []string{"items", "[3]", "token"}))
+	assert.True(t, matchFieldPath([]string{"*", "password"}, []string{"user", "password"}))
+	assert.False(t, matchFieldPath([]string{"*", "password"}, []string{"password"}))
+	assert.True(t, matchFieldPath([]string{"**", "password"}, []string{"password"}))
+	assert.True(t, matchFieldPath([]string{"**", "password"}, []string{"a", "[0]", "b", "password"}))
+}
+
+func TestMaskFieldPaths(t *testing.T) {
+	t.Parallel()
+
+	inputAndExpected := [][]string{
+		{
+			"data.user.password", // fields
+			`{"data":{"user":{"name":"foo","password":"secret"},"password":"keep"},"password":"keep"}`, // input
+			`{"data":{"user":{"name":"foo","password":"******"},"password":"keep"},"password":"keep"}`, // expected
+		},
+		{
+			"items[*].token",
+			`{"items":[{"id":1,"token":"a"},{"id":2,"token":"b"},{"id":3}],"token":"keep"}`,
+			`{"items":[{"id":1,"token":"******"},{"id":2,"token":"******"},{"id":3}],"token":"keep"}`,
+		},
+		{
+			"items[1].token",
+			`{"items":[{"token":"a"},{"token":"b"}]}`,
+			`{"items":[{"token":"a"},{"token":"******"}]}`,
+		},
+		{
+			"/data/pin",
+			`{"data":{"pin":1234,"name":"foo"}}`,
+			`{"data":{"pin":"******","name":"foo"}}`,
+		},
+		{
+			"**.secret",
+			`{"a":{"b":[{"secret":{"nested":true}}]},"secret":[1,2]}`,
+			`{"a":{"b":[{"secret":"******"}]},"secret":"******"}`,
+		},
+		{
+			"[*].token",
+			`[{"token":"a"},{"token":"b"}]`,
+			`[{"token":"******"},{"token":"******"}]`,
+		},
+		{
+			"data.password,name",
+			`{"name":"foo","data":{"password":"x\"y"}}`,
+			`{"name":"******","data":{"password":"******"}}`,
+		},
+		{
+			"data.password",
+			"{\n  \"name\": \"foo\",\n  \"data\": {\"password\": \"x\\\"y\"}\n}",
+			"{\n  \"name\": \"foo\",\n  \"data\": {\"password\": \"******\"}\n}",
+		},
+		// uncompleted json, fallback to mask the last field name
+		{
+			"data.user.password",
+			`{"data":{"user":{"password":"secret"`,
+			`{"data":{"user":{"password":"******"`,
+		},
+	}
+
+	for _, val := range inputAndExpected {
+		assert.Equal(t, val[2], MaskFields("application/json", val[1], val[0]), val[0])
+	}
+}
+
+func TestMaskJSONFields(t *testing.T) {
+	t.Parallel()
+
+	masked, err := MaskJSONFields(`{"data":{"password":"secret","token":"a"},"token":"b"}`, "password", "data.token")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"data":{"password":"******","token":"******"},"token":"b"}`, masked)
+
+	_, err = MaskJSONFields(`{"password":"secret"`, "password")
+	assert.Error(t, err)
+}
+
+func TestMaskJSONFields_DeepNesting(t *testing.T) {
+	t.Parallel()
+
+	content := strings.Repeat(`{"a":`, maxMaskJSONDepth) + "1" + strings.Repeat("}", maxMaskJSONDepth)
+	_, err := MaskJSONFields(content, "password")
+	assert.NoError(t, err)
+
+	content = strings.Repeat("[", 1000000) + strings.Repeat("]", 1000000)
+	_, err = MaskJSONFields(content, "password")
+	assert.Error(t, err)
+	assert.Equal(t, content, MaskFields("application/json", content, "password"))
+}
+
+func TestMatchFieldPath_RepeatedAnyDepth(t *testing.T) {
+	t.Parallel()
+
+	path := strings.Split(strings.Repeat("a,", 200)+"b", ",")
+	pattern := parseFieldPath(strings.Repeat("**.", 20) + "a")
+	assert.False(t, matchFieldPath(pattern, path))
+	assert.True(t, matchFieldPath(append(pattern, "b"), path))
+}
@@ -16,6 +16,7 @@ package log
 
 import (
 	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
 	"sync"
@@ -35,68 +36,170 @@ type FieldRegex struct {
 }
 
 // InitFieldRegex initialize the FieldRegex along with its regex patterns.
+// The field name is matched literally, the regex metacharacters in it are escaped
+// and the invalid UTF-8 bytes are replaced with U+FFFD.
 func (f *FieldRegex) InitFieldRegex(fieldName string) {
 	f.FieldName = fieldName
-	// "fieldName":"(.*?)"
-	f.JsonPattern = regexp.MustCompile(fmt.Sprintf("\"%s\":\"(.*?)\"", fieldName))
-	// fieldName=(.*?[^&]*)|fieldName=(.*?)$
-	f.QueryStringPattern = regexp.MustCompile(fmt.Sprintf("%s=(.*?[^&]*)|%s=(.*?)$", fieldName, fieldName))
+	quoted := regexp.QuoteMeta(strings.ToValidUTF8(fieldName, "\uFFFD"))
+	// "fieldName" : "..." including the escaped quotes, or up to the end of the truncated content
+	f.JsonPattern = regexp.MustCompile(fmt.Sprintf(`"%s"\s*:\s*"(?:[^"\\]|\\.)*(?:"|\\?$)`, quoted))
+	// fieldName=...
+	f.QueryStringPattern = regexp.MustCompile(fmt.Sprintf("%s=[^&]*", quoted))
+}
+
+func getFieldRegex(fieldName string) FieldRegex {
+	if val, ok := FieldRegexCache.Load(fieldName); ok {
+		return val.(FieldRegex)
+	}
+	fieldRegex := FieldRegex{}
+	fieldRegex.InitFieldRegex(fieldName)
+	FieldRegexCache.Store(fieldName, fieldRegex)
+	return fieldRegex
 }
 
 // MaskFields will mask the field value on the content string based on the
 // provided field name(s) in "fields" parameter separated by comma.
+// The field can also be a path of nested JSON field, e.g. "data.user.password", "items[*].token",
+// JSON pointer "/data/user/password", or wildcard "*.password" and "**.token" (any depth).
+// A JSON content is masked structurally, so a plain field name masks the value of the key at any depth
+// regardless of the whitespace, escaping or the type of the value.
+// The content which can't be parsed, e.g. truncated body, is masked using the field name patterns.
 func MaskFields(contentType, content, fields string) string {
 	if content == "" || fields == "" {
 		return content
 	}
 
-	fieldNames := strings.Split(fields, ",")
-	for _, fieldName := range fieldNames {
-		var fieldRegex FieldRegex
-		if val, ok := FieldRegexCache.Load(fieldName); ok {
-			fieldRegex = val.(FieldRegex)
-		} else {
-			fieldRegex = FieldRegex{}
-			fieldRegex.InitFieldRegex(fieldName)
-			FieldRegexCache.Store(fieldName, fieldRegex)
+	fieldNames := make([]string, 0)
+	fieldPaths := make([][]string, 0)
+	for _, fieldName := range strings.Split(fields, ",") {
+		if isFieldPath(fieldName) {
+			fieldPaths = append(fieldPaths, parseFieldPath(fieldName))
+			continue
+		}
+		fieldNames = append(fieldNames, fieldName)
+	}
+
+	if isJSONContent(contentType, content) {
+		patterns := fieldPaths
+		for _, fieldName := range fieldNames {
+			patterns = append(patterns, []string{anyDepthSegment, fieldName})
+		}
+		if masked, err := maskJSONPaths(content, patterns); err == nil {
+			return masked
 		}
+	}
+
+	content = maskFieldNames(contentType, content, fieldNames)
+
+	if len(fieldPaths) > 0 {
+		content = maskFieldPaths(contentType, content, fieldPaths)
+	}
+
+	return content
+}
+
+// isJSONContent checks whether the content should be masked as JSON
+func isJSONContent(contentType, content string) bool {
+	if strings.Contains(contentType, "application/json") {
+		return true
+	}
+	if strings.Contains(contentType, "application/x-www-form-urlencoded") {
+		return false
+	}
+	trimmed := strings.TrimLeft(content, " \t\r\n")
+	return strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")
+}
+
+// maskFieldNames masks the plain field names using the patterns of the content type
+func maskFieldNames(contentType, content string, fieldNames []string) string {
+	if len(fieldNames) == 0 {
+		return content
+	}
 
+	if strings.Contains(contentType, "application/x-www-form-urlencoded") {
+		return MaskFormValues(content, fieldNames...)
+	}
+
+	for _, fieldName := range fieldNames {
+		fieldRegex := getFieldRegex(fieldName)
 		if strings.Contains(contentType, "application/json") {
-			content = fieldRegex.JsonPattern.ReplaceAllString(content, fmt.Sprintf("\"%s\":\"%s\"", fieldName, MaskedValue))
-		} else if strings.Contains(contentType, "application/x-www-form-urlencoded") {
-			content = fieldRegex.QueryStringPattern.ReplaceAllString(content, fmt.Sprintf("%s=%s", fieldName, MaskedValue))
-		} else {
+			content = fieldRegex.JsonPattern.ReplaceAllLiteralString(content, fmt.Sprintf("\"%s\":\"%s\"", fieldName, MaskedValue))
+		} else if fieldRegex.JsonPattern.MatchString(content) {
 			// try json pattern and form-data pattern
-			if fieldRegex.JsonPattern.MatchString(content) {
-				content = fieldRegex.JsonPattern.ReplaceAllString(content, fmt.Sprintf("\"%s\":\"%s\"", fieldName, MaskedValue))
-			} else if fieldRegex.QueryStringPattern.MatchString(content) {
-				content = fieldRegex.QueryStringPattern.ReplaceAllString(content, fmt.Sprintf("%s=%s", fieldName, MaskedValue))
-			}
+			content = fieldRegex.JsonPattern.ReplaceAllLiteralString(content, fmt.Sprintf("\"%s\":\"%s\"", fieldName, MaskedValue))
+		} else if strings.Contains(content, "=") {
+			content = MaskFormValues(content, fieldName)
 		}
 	}
-
 	return content
 }
 
+// maskFieldPaths masks the nested JSON field(s) matching the path patterns.
+// If the content is not a valid JSON, e.g. truncated body,
+// it falls back to mask the last field name of the path wherever it is.
+func maskFieldPaths(contentType, content string, fieldPaths [][]string) string {
+	masked, err := maskJSONPaths(content, fieldPaths)
+	if err == nil {
+		return masked
+	}
+
+	fallbackFields := make([]string, 0)
+	for _, fieldPath := range fieldPaths {
+		for i := len(fieldPath) - 1; i >= 0; i-- {
+			if !isFieldPath(fieldPath[i]) && !strings.HasPrefix(fieldPath[i], "[") {
+				fallbackFields = append(fallbackFields, fieldPath[i])
+				break
+			}
+		}
+	}
+	return maskFieldNames(contentType, content, fallbackFields)
+}
+
 // MaskQueryParams will mask the field value on the uri based on the
 // provided field name(s) in "fields" parameter separated by comma.
+// The query parameter names are compared after URL decoding, see MaskFormValues.
 func MaskQueryParams(uri string, fields string) string {
 	if uri == "" || fields == "" {
 		return uri
 	}
 
 	fieldNames := strings.Split(fields, ",")
-	for _, fieldName := range fieldNames {
-		var fieldRegex FieldRegex
-		if val, ok := FieldRegexCache.Load(fieldName); ok {
-			fieldRegex = val.(FieldRegex)
-		} else {
-			fieldRegex = FieldRegex{}
-			fieldRegex.InitFieldRegex(fieldName)
-			FieldRegexCache.Store(fieldName, fieldRegex)
-		}
+	queryIndex := strings.Index(uri, "?")
+	if queryIndex == -1 {
+		return MaskFormValues(uri, fieldNames...)
+	}
+	return uri[:queryIndex+1] + MaskFormValues(uri[queryIndex+1:], fieldNames...)
+}
+
+// MaskFormValues masks the value of the URL encoded parameters, e.g. the query string or
+// the "application/x-www-form-urlencoded" body, whose name ends with one of the field names.
+// The name is compared both as is and URL decoded, so an encoded name (e.g. "pass%77ord") can't bypass the masking.
+// The rest of the content is kept as is.
+func MaskFormValues(content string, fieldNames ...string) string {
+	if content == "" || len(fieldNames) == 0 {
+		return content
+	}
 
-		uri = fieldRegex.QueryStringPattern.ReplaceAllString(uri, fmt.Sprintf("%s=%s", fieldName, MaskedValue))
+	params := strings.Split(content, "&")
+	for i, param := range params {
+		separatorIndex := strings.Index(param, "=")
+		if separatorIndex == -1 {
+			continue
+		}
+		name := param[:separatorIndex]
+		decodedName, err := url.QueryUnescape(name)
+		if err != nil {
+			decodedName = name
+		}
+		for _, fieldName := range fieldNames {
+			if fieldName == "" {
+				continue
+			}
+			if strings.HasSuffix(name, fieldName) || strings.HasSuffix(decodedName, fieldName) {
+				params[i] = name + "=" + MaskedValue
+				break
+			}
+		}
 	}
-	return uri
+	return strings.Join(params, "&")
 }
@@ -394,3 +394,67 @@ func TestMaskQueryParam_ConcurrentCall(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestMaskFields_Hardened(t *testing.T) {
+	t.Parallel()
+
+	inputAndExpected := [][]string{
+		{
+			`{"password" : "secret"}`, // input
+			`{"password" : "******"}`, // expected
+		},
+		{
+			`{"password":"sec\"ret"}`,
+			`{"password":"******"}`,
+		},
+		{
+			`{"password":1234}`,
+			`{"password":"******"}`,
+		},
+		{
+			`{"pass\u0077ord":"secret"}`,
+			`{"pass\u0077ord":"******"}`,
+		},
+		{
+			`{"user":{"password":{"value":"secret"}}}`,
+			`{"user":{"password":"******"}}`,
+		},
+		{
+			// truncated body
+			`{"password":"sec\"ret`,
+			`{"password":"******"`,
+		},
+	}
+
+	for _, val := range inputAndExpected {
+		assert.Equal(t, val[1], MaskFields("application/json", val[0], "password"))
+	}
+}
+
+func TestMaskFields_RegexMetacharacters(t *testing.T) {
+	t.Parallel()
+
+	assert.NotPanics(t, func() {
+		assert.Equal(t, "a(b=******&c=d", MaskFields("application/x-www-form-urlencoded", "a(b=secret&c=d", "a(b"))
+	})
+	assert.Equal(t, `{"a.b":"keep","a+b":"******"}`, MaskFields("text/plain", `{"a.b":"keep","a+b":"secret"}`, "a+b"))
+}
+
+func TestMaskQueryParamOfEncodedNames(t *testing.T) {
+	t.Parallel()
+
+	inputAndExpected := [][]string{
+		{
+			"https://example.net?pass%77ord=mypassword123", // input
+			"https://example.net?pass%77ord=******",        // expected
+		},
+		{
+			"https://example.net?username=foo&%70assword=mypassword123&%zz=keep",
+			"https://example.net?username=foo&%70assword=******&%zz=keep",
+		},
+	}
+
+	for _, val := range inputAndExpected {
+		assert.Equal(t, val[1], MaskQueryParams(val[0], "password"))
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+const (
+	fieldPanic      = "panic"
+	fieldPanicStack = "panic_stack"
+)
+
+// filterPanic holds the panic raised by the inner filters or the route function
+type filterPanic struct {
+	value interface{}
+	stack []byte
+}
+
+// processFilterChain continues the filter chain and recovers the panic raised by it,
+// so the access log record can be completed before the panic is propagated.
+func processFilterChain(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) (p *filterPanic) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			p = &filterPanic{value: recovered, stack: debug.Stack()}
+		}
+	}()
+
+	chain.ProcessFilter(req, resp)
+
+	return nil
+}
+
+// addPanicFields adds the panic info into the entry, scrubbing the secrets of the request.
+// The status is reported as 500 if the response header isn't written yet,
+// since the recovery handler will respond with it.
+func addPanicFields(req *restful.Request, p *filterPanic, respWriter *ResponseWriterInterceptor, entry *AccessLogEntry) {
+	if p == nil {
+		return
+	}
+
+	entry.Extras[fieldPanic] = ScrubSecrets(req, fmt.Sprintf("%v", p.value))
+	entry.Extras[fieldPanicStack] = ScrubSecrets(req, string(p.stack))
+	if !respWriter.WroteHeader() {
+		entry.Status = http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrEmptyAccessLogLine is returned when parsing an empty line
+var ErrEmptyAccessLogLine = errors.New("empty access log line")
+
+// ParseAccessLogLine parses the access log record printed in text or json format back into the entry,
+// e.g. to assert on the fields of the record in the tests instead of matching the raw line.
+// The body fields wrapped with AB[ and ]AB and the quoted fields are unwrapped,
+// the time and duration fields are parsed using FullAccessLogTimeFormat and FullAccessLogDurationUnit.
+// The fields other than the typed fields are kept in Extras, as strings in text format
+// and as their JSON types in json format, with the numbers decoded as int64 or float64.
+func ParseAccessLogLine(line string) (AccessLogEntry, error) {
+	if strings.TrimSpace(line) == "" {
+		return AccessLogEntry{}, ErrEmptyAccessLogLine
+	}
+
+	fields, err := decodeAccessLogRecord([]byte(line))
+	if err != nil {
+		return AccessLogEntry{}, fmt.Errorf("unable to decode access log record: %v", err)
+	}
+	if logType, ok := fields[fieldLogType]; ok && valueString(logType) != logTypeAccess {
+		return AccessLogEntry{}, fmt.Errorf("unexpected log_type %q", valueString(logType))
+	}
+	delete(fields, fieldLogType)
+
+	entry := AccessLogEntry{Extras: map[string]interface{}{}}
+	stringFields := map[string]*string{
+		fieldMethod:              &entry.Method,
+		fieldPath:                &entry.Path,
+		fieldSourceIP:            &entry.SourceIP,
+		fieldUserAgent:           &entry.UserAgent,
+		fieldReferer:             &entry.Referer,
+		fieldTraceID:             &entry.TraceID,
+		fieldNamespace:           &entry.Namespace,
+		fieldUserID:              &entry.UserID,
+		fieldClientID:            &entry.ClientID,
+		fieldRequestContentType:  &entry.RequestContentType,
+		fieldRequestBody:         &entry.RequestBody,
+		fieldResponseContentType: &entry.ResponseContentType,
+		fieldResponseBody:        &entry.ResponseBody,
+		fieldOperation:           &entry.Operation,
+	}
+
+	for key, value := range fields {
+		if target, ok := stringFields[key]; ok {
+			*target = valueString(value)
+			continue
+		}
+
+		switch key {
+		case fieldTime:
+			entry.Time, err = parseTime(valueString(value))
+		case fieldStatus:
+			entry.Status, err = strconv.Atoi(valueString(value))
+		case fieldLength:
+			entry.Length, err = strconv.Atoi(valueString(value))
+		case fieldDuration:
+			entry.Duration, err = parseDuration(valueString(value))
+		default:
+			entry.Extras[key] = decodeExtraValue(value)
+		}
+		if err != nil {
+			return AccessLogEntry{}, fmt.Errorf("invalid %s field: %v", key, err)
+		}
+	}
+
+	return entry, nil
+}
+
+// parseTime parses the time field printed by formatTime
+func parseTime(s string) (time.Time, error) {
+	switch strings.ToLower(FullAccessLogTimeFormat) {
+	case "":
+		return time.Parse(defaultTimeLayout, s)
+	case TimeFormatRFC3339Nano:
+		return time.Parse(time.RFC3339Nano, s)
+	case TimeFormatEpochMillis:
+		millis, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(0, millis*int64(time.Millisecond)).UTC(), nil
+	default:
+		return time.Parse(FullAccessLogTimeFormat, s)
+	}
+}
+
+// parseDuration parses the duration field printed by formatDuration
+func parseDuration(s string) (time.Duration, error) {
+	value, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if FullAccessLogDurationUnit == DurationUnitMicrosecond {
+		return time.Duration(value) * time.Microsecond, nil
+	}
+	return time.Duration(value) * time.Millisecond, nil
+}
+
+// decodeExtraValue converts the JSON number into int64, or float64 if it isn't an integer
+func decodeExtraValue(value interface{}) interface{} {
+	number, ok := value.(json.Number)
+	if !ok {
+		return value
+	}
+	if i, err := number.Int64(); err == nil {
+		return i
+	}
+	if f, err := number.Float64(); err == nil {
+		return f
+	}
+	return number.String()
+}
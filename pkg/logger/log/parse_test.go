@@ -0,0 +1,153 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAccessLogLine(t *testing.T) {
+	t.Parallel()
+
+	entry := AccessLogEntry{
+		Time:                time.Date(2022, 1, 1, 0, 0, 0, int(123*time.Millisecond), time.UTC),
+		Method:              http.MethodPost,
+		Path:                "/users?name=a b",
+		Status:              http.StatusCreated,
+		Duration:            15 * time.Millisecond,
+		Length:              42,
+		SourceIP:            "10.0.0.1",
+		UserAgent:           `curl "quoted" agent`,
+		TraceID:             "trace",
+		Namespace:           "accelbyte",
+		UserID:              "user",
+		ClientID:            "client",
+		RequestContentType:  "application/json",
+		RequestBody:         `{"note":"x]AB y"}`,
+		ResponseContentType: "application/json",
+		ResponseBody:        `{"id":"abc"}`,
+		Operation:           "createUser",
+		Extras:              map[string]interface{}{"message": `say "hi"`, "retries": 2, "cache": "hit"},
+	}
+
+	for _, format := range []string{AccessLogFormatText, AccessLogFormatJSON} {
+		record, err := NewAccessLogFormatter(format).Format(&entry)
+		assert.NoError(t, err)
+
+		parsed, err := ParseAccessLogLine(string(record))
+		assert.NoError(t, err, format)
+
+		expected := entry
+		expected.Extras = map[string]interface{}{"message": `say "hi"`, "retries": "2", "cache": "hit"}
+		if format == AccessLogFormatJSON {
+			expected.Extras["retries"] = int64(2)
+		}
+		assert.Equal(t, expected, parsed, format)
+	}
+}
+
+func TestParseAccessLogLine_BodyDelimiter(t *testing.T) {
+	t.Parallel()
+
+	bodies := []string{
+		`hello ]AB user_id=evil "q" done`,
+		`]AB`,
+		`already ]\AB escaped ]\\AB`,
+		`]]AB]AB trailing]AB`,
+	}
+	for _, body := range bodies {
+		entry := AccessLogEntry{
+			Method:       http.MethodPost,
+			Path:         "/users",
+			UserID:       "user",
+			RequestBody:  body,
+			ResponseBody: body,
+			Extras:       map[string]interface{}{},
+		}
+
+		record, err := NewAccessLogFormatter(AccessLogFormatText).Format(&entry)
+		assert.NoError(t, err, body)
+		// the body can't close its wrapper and forge the following fields
+		assert.Equal(t, 2, strings.Count(string(record), "]AB"), body)
+
+		parsed, err := ParseAccessLogLine(string(record))
+		assert.NoError(t, err, body)
+		assert.Equal(t, body, parsed.RequestBody)
+		assert.Equal(t, body, parsed.ResponseBody)
+		assert.Equal(t, "user", parsed.UserID)
+		assert.Empty(t, parsed.Extras)
+	}
+}
+
+func TestParseAccessLogLine_SelectedFields(t *testing.T) {
+	t.Parallel()
+
+	parsed, err := ParseAccessLogLine(`method=GET path="/users" status=200 request_body=AB[-]AB trace_id=abc`)
+	assert.NoError(t, err)
+	assert.Equal(t, AccessLogEntry{
+		Method:      http.MethodGet,
+		Path:        "/users",
+		Status:      http.StatusOK,
+		RequestBody: "-",
+		TraceID:     "abc",
+		Extras:      map[string]interface{}{},
+	}, parsed)
+}
+
+func TestParseAccessLogLine_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseAccessLogLine("  ")
+	assert.Equal(t, ErrEmptyAccessLogLine, err)
+
+	_, err = ParseAccessLogLine(`time=now log_type=access`)
+	assert.EqualError(t, err, `invalid time field: parsing time "now" as "2006-01-02T15:04:05.000Z": cannot parse "now" as "2006"`)
+
+	_, err = ParseAccessLogLine(`status=OK`)
+	assert.Error(t, err)
+
+	_, err = ParseAccessLogLine(`{"log_type":"app","msg":"hello"}`)
+	assert.EqualError(t, err, `unexpected log_type "app"`)
+
+	_, err = ParseAccessLogLine(`path="/unterminated`)
+	assert.Error(t, err)
+}
+
+// nolint:paralleltest
+func TestParseAccessLogLine_TimeFormat(t *testing.T) {
+	defer func() {
+		FullAccessLogTimeFormat = ""
+		FullAccessLogDurationUnit = DurationUnitMillisecond
+	}()
+
+	FullAccessLogTimeFormat = TimeFormatEpochMillis
+	FullAccessLogDurationUnit = DurationUnitMicrosecond
+
+	parsed, err := ParseAccessLogLine(`{"time":1640995200123,"duration":1500,"status":200}`)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2022, 1, 1, 0, 0, 0, int(123*time.Millisecond), time.UTC), parsed.Time)
+	assert.Equal(t, 1500*time.Microsecond, parsed.Duration)
+
+	FullAccessLogTimeFormat = TimeFormatRFC3339Nano
+
+	parsed, err = ParseAccessLogLine(`time=2022-01-01T00:00:00.000000001Z status=200`)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2022, 1, 1, 0, 0, 0, 1, time.UTC), parsed.Time)
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"fmt"
+	"strings"
+)
+
+// built-in access log profiles
+const (
+	ProfileDev        = "dev"
+	ProfileStaging    = "staging"
+	ProfileProd       = "prod"
+	ProfileCompliance = "compliance"
+)
+
+// Profile is a preset of the access log configuration
+type Profile struct {
+	FullAccessLogEnabled       bool
+	RequestBodyEnabled         bool
+	ResponseBodyEnabled        bool
+	MaxBodySize                int
+	Format                     string
+	SensitiveHeaders           []string
+	SamplingEnabled            bool
+	BodyReadDiagnosticsEnabled bool
+}
+
+// FullAccessLogProfile is the name of the applied profile, empty if no profile is applied
+var FullAccessLogProfile string
+
+var profiles = map[string]Profile{
+	// verbose local debugging, bodies are logged in the human readable format
+	ProfileDev: {
+		FullAccessLogEnabled:       true,
+		RequestBodyEnabled:         true,
+		ResponseBodyEnabled:        true,
+		MaxBodySize:                64 << 10, // 64KB
+		Format:                     AccessLogFormatText,
+		SamplingEnabled:            true,
+		BodyReadDiagnosticsEnabled: true,
+	},
+	// bodies are logged for troubleshooting the integration, in the format ingested by the log pipeline
+	ProfileStaging: {
+		FullAccessLogEnabled: true,
+		RequestBodyEnabled:   true,
+		ResponseBodyEnabled:  true,
+		MaxBodySize:          10 << 10, // 10KB
+		Format:               AccessLogFormatJSON,
+		SamplingEnabled:      true,
+	},
+	// bodies are not logged
+	ProfileProd: {
+		MaxBodySize:     10 << 10, // 10KB
+		Format:          AccessLogFormatJSON,
+		SamplingEnabled: true,
+	},
+	// bodies are not logged, the credential headers are masked and every request is logged regardless the sampling
+	ProfileCompliance: {
+		MaxBodySize:      10 << 10, // 10KB
+		Format:           AccessLogFormatJSON,
+		SensitiveHeaders: []string{"X-Api-Key", "X-Auth-Token", "X-Access-Token", "X-Refresh-Token"},
+		SamplingEnabled:  false,
+	},
+}
+
+// GetProfile returns the built-in profile by its name
+func GetProfile(name string) (Profile, bool) {
+	profile, ok := profiles[strings.ToLower(strings.TrimSpace(name))]
+	return profile, ok
+}
+
+// ApplyProfile applies the built-in profile (dev, staging, prod or compliance) into the access log configuration.
+// It overrides the configuration set by the environment variables, so it should be called before the specific overrides.
+func ApplyProfile(name string) error {
+	profile, ok := GetProfile(name)
+	if !ok {
+		return fmt.Errorf("unknown access log profile %s", name)
+	}
+
+	FullAccessLogEnabled = profile.FullAccessLogEnabled
+	FullAccessLogRequestBodyEnabled = profile.RequestBodyEnabled
+	FullAccessLogResponseBodyEnabled = profile.ResponseBodyEnabled
+	FullAccessLogMaxBodySize = nonNegativeBodySize(profile.MaxBodySize)
+	FullAccessLogFormat = profile.Format
+	FullAccessLogSensitiveHeaders = profile.SensitiveHeaders
+	FullAccessLogBodyReadDiagnosticsEnabled = profile.BodyReadDiagnosticsEnabled
+	setSamplingEnabled(profile.SamplingEnabled)
+
+	FullAccessLogProfile = strings.ToLower(strings.TrimSpace(name))
+
+	return nil
+}
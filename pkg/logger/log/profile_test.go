@@ -0,0 +1,88 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func restoreProfileConfig() {
+	FullAccessLogEnabled = false
+	FullAccessLogRequestBodyEnabled = true
+	FullAccessLogResponseBodyEnabled = true
+	FullAccessLogMaxBodySize = 10 << 10
+	FullAccessLogFormat = AccessLogFormatText
+	FullAccessLogSensitiveHeaders = nil
+	FullAccessLogBodyReadDiagnosticsEnabled = false
+	FullAccessLogProfile = ""
+	setSamplingEnabled(true)
+}
+
+// nolint:paralleltest
+func TestApplyProfile(t *testing.T) {
+	defer restoreProfileConfig()
+
+	assert.NoError(t, ApplyProfile("Dev"))
+	assert.Equal(t, ProfileDev, FullAccessLogProfile)
+	assert.True(t, FullAccessLogEnabled)
+	assert.True(t, FullAccessLogRequestBodyEnabled)
+	assert.Equal(t, 64<<10, FullAccessLogMaxBodySize)
+	assert.Equal(t, AccessLogFormatText, FullAccessLogFormat)
+	assert.True(t, FullAccessLogBodyReadDiagnosticsEnabled)
+
+	assert.NoError(t, ApplyProfile(ProfileProd))
+	assert.False(t, FullAccessLogEnabled)
+	assert.False(t, FullAccessLogRequestBodyEnabled)
+	assert.False(t, FullAccessLogResponseBodyEnabled)
+	assert.Equal(t, AccessLogFormatJSON, FullAccessLogFormat)
+	assert.False(t, FullAccessLogBodyReadDiagnosticsEnabled)
+
+	assert.NoError(t, ApplyProfile(ProfileCompliance))
+	assert.True(t, isSensitiveHeader("X-Api-Key", ""))
+}
+
+// nolint:paralleltest
+func TestApplyProfile_Unknown(t *testing.T) {
+	defer restoreProfileConfig()
+
+	FullAccessLogEnabled = true
+
+	assert.Error(t, ApplyProfile("qa"))
+	assert.True(t, FullAccessLogEnabled)
+	assert.Equal(t, "", FullAccessLogProfile)
+}
+
+// nolint:paralleltest
+func TestApplyProfile_ComplianceIgnoresSampling(t *testing.T) {
+	defer func() {
+		restoreProfileConfig()
+		ResetAccessLogRules()
+	}()
+
+	SampleRoute("getUser", 0)
+	ws := createRuleTestWebService()
+
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/user/abc", nil))
+	assert.Empty(t, fields)
+
+	assert.NoError(t, ApplyProfile(ProfileCompliance))
+
+	fields, _ = serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/user/abc", nil))
+	assert.Equal(t, "getUser", fields[fieldOperation])
+}
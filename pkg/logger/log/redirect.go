@@ -0,0 +1,41 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"net/http"
+)
+
+const (
+	fieldRedirectLocation = "redirect_location"
+)
+
+// addRedirectFields adds the redirect target of the redirect response, e.g. the login redirect of the auth filter
+// or the canonical host redirect, so the redirect loop can be followed across the access log records.
+// The masked query param(s) of the target are masked the same way as the request path.
+func addRedirectFields(status int, respHeader http.Header, maskedQueryParams string, fields map[string]interface{}) {
+	if status < http.StatusMultipleChoices || status >= http.StatusBadRequest {
+		return
+	}
+
+	location := respHeader.Get("Location")
+	if location == "" {
+		return
+	}
+	if maskedQueryParams != "" {
+		location = MaskQueryParams(location, maskedQueryParams)
+	}
+	fields[fieldRedirectLocation] = location
+}
@@ -0,0 +1,81 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// nolint:paralleltest
+func TestAccessLog_Redirect(t *testing.T) {
+	FullAccessLogEnabled = true
+	defer func() {
+		FullAccessLogEnabled = false
+	}()
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Filter(func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		if req.Request.Header.Get("Authorization") == "" {
+			resp.Header().Set("Location", "/login?redirect_uri=/users&state=secret-state")
+			resp.WriteHeader(http.StatusTemporaryRedirect)
+			return
+		}
+		chain.ProcessFilter(req, resp)
+	})
+	ws.Route(ws.GET("/users").
+		Filter(Attribute(Option{MaskedQueryParams: "state"})).
+		To(func(request *restful.Request, response *restful.Response) {
+			response.Header().Set("Location", "/users/abc")
+			response.WriteHeader(http.StatusCreated)
+		}))
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	fields, _ := serveWithAccessLog(t, ws, req)
+
+	assert.Equal(t, float64(http.StatusTemporaryRedirect), fields[fieldStatus])
+	assert.Equal(t, "/login?redirect_uri=/users&state=secret-state", fields[fieldRedirectLocation])
+
+	req = httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("Authorization", "Bearer foo")
+	fields, _ = serveWithAccessLog(t, ws, req)
+
+	assert.Equal(t, float64(http.StatusCreated), fields[fieldStatus])
+	assert.NotContains(t, fields, fieldRedirectLocation)
+}
+
+func TestAddRedirectFields(t *testing.T) {
+	t.Parallel()
+
+	header := http.Header{}
+	header.Set("Location", "https://example.com/login?token=secret&next=/")
+
+	fields := map[string]interface{}{}
+	addRedirectFields(http.StatusPermanentRedirect, header, "token", fields)
+	assert.Equal(t, "https://example.com/login?token=******&next=/", fields[fieldRedirectLocation])
+
+	fields = map[string]interface{}{}
+	addRedirectFields(http.StatusFound, http.Header{}, "", fields)
+	assert.Empty(t, fields)
+
+	fields = map[string]interface{}{}
+	addRedirectFields(http.StatusOK, header, "", fields)
+	assert.Empty(t, fields)
+}
@@ -14,16 +14,165 @@
 
 package log
 
-import "net/http"
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+)
 
 // ResponseWriterInterceptor is used to decorate http.ResponseWriter,
-// so we can intercept the Write process
+// so we can intercept the Write process.
+// The written bytes are captured into a pooled buffer up to FullAccessLogMaxBodySize,
+// the rest is streamed without being captured.
+// It also passes through http.Flusher, http.Hijacker and http.CloseNotifier of the decorated http.ResponseWriter,
+// so the streaming endpoints (e.g. SSE, large file download) keep working.
 type ResponseWriterInterceptor struct {
 	http.ResponseWriter
-	data []byte
+	buffer       *bytes.Buffer
+	skipCapture  bool
+	bytesWritten int
+	truncated    bool
+	wroteHeader  bool
+	statusCode   int
+	// maxBodySize overrides FullAccessLogMaxBodySize when it's positive
+	maxBodySize int
+	hijacked    bool
+	// onHijack decorates the hijacked connection, e.g. to log the upgraded connection
+	onHijack func(net.Conn, *bufio.ReadWriter) (net.Conn, *bufio.ReadWriter, error)
+}
+
+// NewResponseWriterInterceptor creates the ResponseWriterInterceptor capturing up to maxBodySize bytes
+// of the response body written into the given http.ResponseWriter, e.g. to store the response in the other filters.
+// Zero maxBodySize captures up to FullAccessLogMaxBodySize. Release must be called after the captured body is used.
+func NewResponseWriterInterceptor(w http.ResponseWriter, maxBodySize int) *ResponseWriterInterceptor {
+	return &ResponseWriterInterceptor{ResponseWriter: w, maxBodySize: maxBodySize}
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *ResponseWriterInterceptor) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.statusCode = statusCode
+	}
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(statusCode)
 }
 
 func (w *ResponseWriterInterceptor) Write(b []byte) (int, error) {
-	w.data = b
-	return w.ResponseWriter.Write(b)
+	if !w.wroteHeader {
+		w.statusCode = http.StatusOK
+	}
+	w.wroteHeader = true
+	w.capture(b)
+	n, err := w.ResponseWriter.Write(b)
+	w.bytesWritten += n
+	return n, err
+}
+
+// capture appends the written bytes into the buffer up to the maximum body size
+func (w *ResponseWriterInterceptor) capture(b []byte) {
+	if w.skipCapture || w.truncated {
+		return
+	}
+	if w.buffer == nil {
+		w.buffer = getBodyBuffer()
+	}
+	maxBodySize := FullAccessLogMaxBodySize
+	if w.maxBodySize > 0 {
+		maxBodySize = w.maxBodySize
+	}
+	if w.buffer.Len()+len(b) > maxBodySize {
+		// the body is captured up to the maximum size, e.g. to log the truncated body
+		if remaining := maxBodySize - w.buffer.Len(); remaining > 0 {
+			w.buffer.Write(b[:remaining])
+		}
+		w.truncated = true
+		return
+	}
+	w.buffer.Write(b)
+}
+
+// nonNegativeBodySize clamps the negative maximum body size to zero, so nothing is captured instead of panicking
+func nonNegativeBodySize(size int) int {
+	if size < 0 {
+		return 0
+	}
+	return size
+}
+
+// Body returns the captured response body, it's only valid until Release is called
+func (w *ResponseWriterInterceptor) Body() []byte {
+	if w.buffer == nil {
+		return nil
+	}
+	return w.buffer.Bytes()
+}
+
+// Release puts the buffer back into the pool and stops capturing the response body
+func (w *ResponseWriterInterceptor) Release() {
+	w.skipCapture = true
+	putBodyBuffer(w.buffer)
+	w.buffer = nil
+}
+
+// BytesWritten returns the number of response body bytes written into the underlying http.ResponseWriter
+func (w *ResponseWriterInterceptor) BytesWritten() int {
+	return w.bytesWritten
+}
+
+// StatusCode returns the status code written into the underlying http.ResponseWriter,
+// or zero if the header has not been written yet
+func (w *ResponseWriterInterceptor) StatusCode() int {
+	return w.statusCode
+}
+
+// WroteHeader returns true if the response header has been written into the underlying http.ResponseWriter
+func (w *ResponseWriterInterceptor) WroteHeader() bool {
+	return w.wroteHeader
+}
+
+// Truncated returns true if the response body exceeds the maximum body size and is not fully captured
+func (w *ResponseWriterInterceptor) Truncated() bool {
+	return w.truncated
+}
+
+// Flush implements http.Flusher
+func (w *ResponseWriterInterceptor) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker
+func (w *ResponseWriterInterceptor) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the decorated http.ResponseWriter doesn't implement http.Hijacker")
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return conn, rw, err
+	}
+	w.hijacked = true
+	if w.onHijack != nil {
+		return w.onHijack(conn, rw)
+	}
+	return conn, rw, nil
+}
+
+// Hijacked returns true if the connection has been hijacked, e.g. upgraded into WebSocket
+func (w *ResponseWriterInterceptor) Hijacked() bool {
+	return w.hijacked
+}
+
+// CloseNotify implements http.CloseNotifier
+// nolint:staticcheck // http.CloseNotifier is deprecated but still used by the existing handlers
+func (w *ResponseWriterInterceptor) CloseNotify() <-chan bool {
+	if closeNotifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return closeNotifier.CloseNotify()
+	}
+	// never notify if the decorated http.ResponseWriter doesn't support it
+	return make(chan bool)
 }
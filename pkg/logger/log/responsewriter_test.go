@@ -0,0 +1,151 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseWriterInterceptor_PassThrough(t *testing.T) {
+	t.Parallel()
+
+	recorder := httptest.NewRecorder()
+	interceptor := &ResponseWriterInterceptor{ResponseWriter: recorder}
+
+	var _ http.Flusher = interceptor
+	var _ http.Hijacker = interceptor
+	var _ http.CloseNotifier = interceptor // nolint:staticcheck
+
+	interceptor.Flush()
+	assert.True(t, recorder.Flushed)
+
+	// httptest.ResponseRecorder doesn't implement http.Hijacker
+	_, _, err := interceptor.Hijack()
+	assert.Error(t, err)
+
+	assert.NotNil(t, interceptor.CloseNotify())
+}
+
+// nolint:paralleltest
+func TestResponseWriterInterceptor_CaptureLimit(t *testing.T) {
+	FullAccessLogMaxBodySize = 8
+
+	recorder := httptest.NewRecorder()
+	interceptor := &ResponseWriterInterceptor{ResponseWriter: recorder}
+
+	_, _ = interceptor.Write([]byte("abc"))
+	_, _ = interceptor.Write([]byte("def"))
+	assert.Equal(t, "abcdef", string(interceptor.Body()))
+	assert.False(t, interceptor.Truncated())
+
+	_, _ = interceptor.Write([]byte("ghi"))
+	_, _ = interceptor.Write([]byte("j"))
+	// the body is captured up to the limit
+	assert.Equal(t, "abcdefgh", string(interceptor.Body()))
+	assert.True(t, interceptor.Truncated())
+
+	// the whole response is still streamed
+	assert.Equal(t, "abcdefghij", recorder.Body.String())
+	assert.Equal(t, 10, interceptor.BytesWritten())
+}
+
+// nolint:paralleltest
+func TestResponseWriterInterceptor_NegativeCaptureLimit(t *testing.T) {
+	defer func(maxBodySize int) { FullAccessLogMaxBodySize = maxBodySize }(FullAccessLogMaxBodySize)
+	FullAccessLogMaxBodySize = -1
+
+	recorder := httptest.NewRecorder()
+	interceptor := &ResponseWriterInterceptor{ResponseWriter: recorder}
+
+	assert.NotPanics(t, func() {
+		_, _ = interceptor.Write([]byte("abc"))
+	})
+	assert.Empty(t, interceptor.Body())
+	assert.True(t, interceptor.Truncated())
+	assert.Equal(t, "abc", recorder.Body.String())
+
+	assert.Equal(t, 0, nonNegativeBodySize(-1))
+	assert.Equal(t, 8, nonNegativeBodySize(8))
+}
+
+func TestNewResponseWriterInterceptor(t *testing.T) {
+	t.Parallel()
+
+	recorder := httptest.NewRecorder()
+	interceptor := NewResponseWriterInterceptor(recorder, 4)
+	defer interceptor.Release()
+	assert.Equal(t, 0, interceptor.StatusCode())
+
+	interceptor.WriteHeader(http.StatusCreated)
+	interceptor.WriteHeader(http.StatusOK)
+	_, _ = interceptor.Write([]byte("abc"))
+	assert.Equal(t, http.StatusCreated, interceptor.StatusCode())
+	assert.Equal(t, "abc", string(interceptor.Body()))
+	assert.False(t, interceptor.Truncated())
+
+	_, _ = interceptor.Write([]byte("de"))
+	assert.Equal(t, "abcd", string(interceptor.Body()))
+	assert.True(t, interceptor.Truncated())
+	assert.Equal(t, "abcde", recorder.Body.String())
+
+	// the status code of the implicit header
+	interceptor = NewResponseWriterInterceptor(httptest.NewRecorder(), 0)
+	defer interceptor.Release()
+	_, _ = interceptor.Write([]byte("abc"))
+	assert.Equal(t, http.StatusOK, interceptor.StatusCode())
+}
+
+// nolint:paralleltest
+func TestAccessLog_StreamingResponse(t *testing.T) {
+	FullAccessLogEnabled = true
+	FullAccessLogMaxBodySize = 16
+	defer func() {
+		FullAccessLogEnabled = false
+		FullAccessLogMaxBodySize = 10 << 10
+	}()
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/events").
+		To(func(request *restful.Request, response *restful.Response) {
+			response.Header().Set("Content-Type", "text/plain")
+			for i := 0; i < 5; i++ {
+				_, _ = response.Write([]byte("data: event\n\n"))
+				response.Flush()
+			}
+		}))
+	ws.Route(ws.GET("/small").
+		To(func(request *restful.Request, response *restful.Response) {
+			response.Header().Set("Content-Type", "text/plain")
+			_, _ = response.Write([]byte("foo"))
+			_, _ = response.Write([]byte("bar"))
+		}))
+
+	fields, resp := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/events", nil))
+	assert.True(t, resp.Flushed)
+	assert.Equal(t, strings.Repeat("data: event\n\n", 5), resp.Body.String())
+	assert.Equal(t, "data too large", fields[fieldResponseBody])
+	assert.Equal(t, true, fields[fieldResponseTruncated])
+
+	fields, _ = serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/small", nil))
+	assert.Equal(t, "foobar", fields[fieldResponseBody])
+	assert.Nil(t, fields[fieldResponseTruncated])
+}
@@ -0,0 +1,137 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	accessLogRuleMutex sync.RWMutex
+	excludedPaths      []string
+	sampleRates        = map[string]float64{}
+	samplingEnabled    = true
+
+	randomFloat = rand.Float64
+)
+
+// ExcludePaths excludes the request path(s) from the access log, e.g. "/healthz", "/metrics".
+// A path ending with "*" will exclude all paths with that prefix, e.g. "/debug/*".
+func ExcludePaths(paths ...string) {
+	accessLogRuleMutex.Lock()
+	defer accessLogRuleMutex.Unlock()
+
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path != "" {
+			excludedPaths = append(excludedPaths, path)
+		}
+	}
+}
+
+// SampleRoute sets the sampling rate of the route's access log by its operation id.
+// The rate is between 0 (never logged) and 1 (always logged).
+func SampleRoute(operation string, rate float64) {
+	accessLogRuleMutex.Lock()
+	defer accessLogRuleMutex.Unlock()
+
+	if rate < 0 {
+		rate = 0
+	}
+	if rate > 1 {
+		rate = 1
+	}
+	sampleRates[operation] = rate
+}
+
+// ResetAccessLogRules removes all the exclusion and sampling rules
+func ResetAccessLogRules() {
+	accessLogRuleMutex.Lock()
+	defer accessLogRuleMutex.Unlock()
+
+	excludedPaths = nil
+	sampleRates = map[string]float64{}
+}
+
+// setSamplingEnabled enables or disables the sampling rules,
+// every request is logged when the sampling is disabled.
+func setSamplingEnabled(enabled bool) {
+	accessLogRuleMutex.Lock()
+	defer accessLogRuleMutex.Unlock()
+
+	samplingEnabled = enabled
+}
+
+// shouldLog evaluates the exclusion and sampling rules against the request
+func shouldLog(req *restful.Request) bool {
+	accessLogRuleMutex.RLock()
+	defer accessLogRuleMutex.RUnlock()
+
+	path := req.Request.URL.Path
+	for _, excludedPath := range excludedPaths {
+		if strings.HasSuffix(excludedPath, "*") {
+			if strings.HasPrefix(path, strings.TrimSuffix(excludedPath, "*")) {
+				return false
+			}
+		} else if path == excludedPath {
+			return false
+		}
+	}
+
+	if !samplingEnabled || len(sampleRates) == 0 {
+		return true
+	}
+
+	route := req.SelectedRoute()
+	if route == nil {
+		return true
+	}
+
+	rate, ok := sampleRates[route.Operation()]
+	if !ok || rate >= 1 {
+		return true
+	}
+
+	return randomFloat() < rate
+}
+
+// parseSampleRates parses the sampling rules in "operation1:rate1,operation2:rate2" format
+func parseSampleRates(s string) {
+	for _, rule := range strings.Split(s, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		separatorIndex := strings.LastIndex(rule, ":")
+		if separatorIndex == -1 {
+			logrus.Errorf("Parse FULL_ACCESS_LOG_SAMPLE_RATES env error: invalid rule %s", rule)
+			continue
+		}
+
+		rate, err := strconv.ParseFloat(rule[separatorIndex+1:], 64)
+		if err != nil {
+			logrus.Errorf("Parse FULL_ACCESS_LOG_SAMPLE_RATES env error: %v", err)
+			continue
+		}
+		SampleRoute(rule[:separatorIndex], rate)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRuleTestWebService() *restful.WebService {
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/healthz").Operation("healthz").
+		To(func(request *restful.Request, response *restful.Response) {}))
+	ws.Route(ws.GET("/debug/vars").Operation("debugVars").
+		To(func(request *restful.Request, response *restful.Response) {}))
+	ws.Route(ws.GET("/user/{id}").Operation("getUser").
+		To(func(request *restful.Request, response *restful.Response) {}))
+	return ws
+}
+
+// nolint:paralleltest
+func TestAccessLog_ExcludePaths(t *testing.T) {
+	defer ResetAccessLogRules()
+	ExcludePaths("/healthz", "/debug/*")
+
+	ws := createRuleTestWebService()
+
+	fields, resp := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Empty(t, fields)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	fields, _ = serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+	assert.Empty(t, fields)
+
+	fields, _ = serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/user/abc", nil))
+	assert.Equal(t, "getUser", fields[fieldOperation])
+}
+
+// nolint:paralleltest
+func TestAccessLog_SampleRoute(t *testing.T) {
+	defer func() {
+		ResetAccessLogRules()
+		randomFloat = rand.Float64
+	}()
+	SampleRoute("getUser", 0.1)
+
+	ws := createRuleTestWebService()
+
+	randomFloat = func() float64 { return 0.05 }
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/user/abc", nil))
+	assert.Equal(t, "getUser", fields[fieldOperation])
+
+	randomFloat = func() float64 { return 0.5 }
+	fields, _ = serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/user/abc", nil))
+	assert.Empty(t, fields)
+
+	// other route is not sampled
+	fields, _ = serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, "healthz", fields[fieldOperation])
+}
+
+// nolint:paralleltest
+func TestParseSampleRates(t *testing.T) {
+	defer ResetAccessLogRules()
+
+	parseSampleRates("getUser:0.1, healthz:0,invalid,bad:rate,tooHigh:2")
+
+	assert.Equal(t, map[string]float64{
+		"getUser": 0.1,
+		"healthz": 0,
+		"tooHigh": 1,
+	}, sampleRates)
+}
@@ -0,0 +1,218 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// FieldType is the data type of access log field
+type FieldType string
+
+const (
+	FieldTypeString  FieldType = "string"
+	FieldTypeInteger FieldType = "integer"
+	FieldTypeBoolean FieldType = "boolean"
+
+	accessLogSchemaName      = "AccessLog"
+	accessLogSchemaNamespace = "net.accelbyte.log"
+)
+
+// FieldDefinition describes a field of the structured access log record
+type FieldDefinition struct {
+	Name        string
+	Type        FieldType
+	Description string
+	// Required field is always present in the record, the optional field is only present when it has a value
+	Required bool
+}
+
+// accessLogFields is the list of structured access log fields, in the order of the text format
+var accessLogFields = []FieldDefinition{
+	{fieldTime, FieldTypeString, "Time when the request is completed in UTC, e.g. 2022-01-01T00:00:00.000Z", true},
+	{fieldLogType, FieldTypeString, "Type of the log record, always \"access\"", true},
+	{fieldMethod, FieldTypeString, "HTTP method", true},
+	{fieldPath, FieldTypeString, "Request URI with the masked query params", true},
+	{fieldStatus, FieldTypeInteger, "HTTP response status code", true},
+	{fieldDuration, FieldTypeInteger, "Duration of the request in milliseconds", true},
+	{fieldLength, FieldTypeInteger, "Response content length in bytes", true},
+	{fieldSourceIP, FieldTypeString, "Public IP address of the client", true},
+	{fieldUserAgent, FieldTypeString, "User-Agent request header", true},
+	{fieldReferer, FieldTypeString, "Referer request header", true},
+	{fieldTraceID, FieldTypeString, "Trace ID of the request", true},
+	{fieldNamespace, FieldTypeString, "Namespace of the request", true},
+	{fieldUserID, FieldTypeString, "User ID of the request", true},
+	{fieldClientID, FieldTypeString, "Client ID of the request", true},
+	{fieldRequestContentType, FieldTypeString, "Content-Type request header", true},
+	{fieldRequestBody, FieldTypeString, "Request body with the masked fields, \"-\" when not captured", true},
+	{fieldResponseContentType, FieldTypeString, "Content-Type response header", true},
+	{fieldResponseBody, FieldTypeString, "Response body with the masked fields, \"-\" when not captured", true},
+	{fieldOperation, FieldTypeString, "Route operation id", true},
+	{fieldResponseTruncated, FieldTypeBoolean, "Whether the response body exceeds the maximum body size and is not fully captured", false},
+	{fieldRequestBodyTruncated, FieldTypeBoolean, "Whether the logged request body is truncated into the first bytes", false},
+	{fieldRequestBodySize, FieldTypeInteger, "Original size of the truncated request body in bytes", false},
+	{fieldResponseBodyTruncated, FieldTypeBoolean, "Whether the logged response body is truncated into the first bytes", false},
+	{fieldResponseBodySize, FieldTypeInteger, "Original size of the truncated response body in bytes", false},
+	{fieldJourneyID, FieldTypeString, "Client-provided journey ID correlating the requests of a multi-request flow", false},
+	{fieldConsumerID, FieldTypeString, "Consumer (application) ID injected by the API gateway", false},
+	{fieldFlightID, FieldTypeString, "Flight ID of the game client launch from X-Flight-Id header", false},
+	{fieldGameClientVersion, FieldTypeString, "Game client version from Game-Client-Version header", false},
+	{fieldPlatform, FieldTypeString, "Platform of the game client from X-Ab-Platform header, e.g. steam", false},
+	{fieldRequestID, FieldTypeString, "Request ID from X-Request-Id header or generated by the request ID filter", false},
+	{fieldTokenIssuer, FieldTypeString, "Name of the IAM issuer which accepted the access token", false},
+	{fieldAuthMode, FieldTypeString, "Kind of credential which authenticated the request: user, client or api_key", false},
+	{fieldCache, FieldTypeString, "Cache status of the response: hit, miss or stale", false},
+	{fieldErrorCode, FieldTypeInteger, "Error code of the error response", false},
+	{fieldDependencies, FieldTypeString, "Downstream services called by the request as comma separated service:count:latency_ms:errors", false},
+	{fieldUpstreamThrottled, FieldTypeString, "Downstream services which throttled the request with 429 or 503 status as comma separated service:throttled:retry_after_ms", false},
+	{fieldRedirectLocation, FieldTypeString, "Target of the redirect response from the Location header", false},
+	{fieldDataClassification, FieldTypeString, "Data classification of the record", false},
+	{fieldPII, FieldTypeBoolean, "Whether the record contains personally identifiable information", false},
+	{fieldRetention, FieldTypeString, "Retention hint of the record, e.g. 30d", false},
+	{fieldAborted, FieldTypeBoolean, "Whether the client disconnected before the response was completed", false},
+	{fieldBytesWritten, FieldTypeInteger, "Response bytes written before the client disconnected", false},
+	{fieldUpgrade, FieldTypeString, "Protocol the connection is upgraded into, e.g. websocket", false},
+	{fieldConnectionState, FieldTypeString, "State of the upgraded connection: established or closed", false},
+	{fieldConnectionDuration, FieldTypeInteger, "Duration of the upgraded connection in milliseconds, only in the closed record", false},
+	{fieldBytesReceived, FieldTypeInteger, "Bytes received over the upgraded connection, only in the closed record", false},
+	{fieldBytesSent, FieldTypeInteger, "Bytes sent over the upgraded connection, only in the closed record", false},
+	{fieldRequestBodyReadsAfterEOF, FieldTypeInteger, "Number of request body reads after the body was fully consumed", false},
+	{fieldRequestBodyRewinds, FieldTypeInteger, "Number of times the request body was set back into the request", false},
+	{fieldDebug, FieldTypeBoolean, "Whether the debug access log is enabled for the request by X-Ab-Debug-Log header", false},
+	{fieldPanic, FieldTypeString, "Panic value raised while processing the request", false},
+	{fieldPanicStack, FieldTypeString, "Stack trace of the panic raised while processing the request", false},
+}
+
+// AccessLogFields returns the definition of the structured access log fields.
+// The allowlisted header fields (request_header_<name> and response_header_<name>)
+// and the custom fields added using AdditionalFields are not included.
+func AccessLogFields() []FieldDefinition {
+	return accessLogFieldDefinitions()
+}
+
+// accessLogFieldDefinitions returns the copy of the field definitions,
+// with the time and duration fields described by the configured time format and duration unit,
+// and without the fields trimmed by the field selection
+func accessLogFieldDefinitions() []FieldDefinition {
+	fields := make([]FieldDefinition, len(accessLogFields))
+	copy(fields, accessLogFields)
+	for i := range fields {
+		switch fields[i].Name {
+		case fieldTime:
+			switch strings.ToLower(FullAccessLogTimeFormat) {
+			case "":
+			case TimeFormatEpochMillis:
+				fields[i].Type = FieldTypeInteger
+				fields[i].Description = "Time when the request is completed in milliseconds since the Unix epoch"
+			case TimeFormatRFC3339Nano:
+				fields[i].Description = "Time when the request is completed in UTC, e.g. 2022-01-01T00:00:00.123456789Z"
+			default:
+				fields[i].Description = "Time when the request is completed in UTC, formatted as " + FullAccessLogTimeFormat
+			}
+		case fieldDuration:
+			if FullAccessLogDurationUnit == DurationUnitMicrosecond {
+				fields[i].Description = "Duration of the request in microseconds"
+			}
+		case fieldConnectionDuration:
+			if FullAccessLogDurationUnit == DurationUnitMicrosecond {
+				fields[i].Description = "Duration of the upgraded connection in microseconds, only in the closed record"
+			}
+		}
+	}
+
+	// the fields trimmed by FullAccessLogFields are never emitted
+	selected := fields[:0]
+	for _, field := range fields {
+		if isFieldSelected(field.Name) {
+			selected = append(selected, field)
+		}
+	}
+	return selected
+}
+
+// AccessLogJSONSchema returns the JSON Schema of the structured access log record
+func AccessLogJSONSchema() ([]byte, error) {
+	properties := make(map[string]interface{})
+	required := make([]string, 0)
+	for _, field := range accessLogFieldDefinitions() {
+		properties[field.Name] = map[string]interface{}{
+			"type":        string(field.Type),
+			"description": field.Description,
+		}
+		if field.Required {
+			required = append(required, field.Name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"$schema":    "http://json-schema.org/draft-07/schema#",
+		"$id":        accessLogSchemaNamespace + "." + accessLogSchemaName,
+		"title":      accessLogSchemaName,
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+		"patternProperties": map[string]interface{}{
+			"^(request|response)_header_": map[string]interface{}{
+				"type":        "string",
+				"description": "Allowlisted request or response header",
+			},
+		},
+		"additionalProperties": true,
+	}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// AccessLogAvroSchema returns the Avro schema of the structured access log record.
+// The optional field is represented as union of null and its type.
+func AccessLogAvroSchema() ([]byte, error) {
+	definitions := accessLogFieldDefinitions()
+	fields := make([]map[string]interface{}, 0, len(definitions))
+	for _, field := range accessLogFieldDefinitions() {
+		avroType := avroFieldType(field.Type)
+		avroField := map[string]interface{}{
+			"name": field.Name,
+			"doc":  field.Description,
+		}
+		if field.Required {
+			avroField["type"] = avroType
+		} else {
+			avroField["type"] = []string{"null", avroType}
+			avroField["default"] = nil
+		}
+		fields = append(fields, avroField)
+	}
+
+	schema := map[string]interface{}{
+		"type":      "record",
+		"name":      accessLogSchemaName,
+		"namespace": accessLogSchemaNamespace,
+		"fields":    fields,
+	}
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func avroFieldType(fieldType FieldType) string {
+	switch fieldType {
+	case FieldTypeInteger:
+		return "long"
+	case FieldTypeBoolean:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
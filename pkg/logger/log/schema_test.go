@@ -0,0 +1,83 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessLogFields_ContainsFormatFields(t *testing.T) {
+	t.Parallel()
+
+	names := map[string]bool{}
+	for _, field := range AccessLogFields() {
+		names[field.Name] = true
+		if fullAccessLogFormatFields[field.Name] {
+			assert.True(t, field.Required, field.Name)
+		}
+	}
+	for name := range fullAccessLogFormatFields {
+		assert.True(t, names[name], name)
+	}
+}
+
+func TestAccessLogJSONSchema(t *testing.T) {
+	t.Parallel()
+
+	result, err := AccessLogJSONSchema()
+	assert.NoError(t, err)
+
+	var schema struct {
+		Type       string                       `json:"type"`
+		Properties map[string]map[string]string `json:"properties"`
+		Required   []string                     `json:"required"`
+	}
+	assert.NoError(t, json.Unmarshal(result, &schema))
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, "integer", schema.Properties[fieldStatus]["type"])
+	assert.Equal(t, "boolean", schema.Properties[fieldAborted]["type"])
+	assert.Contains(t, schema.Required, fieldMethod)
+	assert.NotContains(t, schema.Required, fieldJourneyID)
+}
+
+func TestAccessLogAvroSchema(t *testing.T) {
+	t.Parallel()
+
+	result, err := AccessLogAvroSchema()
+	assert.NoError(t, err)
+
+	var schema struct {
+		Type   string `json:"type"`
+		Name   string `json:"name"`
+		Fields []struct {
+			Name    string      `json:"name"`
+			Type    interface{} `json:"type"`
+			Default interface{} `json:"default"`
+		} `json:"fields"`
+	}
+	assert.NoError(t, json.Unmarshal(result, &schema))
+	assert.Equal(t, "record", schema.Type)
+	assert.Equal(t, "AccessLog", schema.Name)
+
+	types := map[string]interface{}{}
+	for _, field := range schema.Fields {
+		types[field.Name] = field.Type
+	}
+	assert.Equal(t, "long", types[fieldStatus])
+	assert.Equal(t, []interface{}{"null", "boolean"}, types[fieldAborted])
+}
@@ -0,0 +1,177 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultAsyncWriterBufferSize = 1024
+)
+
+var (
+	fullAccessLogOutput io.Writer
+
+	// ErrAsyncWriterClosed is returned when writing into a closed AsyncWriter
+	ErrAsyncWriterClosed = errors.New("async writer is closed")
+)
+
+// SetAccessLogOutput sets the destination of the access log, e.g. a file, a socket or an AsyncWriter.
+// The destination is wrapped with FallbackWriter writing into os.Stderr when it fails. Default: os.Stdout
+func SetAccessLogOutput(out io.Writer) {
+	if out != nil {
+		out = withFallback(out)
+	}
+	fullAccessLogOutput = out
+	if fullAccessLogLogger != nil && out != nil {
+		fullAccessLogLogger.SetOutput(out)
+	}
+}
+
+// AsyncWriterOptions contains options for AsyncWriter
+type AsyncWriterOptions struct {
+	// Number of records that can be queued before applying back-pressure. Default: 1024
+	BufferSize int
+	// How long Write waits for free space in a full buffer before the record is dropped.
+	// Zero means the record is dropped immediately when the buffer is full.
+	MaxBlockDuration time.Duration
+}
+
+// AsyncWriter is an io.Writer that queues the records in a bounded buffer
+// and writes them into the underlying writer from a background goroutine,
+// so a slow destination doesn't block the request. The underlying writer is wrapped with FallbackWriter
+// writing into os.Stderr, since its failures are no longer visible to the caller of Write.
+type AsyncWriter struct {
+	out              io.Writer
+	queue            chan []byte
+	maxBlockDuration time.Duration
+	dropped          uint64
+	pending          int64
+
+	mutex  sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// NewAsyncWriter creates new AsyncWriter instance which writes into the given writer
+func NewAsyncWriter(out io.Writer, options *AsyncWriterOptions) *AsyncWriter {
+	if options == nil {
+		options = &AsyncWriterOptions{}
+	}
+	bufferSize := options.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultAsyncWriterBufferSize
+	}
+
+	w := &AsyncWriter{
+		out:              withFallback(out),
+		queue:            make(chan []byte, bufferSize),
+		maxBlockDuration: options.MaxBlockDuration,
+		done:             make(chan struct{}),
+	}
+	go w.run()
+
+	return w
+}
+
+// Write queues a copy of the record to be written asynchronously.
+// The record is dropped if the buffer is still full after MaxBlockDuration.
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	if w.closed {
+		return 0, ErrAsyncWriterClosed
+	}
+
+	record := make([]byte, len(p))
+	copy(record, p)
+
+	// counted before it's queued, so the written record is never counted as pending
+	atomic.AddInt64(&w.pending, 1)
+
+	select {
+	case w.queue <- record:
+		return len(p), nil
+	default:
+	}
+
+	if w.maxBlockDuration > 0 {
+		timer := time.NewTimer(w.maxBlockDuration)
+		defer timer.Stop()
+
+		select {
+		case w.queue <- record:
+			return len(p), nil
+		case <-timer.C:
+		}
+	}
+
+	atomic.AddInt64(&w.pending, -1)
+	atomic.AddUint64(&w.dropped, 1)
+
+	return len(p), nil
+}
+
+// Dropped returns the number of records dropped because the buffer was full
+func (w *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// ForceFlush waits until the queued records are written, or the context is done
+func (w *AsyncWriter) ForceFlush(ctx context.Context) error {
+	if err := waitUntil(ctx, func() bool { return atomic.LoadInt64(&w.pending) == 0 }); err != nil {
+		return err
+	}
+	return flushWriter(ctx, w.out)
+}
+
+// Close stops accepting new records and waits until the queued records are written
+func (w *AsyncWriter) Close() error {
+	w.mutex.Lock()
+	if w.closed {
+		w.mutex.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.queue)
+	w.mutex.Unlock()
+
+	<-w.done
+
+	if closer, ok := w.out.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+
+	for record := range w.queue {
+		if _, err := w.out.Write(record); err != nil {
+			logrus.Errorf("failed to write access log: %v", err)
+		}
+		atomic.AddInt64(&w.pending, -1)
+	}
+}
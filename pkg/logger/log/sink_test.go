@@ -0,0 +1,138 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type safeBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *safeBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *safeBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.String()
+}
+
+type blockingWriter struct {
+	release chan struct{}
+	out     safeBuffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return w.out.Write(p)
+}
+
+func TestAsyncWriter(t *testing.T) {
+	t.Parallel()
+
+	out := &safeBuffer{}
+	writer := NewAsyncWriter(out, nil)
+
+	record := []byte("first\n")
+	_, err := writer.Write(record)
+	assert.NoError(t, err)
+	record[0] = 'F' // the writer should keep its own copy
+	_, err = writer.Write([]byte("second\n"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, writer.Close())
+	assert.Equal(t, "first\nsecond\n", out.String())
+	assert.Equal(t, uint64(0), writer.Dropped())
+
+	_, err = writer.Write([]byte("third\n"))
+	assert.Equal(t, ErrAsyncWriterClosed, err)
+}
+
+func TestAsyncWriter_FailingSink(t *testing.T) {
+	t.Parallel()
+
+	primary := &toggleWriter{broken: true}
+	writer := NewAsyncWriter(primary, nil)
+
+	failures := AccessLogSinkFailures()
+	_, err := writer.Write([]byte("first\n"))
+	assert.NoError(t, err)
+	_, err = writer.Write([]byte("second\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	// the records failed to be written asynchronously fall back into os.Stderr and are counted
+	fallback, ok := writer.out.(*FallbackWriter)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(2), fallback.Failures())
+	assert.True(t, AccessLogSinkFailures() >= failures+2)
+}
+
+func TestAsyncWriter_DropWhenFull(t *testing.T) {
+	t.Parallel()
+
+	out := &blockingWriter{release: make(chan struct{})}
+	writer := NewAsyncWriter(out, &AsyncWriterOptions{BufferSize: 1})
+
+	// the first record is consumed by the background goroutine and blocks there,
+	// the second record fills the buffer
+	_, _ = writer.Write([]byte("1\n"))
+	assert.Eventually(t, func() bool { return len(writer.queue) == 0 }, time.Second, time.Millisecond)
+	_, _ = writer.Write([]byte("2\n"))
+
+	// buffer is full
+	_, err := writer.Write([]byte("3\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), writer.Dropped())
+
+	close(out.release)
+	assert.NoError(t, writer.Close())
+	assert.Equal(t, "1\n2\n", out.out.String())
+}
+
+func TestAsyncWriter_BlockWhenFull(t *testing.T) {
+	t.Parallel()
+
+	out := &blockingWriter{release: make(chan struct{})}
+	writer := NewAsyncWriter(out, &AsyncWriterOptions{BufferSize: 1, MaxBlockDuration: time.Second})
+
+	_, _ = writer.Write([]byte("1\n"))
+	assert.Eventually(t, func() bool { return len(writer.queue) == 0 }, time.Second, time.Millisecond)
+	_, _ = writer.Write([]byte("2\n"))
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(out.release)
+	}()
+
+	// wait until the buffer has free space
+	_, err := writer.Write([]byte("3\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), writer.Dropped())
+
+	assert.NoError(t, writer.Close())
+	assert.Equal(t, "1\n2\n3\n", out.out.String())
+}
@@ -0,0 +1,280 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// TimingAttribute is the request attribute holding the handler phase timings recorded by RecordTiming
+	TimingAttribute = "AccessLogTiming"
+
+	fieldThreshold  = "threshold"
+	fieldGoroutines = "goroutines"
+	fieldTimings    = "timings"
+
+	logTypeSlow = "slow"
+)
+
+var (
+	// FullAccessLogSlowThreshold is the duration after which the request is written into the slow log.
+	// Zero disables the slow log of the routes without their own threshold, see SlowRouteThreshold. Default: 0
+	FullAccessLogSlowThreshold time.Duration
+
+	slowThresholdMutex sync.RWMutex
+	slowThresholds     = map[string]time.Duration{}
+
+	slowRequests  uint64
+	slowLogOutput io.Writer
+	slowLogLogger *logrus.Logger
+)
+
+// SlowRouteThreshold sets the slow log threshold of the route by its operation id,
+// overriding FullAccessLogSlowThreshold. Zero excludes the route from the slow log.
+func SlowRouteThreshold(operation string, threshold time.Duration) {
+	slowThresholdMutex.Lock()
+	defer slowThresholdMutex.Unlock()
+
+	if threshold < 0 {
+		threshold = 0
+	}
+	slowThresholds[operation] = threshold
+}
+
+// ResetSlowRouteThresholds removes all the slow log thresholds of the routes
+func ResetSlowRouteThresholds() {
+	slowThresholdMutex.Lock()
+	defer slowThresholdMutex.Unlock()
+
+	slowThresholds = map[string]time.Duration{}
+}
+
+// SlowRequests returns the total number of the requests written into the slow log
+func SlowRequests() uint64 {
+	return atomic.LoadUint64(&slowRequests)
+}
+
+// SetSlowLogOutput sets the destination of the slow log, the destination is wrapped with FallbackWriter
+// writing into os.Stderr when it fails. Default: os.Stdout
+func SetSlowLogOutput(out io.Writer) {
+	if out != nil {
+		out = withFallback(out)
+	}
+	slowLogOutput = out
+	if slowLogLogger != nil && out != nil {
+		slowLogLogger.SetOutput(out)
+	}
+}
+
+// RecordTiming adds the duration of a handler phase (e.g. "db" or "render") into the request,
+// the timings are printed in the slow log. The durations of the same phase are summed.
+// It does nothing if the slow log is disabled.
+func RecordTiming(req *restful.Request, phase string, duration time.Duration) {
+	if timings, ok := req.Attribute(TimingAttribute).(*requestTimings); ok {
+		timings.add(phase, duration)
+	}
+}
+
+// StartTiming starts measuring a handler phase of the request, the returned function records its duration:
+//
+//	defer log.StartTiming(req, "db")()
+func StartTiming(req *restful.Request, phase string) func() {
+	start := accessLogClock.Now()
+	return func() {
+		RecordTiming(req, phase, accessLogClock.Now().Sub(start))
+	}
+}
+
+// requestTimings holds the handler phase timings of a request in the order they're first recorded
+type requestTimings struct {
+	mutex     sync.Mutex
+	phases    []string
+	durations map[string]time.Duration
+}
+
+func (t *requestTimings) add(phase string, duration time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if _, exists := t.durations[phase]; !exists {
+		t.phases = append(t.phases, phase)
+	}
+	t.durations[phase] += duration
+}
+
+// String formats the timings as "phase1:duration1,phase2:duration2" in FullAccessLogDurationUnit
+func (t *requestTimings) String() string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	timings := make([]string, 0, len(t.phases))
+	for _, phase := range t.phases {
+		timings = append(timings, phase+":"+strconv.FormatInt(formatDuration(t.durations[phase]), 10))
+	}
+	return strings.Join(timings, ",")
+}
+
+// slowLogEnabled returns whether any request can be written into the slow log
+func slowLogEnabled() bool {
+	if FullAccessLogSlowThreshold > 0 {
+		return true
+	}
+
+	slowThresholdMutex.RLock()
+	defer slowThresholdMutex.RUnlock()
+
+	return len(slowThresholds) > 0
+}
+
+// startTimings initializes the timings of the request, so the handler can record its phases
+func startTimings(req *restful.Request) {
+	if slowLogEnabled() {
+		req.SetAttribute(TimingAttribute, &requestTimings{durations: map[string]time.Duration{}})
+	}
+}
+
+// getSlowThreshold returns the slow log threshold of the operation
+func getSlowThreshold(operation string) time.Duration {
+	slowThresholdMutex.RLock()
+	defer slowThresholdMutex.RUnlock()
+
+	if threshold, ok := slowThresholds[operation]; ok {
+		return threshold
+	}
+	return FullAccessLogSlowThreshold
+}
+
+// logSlowRequest writes the entry into the slow log if its duration exceeds the threshold of its route
+func logSlowRequest(entry *AccessLogEntry) {
+	threshold := getSlowThreshold(entry.Operation)
+	if threshold <= 0 || entry.Duration <= threshold {
+		return
+	}
+
+	atomic.AddUint64(&slowRequests, 1)
+
+	fields := logrus.Fields{
+		fieldTime:       formatTime(entry.Time),
+		fieldLogType:    logTypeSlow,
+		fieldMethod:     entry.Method,
+		fieldPath:       entry.Path,
+		fieldStatus:     entry.Status,
+		fieldDuration:   formatDuration(entry.Duration),
+		fieldThreshold:  formatDuration(threshold),
+		fieldOperation:  entry.Operation,
+		fieldTraceID:    entry.TraceID,
+		fieldNamespace:  entry.Namespace,
+		fieldUserID:     entry.UserID,
+		fieldClientID:   entry.ClientID,
+		fieldGoroutines: runtime.NumGoroutine(),
+	}
+	for _, key := range []string{fieldRequestID, fieldDependencies, fieldUpstreamThrottled} {
+		if value, ok := entry.Extras[key]; ok {
+			fields[key] = value
+		}
+	}
+	if entry.Request != nil {
+		if timings, ok := entry.Request.Attribute(TimingAttribute).(*requestTimings); ok {
+			if s := timings.String(); s != "" {
+				fields[fieldTimings] = s
+			}
+		}
+	}
+
+	getSlowLogLogger().WithFields(fields).Info()
+}
+
+// slowLogFormatter represent logrus.Formatter,
+// this is used to print the slow log as a single key=value line in the text format.
+type slowLogFormatter struct {
+}
+
+func (f *slowLogFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		if key != fieldTime && key != fieldLogType {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	builder.WriteString(fieldTime + "=" + formatFieldValue(entry.Data[fieldTime]))
+	builder.WriteString(" " + fieldLogType + "=" + formatFieldValue(entry.Data[fieldLogType]))
+	for _, key := range keys {
+		builder.WriteString(" ")
+		builder.WriteString(key)
+		builder.WriteString("=")
+		builder.WriteString(formatFieldValue(entry.Data[key]))
+	}
+	builder.WriteString("\n")
+
+	return []byte(builder.String()), nil
+}
+
+// getSlowLogLogger initialize the dedicated logger for slow log if not yet initialized
+func getSlowLogLogger() *logrus.Logger {
+	if slowLogLogger == nil {
+		out := slowLogOutput
+		if out == nil {
+			out = withFallback(os.Stdout)
+		}
+		var formatter logrus.Formatter = &slowLogFormatter{}
+		if strings.EqualFold(FullAccessLogFormat, AccessLogFormatJSON) {
+			formatter = &fullAccessLogJSONFormatter{}
+		}
+		slowLogLogger = &logrus.Logger{
+			Out:       out,
+			Level:     logrus.GetLevel(),
+			Formatter: formatter,
+		}
+	}
+	return slowLogLogger
+}
+
+// parseSlowThresholds parses the slow log thresholds in "operation1:milliseconds1,operation2:milliseconds2" format
+func parseSlowThresholds(s string) {
+	for _, rule := range strings.Split(s, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		separatorIndex := strings.LastIndex(rule, ":")
+		if separatorIndex == -1 {
+			logrus.Errorf("Parse FULL_ACCESS_LOG_SLOW_THRESHOLDS env error: invalid rule %s", rule)
+			continue
+		}
+
+		milliseconds, err := strconv.ParseInt(rule[separatorIndex+1:], 0, 64)
+		if err != nil {
+			logrus.Errorf("Parse FULL_ACCESS_LOG_SLOW_THRESHOLDS env error: %v", err)
+			continue
+		}
+		SlowRouteThreshold(rule[:separatorIndex], time.Duration(milliseconds)*time.Millisecond)
+	}
+}
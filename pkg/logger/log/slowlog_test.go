@@ -0,0 +1,148 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// serveWithSlowLog serves the request and returns the slow log record
+func serveWithSlowLog(t *testing.T, ws *restful.WebService, req *http.Request) map[string]interface{} {
+	t.Helper()
+
+	buffer := new(bytes.Buffer)
+	slowLogLogger = &logrus.Logger{
+		Out:       buffer,
+		Level:     logrus.InfoLevel,
+		Formatter: &fullAccessLogJSONFormatter{},
+	}
+	defer func() {
+		slowLogLogger = nil
+	}()
+
+	serveWithAccessLog(t, ws, req)
+
+	fields := map[string]interface{}{}
+	if buffer.Len() > 0 {
+		assert.NoError(t, json.Unmarshal(buffer.Bytes(), &fields))
+	}
+	return fields
+}
+
+func newSlowLogWebService() *restful.WebService {
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/slow").
+		Operation("getSlow").
+		To(func(request *restful.Request, response *restful.Response) {
+			RecordTiming(request, "db", 30*time.Millisecond)
+			func() {
+				defer StartTiming(request, "render")()
+			}()
+			RecordTiming(request, "db", 20*time.Millisecond)
+			response.WriteHeader(http.StatusOK)
+		}))
+	return ws
+}
+
+// nolint:paralleltest
+func TestAccessLog_SlowLog(t *testing.T) {
+	FullAccessLogSlowThreshold = 200 * time.Millisecond
+	SetClock(&steppingClock{now: time.Unix(1641067445, 0), step: 100 * time.Millisecond})
+	defer func() {
+		FullAccessLogSlowThreshold = 0
+		SetClock(nil)
+	}()
+
+	slowRequestsBefore := SlowRequests()
+
+	req := httptest.NewRequest(http.MethodGet, "/slow", nil)
+	fields := serveWithSlowLog(t, newSlowLogWebService(), req)
+
+	assert.Equal(t, logTypeSlow, fields[fieldLogType])
+	assert.Equal(t, "/slow", fields[fieldPath])
+	assert.Equal(t, "getSlow", fields[fieldOperation])
+	assert.Equal(t, float64(300), fields[fieldDuration])
+	assert.Equal(t, float64(200), fields[fieldThreshold])
+	assert.Equal(t, "db:50,render:100", fields[fieldTimings])
+	assert.Greater(t, fields[fieldGoroutines], float64(0))
+	assert.Equal(t, slowRequestsBefore+1, SlowRequests())
+}
+
+// nolint:paralleltest
+func TestAccessLog_SlowLogRouteThreshold(t *testing.T) {
+	FullAccessLogSlowThreshold = 200 * time.Millisecond
+	SetClock(&steppingClock{now: time.Unix(1641067445, 0), step: 100 * time.Millisecond})
+	defer func() {
+		FullAccessLogSlowThreshold = 0
+		ResetSlowRouteThresholds()
+		SetClock(nil)
+	}()
+
+	SlowRouteThreshold("getSlow", time.Second)
+	fields := serveWithSlowLog(t, newSlowLogWebService(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	assert.Empty(t, fields)
+
+	SlowRouteThreshold("getSlow", 0)
+	fields = serveWithSlowLog(t, newSlowLogWebService(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	assert.Empty(t, fields)
+
+	FullAccessLogSlowThreshold = 0
+	SlowRouteThreshold("getSlow", 250*time.Millisecond)
+	fields = serveWithSlowLog(t, newSlowLogWebService(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	assert.Equal(t, float64(250), fields[fieldThreshold])
+}
+
+// nolint:paralleltest
+func TestAccessLog_SlowLogDisabled(t *testing.T) {
+	fields := serveWithSlowLog(t, newSlowLogWebService(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	assert.Empty(t, fields)
+}
+
+func TestSlowLogFormatter(t *testing.T) {
+	t.Parallel()
+
+	formatter := &slowLogFormatter{}
+	output, err := formatter.Format(&logrus.Entry{Data: logrus.Fields{
+		fieldTime:      "2022-01-01T00:00:00.000Z",
+		fieldLogType:   logTypeSlow,
+		fieldPath:      "/slow",
+		fieldDuration:  int64(300),
+		fieldOperation: "",
+	}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "time=2022-01-01T00:00:00.000Z log_type=slow duration=300 operation=\"\" path=/slow\n", string(output))
+}
+
+// nolint:paralleltest
+func TestParseSlowThresholds(t *testing.T) {
+	defer ResetSlowRouteThresholds()
+
+	parseSlowThresholds("getUser:500, listUsers:1000,invalid,bad:abc")
+
+	assert.Equal(t, 500*time.Millisecond, getSlowThreshold("getUser"))
+	assert.Equal(t, time.Second, getSlowThreshold("listUsers"))
+	assert.Equal(t, FullAccessLogSlowThreshold, getSlowThreshold("bad"))
+}
@@ -0,0 +1,153 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/constant"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/killswitch"
+	"github.com/emicklei/go-restful/v3"
+)
+
+// capturedRequestBodyAttribute is the key of the request body captured by the AccessLog filter
+const capturedRequestBodyAttribute = "LogCapturedRequestBody"
+
+// minSecretLength avoids masking short header values (e.g. "1") which would scrub unrelated text
+const minSecretLength = 8
+
+// RequestSnapshot is a copy of the request scrubbed using the masking configuration of the request,
+// safe to be attached to the panic and error reports.
+type RequestSnapshot struct {
+	Method  string            `json:"method"`
+	URI     string            `json:"uri"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// NewRequestSnapshot creates the scrubbed snapshot of the request.
+// The Authorization, Proxy-Authorization and Cookie headers, the sensitive headers,
+// and the masked query params, request fields and headers of the endpoint are masked.
+// The body is included only when it's captured by the AccessLog filter.
+func NewRequestSnapshot(req *restful.Request) RequestSnapshot {
+	masked := resolveMasking(req)
+
+	uri := req.Request.URL.RequestURI()
+	if masked.queryParams != "" {
+		uri = MaskQueryParams(uri, masked.queryParams)
+	}
+
+	headers := make(map[string]string, len(req.Request.Header))
+	for name, values := range req.Request.Header {
+		headers[name] = headerValue(values, isSensitiveHeader(name, masked.headers))
+	}
+
+	snapshot := RequestSnapshot{
+		Method:  req.Request.Method,
+		URI:     uri,
+		Headers: headers,
+	}
+
+	if body, ok := req.Attribute(capturedRequestBodyAttribute).(string); ok && body != "" {
+		snapshot.Body = MaskRequestBody(req, body)
+	}
+
+	return snapshot
+}
+
+// CaptureRequestBody returns the request body captured by the AccessLog filter, or captures it if it isn't,
+// so the filters needing the request body read it only once.
+// The body is empty if its content type is unsupported or the body capture is disabled by the kill switch.
+// The returned body isn't masked, see MaskRequestBody.
+func CaptureRequestBody(req *restful.Request) string {
+	if !bodyCaptureEnabled() {
+		return ""
+	}
+	if body, ok := req.Attribute(capturedRequestBodyAttribute).(string); ok {
+		return body
+	}
+
+	body := getRequestBody(req, req.HeaderParameter(constant.ContentType))
+	req.SetAttribute(capturedRequestBodyAttribute, body)
+	return body
+}
+
+// MaskRequestBody masks the request fields of the endpoint in the captured request body.
+// The body is dropped if the masking is disabled by the kill switch.
+// Call it after the chain is processed, since the masked fields are set by the inner filters.
+func MaskRequestBody(req *restful.Request, body string) string {
+	if killswitch.IsDisabled(killswitch.Masking) {
+		return ""
+	}
+	if masked := resolveMasking(req); masked.requestFields != "" && body != "" {
+		return MaskFields(req.HeaderParameter(constant.ContentType), body, masked.requestFields)
+	}
+	return body
+}
+
+// ScrubSecrets masks the values of the request's sensitive headers found in the text,
+// e.g. a panic message or an error message containing the access token.
+func ScrubSecrets(req *restful.Request, text string) string {
+	if text == "" {
+		return text
+	}
+
+	masked := resolveMasking(req)
+	for _, secret := range sensitiveHeaderSecrets(req.Request.Header, masked.headers) {
+		text = strings.ReplaceAll(text, secret, MaskedValue)
+	}
+
+	return text
+}
+
+// sensitiveHeaderSecrets returns the secret values of the sensitive headers, longest first,
+// e.g. both "Bearer <token>" and "<token>" of the Authorization header, and each cookie value.
+func sensitiveHeaderSecrets(header http.Header, maskedHeaders string) []string {
+	secrets := make([]string, 0)
+	for name, values := range header {
+		if !isSensitiveHeader(name, maskedHeaders) {
+			continue
+		}
+		for _, value := range values {
+			secrets = append(secrets, value)
+			if index := strings.IndexByte(value, ' '); index > 0 {
+				// the credentials of the authorization scheme, e.g. "Bearer <token>"
+				secrets = append(secrets, strings.TrimSpace(value[index+1:]))
+			}
+			if strings.EqualFold(name, "Cookie") {
+				for _, cookie := range (&http.Request{Header: http.Header{"Cookie": {value}}}).Cookies() {
+					secrets = append(secrets, cookie.Value)
+				}
+			}
+		}
+	}
+
+	result := secrets[:0]
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLength {
+			result = append(result, secret)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return len(result[i]) > len(result[j]) })
+
+	return result
+}
+
+// bodyCaptureEnabled checks the kill switches, the body isn't captured when it can't be masked
+func bodyCaptureEnabled() bool {
+	return !killswitch.IsDisabled(killswitch.BodyCapture) && !killswitch.IsDisabled(killswitch.Masking)
+}
@@ -0,0 +1,168 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	fieldTotalRequests = "total_requests"
+	fieldStatusCounts  = "status_counts"
+	fieldP95Duration   = "p95_duration"
+	fieldSince         = "since"
+
+	logTypeAccessSummary = "access_summary"
+
+	// the latency histogram buckets grow by 10% from 1µs, so the percentile is within 10% of the actual latency,
+	// the last bucket holds the latencies above ~10 hours
+	summaryBucketGrowth = 1.1
+	summaryBucketCount  = 256
+)
+
+// FullAccessLogSummaryEnabled enables the summary of the requests since the service started (total requests,
+// per-status counts and p95 duration), written by WriteAccessLogSummary on the graceful shutdown.
+// The summary counts every request passing the AccessLog filter, including the excluded and unsampled ones.
+var FullAccessLogSummaryEnabled bool
+
+var summaryLogBucketGrowth = math.Log(summaryBucketGrowth)
+
+// accessLogSummary aggregates the requests since the service started
+type accessLogSummary struct {
+	mutex       sync.Mutex
+	since       time.Time
+	total       uint64
+	statuses    map[int]uint64
+	buckets     [summaryBucketCount]uint64
+	maxDuration time.Duration
+}
+
+var summary = newAccessLogSummary(accessLogClock.Now())
+
+func newAccessLogSummary(since time.Time) *accessLogSummary {
+	return &accessLogSummary{since: since, statuses: map[int]uint64{}}
+}
+
+// ResetAccessLogSummary clears the aggregated requests and starts the summary from now
+func ResetAccessLogSummary() {
+	since := accessLogClock.Now()
+
+	summary.mutex.Lock()
+	defer summary.mutex.Unlock()
+
+	summary.since = since
+	summary.total = 0
+	summary.statuses = map[int]uint64{}
+	summary.buckets = [summaryBucketCount]uint64{}
+	summary.maxDuration = 0
+}
+
+// recordAccessLogSummary adds the request into the summary if it's enabled
+func recordAccessLogSummary(status int, duration time.Duration) {
+	if !FullAccessLogSummaryEnabled {
+		return
+	}
+
+	bucket := summaryBucket(duration)
+
+	summary.mutex.Lock()
+	defer summary.mutex.Unlock()
+
+	summary.total++
+	summary.statuses[status]++
+	summary.buckets[bucket]++
+	if duration > summary.maxDuration {
+		summary.maxDuration = duration
+	}
+}
+
+// WriteAccessLogSummary writes the summary of the requests since the service started into the access log output
+// as a record with access_summary log type, so the short-lived jobs and canaries leave a digest even when
+// the access log of their requests is sampled away. It's called by the graceful shutdown of the health package.
+// Nothing is written if FullAccessLogSummaryEnabled is false.
+func WriteAccessLogSummary() {
+	if !FullAccessLogSummaryEnabled {
+		return
+	}
+
+	fields := summary.fields()
+	fields[fieldTime] = formatTime(accessLogClock.Now())
+	fields[fieldLogType] = logTypeAccessSummary
+	getFullAccessLogLogger().WithFields(fields).Info()
+}
+
+// fields returns the summary fields, the status counts are sorted by the status, e.g. "200:10,404:2"
+func (s *accessLogSummary) fields() map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	statuses := make([]int, 0, len(s.statuses))
+	for status := range s.statuses {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+
+	counts := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		counts = append(counts, strconv.Itoa(status)+":"+strconv.FormatUint(s.statuses[status], 10))
+	}
+
+	return map[string]interface{}{
+		fieldTotalRequests: s.total,
+		fieldStatusCounts:  strings.Join(counts, ","),
+		fieldP95Duration:   formatDuration(s.percentile(0.95)),
+		fieldSince:         formatTime(s.since),
+	}
+}
+
+// percentile returns the upper bound of the histogram bucket holding the percentile, capped at the max duration
+func (s *accessLogSummary) percentile(p float64) time.Duration {
+	if s.total == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(p * float64(s.total)))
+	var cumulative uint64
+	for bucket, count := range s.buckets {
+		cumulative += count
+		if cumulative < rank {
+			continue
+		}
+		upperBound := time.Duration(math.Pow(summaryBucketGrowth, float64(bucket))) * time.Microsecond
+		if upperBound > s.maxDuration {
+			return s.maxDuration
+		}
+		return upperBound
+	}
+	return s.maxDuration
+}
+
+// summaryBucket returns the histogram bucket of the duration
+func summaryBucket(duration time.Duration) int {
+	micros := float64(duration) / float64(time.Microsecond)
+	if micros <= 1 {
+		return 0
+	}
+	bucket := int(math.Ceil(math.Log(micros) / summaryLogBucketGrowth))
+	if bucket >= summaryBucketCount {
+		return summaryBucketCount - 1
+	}
+	return bucket
+}
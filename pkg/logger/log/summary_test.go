@@ -0,0 +1,121 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/clock"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// nolint:paralleltest
+func TestAccessLog_Summary(t *testing.T) {
+	fakeClock := clock.NewFake(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	SetClock(fakeClock)
+	FullAccessLogSummaryEnabled = true
+	ResetAccessLogSummary()
+	ExcludePaths("/health")
+	defer func() {
+		SetClock(nil)
+		FullAccessLogSummaryEnabled = false
+		ResetAccessLogSummary()
+		ResetAccessLogRules()
+	}()
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/users/{id}").
+		To(func(request *restful.Request, response *restful.Response) {
+			if request.PathParameter("id") == "slow" {
+				fakeClock.Advance(500 * time.Millisecond)
+				response.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fakeClock.Advance(10 * time.Millisecond)
+		}))
+	ws.Route(ws.GET("/health").
+		To(func(request *restful.Request, response *restful.Response) {
+			fakeClock.Advance(time.Millisecond)
+		}))
+
+	for i := 0; i < 18; i++ {
+		serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/users/abc", nil))
+	}
+	serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/users/slow", nil))
+	// the excluded request is still counted
+	fields, _ := serveWithAccessLog(t, ws, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Empty(t, fields)
+
+	buffer := new(bytes.Buffer)
+	fullAccessLogLogger = &logrus.Logger{
+		Out:       buffer,
+		Level:     logrus.InfoLevel,
+		Formatter: &fullAccessLogJSONFormatter{},
+	}
+	defer func() {
+		fullAccessLogLogger = nil
+	}()
+
+	WriteAccessLogSummary()
+
+	summaryFields := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &summaryFields))
+	assert.Equal(t, logTypeAccessSummary, summaryFields[fieldLogType])
+	assert.Equal(t, "2022-01-01T00:00:00.000Z", summaryFields[fieldSince])
+	assert.Equal(t, "2022-01-01T00:00:00.681Z", summaryFields[fieldTime])
+	assert.Equal(t, float64(20), summaryFields[fieldTotalRequests])
+	assert.Equal(t, "200:19,404:1", summaryFields[fieldStatusCounts])
+	assert.InDelta(t, 10, summaryFields[fieldP95Duration], 1)
+
+	// nothing is written when the summary is disabled
+	buffer.Reset()
+	FullAccessLogSummaryEnabled = false
+	WriteAccessLogSummary()
+	assert.Empty(t, buffer.String())
+}
+
+func TestAccessLogSummary_Percentile(t *testing.T) {
+	t.Parallel()
+
+	s := newAccessLogSummary(time.Time{})
+	assert.Equal(t, time.Duration(0), s.percentile(0.95))
+
+	record := func(duration time.Duration) {
+		s.total++
+		s.buckets[summaryBucket(duration)]++
+		if duration > s.maxDuration {
+			s.maxDuration = duration
+		}
+	}
+	for i := 1; i <= 100; i++ {
+		record(time.Duration(i) * time.Millisecond)
+	}
+
+	p95 := s.percentile(0.95)
+	assert.True(t, p95 >= 95*time.Millisecond && p95 <= 105*time.Millisecond, p95)
+	assert.Equal(t, 100*time.Millisecond, s.percentile(1))
+
+	assert.Equal(t, 0, summaryBucket(0))
+	assert.Equal(t, 0, summaryBucket(time.Microsecond))
+	assert.Equal(t, summaryBucketCount-1, summaryBucket(24*time.Hour))
+}
@@ -0,0 +1,209 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	fieldRequestBodyTruncated  = "request_body_truncated"
+	fieldRequestBodySize       = "request_body_size"
+	fieldResponseBodyTruncated = "response_body_truncated"
+	fieldResponseBodySize      = "response_body_size"
+
+	// requestBodyTruncatedAttribute holds the original size of the truncated request body, -1 if it's unknown
+	requestBodyTruncatedAttribute = "LogRequestBodyTruncated"
+
+	anyContentType = "*"
+)
+
+var (
+	bodyTruncationMutex sync.RWMutex
+	bodyTruncations     = map[string]int{}
+)
+
+// TruncateBody logs the first size bytes of the body of the content type exceeding FullAccessLogMaxBodySize,
+// instead of replacing the whole body with "data too large".
+// The content type is matched as a substring of the Content-Type header, "*" matches any content type.
+// Zero size (or larger than FullAccessLogMaxBodySize) truncates the body into FullAccessLogMaxBodySize,
+// negative size removes the truncation of the content type.
+func TruncateBody(contentType string, size int) {
+	bodyTruncationMutex.Lock()
+	defer bodyTruncationMutex.Unlock()
+
+	if size < 0 {
+		delete(bodyTruncations, contentType)
+		return
+	}
+	bodyTruncations[contentType] = size
+}
+
+// ResetBodyTruncations removes the truncation of all content types
+func ResetBodyTruncations() {
+	bodyTruncationMutex.Lock()
+	defer bodyTruncationMutex.Unlock()
+
+	bodyTruncations = map[string]int{}
+}
+
+// parseBodyTruncations parses the comma separated content types with the optional size,
+// e.g. "application/json:2048,text/plain"
+func parseBodyTruncations(s string) {
+	for _, rule := range strings.Split(s, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+
+		separatorIndex := strings.LastIndex(rule, ":")
+		if separatorIndex == -1 {
+			TruncateBody(rule, 0)
+			continue
+		}
+
+		size, err := strconv.ParseInt(rule[separatorIndex+1:], 0, 64)
+		if err != nil {
+			logrus.Errorf("Parse FULL_ACCESS_LOG_BODY_TRUNCATION env error: %v", err)
+			continue
+		}
+		TruncateBody(rule[:separatorIndex], int(size))
+	}
+}
+
+// bodyTruncationSize returns the truncated size of the body of the content type,
+// the longest matching content type takes precedence
+func bodyTruncationSize(contentType string) (int, bool) {
+	bodyTruncationMutex.RLock()
+	defer bodyTruncationMutex.RUnlock()
+
+	matched := ""
+	size, ok := bodyTruncations[anyContentType]
+	for truncatedContentType, truncatedSize := range bodyTruncations {
+		if truncatedContentType == anyContentType || len(truncatedContentType) <= len(matched) {
+			continue
+		}
+		if strings.Contains(contentType, truncatedContentType) {
+			matched = truncatedContentType
+			size, ok = truncatedSize, true
+		}
+	}
+
+	if size == 0 || size > FullAccessLogMaxBodySize {
+		size = FullAccessLogMaxBodySize
+	}
+	return size, ok
+}
+
+// truncateBody cuts the body into the truncated size of the content type without splitting a UTF-8 character.
+// The JSON body is cut after the last complete value and its open objects and arrays are closed,
+// so the truncated body is still a valid JSON and masked as usual.
+func truncateBody(body []byte, contentType string) ([]byte, bool) {
+	size, ok := bodyTruncationSize(contentType)
+	if !ok {
+		return nil, false
+	}
+	if len(body) > size {
+		body = body[:size]
+	}
+
+	if strings.Contains(contentType, "json") {
+		if truncated := truncateJSON(body); len(truncated) > 0 {
+			return truncated, true
+		}
+	}
+	return truncateUTF8(body), true
+}
+
+// truncateUTF8 removes the incomplete UTF-8 character at the end of the body
+func truncateUTF8(body []byte) []byte {
+	for i := len(body) - 1; i >= 0 && i >= len(body)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(body[i]) {
+			if !utf8.FullRune(body[i:]) {
+				return body[:i]
+			}
+			break
+		}
+	}
+	return body
+}
+
+// truncateJSON cuts the JSON after its last complete member or element and closes the open objects and arrays.
+// It returns empty if there is no complete member, e.g. a truncated top level string.
+func truncateJSON(body []byte) []byte {
+	// the open objects and arrays below the depth of the last safe cut are never changed after it,
+	// since closing any of them moves the safe cut
+	stack := make([]byte, 0)
+	safe, safeDepth := 0, 0
+	inString, escaped := false, false
+
+	for i, c := range body {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			stack = append(stack, '}')
+			safe, safeDepth = i+1, len(stack)
+		case '[':
+			stack = append(stack, ']')
+			safe, safeDepth = i+1, len(stack)
+		case '}', ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			safe, safeDepth = i+1, len(stack)
+		case ',':
+			safe, safeDepth = i, len(stack)
+		}
+	}
+
+	truncated := make([]byte, 0, safe+safeDepth)
+	truncated = append(truncated, body[:safe]...)
+	for i := safeDepth - 1; i >= 0; i-- {
+		truncated = append(truncated, stack[i])
+	}
+	return truncated
+}
+
+// addRequestBodyTruncationFields marks the record whose request body is truncated along with its original size
+func addRequestBodyTruncationFields(req *restful.Request, fields logrus.Fields) {
+	size, ok := req.Attribute(requestBodyTruncatedAttribute).(int64)
+	if !ok {
+		return
+	}
+
+	fields[fieldRequestBodyTruncated] = true
+	if size >= 0 {
+		fields[fieldRequestBodySize] = size
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateJSON(t *testing.T) {
+	t.Parallel()
+
+	inputAndExpected := [][]string{
+		{
+			`{"a":1,"b":{"c":"xy`, // input
+			`{"a":1,"b":{}}`,      // expected
+		},
+		{
+			`[1,2,3`,
+			`[1,2]`,
+		},
+		{
+			`{"a":"x,y}`,
+			`{}`,
+		},
+		{
+			`{"a":[{"b":"\"},"},{"c`,
+			`{"a":[{"b":"\"},"},{}]}`,
+		},
+		{
+			`"abc`,
+			``,
+		},
+	}
+
+	for _, val := range inputAndExpected {
+		assert.Equal(t, val[1], string(truncateJSON([]byte(val[0]))))
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	t.Parallel()
+
+	body := []byte("héllo")
+	assert.Equal(t, "h", string(truncateUTF8(body[:2])))
+	assert.Equal(t, "hé", string(truncateUTF8(body[:3])))
+	assert.Equal(t, "héllo", string(truncateUTF8(body)))
+}
+
+// nolint:paralleltest
+func TestBodyTruncationSize(t *testing.T) {
+	FullAccessLogMaxBodySize = 100
+	defer func() {
+		FullAccessLogMaxBodySize = 10 << 10
+		ResetBodyTruncations()
+	}()
+
+	_, ok := bodyTruncationSize("application/json")
+	assert.False(t, ok)
+
+	parseBodyTruncations("application/json:20, json:30,*,text/plain:1000,invalid:abc")
+
+	size, ok := bodyTruncationSize("application/json; charset=utf-8")
+	assert.True(t, ok)
+	assert.Equal(t, 20, size)
+
+	size, ok = bodyTruncationSize("application/problem+json")
+	assert.True(t, ok)
+	assert.Equal(t, 30, size)
+
+	size, ok = bodyTruncationSize("text/plain")
+	assert.True(t, ok)
+	assert.Equal(t, 100, size)
+
+	size, ok = bodyTruncationSize("text/html")
+	assert.True(t, ok)
+	assert.Equal(t, 100, size)
+
+	TruncateBody("*", -1)
+	_, ok = bodyTruncationSize("text/html")
+	assert.False(t, ok)
+}
+
+// nolint:paralleltest
+func TestAccessLog_BodyTruncation(t *testing.T) {
+	FullAccessLogEnabled = true
+	FullAccessLogMaxBodySize = 40
+	TruncateBody("application/json", 0)
+	defer func() {
+		FullAccessLogEnabled = false
+		FullAccessLogMaxBodySize = 10 << 10
+		ResetBodyTruncations()
+	}()
+
+	responseBody := `{"token":"secret","items":["` + strings.Repeat("a", 30) + `"]}`
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.POST("/users").
+		Filter(Attribute(Option{MaskedRequestFields: "password", MaskedResponseFields: "token"})).
+		To(func(request *restful.Request, response *restful.Response) {
+			response.Header().Set("Content-Type", "application/json")
+			_, _ = response.Write([]byte(responseBody))
+		}))
+
+	requestBody := `{"name":"foo","password":"secret","bio":"` + strings.Repeat("b", 30) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	fields, _ := serveWithAccessLog(t, ws, req)
+
+	assert.Equal(t, `{"name":"foo","password":"******"}`, fields[fieldRequestBody])
+	assert.Equal(t, true, fields[fieldRequestBodyTruncated])
+	assert.Equal(t, float64(len(requestBody)), fields[fieldRequestBodySize])
+	assert.Equal(t, `{"token":"******","items":[]}`, fields[fieldResponseBody])
+	assert.Equal(t, true, fields[fieldResponseBodyTruncated])
+	assert.Equal(t, float64(len(responseBody)), fields[fieldResponseBodySize])
+
+	// the content type without truncation
+	req = httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(strings.Repeat("c", 50)))
+	req.Header.Set("Content-Type", "text/plain")
+	fields, _ = serveWithAccessLog(t, ws, req)
+
+	assert.Equal(t, "data too large", fields[fieldRequestBody])
+	assert.Nil(t, fields[fieldRequestBodyTruncated])
+}
@@ -0,0 +1,192 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/constant"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/trace"
+	publicsourceip "github.com/AccelByte/public-source-ip"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	fieldUpgrade            = "upgrade"
+	fieldConnectionState    = "connection_state"
+	fieldConnectionDuration = "connection_duration"
+	fieldBytesReceived      = "bytes_received"
+	fieldBytesSent          = "bytes_sent"
+
+	connectionStateEstablished = "established"
+	connectionStateClosed      = "closed"
+)
+
+// isUpgradeRequest checks whether the request asks to switch the protocol, e.g. into WebSocket
+func isUpgradeRequest(req *http.Request) bool {
+	if req.Header.Get("Upgrade") == "" {
+		return false
+	}
+	for _, value := range req.Header["Connection"] {
+		for _, token := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// upgradeLog writes the records of the upgraded connection:
+// the established record when the connection is hijacked, and the closed record when it's closed.
+type upgradeLog struct {
+	logger *logrus.Logger
+	req    *restful.Request
+	start  time.Time
+}
+
+// hijack wraps the hijacked connection to count the transferred bytes, and writes the established record
+func (u *upgradeLog) hijack(conn net.Conn, rw *bufio.ReadWriter) (net.Conn, *bufio.ReadWriter, error) {
+	established := accessLogClock.Now()
+	entry := newUpgradeEntry(u.req, u.start, established)
+
+	tracked := &trackedConn{Conn: conn}
+	tracked.onClose = func() {
+		closed := accessLogClock.Now()
+		closedEntry := *entry
+		closedEntry.Time = closed
+		closedEntry.Extras = make(map[string]interface{}, len(entry.Extras)+4)
+		for key, value := range entry.Extras {
+			closedEntry.Extras[key] = value
+		}
+		closedEntry.Extras[fieldConnectionState] = connectionStateClosed
+		closedEntry.Extras[fieldConnectionDuration] = formatDuration(closed.Sub(established))
+		closedEntry.Extras[fieldBytesReceived] = atomic.LoadInt64(&tracked.bytesRead)
+		closedEntry.Extras[fieldBytesSent] = atomic.LoadInt64(&tracked.bytesWritten)
+
+		writeAccessLogEntry(u.logger, &closedEntry)
+		publishAccessLogEntry(closedEntry)
+	}
+
+	// the bytes already buffered by the server are read before the connection
+	var reader io.Reader = tracked
+	if rw != nil && rw.Reader != nil && rw.Reader.Buffered() > 0 {
+		buffered, _ := rw.Reader.Peek(rw.Reader.Buffered())
+		tracked.bytesRead = int64(len(buffered))
+		reader = io.MultiReader(bytes.NewReader(append([]byte(nil), buffered...)), tracked)
+	}
+	if rw != nil && rw.Writer != nil {
+		if err := rw.Writer.Flush(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	entry.Extras[fieldConnectionState] = connectionStateEstablished
+	writeAccessLogEntry(u.logger, entry)
+	publishAccessLogEntry(*entry)
+
+	return tracked, bufio.NewReadWriter(bufio.NewReader(reader), bufio.NewWriter(tracked)), nil
+}
+
+// newUpgradeEntry builds the record of the upgraded connection, the bodies are never captured
+func newUpgradeEntry(req *restful.Request, start, end time.Time) *AccessLogEntry {
+	masked := resolveMasking(req)
+	requestURI := req.Request.URL.RequestURI()
+	if masked.queryParams != "" {
+		requestURI = MaskQueryParams(requestURI, masked.queryParams)
+	}
+
+	operation := ""
+	if selectedRoute := req.SelectedRoute(); selectedRoute != nil {
+		operation = selectedRoute.Operation()
+	}
+
+	namespace, userID, clientID, claims := getRequestIdentity(req)
+	traceID, _ := req.Attribute(trace.TraceIDKey).(string)
+
+	entry := &AccessLogEntry{
+		Time:               end,
+		Method:             req.Request.Method,
+		Path:               requestURI,
+		Status:             http.StatusSwitchingProtocols,
+		Duration:           end.Sub(start),
+		SourceIP:           publicsourceip.PublicIP(&http.Request{Header: req.Request.Header}),
+		UserAgent:          req.HeaderParameter(constant.UserAgent),
+		Referer:            req.HeaderParameter(constant.Referer),
+		TraceID:            traceID,
+		Namespace:          namespace,
+		UserID:             userID,
+		ClientID:           clientID,
+		Claims:             claims,
+		RequestContentType: req.HeaderParameter(constant.ContentType),
+		RequestBody:        "-",
+		ResponseBody:       "-",
+		Operation:          operation,
+		Extras: map[string]interface{}{
+			fieldUpgrade: strings.ToLower(req.HeaderParameter("Upgrade")),
+		},
+		Request: req,
+	}
+	if journeyID := trace.GetJourneyID(req); journeyID != "" {
+		entry.Extras[fieldJourneyID] = journeyID
+	}
+	if consumerID := trace.GetConsumerID(req); consumerID != "" {
+		entry.Extras[fieldConsumerID] = consumerID
+	}
+	if requestID := trace.GetRequestID(req); requestID != "" {
+		entry.Extras[fieldRequestID] = requestID
+	}
+	addClassificationFields(req, masked, entry.Extras)
+
+	return entry
+}
+
+// trackedConn is the hijacked net.Conn counting the transferred bytes,
+// it calls onClose once when the connection is closed
+type trackedConn struct {
+	net.Conn
+	bytesRead    int64
+	bytesWritten int64
+	onClose      func()
+	closeOnce    sync.Once
+}
+
+func (c *trackedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	atomic.AddInt64(&c.bytesRead, int64(n))
+	return n, err
+}
+
+func (c *trackedConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	atomic.AddInt64(&c.bytesWritten, int64(n))
+	return n, err
+}
+
+// Close closes the connection and writes the closed record
+func (c *trackedConn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(c.onClose)
+	return err
+}
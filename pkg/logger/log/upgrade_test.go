@@ -0,0 +1,148 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// hijackableRecorder is the httptest.ResponseRecorder which can be hijacked into the server side of a pipe
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+	conn net.Conn
+}
+
+func (r *hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return r.conn, bufio.NewReadWriter(bufio.NewReader(r.conn), bufio.NewWriter(r.conn)), nil
+}
+
+func TestIsUpgradeRequest(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	assert.False(t, isUpgradeRequest(req))
+
+	req.Header.Set("Upgrade", "websocket")
+	assert.False(t, isUpgradeRequest(req))
+
+	req.Header.Set("Connection", "keep-alive, Upgrade")
+	assert.True(t, isUpgradeRequest(req))
+}
+
+// nolint:paralleltest
+func TestAccessLog_UpgradedConnection(t *testing.T) {
+	FullAccessLogEnabled = true
+	defer func() {
+		FullAccessLogEnabled = false
+	}()
+
+	buffer := new(bytes.Buffer)
+	fullAccessLogLogger = &logrus.Logger{
+		Out:       buffer,
+		Level:     logrus.InfoLevel,
+		Formatter: &fullAccessLogJSONFormatter{},
+	}
+	defer func() {
+		fullAccessLogLogger = nil
+	}()
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/ws").
+		Operation("connect").
+		To(func(request *restful.Request, response *restful.Response) {
+			conn, rw, err := response.ResponseWriter.(http.Hijacker).Hijack()
+			if !assert.NoError(t, err) {
+				return
+			}
+			_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n\r\npong")
+			_ = rw.Flush()
+
+			message := make([]byte, 4)
+			_, _ = rw.Read(message)
+			_ = conn.Close()
+		}))
+
+	serverConn, clientConn := net.Pipe()
+	done := make(chan []byte)
+	go func() {
+		_, _ = clientConn.Write([]byte("ping"))
+	}()
+	go func() {
+		received, _ := ioutil.ReadAll(clientConn)
+		done <- received
+	}()
+
+	req := httptest.NewRequest(http.MethodGet, "/ws?token=secret", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "WebSocket")
+
+	container := restful.NewContainer()
+	container.Add(ws)
+	container.ServeHTTP(&hijackableRecorder{ResponseRecorder: httptest.NewRecorder(), conn: serverConn}, req)
+	assert.Equal(t, "HTTP/1.1 101 Switching Protocols\r\n\r\npong", string(<-done))
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if !assert.Len(t, lines, 2) {
+		return
+	}
+
+	established := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &established))
+	assert.Equal(t, float64(http.StatusSwitchingProtocols), established[fieldStatus])
+	assert.Equal(t, "connect", established[fieldOperation])
+	assert.Equal(t, "websocket", established[fieldUpgrade])
+	assert.Equal(t, connectionStateEstablished, established[fieldConnectionState])
+	assert.Equal(t, "-", established[fieldResponseBody])
+	assert.Nil(t, established[fieldBytesSent])
+
+	closed := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &closed))
+	assert.Equal(t, float64(http.StatusSwitchingProtocols), closed[fieldStatus])
+	assert.Equal(t, connectionStateClosed, closed[fieldConnectionState])
+	assert.Equal(t, float64(len("ping")), closed[fieldBytesReceived])
+	assert.Equal(t, float64(len("HTTP/1.1 101 Switching Protocols\r\n\r\npong")), closed[fieldBytesSent])
+	assert.NotNil(t, closed[fieldConnectionDuration])
+}
+
+// nolint:paralleltest
+func TestAccessLog_UpgradeRejected(t *testing.T) {
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/ws").
+		To(func(request *restful.Request, response *restful.Response) {
+			response.WriteHeader(http.StatusBadRequest)
+		}))
+
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	fields, _ := serveWithAccessLog(t, ws, req)
+
+	assert.Equal(t, float64(http.StatusBadRequest), fields[fieldStatus])
+	assert.Nil(t, fields[fieldConnectionState])
+}
@@ -0,0 +1,402 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// kind of the field level discrepancy between the access log formats
+const (
+	DiscrepancyMissing = "missing" // the field is only printed by the current format
+	DiscrepancyAdded   = "added"   // the field is only printed by the candidate format
+	DiscrepancyRenamed = "renamed" // the value of the missing field is printed under another name by the candidate format
+	DiscrepancyType    = "type"    // the field has the same value but a different type, e.g. "200" and 200
+	DiscrepancyValue   = "value"   // the field has a different value
+)
+
+// FormatDiscrepancy is a field level difference between the records printed by the current and the candidate formats
+type FormatDiscrepancy struct {
+	Field string `json:"field"`
+	Kind  string `json:"kind"`
+	// RenamedTo is the name of the renamed field in the candidate format
+	RenamedTo string      `json:"renamedTo,omitempty"`
+	Current   interface{} `json:"current,omitempty"`
+	Candidate interface{} `json:"candidate,omitempty"`
+}
+
+// key returns the aggregation key of the discrepancy, e.g. "status:type"
+func (d FormatDiscrepancy) key() string {
+	return d.Field + ":" + d.Kind
+}
+
+// FormatVerificationOptions contains options to verify the candidate access log format against the current one
+type FormatVerificationOptions struct {
+	// Candidate is the formatter to be verified, e.g. NewAccessLogFormatter(AccessLogFormatJSON)
+	Candidate AccessLogFormatter
+	// SampleRate is the fraction of the records verified, between 0 and 1. Default: 1
+	SampleRate float64
+	// Reporter receives the discrepancies of a record. Default: the first occurrence of each discrepancy is logged
+	Reporter func(discrepancies []FormatDiscrepancy)
+}
+
+var (
+	nextFieldPattern = regexp.MustCompile(`^ +[A-Za-z0-9_.-]+=`)
+
+	formatVerificationMutex sync.RWMutex
+	formatVerification      *FormatVerificationOptions
+	formatDiscrepancyCounts = map[string]uint64{}
+)
+
+// NewAccessLogFormatter returns the formatter printing the entry in the given format (text or json),
+// regardless of the configured FullAccessLogFormat
+func NewAccessLogFormatter(format string) AccessLogFormatter {
+	var formatter logrus.Formatter = &fullAccessLogFormatter{}
+	if strings.EqualFold(format, AccessLogFormatJSON) {
+		formatter = &fullAccessLogJSONFormatter{}
+	}
+	return AccessLogFormatterFunc(func(entry *AccessLogEntry) ([]byte, error) {
+		return formatter.Format(&logrus.Entry{Data: entry.Fields(), Time: entry.Time, Level: logrus.InfoLevel})
+	})
+}
+
+// VerifyAccessLogFormat formats the access log entries with the candidate formatter side by side with the current
+// format, and reports the field level discrepancies between them, to de-risk the switch into the new format.
+// Only the current format is written into the access log output. Nil options disables the verification.
+func VerifyAccessLogFormat(options *FormatVerificationOptions) {
+	formatVerificationMutex.Lock()
+	defer formatVerificationMutex.Unlock()
+
+	if options == nil || options.Candidate == nil {
+		formatVerification = nil
+		return
+	}
+
+	verification := *options
+	if verification.SampleRate <= 0 || verification.SampleRate > 1 {
+		verification.SampleRate = 1
+	}
+	if verification.Reporter == nil {
+		verification.Reporter = logFormatDiscrepancies
+	}
+	formatVerification = &verification
+	formatDiscrepancyCounts = map[string]uint64{}
+}
+
+// AccessLogFormatDiscrepancies returns the number of the verified records having each discrepancy,
+// keyed by the field and the kind of the discrepancy, e.g. "status:type"
+func AccessLogFormatDiscrepancies() map[string]uint64 {
+	formatVerificationMutex.RLock()
+	defer formatVerificationMutex.RUnlock()
+
+	counts := make(map[string]uint64, len(formatDiscrepancyCounts))
+	for key, count := range formatDiscrepancyCounts {
+		counts[key] = count
+	}
+	return counts
+}
+
+// verifyAccessLogFormat compares the records of the entry printed by the current and the candidate formats
+func verifyAccessLogFormat(entry *AccessLogEntry) {
+	formatVerificationMutex.RLock()
+	verification := formatVerification
+	formatVerificationMutex.RUnlock()
+
+	if verification == nil || (verification.SampleRate < 1 && randomFloat() >= verification.SampleRate) {
+		return
+	}
+
+	current := accessLogEntryFormatter
+	if current == nil {
+		current = DefaultAccessLogFormatter()
+	}
+
+	discrepancies, err := diffAccessLogRecords(current, verification.Candidate, entry)
+	if err != nil {
+		logrus.Errorf("failed to verify access log format: %v", err)
+		return
+	}
+	if len(discrepancies) == 0 {
+		return
+	}
+
+	formatVerificationMutex.Lock()
+	for _, discrepancy := range discrepancies {
+		formatDiscrepancyCounts[discrepancy.key()]++
+	}
+	formatVerificationMutex.Unlock()
+
+	verification.Reporter(discrepancies)
+}
+
+// logFormatDiscrepancies logs the discrepancies which are seen for the first time
+func logFormatDiscrepancies(discrepancies []FormatDiscrepancy) {
+	counts := AccessLogFormatDiscrepancies()
+	for _, discrepancy := range discrepancies {
+		if counts[discrepancy.key()] > 1 {
+			continue
+		}
+		switch discrepancy.Kind {
+		case DiscrepancyRenamed:
+			logrus.Warnf("access log format discrepancy: field %s is renamed to %s", discrepancy.Field, discrepancy.RenamedTo)
+		default:
+			logrus.Warnf("access log format discrepancy: field %s has %s discrepancy, current: %#v, candidate: %#v",
+				discrepancy.Field, discrepancy.Kind, discrepancy.Current, discrepancy.Candidate)
+		}
+	}
+}
+
+// diffAccessLogRecords formats the entry with both formatters and compares their fields
+func diffAccessLogRecords(current, candidate AccessLogFormatter, entry *AccessLogEntry) ([]FormatDiscrepancy, error) {
+	currentRecord, err := current.Format(entry)
+	if err != nil {
+		return nil, fmt.Errorf("unable to format current record: %v", err)
+	}
+	candidateRecord, err := candidate.Format(entry)
+	if err != nil {
+		return nil, fmt.Errorf("unable to format candidate record: %v", err)
+	}
+
+	currentFields, err := decodeAccessLogRecord(currentRecord)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode current record: %v", err)
+	}
+	candidateFields, err := decodeAccessLogRecord(candidateRecord)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode candidate record: %v", err)
+	}
+
+	return diffFields(currentFields, candidateFields), nil
+}
+
+// diffFields compares the decoded fields of the records, the discrepancies are sorted by the field
+func diffFields(current, candidate map[string]interface{}) []FormatDiscrepancy {
+	discrepancies := make([]FormatDiscrepancy, 0)
+	added := map[string]bool{}
+	for field := range candidate {
+		if _, ok := current[field]; !ok {
+			added[field] = true
+		}
+	}
+
+	fields := make([]string, 0, len(current))
+	for field := range current {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		currentValue := current[field]
+		candidateValue, ok := candidate[field]
+		if !ok {
+			discrepancies = append(discrepancies, missingDiscrepancy(field, currentValue, candidate, added))
+			continue
+		}
+
+		currentString, candidateString := valueString(currentValue), valueString(candidateValue)
+		switch {
+		case currentString != candidateString:
+			discrepancies = append(discrepancies, FormatDiscrepancy{
+				Field: field, Kind: DiscrepancyValue, Current: currentValue, Candidate: candidateValue})
+		case valueType(currentValue) != valueType(candidateValue):
+			discrepancies = append(discrepancies, FormatDiscrepancy{
+				Field: field, Kind: DiscrepancyType, Current: currentValue, Candidate: candidateValue})
+		}
+	}
+
+	addedFields := make([]string, 0, len(added))
+	for field := range added {
+		addedFields = append(addedFields, field)
+	}
+	sort.Strings(addedFields)
+	for _, field := range addedFields {
+		discrepancies = append(discrepancies, FormatDiscrepancy{
+			Field: field, Kind: DiscrepancyAdded, Candidate: candidate[field]})
+	}
+
+	return discrepancies
+}
+
+// missingDiscrepancy reports the missing field as renamed if an added field has the same non-empty value
+func missingDiscrepancy(field string, value interface{}, candidate map[string]interface{},
+	added map[string]bool) FormatDiscrepancy {
+	if s := valueString(value); s != "" && s != "-" {
+		renamed := ""
+		for addedField := range added {
+			if valueString(candidate[addedField]) == s && (renamed == "" || addedField < renamed) {
+				renamed = addedField
+			}
+		}
+		if renamed != "" {
+			delete(added, renamed)
+			return FormatDiscrepancy{Field: field, Kind: DiscrepancyRenamed, RenamedTo: renamed,
+				Current: value, Candidate: candidate[renamed]}
+		}
+	}
+	return FormatDiscrepancy{Field: field, Kind: DiscrepancyMissing, Current: value}
+}
+
+// valueString formats the decoded value to compare the values regardless of their types
+func valueString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case nil:
+		return ""
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(encoded)
+	}
+}
+
+// valueType returns the JSON type of the decoded value
+func valueType(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// decodeAccessLogRecord decodes the record printed in json or text format into its fields,
+// the values of the text format are decoded as strings
+func decodeAccessLogRecord(record []byte) (map[string]interface{}, error) {
+	record = bytes.TrimSpace(record)
+	if bytes.HasPrefix(record, []byte("{")) {
+		decoder := json.NewDecoder(bytes.NewReader(record))
+		decoder.UseNumber()
+		fields := map[string]interface{}{}
+		if err := decoder.Decode(&fields); err != nil {
+			return nil, err
+		}
+		return fields, nil
+	}
+
+	textFields, err := parseTextRecord(string(record))
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{}, len(textFields))
+	for key, value := range textFields {
+		fields[key] = value
+	}
+	return fields, nil
+}
+
+// parseTextRecord parses the record printed in text format into its fields.
+// The value is either unquoted, quoted, or a body wrapped with AB[ and ]AB.
+func parseTextRecord(line string) (map[string]string, error) {
+	fields := map[string]string{}
+	rest := strings.TrimSpace(line)
+	for rest != "" {
+		separatorIndex := strings.Index(rest, "=")
+		if separatorIndex <= 0 || strings.Contains(rest[:separatorIndex], " ") {
+			return nil, fmt.Errorf("invalid field at %q", truncateText(rest))
+		}
+		key := rest[:separatorIndex]
+		rest = rest[separatorIndex+1:]
+
+		var value string
+		var err error
+		switch {
+		case strings.HasPrefix(rest, "AB["):
+			value, rest, err = cutBody(rest[len("AB["):])
+		case strings.HasPrefix(rest, `"`):
+			value, rest, err = cutQuoted(rest)
+		default:
+			if spaceIndex := strings.Index(rest, " "); spaceIndex != -1 {
+				value, rest = rest[:spaceIndex], rest[spaceIndex:]
+			} else {
+				value, rest = rest, ""
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s field: %v", key, err)
+		}
+
+		fields[key] = value
+		rest = strings.TrimLeft(rest, " ")
+	}
+	return fields, nil
+}
+
+// cutBody returns the body value until the closing "]AB" delimiter, which isn't part of the escaped body
+func cutBody(s string) (string, string, error) {
+	index := strings.Index(s, "]AB")
+	if index == -1 {
+		return "", "", errors.New("missing closing ]AB")
+	}
+	return unescapeBodyValue(s[:index]), s[index+len("]AB"):], nil
+}
+
+// isFieldEnd checks whether the rest of the line starts with the next field or is empty,
+// since the values of the core fields are printed without escaping their quotes
+func isFieldEnd(rest string) bool {
+	return rest == "" || nextFieldPattern.MatchString(rest)
+}
+
+// cutQuoted returns the quoted value, the escaped value is unquoted and the raw value is kept as is
+func cutQuoted(s string) (string, string, error) {
+	for index := 1; index < len(s); index++ {
+		switch s[index] {
+		case '\\':
+			index++
+		case '"':
+			end := index + 1
+			if !isFieldEnd(s[end:]) {
+				continue
+			}
+			quoted := s[:end]
+			if value, err := strconv.Unquote(quoted); err == nil {
+				return value, s[end:], nil
+			}
+			return quoted[1 : len(quoted)-1], s[end:], nil
+		}
+	}
+	return "", "", errors.New("missing closing quote")
+}
+
+func truncateText(s string) string {
+	if len(s) > 32 {
+		return s[:32] + "..."
+	}
+	return s
+}
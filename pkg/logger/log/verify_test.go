@@ -0,0 +1,160 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTextRecord(t *testing.T) {
+	t.Parallel()
+
+	entry := &AccessLogEntry{
+		Time:               time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		Method:             http.MethodPost,
+		Path:               "/users?name=a b",
+		Status:             http.StatusCreated,
+		UserAgent:          `curl "quoted" agent`,
+		RequestContentType: "application/json",
+		RequestBody:        `{"note":"x]AB y"}`,
+		ResponseBody:       "-",
+		Extras:             map[string]interface{}{"message": `say "hi"`, "retries": 2},
+	}
+	record, err := NewAccessLogFormatter(AccessLogFormatText).Format(entry)
+	assert.NoError(t, err)
+
+	fields, err := parseTextRecord(string(record))
+	assert.NoError(t, err)
+	assert.Equal(t, "2022-01-01T00:00:00.000Z", fields[fieldTime])
+	assert.Equal(t, logTypeAccess, fields[fieldLogType])
+	assert.Equal(t, "/users?name=a b", fields[fieldPath])
+	assert.Equal(t, "201", fields[fieldStatus])
+	assert.Equal(t, `curl "quoted" agent`, fields[fieldUserAgent])
+	assert.Equal(t, `{"note":"x]AB y"}`, fields[fieldRequestBody])
+	assert.Equal(t, "-", fields[fieldResponseBody])
+	assert.Equal(t, "", fields[fieldReferer])
+	assert.Equal(t, `say "hi"`, fields["message"])
+	assert.Equal(t, "2", fields["retries"])
+
+	_, err = parseTextRecord(`time=now path="/unterminated`)
+	assert.Error(t, err)
+	_, err = parseTextRecord(`not a record`)
+	assert.Error(t, err)
+}
+
+func TestDiffFields(t *testing.T) {
+	t.Parallel()
+
+	current := map[string]interface{}{
+		"status":  "200",
+		"path":    "/users",
+		"method":  "GET",
+		"length":  "10",
+		"dropped": "",
+	}
+	candidate := map[string]interface{}{
+		"status": json.Number("200"),
+		"url":    "/users",
+		"method": "get",
+		"length": json.Number("10"),
+		"extra":  true,
+	}
+
+	assert.Equal(t, []FormatDiscrepancy{
+		{Field: "dropped", Kind: DiscrepancyMissing, Current: ""},
+		{Field: "length", Kind: DiscrepancyType, Current: "10", Candidate: json.Number("10")},
+		{Field: "method", Kind: DiscrepancyValue, Current: "GET", Candidate: "get"},
+		{Field: "path", Kind: DiscrepancyRenamed, RenamedTo: "url", Current: "/users", Candidate: "/users"},
+		{Field: "status", Kind: DiscrepancyType, Current: "200", Candidate: json.Number("200")},
+		{Field: "extra", Kind: DiscrepancyAdded, Candidate: true},
+	}, diffFields(current, candidate))
+
+	assert.Empty(t, diffFields(current, current))
+}
+
+// nolint:paralleltest
+func TestAccessLog_VerifyFormat(t *testing.T) {
+	var reported []FormatDiscrepancy
+	jsonFormatter := NewAccessLogFormatter(AccessLogFormatJSON)
+	VerifyAccessLogFormat(&FormatVerificationOptions{
+		Candidate: AccessLogFormatterFunc(func(entry *AccessLogEntry) ([]byte, error) {
+			record, err := jsonFormatter.Format(entry)
+			if err != nil {
+				return nil, err
+			}
+			fields := map[string]interface{}{}
+			if err = json.Unmarshal(record, &fields); err != nil {
+				return nil, err
+			}
+			fields["url"] = fields[fieldPath]
+			delete(fields, fieldPath)
+			return json.Marshal(fields)
+		}),
+		Reporter: func(discrepancies []FormatDiscrepancy) {
+			reported = discrepancies
+		},
+	})
+	defer VerifyAccessLogFormat(nil)
+
+	ws := new(restful.WebService)
+	ws.Filter(AccessLog)
+	ws.Route(ws.GET("/verify").
+		To(func(request *restful.Request, response *restful.Response) {
+			response.WriteHeader(http.StatusOK)
+		}))
+
+	// the current format is the text format
+	fullAccessLogLogger = &logrus.Logger{
+		Out:       new(nopWriter),
+		Level:     logrus.InfoLevel,
+		Formatter: &fullAccessLogFormatter{},
+	}
+	defer func() {
+		fullAccessLogLogger = nil
+	}()
+
+	container := restful.NewContainer()
+	container.Add(ws)
+	for i := 0; i < 2; i++ {
+		container.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/verify", nil))
+	}
+
+	kinds := map[string]string{}
+	for _, discrepancy := range reported {
+		kinds[discrepancy.Field] = discrepancy.Kind
+	}
+	assert.Equal(t, DiscrepancyRenamed, kinds[fieldPath])
+	assert.Equal(t, DiscrepancyType, kinds[fieldStatus])
+	assert.Equal(t, DiscrepancyType, kinds[fieldDuration])
+	assert.NotContains(t, kinds, fieldMethod)
+
+	counts := AccessLogFormatDiscrepancies()
+	assert.Equal(t, uint64(2), counts["path:renamed"])
+	assert.Equal(t, uint64(2), counts["status:type"])
+}
+
+type nopWriter struct{}
+
+func (nopWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package longpoll
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/response"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// WaitQueryParam is the query param of the duration in seconds the client wants to wait, capped by MaxWait
+	WaitQueryParam = "wait"
+
+	defaultMaxWait        = 30 * time.Second
+	defaultKeepAlive      = "\n"
+	defaultDeadlineMargin = 100 * time.Millisecond
+
+	fieldLongPollWait    = "long_poll_wait"
+	fieldLongPollTimeout = "long_poll_timeout"
+)
+
+// Options contains options for the long-poll wait
+type Options struct {
+	// MaxWait is the maximum duration the request waits for the result. Default: 30 seconds
+	MaxWait time.Duration
+	// KeepAliveInterval is the interval of writing KeepAlive while waiting, so the proxies don't close
+	// the idle connection. The 200 status is sent with the first keep-alive. Default: 0 (disabled)
+	KeepAliveInterval time.Duration
+	// KeepAlive is written every KeepAliveInterval, e.g. ":\n\n" comment for text/event-stream.
+	// Default: "\n", which is ignored by the JSON parsers
+	KeepAlive string
+}
+
+// WaitFunc waits until the result is available or the context is done.
+// It returns the context error when the context is done, and nil result when there's nothing new.
+type WaitFunc func(ctx context.Context) (interface{}, error)
+
+// Wait calls the wait function and responds 200 with the result in JSON format,
+// or 204 when the wait times out or the result is nil.
+// The wait is limited by MaxWait, the wait query param, and the deadline of the request context set by
+// the timeout filter, so the long-poll responds before the timeout filter does.
+// Nothing is sent when the client disconnects while waiting.
+// The wait duration in milliseconds is printed as long_poll_wait field in the access log,
+// and the timed out wait is printed as long_poll_timeout field.
+func Wait(req *restful.Request, resp *restful.Response, options Options, wait WaitFunc) {
+	ctx, cancel := context.WithTimeout(req.Request.Context(), waitDuration(req, options))
+	defer cancel()
+
+	start := time.Now()
+	result, committed, err := waitWithKeepAlive(ctx, resp, options, wait)
+
+	fields := map[string]interface{}{fieldLongPollWait: time.Since(start).Milliseconds()}
+
+	switch {
+	case req.Request.Context().Err() != nil:
+		// the client gave up waiting, the access log records the aborted request
+		log.AdditionalFields(req, fields)
+		return
+	case err == nil && result != nil:
+		log.AdditionalFields(req, fields)
+		writeResult(resp, result, committed)
+	case err == nil || errors.Is(err, context.DeadlineExceeded):
+		fields[fieldLongPollTimeout] = err != nil
+		log.AdditionalFields(req, fields)
+		if !committed {
+			resp.WriteHeader(http.StatusNoContent)
+		}
+	default:
+		log.AdditionalFields(req, fields)
+		if committed {
+			logrus.Errorf("%s %s: %v", req.Request.Method, req.Request.URL.Path, err)
+			return
+		}
+		response.WriteErrorEnvelope(req, resp, http.StatusInternalServerError, err)
+	}
+}
+
+// waitDuration returns the shortest of MaxWait, the wait query param, and the time left before the deadline
+// of the request context
+func waitDuration(req *restful.Request, options Options) time.Duration {
+	duration := options.MaxWait
+	if duration <= 0 {
+		duration = defaultMaxWait
+	}
+
+	if s := req.QueryParameter(WaitQueryParam); s != "" {
+		if seconds, err := strconv.Atoi(s); err == nil && seconds >= 0 {
+			if requested := time.Duration(seconds) * time.Second; requested < duration {
+				duration = requested
+			}
+		}
+	}
+
+	if deadline, ok := req.Request.Context().Deadline(); ok {
+		if left := time.Until(deadline) - defaultDeadlineMargin; left < duration {
+			duration = left
+		}
+	}
+
+	return duration
+}
+
+// waitWithKeepAlive calls the wait function while writing the keep-alive, it returns whether the response
+// header is already written
+func waitWithKeepAlive(ctx context.Context, resp *restful.Response, options Options,
+	wait WaitFunc) (result interface{}, committed bool, err error) {
+	if options.KeepAliveInterval <= 0 {
+		result, err = wait(ctx)
+		return result, false, err
+	}
+
+	keepAlive := options.KeepAlive
+	if keepAlive == "" {
+		keepAlive = defaultKeepAlive
+	}
+
+	type outcome struct {
+		result interface{}
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := wait(ctx)
+		done <- outcome{result, err}
+	}()
+
+	ticker := time.NewTicker(options.KeepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case o := <-done:
+			return o.result, committed, o.err
+		case <-ticker.C:
+			if !committed {
+				resp.Header().Set(restful.HEADER_ContentType, restful.MIME_JSON)
+				resp.WriteHeader(http.StatusOK)
+				committed = true
+			}
+			if _, writeErr := resp.Write([]byte(keepAlive)); writeErr != nil {
+				logrus.Debugf("unable to write long-poll keep-alive: %v", writeErr)
+			}
+			resp.Flush()
+		}
+	}
+}
+
+func writeResult(resp *restful.Response, result interface{}, committed bool) {
+	if !committed {
+		if err := resp.WriteHeaderAndJson(http.StatusOK, result, restful.MIME_JSON); err != nil {
+			logrus.Error(err)
+		}
+		return
+	}
+
+	if err := json.NewEncoder(resp).Encode(result); err != nil {
+		logrus.Error(err)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package longpoll
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// createTestContainer creates container whose /events route waits using the wait function
+func createTestContainer(options Options, wait WaitFunc, filters ...restful.FilterFunction) *restful.Container {
+	ws := new(restful.WebService)
+	for _, filter := range filters {
+		ws.Filter(filter)
+	}
+	ws.Route(ws.GET("/events").
+		To(func(request *restful.Request, response *restful.Response) {
+			Wait(request, response, options, wait)
+		}))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	return container
+}
+
+func serve(container *restful.Container, path string) *httptest.ResponseRecorder {
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, path, nil))
+	return resp
+}
+
+// waitForEvent returns the event after the delay, or the context error
+func waitForEvent(delay time.Duration) WaitFunc {
+	return func(ctx context.Context) (interface{}, error) {
+		select {
+		case <-time.After(delay):
+			return map[string]string{"event": "updated"}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func TestWait_Result(t *testing.T) {
+	t.Parallel()
+
+	container := createTestContainer(Options{MaxWait: time.Second}, waitForEvent(time.Millisecond))
+
+	resp := serve(container, "/events")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"event":"updated"}`, resp.Body.String())
+}
+
+func TestWait_Timeout(t *testing.T) {
+	t.Parallel()
+
+	container := createTestContainer(Options{MaxWait: 10 * time.Millisecond}, waitForEvent(time.Minute))
+
+	start := time.Now()
+	resp := serve(container, "/events")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Empty(t, resp.Body.String())
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+}
+
+func TestWait_NoResult(t *testing.T) {
+	t.Parallel()
+
+	container := createTestContainer(Options{}, func(ctx context.Context) (interface{}, error) {
+		return nil, nil
+	})
+
+	assert.Equal(t, http.StatusNoContent, serve(container, "/events").Code)
+}
+
+func TestWait_Error(t *testing.T) {
+	t.Parallel()
+
+	container := createTestContainer(Options{}, func(ctx context.Context) (interface{}, error) {
+		return nil, errors.New("subscription failed")
+	})
+
+	resp := serve(container, "/events")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.JSONEq(t, `{"errorCode":20000,"errorMessage":"internal server error"}`, resp.Body.String())
+}
+
+func TestWait_Duration(t *testing.T) {
+	t.Parallel()
+
+	var deadline time.Duration
+	wait := func(ctx context.Context) (interface{}, error) {
+		d, _ := ctx.Deadline()
+		deadline = time.Until(d)
+		return nil, nil
+	}
+
+	// the wait query param can only shorten MaxWait
+	serve(createTestContainer(Options{MaxWait: time.Minute}, wait), "/events?wait=5")
+	assert.InDelta(t, float64(5*time.Second), float64(deadline), float64(time.Second))
+
+	serve(createTestContainer(Options{MaxWait: time.Minute}, wait), "/events?wait=600")
+	assert.InDelta(t, float64(time.Minute), float64(deadline), float64(time.Second))
+
+	// the long-poll responds before the deadline of the timeout filter
+	timeoutFilter := func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		ctx, cancel := context.WithTimeout(req.Request.Context(), 2*time.Second)
+		defer cancel()
+		req.Request = req.Request.WithContext(ctx)
+		chain.ProcessFilter(req, resp)
+	}
+	serve(createTestContainer(Options{MaxWait: time.Minute}, wait, timeoutFilter), "/events")
+	assert.Less(t, int64(deadline), int64(2*time.Second-defaultDeadlineMargin+10*time.Millisecond))
+}
+
+func TestWait_KeepAlive(t *testing.T) {
+	t.Parallel()
+
+	container := createTestContainer(Options{MaxWait: time.Second, KeepAliveInterval: 5 * time.Millisecond},
+		waitForEvent(30*time.Millisecond))
+
+	resp := serve(container, "/events")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.True(t, strings.HasPrefix(resp.Body.String(), "\n"))
+	assert.JSONEq(t, `{"event":"updated"}`, strings.TrimSpace(resp.Body.String()))
+}
+
+func TestWait_ClientDisconnected(t *testing.T) {
+	t.Parallel()
+
+	container := createTestContainer(Options{MaxWait: time.Minute}, waitForEvent(time.Minute))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx))
+	assert.Empty(t, resp.Body.String())
+}
+
+// nolint:paralleltest
+func TestWait_AccessLog(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	log.SetAccessLogOutput(buffer)
+	log.FullAccessLogEnabled = true
+	defer func() {
+		log.SetAccessLogOutput(os.Stdout)
+		log.FullAccessLogEnabled = false
+	}()
+
+	container := createTestContainer(Options{MaxWait: 10 * time.Millisecond}, waitForEvent(time.Minute), log.AccessLog)
+	serve(container, "/events")
+
+	assert.Contains(t, buffer.String(), "status=204")
+	assert.Contains(t, buffer.String(), fieldLongPollWait+"=")
+	assert.Contains(t, buffer.String(), fieldLongPollTimeout+"=true")
+}
@@ -0,0 +1,86 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterAccessLogSink registers the failure metric of the access log sink into the registerer,
+// so the fallback into the secondary sink can be alerted.
+// The Namespace, Subsystem and Registerer options are used the same way as NewFilter.
+func RegisterAccessLogSink(options *Options) error {
+	if options == nil {
+		options = &Options{}
+	}
+	registerer := options.Registerer
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+
+	return registerer.Register(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: options.Namespace,
+		Subsystem: options.Subsystem,
+		Name:      "access_log_sink_failures_total",
+		Help:      "Total number of the access log records failed to be written into the primary sink and written into the fallback sink.",
+	}, func() float64 {
+		return float64(log.AccessLogSinkFailures())
+	}))
+}
+
+// RegisterAccessLogHook registers the metric of the access log entries dropped because the hook queue was full,
+// so a slow access log hook can be alerted.
+// The Namespace, Subsystem and Registerer options are used the same way as NewFilter.
+func RegisterAccessLogHook(options *Options) error {
+	if options == nil {
+		options = &Options{}
+	}
+	registerer := options.Registerer
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+
+	return registerer.Register(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: options.Namespace,
+		Subsystem: options.Subsystem,
+		Name:      "access_log_hook_dropped_total",
+		Help:      "Total number of the access log entries dropped because the access log hook queue was full.",
+	}, func() float64 {
+		return float64(log.AccessLogHookDropped())
+	}))
+}
+
+// RegisterSlowRequests registers the metric of the requests written into the slow log,
+// so the tail latency of the service can be alerted.
+// The Namespace, Subsystem and Registerer options are used the same way as NewFilter.
+func RegisterSlowRequests(options *Options) error {
+	if options == nil {
+		options = &Options{}
+	}
+	registerer := options.Registerer
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+
+	return registerer.Register(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: options.Namespace,
+		Subsystem: options.Subsystem,
+		Name:      "slow_requests_total",
+		Help:      "Total number of the requests exceeding the slow log threshold.",
+	}, func() float64 {
+		return float64(log.SlowRequests())
+	}))
+}
@@ -0,0 +1,75 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestRegisterAccessLogSink(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	assert.NoError(t, RegisterAccessLogSink(&Options{Registerer: registry}))
+
+	writer := log.NewFallbackWriter(failingWriter{}, new(bytes.Buffer))
+	_, _ = writer.Write([]byte("record\n"))
+
+	expected := `
+# HELP access_log_sink_failures_total Total number of the access log records failed to be written into the primary sink and written into the fallback sink.
+# TYPE access_log_sink_failures_total counter
+access_log_sink_failures_total 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected)))
+}
+
+func TestRegisterAccessLogHook(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	assert.NoError(t, RegisterAccessLogHook(&Options{Registerer: registry}))
+
+	expected := `
+# HELP access_log_hook_dropped_total Total number of the access log entries dropped because the access log hook queue was full.
+# TYPE access_log_hook_dropped_total counter
+access_log_hook_dropped_total 0
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected)))
+}
+
+func TestRegisterSlowRequests(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	assert.NoError(t, RegisterSlowRequests(&Options{Registerer: registry, Namespace: "test"}))
+
+	count, err := testutil.GatherAndCount(registry, "test_slow_requests_total")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,60 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/cache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterCache registers the hit and miss metrics of the response cache into the registerer,
+// so the hit ratio of the cache can be monitored.
+// The Namespace, Subsystem and Registerer options are used the same way as NewFilter.
+func RegisterCache(options *Options) error {
+	if options == nil {
+		options = &Options{}
+	}
+	registerer := options.Registerer
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+
+	collectors := []prometheus.Collector{
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: options.Namespace,
+			Subsystem: options.Subsystem,
+			Name:      "http_cache_hits_total",
+			Help:      "Total number of the HTTP GET requests served from the response cache.",
+		}, func() float64 {
+			return float64(cache.GetStats().Hits)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: options.Namespace,
+			Subsystem: options.Subsystem,
+			Name:      "http_cache_misses_total",
+			Help:      "Total number of the HTTP GET requests served by the handler instead of the response cache.",
+		}, func() float64 {
+			return float64(cache.GetStats().Misses)
+		}),
+	}
+
+	for _, collector := range collectors {
+		if err := registerer.Register(collector); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
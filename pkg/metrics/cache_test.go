@@ -0,0 +1,36 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterCache(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	assert.NoError(t, RegisterCache(&Options{Registerer: registry, Namespace: "test"}))
+
+	count, err := testutil.GatherAndCount(registry, "test_http_cache_hits_total", "test_http_cache_misses_total")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.Error(t, RegisterCache(&Options{Registerer: registry, Namespace: "test"}))
+}
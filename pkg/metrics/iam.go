@@ -0,0 +1,78 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterIAMLocalValidation registers the metrics of the IAM filter's local validation cache into the registerer.
+// The Namespace, Subsystem and Registerer options are used the same way as NewFilter.
+func RegisterIAMLocalValidation(filter *iam.Filter, options *Options) error {
+	if options == nil {
+		options = &Options{}
+	}
+	registerer := options.Registerer
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+
+	collectors := []prometheus.Collector{
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: options.Namespace,
+			Subsystem: options.Subsystem,
+			Name:      "iam_local_validation_active",
+			Help:      "Whether the JWKS and revocation list for the IAM local token validation are loaded.",
+		}, func() float64 {
+			if filter.LocalValidationStats().Active {
+				return 1
+			}
+			return 0
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: options.Namespace,
+			Subsystem: options.Subsystem,
+			Name:      "iam_token_cache_size",
+			Help:      "Number of the validated tokens in the IAM local validation cache.",
+		}, func() float64 {
+			return float64(filter.LocalValidationStats().Size)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: options.Namespace,
+			Subsystem: options.Subsystem,
+			Name:      "iam_token_cache_hits_total",
+			Help:      "Total number of the tokens found in the IAM local validation cache.",
+		}, func() float64 {
+			return float64(filter.LocalValidationStats().Hits)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: options.Namespace,
+			Subsystem: options.Subsystem,
+			Name:      "iam_token_cache_misses_total",
+			Help:      "Total number of the tokens validated because they aren't in the IAM local validation cache.",
+		}, func() float64 {
+			return float64(filter.LocalValidationStats().Misses)
+		}),
+	}
+
+	for _, collector := range collectors {
+		if err := registerer.Register(collector); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	iamSDK "github.com/AccelByte/iam-go-sdk"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterIAMLocalValidation(t *testing.T) {
+	t.Parallel()
+
+	filter := iam.NewFilterWithOptions(&iamSDK.MockClient{Healthy: true}, &iam.FilterInitializationOptions{
+		LocalValidation: &iam.LocalValidationOptions{},
+	})
+
+	registry := prometheus.NewRegistry()
+	assert.NoError(t, RegisterIAMLocalValidation(filter, &Options{Namespace: "test", Registerer: registry}))
+
+	expected := `
+# HELP test_iam_local_validation_active Whether the JWKS and revocation list for the IAM local token validation are loaded.
+# TYPE test_iam_local_validation_active gauge
+test_iam_local_validation_active 1
+# HELP test_iam_token_cache_hits_total Total number of the tokens found in the IAM local validation cache.
+# TYPE test_iam_token_cache_hits_total counter
+test_iam_token_cache_hits_total 0
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"test_iam_local_validation_active", "test_iam_token_cache_hits_total"))
+}
@@ -0,0 +1,275 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/killswitch"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/trace"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metric labels
+const (
+	LabelOperation   = "operation"
+	LabelMethod      = "method"
+	LabelStatus      = "status"
+	LabelStatusClass = "status_class"
+	LabelRoute       = "route"
+	LabelConsumer    = "consumer"
+
+	unknownOperation   = "unknown"
+	unknownConsumer    = "unknown"
+	unknownStatusClass = "unknown"
+)
+
+// Options contains options for the metrics filter
+type Options struct {
+	// Namespace and Subsystem are prepended to the metric names, e.g. "justice_http_request_duration_seconds"
+	Namespace string
+	Subsystem string
+	// Registerer registers the metrics. Default: prometheus.DefaultRegisterer
+	Registerer prometheus.Registerer
+	// Buckets of http_request_duration_seconds histogram in seconds. Default: prometheus.DefBuckets
+	DurationBuckets []float64
+	// Buckets of http_request_size_bytes and http_response_size_bytes histograms in bytes.
+	// Default: 100B, 1KB, 10KB, 100KB, 1MB, 10MB
+	SizeBuckets []float64
+	// Labels of the metrics, a subset of LabelOperation, LabelMethod, LabelStatus and LabelConsumer,
+	// used to reduce the cardinality. Default: LabelOperation, LabelMethod and LabelStatus.
+	// LabelConsumer is the API gateway consumer ID, see trace.GetConsumerID
+	Labels []string
+	// GroupStatus labels the status by its class (e.g. "2xx") instead of the exact code. Default: false
+	GroupStatus bool
+}
+
+// Filter records the request metrics of the routes
+type Filter struct {
+	labels      map[string]bool
+	groupStatus bool
+
+	duration     *prometheus.HistogramVec
+	requests     *prometheus.CounterVec
+	requestSize  *prometheus.HistogramVec
+	responseSize *prometheus.HistogramVec
+	inFlight     *prometheus.GaugeVec
+}
+
+// NewFilter creates new Filter instance and registers its metrics into the registerer
+func NewFilter(options *Options) (*Filter, error) {
+	if options == nil {
+		options = &Options{}
+	}
+
+	registerer := options.Registerer
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+	durationBuckets := options.DurationBuckets
+	if len(durationBuckets) == 0 {
+		durationBuckets = prometheus.DefBuckets
+	}
+	sizeBuckets := options.SizeBuckets
+	if len(sizeBuckets) == 0 {
+		sizeBuckets = prometheus.ExponentialBuckets(100, 10, 6)
+	}
+
+	labels := options.Labels
+	if labels == nil {
+		labels = []string{LabelOperation, LabelMethod, LabelStatus}
+	}
+	filter := &Filter{labels: map[string]bool{}, groupStatus: options.GroupStatus}
+	for _, label := range labels {
+		switch label {
+		case LabelOperation, LabelMethod, LabelStatus, LabelConsumer:
+			filter.labels[label] = true
+		default:
+			return nil, fmt.Errorf("unsupported metric label %s", label)
+		}
+	}
+
+	routeLabels := filter.labelNames(false)
+	statusLabels := filter.labelNames(true)
+
+	filter.duration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: options.Namespace,
+		Subsystem: options.Subsystem,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of the HTTP requests in seconds.",
+		Buckets:   durationBuckets,
+	}, statusLabels)
+	filter.requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: options.Namespace,
+		Subsystem: options.Subsystem,
+		Name:      "http_requests_total",
+		Help:      "Total number of the HTTP requests by status class.",
+	}, append(routeLabels, LabelStatusClass))
+	filter.requestSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: options.Namespace,
+		Subsystem: options.Subsystem,
+		Name:      "http_request_size_bytes",
+		Help:      "Size of the HTTP request bodies in bytes.",
+		Buckets:   sizeBuckets,
+	}, routeLabels)
+	filter.responseSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: options.Namespace,
+		Subsystem: options.Subsystem,
+		Name:      "http_response_size_bytes",
+		Help:      "Size of the HTTP response bodies in bytes.",
+		Buckets:   sizeBuckets,
+	}, statusLabels)
+	filter.inFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: options.Namespace,
+		Subsystem: options.Subsystem,
+		Name:      "http_requests_in_flight",
+		Help:      "Number of the HTTP requests being served.",
+	}, routeLabels)
+
+	for _, collector := range []prometheus.Collector{
+		filter.duration, filter.requests, filter.requestSize, filter.responseSize, filter.inFlight,
+	} {
+		if err := registerer.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+
+	return filter, nil
+}
+
+// Filter records the metrics of the request, unless the metrics are disabled by the kill switch
+func (f *Filter) Filter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	if killswitch.IsDisabled(killswitch.Metrics) {
+		chain.ProcessFilter(req, resp)
+		return
+	}
+
+	routeLabels := f.routeLabelValues(req)
+
+	inFlight := f.inFlight.With(routeLabels)
+	inFlight.Inc()
+	defer inFlight.Dec()
+
+	start := time.Now()
+
+	chain.ProcessFilter(req, resp)
+
+	duration := time.Since(start)
+	statusLabels := f.statusLabelValues(routeLabels, resp.StatusCode())
+
+	f.duration.With(statusLabels).Observe(duration.Seconds())
+	f.requests.With(statusClassLabelValues(routeLabels, resp.StatusCode())).Inc()
+	if req.Request.ContentLength > 0 {
+		f.requestSize.With(routeLabels).Observe(float64(req.Request.ContentLength))
+	}
+	f.responseSize.With(statusLabels).Observe(float64(resp.ContentLength()))
+}
+
+func (f *Filter) labelNames(withStatus bool) []string {
+	names := make([]string, 0)
+	if f.labels[LabelOperation] {
+		names = append(names, LabelOperation)
+	}
+	if f.labels[LabelMethod] {
+		names = append(names, LabelMethod)
+	}
+	if f.labels[LabelConsumer] {
+		names = append(names, LabelConsumer)
+	}
+	if withStatus && f.labels[LabelStatus] {
+		names = append(names, LabelStatus)
+	}
+	return names
+}
+
+func (f *Filter) routeLabelValues(req *restful.Request) prometheus.Labels {
+	labels := prometheus.Labels{}
+	if f.labels[LabelOperation] {
+		labels[LabelOperation] = getOperation(req)
+	}
+	if f.labels[LabelMethod] {
+		labels[LabelMethod] = req.Request.Method
+	}
+	if f.labels[LabelConsumer] {
+		labels[LabelConsumer] = getConsumer(req)
+	}
+	return labels
+}
+
+func (f *Filter) statusLabelValues(routeLabels prometheus.Labels, status int) prometheus.Labels {
+	labels := copyLabels(routeLabels)
+	if f.labels[LabelStatus] {
+		if f.groupStatus {
+			labels[LabelStatus] = statusClass(status)
+		} else {
+			labels[LabelStatus] = strconv.Itoa(status)
+		}
+	}
+	return labels
+}
+
+func statusClassLabelValues(routeLabels prometheus.Labels, status int) prometheus.Labels {
+	labels := copyLabels(routeLabels)
+	labels[LabelStatusClass] = statusClass(status)
+	return labels
+}
+
+// getOperation returns the operation id of the route.
+// Notes: go-restful uses the handler function name when the operation id isn't set.
+func getOperation(req *restful.Request) string {
+	route := req.SelectedRoute()
+	if route == nil || route.Operation() == "" {
+		return unknownOperation
+	}
+	return route.Operation()
+}
+
+func getConsumer(req *restful.Request) string {
+	if consumerID := trace.GetConsumerID(req); consumerID != "" {
+		return consumerID
+	}
+	return unknownConsumer
+}
+
+func statusClass(status int) string {
+	if status < 100 || status > 599 {
+		return unknownStatusClass
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
+
+func copyLabels(labels prometheus.Labels) prometheus.Labels {
+	result := prometheus.Labels{}
+	for key, value := range labels {
+		result[key] = value
+	}
+	return result
+}
+
+// Handler returns http.Handler exposing the metrics of the gatherer in the Prometheus format.
+// Use prometheus.DefaultGatherer for the metrics registered into the default registerer.
+func Handler(gatherer prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})
+}
+
+// Route registers the metrics route of the default gatherer based on the basepath
+func Route(basePath string) {
+	http.Handle(basePath+"/metrics", Handler(prometheus.DefaultGatherer))
+}
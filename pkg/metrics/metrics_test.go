@@ -0,0 +1,203 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/killswitch"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/trace"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(container *restful.Container, method string, path string, body string) *httptest.ResponseRecorder {
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return resp
+}
+
+func createTestContainer(t *testing.T, options *Options) (*restful.Container, *Filter) {
+	t.Helper()
+
+	filter, err := NewFilter(options)
+	assert.NoError(t, err)
+
+	ws := new(restful.WebService)
+	ws.Filter(filter.Filter)
+	ws.Route(ws.POST("/user/{id}").Operation("updateUser").
+		To(func(request *restful.Request, response *restful.Response) {
+			_, _ = response.Write([]byte("updated"))
+		}))
+	ws.Route(ws.GET("/user/{id}").Operation("getUser").
+		To(func(request *restful.Request, response *restful.Response) {
+			response.WriteHeader(http.StatusNotFound)
+		}))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	return container, filter
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	container, filter := createTestContainer(t, &Options{Registerer: registry})
+
+	serve(container, http.MethodPost, "/user/abc", "foo")
+	serve(container, http.MethodPost, "/user/def", "foo")
+	serve(container, http.MethodGet, "/user/abc", "")
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(filter.requests.WithLabelValues("updateUser", "POST", "2xx")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(filter.requests.WithLabelValues("getUser", "GET", "4xx")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(filter.inFlight.WithLabelValues("updateUser", "POST")))
+
+	assert.Equal(t, 2, testutil.CollectAndCount(filter.duration))
+	assert.Equal(t, 1, testutil.CollectAndCount(filter.requestSize))
+
+	expected := `
+# HELP http_response_size_bytes Size of the HTTP response bodies in bytes.
+# TYPE http_response_size_bytes histogram
+http_response_size_bytes_bucket{method="GET",operation="getUser",status="404",le="100"} 1
+http_response_size_bytes_bucket{method="GET",operation="getUser",status="404",le="1000"} 1
+http_response_size_bytes_bucket{method="GET",operation="getUser",status="404",le="10000"} 1
+http_response_size_bytes_bucket{method="GET",operation="getUser",status="404",le="100000"} 1
+http_response_size_bytes_bucket{method="GET",operation="getUser",status="404",le="1e+06"} 1
+http_response_size_bytes_bucket{method="GET",operation="getUser",status="404",le="1e+07"} 1
+http_response_size_bytes_bucket{method="GET",operation="getUser",status="404",le="+Inf"} 1
+http_response_size_bytes_sum{method="GET",operation="getUser",status="404"} 0
+http_response_size_bytes_count{method="GET",operation="getUser",status="404"} 1
+http_response_size_bytes_bucket{method="POST",operation="updateUser",status="200",le="100"} 2
+http_response_size_bytes_bucket{method="POST",operation="updateUser",status="200",le="1000"} 2
+http_response_size_bytes_bucket{method="POST",operation="updateUser",status="200",le="10000"} 2
+http_response_size_bytes_bucket{method="POST",operation="updateUser",status="200",le="100000"} 2
+http_response_size_bytes_bucket{method="POST",operation="updateUser",status="200",le="1e+06"} 2
+http_response_size_bytes_bucket{method="POST",operation="updateUser",status="200",le="1e+07"} 2
+http_response_size_bytes_bucket{method="POST",operation="updateUser",status="200",le="+Inf"} 2
+http_response_size_bytes_sum{method="POST",operation="updateUser",status="200"} 14
+http_response_size_bytes_count{method="POST",operation="updateUser",status="200"} 2
+`
+	assert.NoError(t, testutil.CollectAndCompare(filter.responseSize, strings.NewReader(expected)))
+}
+
+func TestFilter_ReducedLabels(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	container, filter := createTestContainer(t, &Options{
+		Registerer:  registry,
+		Namespace:   "justice",
+		Labels:      []string{LabelMethod, LabelStatus},
+		GroupStatus: true,
+	})
+
+	serve(container, http.MethodPost, "/user/abc", "foo")
+	serve(container, http.MethodGet, "/user/abc", "")
+
+	assert.Equal(t, 2, testutil.CollectAndCount(filter.duration))
+	assert.Equal(t, float64(1), testutil.ToFloat64(filter.requests.WithLabelValues("POST", "2xx")))
+
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+	for _, family := range families {
+		assert.True(t, strings.HasPrefix(family.GetName(), "justice_http_"))
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				assert.NotEqual(t, LabelOperation, label.GetName())
+				if label.GetName() == LabelStatus {
+					assert.True(t, strings.HasSuffix(label.GetValue(), "xx"))
+				}
+			}
+		}
+	}
+}
+
+func TestFilter_ConsumerLabel(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	container, filter := createTestContainer(t, &Options{
+		Registerer: registry,
+		Labels:     []string{LabelOperation, LabelConsumer},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/user/abc", strings.NewReader("foo"))
+	req.Header.Set(trace.ConsumerIDKey, "mobile-app")
+	container.ServeHTTP(httptest.NewRecorder(), req)
+	serve(container, http.MethodPost, "/user/abc", "foo")
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(filter.requests.WithLabelValues("updateUser", "mobile-app", "2xx")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(filter.requests.WithLabelValues("updateUser", "unknown", "2xx")))
+}
+
+// nolint:paralleltest
+func TestFilter_KillSwitch(t *testing.T) {
+	defer killswitch.Enable(killswitch.Metrics)
+
+	registry := prometheus.NewRegistry()
+	container, filter := createTestContainer(t, &Options{Registerer: registry})
+
+	killswitch.Disable(killswitch.Metrics)
+	resp := serve(container, http.MethodPost, "/user/abc", "foo")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, 0, testutil.CollectAndCount(filter.requests))
+
+	killswitch.Enable(killswitch.Metrics)
+	serve(container, http.MethodPost, "/user/abc", "foo")
+	assert.Equal(t, 1, testutil.CollectAndCount(filter.requests))
+}
+
+func TestNewFilter_Error(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewFilter(&Options{Registerer: prometheus.NewRegistry(), Labels: []string{"path"}})
+	assert.Error(t, err)
+
+	// registering the same metrics twice
+	registry := prometheus.NewRegistry()
+	_, err = NewFilter(&Options{Registerer: registry})
+	assert.NoError(t, err)
+	_, err = NewFilter(&Options{Registerer: registry})
+	assert.Error(t, err)
+}
+
+func TestHandler(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	container, _ := createTestContainer(t, &Options{Registerer: registry})
+	container.Handle("/metrics", Handler(registry))
+
+	serve(container, http.MethodPost, "/user/abc", "foo")
+
+	resp := serve(container, http.MethodGet, "/metrics", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `http_requests_total{method="POST",operation="updateUser",status_class="2xx"} 1`)
+}
+
+func TestStatusClass(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "2xx", statusClass(http.StatusOK))
+	assert.Equal(t, "5xx", statusClass(http.StatusServiceUnavailable))
+	assert.Equal(t, "unknown", statusClass(0))
+}
@@ -0,0 +1,93 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/outbound"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metric labels of the downstream calls
+const (
+	LabelService = "service"
+
+	outboundStatusError = "error"
+)
+
+// RegisterOutbound registers the metrics of the downstream calls made through the outbound client wrapper,
+// labeled by the downstream service name, so the dependency map can be built from the metrics.
+// The Namespace, Subsystem, Registerer and DurationBuckets options are used the same way as NewFilter.
+func RegisterOutbound(options *Options) error {
+	if options == nil {
+		options = &Options{}
+	}
+	registerer := options.Registerer
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+	durationBuckets := options.DurationBuckets
+	if len(durationBuckets) == 0 {
+		durationBuckets = prometheus.DefBuckets
+	}
+
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: options.Namespace,
+		Subsystem: options.Subsystem,
+		Name:      "outbound_request_duration_seconds",
+		Help:      "Duration of the downstream calls in seconds.",
+		Buckets:   durationBuckets,
+	}, []string{LabelService})
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: options.Namespace,
+		Subsystem: options.Subsystem,
+		Name:      "outbound_requests_total",
+		Help:      "Total number of the downstream calls by status, \"error\" when the call failed without response.",
+	}, []string{LabelService, LabelStatus})
+	throttled := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: options.Namespace,
+		Subsystem: options.Subsystem,
+		Name:      "outbound_throttled_total",
+		Help:      "Total number of the downstream calls throttled with 429 status, or 503 status with Retry-After header.",
+	}, []string{LabelService})
+	retryAfter := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: options.Namespace,
+		Subsystem: options.Subsystem,
+		Name:      "outbound_retry_after_seconds",
+		Help:      "Retry-After delay in seconds requested by the last throttled downstream call.",
+	}, []string{LabelService})
+
+	for _, collector := range []prometheus.Collector{duration, requests, throttled, retryAfter} {
+		if err := registerer.Register(collector); err != nil {
+			return err
+		}
+	}
+
+	outbound.RegisterObserver(func(call outbound.Call) {
+		status := outboundStatusError
+		if call.Err == nil {
+			status = strconv.Itoa(call.StatusCode)
+		}
+		duration.WithLabelValues(call.Service).Observe(call.Duration.Seconds())
+		requests.WithLabelValues(call.Service, status).Inc()
+		if call.Throttled {
+			throttled.WithLabelValues(call.Service).Inc()
+			retryAfter.WithLabelValues(call.Service).Set(call.RetryAfter.Seconds())
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/outbound"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRegisterOutbound(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	assert.NoError(t, RegisterOutbound(&Options{Registerer: registry}))
+
+	// the service name is unique to the test, since the observers are registered globally
+	ok := outbound.NewTransport("outbound-test", roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	}))
+	failed := outbound.NewTransport("outbound-test", roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}))
+	for _, transport := range []http.RoundTripper{ok, ok, failed} {
+		resp, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://downstream/", nil))
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+	}
+
+	expected := `
+# HELP outbound_requests_total Total number of the downstream calls by status, "error" when the call failed without response.
+# TYPE outbound_requests_total counter
+outbound_requests_total{service="outbound-test",status="200"} 2
+outbound_requests_total{service="outbound-test",status="error"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "outbound_requests_total"))
+	count, err := testutil.GatherAndCount(registry, "outbound_request_duration_seconds")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestRegisterOutbound_Throttled(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewRegistry()
+	assert.NoError(t, RegisterOutbound(&Options{Registerer: registry}))
+
+	respondWith := func(statusCode int, retryAfter string) http.RoundTripper {
+		return outbound.NewTransport("outbound-throttled-test", roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			header := http.Header{}
+			if retryAfter != "" {
+				header.Set("Retry-After", retryAfter)
+			}
+			return &http.Response{StatusCode: statusCode, Header: header, Body: http.NoBody}, nil
+		}))
+	}
+	transports := []http.RoundTripper{
+		respondWith(http.StatusTooManyRequests, "30"),
+		respondWith(http.StatusServiceUnavailable, "15"),
+		respondWith(http.StatusServiceUnavailable, ""),
+	}
+	for _, transport := range transports {
+		resp, err := transport.RoundTrip(httptest.NewRequest(http.MethodGet, "http://downstream/", nil))
+		if assert.NoError(t, err) {
+			_ = resp.Body.Close()
+		}
+	}
+
+	expected := `
+# HELP outbound_retry_after_seconds Retry-After delay in seconds requested by the last throttled downstream call.
+# TYPE outbound_retry_after_seconds gauge
+outbound_retry_after_seconds{service="outbound-throttled-test"} 15
+# HELP outbound_throttled_total Total number of the downstream calls throttled with 429 status, or 503 status with Retry-After header.
+# TYPE outbound_throttled_total counter
+outbound_throttled_total{service="outbound-throttled-test"} 2
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"outbound_throttled_total", "outbound_retry_after_seconds"))
+}
@@ -0,0 +1,144 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"strings"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/overload"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RegisterOverload registers the metrics of the overload protection Shedder into the registerer,
+// so the rejection rate can be seen next to the request metrics. The Namespace, Subsystem and Registerer
+// options are used the same way as NewFilter.
+func RegisterOverload(shedder *overload.Shedder, options *Options) error {
+	if options == nil {
+		options = &Options{}
+	}
+	registerer := options.Registerer
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+
+	collectors := []prometheus.Collector{
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: options.Namespace,
+			Subsystem: options.Subsystem,
+			Name:      "http_overload_queued_requests",
+			Help:      "Number of the HTTP requests waiting for available capacity.",
+		}, func() float64 {
+			return float64(shedder.State().Queued)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: options.Namespace,
+			Subsystem: options.Subsystem,
+			Name:      "http_overload_accepted_total",
+			Help:      "Total number of the HTTP requests accepted by the overload protection.",
+		}, func() float64 {
+			return float64(shedder.State().Accepted)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: options.Namespace,
+			Subsystem: options.Subsystem,
+			Name:      "http_overload_rejected_total",
+			Help:      "Total number of the HTTP requests rejected because the queue is full.",
+		}, func() float64 {
+			return float64(shedder.State().Rejected)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: options.Namespace,
+			Subsystem: options.Subsystem,
+			Name:      "http_overload_timed_out_total",
+			Help:      "Total number of the HTTP requests rejected after waiting for available capacity.",
+		}, func() float64 {
+			return float64(shedder.State().TimedOut)
+		}),
+	}
+
+	for _, collector := range collectors {
+		if err := registerer.Register(collector); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RegisterRouteOverload registers the metrics of the per-route concurrency RouteLimiter into the registerer,
+// labeled with the method and path of the route. The Namespace, Subsystem and Registerer options are used
+// the same way as NewFilter.
+func RegisterRouteOverload(limiter *overload.RouteLimiter, options *Options) error {
+	if options == nil {
+		options = &Options{}
+	}
+	registerer := options.Registerer
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+
+	newDesc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(options.Namespace, options.Subsystem, name),
+			help, []string{LabelMethod, LabelRoute}, nil)
+	}
+
+	return registerer.Register(&routeOverloadCollector{
+		limiter: limiter,
+		inFlight: newDesc("http_route_overload_in_flight_requests",
+			"Number of the HTTP requests being served by the concurrency limited route."),
+		queued: newDesc("http_route_overload_queued_requests",
+			"Number of the HTTP requests waiting for available capacity of the route."),
+		accepted: newDesc("http_route_overload_accepted_total",
+			"Total number of the HTTP requests accepted by the concurrency limit of the route."),
+		rejected: newDesc("http_route_overload_rejected_total",
+			"Total number of the HTTP requests rejected because the queue of the route is full."),
+		timedOut: newDesc("http_route_overload_timed_out_total",
+			"Total number of the HTTP requests rejected after waiting for available capacity of the route."),
+	})
+}
+
+// routeOverloadCollector collects the state of the routes, which are only known after they're requested
+type routeOverloadCollector struct {
+	limiter  *overload.RouteLimiter
+	inFlight *prometheus.Desc
+	queued   *prometheus.Desc
+	accepted *prometheus.Desc
+	rejected *prometheus.Desc
+	timedOut *prometheus.Desc
+}
+
+func (c *routeOverloadCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- c.inFlight
+	descs <- c.queued
+	descs <- c.accepted
+	descs <- c.rejected
+	descs <- c.timedOut
+}
+
+func (c *routeOverloadCollector) Collect(metrics chan<- prometheus.Metric) {
+	for route, state := range c.limiter.States() {
+		// the route is keyed by the method and path, e.g. "POST /reports"
+		labels := strings.SplitN(route, " ", 2)
+		if len(labels) != 2 {
+			continue
+		}
+
+		metrics <- prometheus.MustNewConstMetric(c.inFlight, prometheus.GaugeValue, float64(state.InFlight), labels...)
+		metrics <- prometheus.MustNewConstMetric(c.queued, prometheus.GaugeValue, float64(state.Queued), labels...)
+		metrics <- prometheus.MustNewConstMetric(c.accepted, prometheus.CounterValue, float64(state.Accepted), labels...)
+		metrics <- prometheus.MustNewConstMetric(c.rejected, prometheus.CounterValue, float64(state.Rejected), labels...)
+		metrics <- prometheus.MustNewConstMetric(c.timedOut, prometheus.CounterValue, float64(state.TimedOut), labels...)
+	}
+}
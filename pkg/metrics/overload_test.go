@@ -0,0 +1,89 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/overload"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterOverload(t *testing.T) {
+	t.Parallel()
+
+	shedder, err := overload.NewShedder(overload.Options{MaxConcurrent: 1})
+	assert.NoError(t, err)
+
+	registry := prometheus.NewRegistry()
+	assert.NoError(t, RegisterOverload(shedder, &Options{Namespace: "test", Registerer: registry}))
+
+	ws := new(restful.WebService)
+	ws.Filter(shedder.Filter)
+	ws.Route(ws.GET("/user").To(func(request *restful.Request, response *restful.Response) {}))
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	assert.Equal(t, http.StatusOK, serve(container, http.MethodGet, "/user", "").Code)
+
+	expected := `
+# HELP test_http_overload_accepted_total Total number of the HTTP requests accepted by the overload protection.
+# TYPE test_http_overload_accepted_total counter
+test_http_overload_accepted_total 1
+# HELP test_http_overload_rejected_total Total number of the HTTP requests rejected because the queue is full.
+# TYPE test_http_overload_rejected_total counter
+test_http_overload_rejected_total 0
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"test_http_overload_accepted_total", "test_http_overload_rejected_total"))
+
+	// the metrics can't be registered twice
+	assert.Error(t, RegisterOverload(shedder, &Options{Namespace: "test", Registerer: registry}))
+}
+
+func TestRegisterRouteOverload(t *testing.T) {
+	t.Parallel()
+
+	limiter := overload.NewRouteLimiter(overload.Options{})
+
+	registry := prometheus.NewRegistry()
+	assert.NoError(t, RegisterRouteOverload(limiter, &Options{Namespace: "test", Registerer: registry}))
+
+	ws := new(restful.WebService)
+	ws.Filter(limiter.Filter)
+	ws.Route(ws.GET("/reports").
+		Metadata(overload.MaxConcurrentMetadata, 2).
+		To(func(request *restful.Request, response *restful.Response) {}))
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	assert.Equal(t, http.StatusOK, serve(container, http.MethodGet, "/reports", "").Code)
+
+	expected := `
+# HELP test_http_route_overload_accepted_total Total number of the HTTP requests accepted by the concurrency limit of the route.
+# TYPE test_http_route_overload_accepted_total counter
+test_http_route_overload_accepted_total{method="GET",route="/reports"} 1
+# HELP test_http_route_overload_in_flight_requests Number of the HTTP requests being served by the concurrency limited route.
+# TYPE test_http_route_overload_in_flight_requests gauge
+test_http_route_overload_in_flight_requests{method="GET",route="/reports"} 0
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"test_http_route_overload_accepted_total", "test_http_route_overload_in_flight_requests"))
+}
@@ -0,0 +1,96 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentelemetry
+
+import (
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/killswitch"
+	"github.com/emicklei/go-restful/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/unit"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+)
+
+const meterName = "github.com/AccelByte/go-restful-plugins/v4/pkg/opentelemetry"
+
+// MetricsFilter records the request metrics of the routes using the OpenTelemetry metrics API,
+// so they are exported by the meter provider (e.g. OTLP exporter) configured by the service,
+// in addition to or instead of the Prometheus metrics of the metrics package.
+// The instruments follow the OpenTelemetry HTTP server semantic conventions.
+func MetricsFilter(options *Options) (restful.FilterFunction, error) {
+	if options == nil {
+		options = &Options{}
+	}
+	meterProvider := options.MeterProvider
+	if meterProvider == nil {
+		meterProvider = global.GetMeterProvider()
+	}
+	meter := meterProvider.Meter(meterName)
+
+	duration, err := meter.NewFloat64Histogram("http.server.duration",
+		metric.WithDescription("Duration of the HTTP requests."), metric.WithUnit(unit.Milliseconds))
+	if err != nil {
+		return nil, err
+	}
+	activeRequests, err := meter.NewInt64UpDownCounter("http.server.active_requests",
+		metric.WithDescription("Number of the HTTP requests in flight."))
+	if err != nil {
+		return nil, err
+	}
+	requestSize, err := meter.NewInt64Histogram("http.server.request_content_length",
+		metric.WithDescription("Size of the HTTP request bodies."), metric.WithUnit(unit.Bytes))
+	if err != nil {
+		return nil, err
+	}
+	responseSize, err := meter.NewInt64Histogram("http.server.response_content_length",
+		metric.WithDescription("Size of the HTTP response bodies."), metric.WithUnit(unit.Bytes))
+	if err != nil {
+		return nil, err
+	}
+
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		if killswitch.IsDisabled(killswitch.Metrics) {
+			chain.ProcessFilter(req, resp)
+			return
+		}
+
+		ctx := req.Request.Context()
+		attributes := []attribute.KeyValue{semconv.HTTPMethodKey.String(req.Request.Method)}
+		if route := req.SelectedRoutePath(); route != "" {
+			attributes = append(attributes, semconv.HTTPRouteKey.String(route))
+		}
+		if selectedRoute := req.SelectedRoute(); selectedRoute != nil && selectedRoute.Operation() != "" {
+			attributes = append(attributes, OperationAttribute.String(selectedRoute.Operation()))
+		}
+
+		activeRequests.Add(ctx, 1, attributes...)
+		defer activeRequests.Add(ctx, -1, attributes...)
+
+		start := time.Now()
+		chain.ProcessFilter(req, resp)
+		elapsed := float64(time.Since(start)) / float64(time.Millisecond)
+
+		if req.Request.ContentLength > 0 {
+			requestSize.Record(ctx, req.Request.ContentLength, attributes...)
+		}
+
+		attributes = append(attributes, semconv.HTTPStatusCodeKey.Int(resp.StatusCode()))
+		duration.Record(ctx, elapsed, attributes...)
+		responseSize.Record(ctx, int64(resp.ContentLength()), attributes...)
+	}, nil
+}
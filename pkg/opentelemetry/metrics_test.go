@@ -0,0 +1,68 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentelemetry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric/metrictest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+)
+
+func TestMetricsFilter(t *testing.T) {
+	t.Parallel()
+
+	meterProvider := metrictest.NewMeterProvider()
+	filter, err := MetricsFilter(&Options{MeterProvider: meterProvider})
+	require.NoError(t, err)
+
+	ws := new(restful.WebService)
+	ws.Filter(filter)
+	ws.Route(ws.POST("/namespace/{namespace}/user").Operation("createUser").
+		To(func(request *restful.Request, response *restful.Response) {
+			response.WriteHeader(http.StatusCreated)
+			_, _ = response.Write([]byte(`{"id":"1"}`))
+		}))
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	req := httptest.NewRequest(http.MethodPost, "/namespace/abc/user", strings.NewReader(`{"name":"john"}`))
+	container.ServeHTTP(httptest.NewRecorder(), req)
+
+	measurements := map[string]metrictest.Measured{}
+	for _, measured := range metrictest.AsStructs(meterProvider.MeasurementBatches) {
+		measurements[measured.Name] = measured
+	}
+
+	assert.Contains(t, measurements, "http.server.active_requests")
+	requestSize := measurements["http.server.request_content_length"].Number
+	assert.Equal(t, int64(15), requestSize.AsInt64())
+	responseSize := measurements["http.server.response_content_length"].Number
+	assert.Equal(t, int64(10), responseSize.AsInt64())
+
+	duration, ok := measurements["http.server.duration"]
+	if assert.True(t, ok) {
+		assert.Equal(t, "POST", duration.Labels[semconv.HTTPMethodKey].AsString())
+		assert.Equal(t, "/namespace/{namespace}/user", duration.Labels[semconv.HTTPRouteKey].AsString())
+		assert.Equal(t, "createUser", duration.Labels[OperationAttribute].AsString())
+		assert.Equal(t, int64(http.StatusCreated), duration.Labels[semconv.HTTPStatusCodeKey].AsInt64())
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentelemetry
+
+import (
+	"net/http"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/plugins"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/trace"
+	"github.com/emicklei/go-restful/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName        = "github.com/AccelByte/go-restful-plugins/v4/pkg/opentelemetry"
+	tracerFlusherName = "opentelemetry/tracer"
+
+	// propagatorAttribute is the request attribute of the propagator of the Filter, used by InjectTraceContext
+	propagatorAttribute = "OpenTelemetryPropagator"
+
+	// OperationAttribute is the span attribute of the route's operation id
+	OperationAttribute = attribute.Key("http.operation")
+)
+
+// Options contains options for the OpenTelemetry filters
+type Options struct {
+	// ServerName is the logical name of the service, e.g. "iam". Default: the request host
+	ServerName string
+	// TracerProvider creates the server span. Default: otel.GetTracerProvider()
+	TracerProvider oteltrace.TracerProvider
+	// Propagator extracts the trace context from the request headers,
+	// and injects it into the outgoing requests by InjectTraceContext. Default: W3C trace context and baggage
+	Propagator propagation.TextMapPropagator
+	// MeterProvider creates the instruments of MetricsFilter. Default: global.GetMeterProvider()
+	MeterProvider metric.MeterProvider
+}
+
+// defaultPropagator is used when the propagator isn't set,
+// since the global propagator of OpenTelemetry is a no-op propagator unless it's set by the service
+var defaultPropagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// Filter starts a server span per request as a child of the incoming W3C traceparent (if any).
+// The span is named after the method and the route path, or only the method when no route matches the request,
+// so the span names don't grow with the requested paths.
+// The span is stored in the request context, and its trace ID is set as the trace ID attribute,
+// so it is printed as the trace_id field of the access log.
+func Filter(options *Options) restful.FilterFunction {
+	if options == nil {
+		options = &Options{}
+	}
+	tracerProvider := options.TracerProvider
+	if tracerProvider == nil {
+		tracerProvider = otel.GetTracerProvider()
+	}
+	propagator := options.Propagator
+	if propagator == nil {
+		propagator = defaultPropagator
+	}
+	tracer := tracerProvider.Tracer(tracerName)
+
+	// the SDK tracer provider buffers the spans, so they are flushed by plugins.ForceFlush
+	if flusher, ok := tracerProvider.(plugins.Flusher); ok {
+		plugins.RegisterFlusher(tracerFlusherName, flusher)
+	}
+
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		ctx := propagator.Extract(req.Request.Context(), propagation.HeaderCarrier(req.Request.Header))
+
+		route := req.SelectedRoutePath()
+		spanName := req.Request.Method
+		if route != "" {
+			spanName += " " + route
+		}
+
+		attributes := semconv.HTTPServerAttributesFromHTTPRequest(options.ServerName, route, req.Request)
+		attributes = append(attributes, semconv.NetAttributesFromHTTPRequest("tcp", req.Request)...)
+		if selectedRoute := req.SelectedRoute(); selectedRoute != nil && selectedRoute.Operation() != "" {
+			attributes = append(attributes, OperationAttribute.String(selectedRoute.Operation()))
+		}
+
+		ctx, span := tracer.Start(ctx, spanName,
+			oteltrace.WithSpanKind(oteltrace.SpanKindServer),
+			oteltrace.WithAttributes(attributes...),
+		)
+		defer span.End()
+
+		req.Request = req.Request.WithContext(ctx)
+		req.SetAttribute(propagatorAttribute, propagator)
+
+		if spanContext := span.SpanContext(); spanContext.HasTraceID() {
+			req.SetAttribute(trace.TraceIDKey, spanContext.TraceID().String())
+		}
+
+		chain.ProcessFilter(req, resp)
+
+		status := resp.StatusCode()
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(status)...)
+		span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(status, oteltrace.SpanKindServer))
+	}
+}
+
+// SpanFromRequest returns the server span of the request started by the Filter,
+// it returns a no-op span if the request isn't filtered through the Filter.
+func SpanFromRequest(req *restful.Request) oteltrace.Span {
+	return oteltrace.SpanFromContext(req.Request.Context())
+}
+
+// InjectTraceContext injects the trace context of the incoming request's span into the outgoing request
+// using the propagator of the Filter, so the downstream service continues the same trace.
+// The W3C traceparent and baggage are injected if the request isn't filtered through the Filter.
+func InjectTraceContext(out *http.Request, in *restful.Request) {
+	propagator, ok := in.Attribute(propagatorAttribute).(propagation.TextMapPropagator)
+	if !ok {
+		propagator = defaultPropagator
+	}
+	propagator.Inject(in.Request.Context(), propagation.HeaderCarrier(out.Header))
+}
@@ -0,0 +1,184 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package opentelemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/plugins"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/trace"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+const (
+	parentTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanID  = "00f067aa0ba902b7"
+)
+
+func createTestContainer(recorder *tracetest.SpanRecorder, handler restful.RouteFunction) *restful.Container {
+	ws := new(restful.WebService)
+	ws.Filter(Filter(&Options{
+		ServerName:     "test",
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+	}))
+	ws.Route(ws.GET("/namespace/{namespace}/user/{id}").Operation("getUser").To(handler))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	return container
+}
+
+func getAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+
+	var traceIDAttribute interface{}
+	var outgoing *http.Request
+
+	container := createTestContainer(recorder, func(request *restful.Request, response *restful.Response) {
+		traceIDAttribute = request.Attribute(trace.TraceIDKey)
+		assert.True(t, SpanFromRequest(request).SpanContext().IsValid())
+
+		outgoing = httptest.NewRequest(http.MethodGet, "http://downstream/", nil)
+		InjectTraceContext(outgoing, request)
+
+		response.WriteHeader(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/namespace/abc/user/def", nil)
+	req.Header.Set("traceparent", "00-"+parentTraceID+"-"+parentSpanID+"-01")
+	container.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+
+	assert.Equal(t, "GET /namespace/{namespace}/user/{id}", span.Name())
+	assert.Equal(t, oteltrace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, parentTraceID, span.SpanContext().TraceID().String())
+	assert.Equal(t, parentSpanID, span.Parent().SpanID().String())
+	assert.Equal(t, "getUser", getAttribute(span, OperationAttribute).AsString())
+	assert.Equal(t, "/namespace/{namespace}/user/{id}", getAttribute(span, "http.route").AsString())
+	assert.Equal(t, int64(http.StatusNotFound), getAttribute(span, "http.status_code").AsInt64())
+	// 4xx is the client error, not the server span error
+	assert.Equal(t, codes.Unset, span.Status().Code)
+
+	assert.Equal(t, parentTraceID, traceIDAttribute)
+	assert.Equal(t, "00-"+parentTraceID+"-"+span.SpanContext().SpanID().String()+"-01", outgoing.Header.Get("traceparent"))
+}
+
+func TestFilter_NewTrace(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	container := createTestContainer(recorder, func(request *restful.Request, response *restful.Response) {
+		response.WriteHeader(http.StatusInternalServerError)
+	})
+
+	container.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/namespace/abc/user/def", nil))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.True(t, spans[0].SpanContext().HasTraceID())
+	assert.False(t, spans[0].Parent().IsValid())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+}
+
+func TestFilter_Propagator(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	var outgoing *http.Request
+
+	ws := new(restful.WebService)
+	ws.Filter(Filter(&Options{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+		Propagator:     propagation.Baggage{},
+	}))
+	ws.Route(ws.GET("/users").To(func(request *restful.Request, response *restful.Response) {
+		outgoing = httptest.NewRequest(http.MethodGet, "http://downstream/", nil)
+		InjectTraceContext(outgoing, request)
+	}))
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("baggage", "tenant=abc")
+	container.ServeHTTP(httptest.NewRecorder(), req)
+
+	// the configured propagator is used instead of the W3C trace context
+	assert.Empty(t, outgoing.Header.Get("traceparent"))
+	assert.Equal(t, "tenant=abc", outgoing.Header.Get("baggage"))
+}
+
+func TestFilter_UnmatchedRoute(t *testing.T) {
+	t.Parallel()
+
+	recorder := tracetest.NewSpanRecorder()
+	container := createTestContainer(recorder, func(request *restful.Request, response *restful.Response) {})
+	container.Filter(Filter(&Options{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+	}))
+
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/unknown/abc", nil))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	// the span isn't named after the requested path
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, http.MethodGet, spans[0].Name())
+}
+
+// nolint:paralleltest
+func TestFilter_ForceFlush(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter, sdktrace.WithBatchTimeout(time.Hour)))
+	defer plugins.UnregisterFlusher(tracerFlusherName)
+
+	ws := new(restful.WebService)
+	ws.Filter(Filter(&Options{TracerProvider: tracerProvider}))
+	ws.Route(ws.GET("/user").To(func(request *restful.Request, response *restful.Response) {}))
+	container := restful.NewContainer()
+	container.Add(ws)
+	container.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/user", nil))
+
+	assert.Empty(t, exporter.GetSpans())
+	require.NoError(t, plugins.ForceFlush(context.Background()))
+	assert.Len(t, exporter.GetSpans(), 1)
+}
@@ -0,0 +1,269 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbound
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+)
+
+type recorderKey struct{}
+
+// Call is a completed call into a downstream service
+type Call struct {
+	Service  string
+	Duration time.Duration
+	// StatusCode is zero when the call failed without response
+	StatusCode int
+	Err        error
+	// Throttled is set when the downstream service responded with 429 status,
+	// or 503 status with Retry-After header
+	Throttled bool
+	// RetryAfter is the delay requested by Retry-After response header, zero when it's absent
+	RetryAfter time.Duration
+}
+
+// Failed checks whether the call failed without response or with 5xx status
+func (c Call) Failed() bool {
+	return c.Err != nil || c.StatusCode >= http.StatusInternalServerError
+}
+
+// Dependency is the summary of the calls into a downstream service made by a request
+type Dependency struct {
+	Service string
+	Count   int
+	Latency time.Duration
+	Errors  int
+	// Throttled is the number of the throttled calls
+	Throttled int
+	// RetryAfter is the longest delay requested by the throttled calls
+	RetryAfter time.Duration
+}
+
+// Dependencies are the downstream services called by a request, in the order of the first call
+type Dependencies []Dependency
+
+// String formats the dependencies as comma separated service:count:latency_ms:errors, e.g. "iam:2:15:0"
+func (d Dependencies) String() string {
+	var builder strings.Builder
+	for i, dependency := range d {
+		if i > 0 {
+			builder.WriteString(",")
+		}
+		builder.WriteString(dependency.Service)
+		builder.WriteString(":")
+		builder.WriteString(strconv.Itoa(dependency.Count))
+		builder.WriteString(":")
+		builder.WriteString(strconv.FormatInt(dependency.Latency.Milliseconds(), 10))
+		builder.WriteString(":")
+		builder.WriteString(strconv.Itoa(dependency.Errors))
+	}
+	return builder.String()
+}
+
+// Throttling formats the throttled dependencies as comma separated service:throttled:retry_after_ms,
+// e.g. "platform:2:30000", or returns empty string if none of the calls was throttled
+func (d Dependencies) Throttling() string {
+	var builder strings.Builder
+	for _, dependency := range d {
+		if dependency.Throttled == 0 {
+			continue
+		}
+		if builder.Len() > 0 {
+			builder.WriteString(",")
+		}
+		builder.WriteString(dependency.Service)
+		builder.WriteString(":")
+		builder.WriteString(strconv.Itoa(dependency.Throttled))
+		builder.WriteString(":")
+		builder.WriteString(strconv.FormatInt(dependency.RetryAfter.Milliseconds(), 10))
+	}
+	return builder.String()
+}
+
+// recorder collects the downstream calls of a request, the calls can be made concurrently
+type recorder struct {
+	mutex        sync.Mutex
+	dependencies Dependencies
+}
+
+func (r *recorder) record(call Call) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	index := -1
+	for i := range r.dependencies {
+		if r.dependencies[i].Service == call.Service {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		r.dependencies = append(r.dependencies, Dependency{Service: call.Service})
+		index = len(r.dependencies) - 1
+	}
+
+	r.dependencies[index].Count++
+	r.dependencies[index].Latency += call.Duration
+	if call.Failed() {
+		r.dependencies[index].Errors++
+	}
+	if call.Throttled {
+		r.dependencies[index].Throttled++
+		if call.RetryAfter > r.dependencies[index].RetryAfter {
+			r.dependencies[index].RetryAfter = call.RetryAfter
+		}
+	}
+}
+
+func (r *recorder) snapshot() Dependencies {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if len(r.dependencies) == 0 {
+		return nil
+	}
+	dependencies := make(Dependencies, len(r.dependencies))
+	copy(dependencies, r.dependencies)
+	return dependencies
+}
+
+// Filter is a filter that records the downstream calls made through Transport with the request context,
+// so they are printed as dependencies field in the access log.
+func Filter() restful.FilterFunction {
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		req.Request = req.Request.WithContext(WithRecorder(req.Request.Context()))
+		chain.ProcessFilter(req, resp)
+	}
+}
+
+// WithRecorder returns the context recording the downstream calls made with it, e.g. in a background job
+func WithRecorder(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(recorderKey{}).(*recorder); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, recorderKey{}, &recorder{})
+}
+
+// GetDependencies returns the downstream calls made with the request context, or nil if there is none
+func GetDependencies(req *restful.Request) Dependencies {
+	return DependenciesFromContext(req.Request.Context())
+}
+
+// DependenciesFromContext returns the downstream calls made with the context, or nil if there is none
+func DependenciesFromContext(ctx context.Context) Dependencies {
+	if r, ok := ctx.Value(recorderKey{}).(*recorder); ok {
+		return r.snapshot()
+	}
+	return nil
+}
+
+var (
+	observers      []func(call Call)
+	observersMutex sync.RWMutex
+)
+
+// RegisterObserver registers the function called after each downstream call, e.g. to record the metrics
+func RegisterObserver(observer func(call Call)) {
+	observersMutex.Lock()
+	defer observersMutex.Unlock()
+	observers = append(observers, observer)
+}
+
+func observe(call Call) {
+	observersMutex.RLock()
+	defer observersMutex.RUnlock()
+	for _, observer := range observers {
+		observer(call)
+	}
+}
+
+// Transport is the outbound client http.RoundTripper recording the calls into the downstream service
+type Transport struct {
+	// Service is the name of the downstream service, e.g. "iam"
+	Service string
+	// Base is the underlying RoundTripper. Default: http.DefaultTransport
+	Base http.RoundTripper
+}
+
+// NewTransport creates new Transport instance calling the named downstream service
+func NewTransport(service string, base http.RoundTripper) *Transport {
+	return &Transport{Service: service, Base: base}
+}
+
+// NewClient returns the copy of the client with its transport wrapped by Transport
+func NewClient(service string, client *http.Client) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	wrapped := *client
+	wrapped.Transport = NewTransport(service, client.Transport)
+	return &wrapped
+}
+
+// RoundTrip executes the call and records it into the request context and the observers
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+
+	call := Call{Service: t.Service, Duration: time.Since(start), Err: err}
+	if resp != nil {
+		call.StatusCode = resp.StatusCode
+		call.Throttled, call.RetryAfter = throttling(resp, start)
+	}
+	if r, ok := req.Context().Value(recorderKey{}).(*recorder); ok {
+		r.record(call)
+	}
+	observe(call)
+
+	return resp, err
+}
+
+// throttling checks whether the response throttles the caller and parses the Retry-After header,
+// either in seconds or as HTTP date relative to the time the call was made
+func throttling(resp *http.Response, now time.Time) (bool, time.Duration) {
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+
+	var retryAfter time.Duration
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds > 0 {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+	} else if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			retryAfter = delay
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true, retryAfter
+	case http.StatusServiceUnavailable:
+		return value != "", retryAfter
+	default:
+		return false, 0
+	}
+}
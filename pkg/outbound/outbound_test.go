@@ -0,0 +1,219 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outbound
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// roundTripperFunc is an adapter to use the function as http.RoundTripper
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func respondWith(statusCode int, err error) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		time.Sleep(time.Millisecond)
+		if err != nil {
+			return nil, err
+		}
+		return &http.Response{StatusCode: statusCode, Body: http.NoBody}, nil
+	})
+}
+
+func TestCall_Failed(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, Call{StatusCode: http.StatusNotFound}.Failed())
+	assert.True(t, Call{StatusCode: http.StatusBadGateway}.Failed())
+	assert.True(t, Call{Err: errors.New("connection refused")}.Failed())
+}
+
+func TestDependencies_String(t *testing.T) {
+	t.Parallel()
+
+	dependencies := Dependencies{
+		{Service: "iam", Count: 2, Latency: 15 * time.Millisecond},
+		{Service: "platform", Count: 1, Latency: 30 * time.Millisecond, Errors: 1},
+	}
+	assert.Equal(t, "iam:2:15:0,platform:1:30:1", dependencies.String())
+	assert.Equal(t, "", Dependencies(nil).String())
+}
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	ctx := WithRecorder(context.Background())
+	assert.Equal(t, ctx, WithRecorder(ctx))
+
+	calls := []struct {
+		transport http.RoundTripper
+		failed    bool
+	}{
+		{NewTransport("iam", respondWith(http.StatusOK, nil)), false},
+		{NewTransport("platform", respondWith(0, errors.New("connection refused"))), true},
+		{NewTransport("iam", respondWith(http.StatusServiceUnavailable, nil)), false},
+	}
+	for _, call := range calls {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://downstream/", nil)
+		resp, err := call.transport.RoundTrip(req)
+		assert.Equal(t, call.failed, err != nil)
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+	}
+
+	dependencies := DependenciesFromContext(ctx)
+	if assert.Len(t, dependencies, 2) {
+		assert.Equal(t, "iam", dependencies[0].Service)
+		assert.Equal(t, 2, dependencies[0].Count)
+		assert.Equal(t, 1, dependencies[0].Errors)
+		assert.True(t, dependencies[0].Latency >= 2*time.Millisecond)
+		assert.Equal(t, "platform", dependencies[1].Service)
+		assert.Equal(t, 1, dependencies[1].Count)
+		assert.Equal(t, 1, dependencies[1].Errors)
+	}
+}
+
+func TestTransport_Throttling(t *testing.T) {
+	t.Parallel()
+
+	respondWithHeader := func(statusCode int, retryAfter string) http.RoundTripper {
+		return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			header := http.Header{}
+			if retryAfter != "" {
+				header.Set("Retry-After", retryAfter)
+			}
+			return &http.Response{StatusCode: statusCode, Header: header, Body: http.NoBody}, nil
+		})
+	}
+
+	ctx := WithRecorder(context.Background())
+	transports := []http.RoundTripper{
+		NewTransport("platform", respondWithHeader(http.StatusTooManyRequests, "")),
+		NewTransport("platform", respondWithHeader(http.StatusTooManyRequests, "30")),
+		NewTransport("platform", respondWithHeader(http.StatusTooManyRequests, "5")),
+		NewTransport("iam", respondWithHeader(http.StatusServiceUnavailable, "")),
+		NewTransport("iam", respondWithHeader(http.StatusOK, "10")),
+		NewTransport("social", respondWithHeader(http.StatusServiceUnavailable,
+			time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))),
+	}
+	for _, transport := range transports {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://downstream/", nil)
+		resp, err := transport.RoundTrip(req)
+		if assert.NoError(t, err) {
+			_ = resp.Body.Close()
+		}
+	}
+
+	dependencies := DependenciesFromContext(ctx)
+	if assert.Len(t, dependencies, 3) {
+		assert.Equal(t, 3, dependencies[0].Throttled)
+		assert.Equal(t, 30*time.Second, dependencies[0].RetryAfter)
+		assert.Equal(t, 0, dependencies[1].Throttled)
+		assert.Equal(t, time.Duration(0), dependencies[1].RetryAfter)
+		assert.Equal(t, 1, dependencies[2].Throttled)
+		assert.True(t, dependencies[2].RetryAfter > 58*time.Second && dependencies[2].RetryAfter <= time.Minute)
+	}
+	assert.Regexp(t, `^platform:3:30000,social:1:\d+$`, dependencies.Throttling())
+}
+
+func TestDependencies_Throttling(t *testing.T) {
+	t.Parallel()
+
+	dependencies := Dependencies{
+		{Service: "iam", Count: 2, Latency: 15 * time.Millisecond},
+		{Service: "platform", Count: 3, Throttled: 2, RetryAfter: 30 * time.Second},
+	}
+	assert.Equal(t, "platform:2:30000", dependencies.Throttling())
+	assert.Equal(t, "", dependencies[:1].Throttling())
+}
+
+func TestTransport_NoRecorder(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "http://downstream/", nil)
+	resp, err := NewTransport("iam", respondWith(http.StatusOK, nil)).RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Nil(t, DependenciesFromContext(req.Context()))
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient("profile", server.Client())
+
+	var dependencies Dependencies
+	ws := new(restful.WebService)
+	ws.Filter(Filter())
+	ws.Route(ws.GET("/users").
+		To(func(request *restful.Request, response *restful.Response) {
+			for i := 0; i < 2; i++ {
+				outgoing, _ := http.NewRequestWithContext(request.Request.Context(), http.MethodGet, server.URL, nil)
+				resp, err := client.Do(outgoing)
+				if assert.NoError(t, err) {
+					_ = resp.Body.Close()
+				}
+			}
+			dependencies = GetDependencies(request)
+		}))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+	container.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+
+	if assert.Len(t, dependencies, 1) {
+		assert.Equal(t, "profile", dependencies[0].Service)
+		assert.Equal(t, 2, dependencies[0].Count)
+		assert.Equal(t, 0, dependencies[0].Errors)
+	}
+}
+
+// nolint:paralleltest
+func TestRegisterObserver(t *testing.T) {
+	defer func() {
+		observers = nil
+	}()
+
+	var observed []Call
+	RegisterObserver(func(call Call) {
+		observed = append(observed, call)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "http://downstream/", nil)
+	_, _ = NewTransport("iam", respondWith(http.StatusBadGateway, nil)).RoundTrip(req)
+
+	if assert.Len(t, observed, 1) {
+		assert.Equal(t, "iam", observed[0].Service)
+		assert.Equal(t, http.StatusBadGateway, observed[0].StatusCode)
+		assert.True(t, observed[0].Failed())
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package overload
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/response"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ServiceOverloaded is the error code when the request is rejected because the service is overloaded
+	ServiceOverloaded = response.ServiceOverloaded
+
+	defaultQueueTimeout = time.Second
+
+	fieldOverloaded = "overloaded"
+)
+
+var (
+	errQueueFull    = errors.New("service is overloaded: too many queued requests")
+	errQueueTimeout = errors.New("service is overloaded: timed out waiting for available capacity")
+)
+
+// Options contains options for the overload protection filter
+type Options struct {
+	// MaxConcurrent is the maximum number of requests served concurrently
+	MaxConcurrent int
+	// MaxQueue is the maximum number of requests waiting for a free slot,
+	// the request is rejected immediately when the queue is full. Default: 0
+	MaxQueue int
+	// QueueTimeout is the maximum duration a request waits in the queue. Default: 1 second
+	QueueTimeout time.Duration
+}
+
+// State is the current state of the Shedder
+type State struct {
+	InFlight   int    `json:"inFlight"`   // Number of requests being served
+	Queued     int    `json:"queued"`     // Number of requests waiting for a free slot
+	Overloaded bool   `json:"overloaded"` // Whether both the slots and the queue are full
+	Accepted   uint64 `json:"accepted"`   // Number of served requests since the service started
+	Rejected   uint64 `json:"rejected"`   // Number of requests rejected because the queue is full
+	TimedOut   uint64 `json:"timedOut"`   // Number of requests rejected after waiting for QueueTimeout
+}
+
+// Shedder sheds the load when the service is saturated, so the accepted requests keep their latency
+// instead of every request slowing down.
+type Shedder struct {
+	// the atomic counters come first to keep them 64-bit aligned on 32-bit platforms
+	queued   int64
+	accepted uint64
+	rejected uint64
+	timedOut uint64
+
+	slots        chan struct{}
+	maxQueue     int64
+	queueTimeout time.Duration
+}
+
+// NewShedder creates new Shedder instance. The MaxConcurrent option must be positive.
+func NewShedder(options Options) (*Shedder, error) {
+	if options.MaxConcurrent <= 0 {
+		return nil, errors.New("MaxConcurrent must be positive")
+	}
+	if options.MaxQueue < 0 {
+		return nil, errors.New("MaxQueue must not be negative")
+	}
+	queueTimeout := options.QueueTimeout
+	if queueTimeout <= 0 {
+		queueTimeout = defaultQueueTimeout
+	}
+
+	return &Shedder{
+		slots:        make(chan struct{}, options.MaxConcurrent),
+		maxQueue:     int64(options.MaxQueue),
+		queueTimeout: queueTimeout,
+	}, nil
+}
+
+// Filter serves the request when a slot is free, or waits in the queue for up to QueueTimeout.
+// The request is rejected with 503 error response when the queue is full or the wait times out.
+func (s *Shedder) Filter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	if err := s.acquire(req.Request.Context().Done()); err != nil {
+		s.reject(req, resp, err)
+		return
+	}
+	defer s.release()
+
+	chain.ProcessFilter(req, resp)
+}
+
+func (s *Shedder) reject(req *restful.Request, resp *restful.Response, err error) {
+	log.AdditionalFields(req, map[string]interface{}{fieldOverloaded: true})
+	logrus.Warnf("%s %s is rejected: %v", req.Request.Method, req.Request.URL.Path, err)
+
+	resp.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(s.queueTimeout.Seconds()))))
+	response.WriteErrorEnvelope(req, resp, http.StatusServiceUnavailable,
+		response.NewError(ServiceOverloaded, err.Error(), nil))
+}
+
+func (s *Shedder) acquire(done <-chan struct{}) error {
+	select {
+	case s.slots <- struct{}{}:
+		atomic.AddUint64(&s.accepted, 1)
+		return nil
+	default:
+	}
+
+	if atomic.AddInt64(&s.queued, 1) > s.maxQueue {
+		atomic.AddInt64(&s.queued, -1)
+		atomic.AddUint64(&s.rejected, 1)
+		return errQueueFull
+	}
+	defer atomic.AddInt64(&s.queued, -1)
+
+	timer := time.NewTimer(s.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case s.slots <- struct{}{}:
+		atomic.AddUint64(&s.accepted, 1)
+		return nil
+	case <-timer.C:
+		atomic.AddUint64(&s.timedOut, 1)
+		return errQueueTimeout
+	case <-done:
+		// the client gave up waiting
+		atomic.AddUint64(&s.timedOut, 1)
+		return errQueueTimeout
+	}
+}
+
+func (s *Shedder) release() {
+	<-s.slots
+}
+
+// State returns the current state of the Shedder
+func (s *Shedder) State() State {
+	inFlight := len(s.slots)
+	queued := int(atomic.LoadInt64(&s.queued))
+	return State{
+		InFlight:   inFlight,
+		Queued:     queued,
+		Overloaded: inFlight >= cap(s.slots) && int64(queued) >= s.maxQueue,
+		Accepted:   atomic.LoadUint64(&s.accepted),
+		Rejected:   atomic.LoadUint64(&s.rejected),
+		TimedOut:   atomic.LoadUint64(&s.timedOut),
+	}
+}
+
+// Handler returns http.Handler exposing the current state of the Shedder in JSON format
+func (s *Shedder) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", restful.MIME_JSON)
+		if err := json.NewEncoder(w).Encode(s.State()); err != nil {
+			logrus.Error(err)
+		}
+	})
+}
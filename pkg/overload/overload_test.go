@@ -0,0 +1,150 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package overload
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// createTestContainer creates container whose /block route waits until the release channel is closed
+func createTestContainer(t *testing.T, options Options) (*restful.Container, *Shedder, chan struct{}) {
+	t.Helper()
+
+	shedder, err := NewShedder(options)
+	assert.NoError(t, err)
+
+	release := make(chan struct{})
+
+	ws := new(restful.WebService)
+	ws.Filter(shedder.Filter)
+	ws.Route(ws.GET("/block").
+		To(func(request *restful.Request, response *restful.Response) {
+			<-release
+			response.WriteHeader(http.StatusNoContent)
+		}))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	return container, shedder, release
+}
+
+func serve(container *restful.Container) *httptest.ResponseRecorder {
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/block", nil))
+	return resp
+}
+
+func waitForState(t *testing.T, shedder *Shedder, condition func(State) bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !condition(shedder.State()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected state: %+v", shedder.State())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestNewShedder_InvalidOptions(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewShedder(Options{})
+	assert.Error(t, err)
+
+	_, err = NewShedder(Options{MaxConcurrent: 1, MaxQueue: -1})
+	assert.Error(t, err)
+}
+
+func TestShedder_QueueFull(t *testing.T) {
+	t.Parallel()
+
+	container, shedder, release := createTestContainer(t, Options{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: time.Minute})
+
+	var wg sync.WaitGroup
+	responses := make([]*httptest.ResponseRecorder, 2)
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = serve(container)
+		}(i)
+		waitForState(t, shedder, func(state State) bool { return state.InFlight+state.Queued == i+1 })
+	}
+
+	state := shedder.State()
+	assert.Equal(t, 1, state.InFlight)
+	assert.Equal(t, 1, state.Queued)
+	assert.True(t, state.Overloaded)
+
+	resp := serve(container)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Equal(t, "60", resp.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"errorCode":20029,"errorMessage":"service is overloaded: too many queued requests"}`,
+		resp.Body.String())
+
+	close(release)
+	wg.Wait()
+
+	for _, resp := range responses {
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+	}
+
+	state = shedder.State()
+	assert.Equal(t, State{Accepted: 2, Rejected: 1}, state)
+}
+
+func TestShedder_QueueTimeout(t *testing.T) {
+	t.Parallel()
+
+	container, shedder, release := createTestContainer(t,
+		Options{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: 10 * time.Millisecond})
+
+	done := make(chan struct{})
+	go func() {
+		serve(container)
+		close(done)
+	}()
+	waitForState(t, shedder, func(state State) bool { return state.InFlight == 1 })
+
+	resp := serve(container)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Equal(t, uint64(1), shedder.State().TimedOut)
+
+	close(release)
+	<-done
+}
+
+func TestShedder_Handler(t *testing.T) {
+	t.Parallel()
+
+	_, shedder, _ := createTestContainer(t, Options{MaxConcurrent: 2})
+
+	resp := httptest.NewRecorder()
+	shedder.Handler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/overload", nil))
+
+	state := State{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &state))
+	assert.Equal(t, State{}, state)
+}
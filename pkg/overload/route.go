@@ -0,0 +1,137 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package overload
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+// MaxConcurrentMetadata is the route metadata key to limit the concurrent executions of the route.
+// The value is either the maximum number of concurrent executions (int),
+// or Options to override the queue options of the RouteLimiter as well.
+const MaxConcurrentMetadata = "MaxConcurrent"
+
+// RouteLimiter limits the concurrent executions of the routes declaring MaxConcurrentMetadata,
+// e.g. heavy report generation, each route has its own slots and queue.
+type RouteLimiter struct {
+	defaults Options
+
+	mutex    sync.RWMutex
+	shedders map[string]*Shedder
+}
+
+// NewRouteLimiter creates new RouteLimiter instance. The MaxQueue and QueueTimeout options are used
+// for the routes declaring only the maximum number of concurrent executions, MaxConcurrent is ignored.
+func NewRouteLimiter(defaults Options) *RouteLimiter {
+	return &RouteLimiter{
+		defaults: defaults,
+		shedders: make(map[string]*Shedder),
+	}
+}
+
+// Filter serves the request when a slot of the route is free, or waits in the queue of the route.
+// The request is rejected with 503 error response when the queue is full or the wait times out.
+// The routes without MaxConcurrentMetadata aren't limited.
+func (l *RouteLimiter) Filter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	shedder := l.getShedder(req.SelectedRoute())
+	if shedder == nil {
+		chain.ProcessFilter(req, resp)
+		return
+	}
+
+	shedder.Filter(req, resp, chain)
+}
+
+// getShedder returns the Shedder of the route, created on the first request of the route
+func (l *RouteLimiter) getShedder(route restful.RouteReader) *Shedder {
+	if route == nil {
+		return nil
+	}
+
+	options, ok := l.routeOptions(route.Metadata()[MaxConcurrentMetadata])
+	if !ok {
+		return nil
+	}
+
+	key := route.Method() + " " + route.Path()
+
+	l.mutex.RLock()
+	shedder, ok := l.shedders[key]
+	l.mutex.RUnlock()
+	if ok {
+		return shedder
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if shedder, ok = l.shedders[key]; ok {
+		return shedder
+	}
+
+	shedder, err := NewShedder(options)
+	if err != nil {
+		logrus.Errorf("invalid %s metadata of %s: %v", MaxConcurrentMetadata, key, err)
+		shedder = nil
+	}
+	// the invalid metadata is stored as well so the error is logged once
+	l.shedders[key] = shedder
+
+	return shedder
+}
+
+func (l *RouteLimiter) routeOptions(metadata interface{}) (Options, bool) {
+	switch value := metadata.(type) {
+	case int:
+		options := l.defaults
+		options.MaxConcurrent = value
+		return options, true
+	case Options:
+		return value, true
+	default:
+		return Options{}, false
+	}
+}
+
+// States returns the current state of each limited route, keyed by the method and path of the route,
+// e.g. "POST /reports". Only the routes which have been requested are included.
+func (l *RouteLimiter) States() map[string]State {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	states := make(map[string]State, len(l.shedders))
+	for key, shedder := range l.shedders {
+		if shedder != nil {
+			states[key] = shedder.State()
+		}
+	}
+
+	return states
+}
+
+// Handler returns http.Handler exposing the current state of each limited route in JSON format
+func (l *RouteLimiter) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", restful.MIME_JSON)
+		if err := json.NewEncoder(w).Encode(l.States()); err != nil {
+			logrus.Error(err)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package overload
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// createRouteTestContainer creates container whose /reports route is limited by the metadata
+// and waits until the release channel is closed
+func createRouteTestContainer(limiter *RouteLimiter, reportsLimit interface{}) (*restful.Container, chan struct{}) {
+	release := make(chan struct{})
+
+	ws := new(restful.WebService)
+	ws.Filter(limiter.Filter)
+	ws.Route(ws.GET("/reports").
+		Metadata(MaxConcurrentMetadata, reportsLimit).
+		To(func(request *restful.Request, response *restful.Response) {
+			<-release
+			response.WriteHeader(http.StatusNoContent)
+		}))
+	ws.Route(ws.GET("/users").
+		To(func(request *restful.Request, response *restful.Response) {
+			response.WriteHeader(http.StatusNoContent)
+		}))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	return container, release
+}
+
+func serveRoute(container *restful.Container, path string) *httptest.ResponseRecorder {
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, path, nil))
+	return resp
+}
+
+func waitForRouteState(t *testing.T, limiter *RouteLimiter, route string, condition func(State) bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !condition(limiter.States()[route]) {
+		if time.Now().After(deadline) {
+			t.Fatalf("unexpected state: %+v", limiter.States()[route])
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRouteLimiter(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewRouteLimiter(Options{MaxQueue: 1, QueueTimeout: time.Minute})
+	container, release := createRouteTestContainer(limiter, 1)
+
+	responses := make(chan *httptest.ResponseRecorder, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			responses <- serveRoute(container, "/reports")
+		}()
+		expected := i + 1
+		waitForRouteState(t, limiter, "GET /reports", func(state State) bool {
+			return state.InFlight+state.Queued == expected
+		})
+	}
+
+	// the route without metadata isn't limited
+	assert.Equal(t, http.StatusNoContent, serveRoute(container, "/users").Code)
+
+	resp := serveRoute(container, "/reports")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Equal(t, "60", resp.Header().Get("Retry-After"))
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusNoContent, (<-responses).Code)
+	}
+
+	assert.Equal(t, map[string]State{"GET /reports": {Accepted: 2, Rejected: 1}}, limiter.States())
+}
+
+func TestRouteLimiter_OptionsMetadata(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewRouteLimiter(Options{MaxQueue: 10, QueueTimeout: time.Minute})
+	container, release := createRouteTestContainer(limiter,
+		Options{MaxConcurrent: 1, QueueTimeout: 10 * time.Millisecond})
+
+	done := make(chan struct{})
+	go func() {
+		serveRoute(container, "/reports")
+		close(done)
+	}()
+	waitForRouteState(t, limiter, "GET /reports", func(state State) bool { return state.InFlight == 1 })
+
+	// the queue options of the metadata override the defaults
+	assert.Equal(t, http.StatusServiceUnavailable, serveRoute(container, "/reports").Code)
+	assert.Equal(t, uint64(1), limiter.States()["GET /reports"].Rejected)
+
+	close(release)
+	<-done
+}
+
+func TestRouteLimiter_InvalidMetadata(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewRouteLimiter(Options{})
+	container, release := createRouteTestContainer(limiter, 0)
+	close(release)
+
+	assert.Equal(t, http.StatusNoContent, serveRoute(container, "/reports").Code)
+	assert.Empty(t, limiter.States())
+}
+
+func TestRouteLimiter_Handler(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewRouteLimiter(Options{})
+	container, release := createRouteTestContainer(limiter, 2)
+	close(release)
+	serveRoute(container, "/reports")
+
+	resp := httptest.NewRecorder()
+	limiter.Handler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/overload/routes", nil))
+
+	states := map[string]State{}
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &states))
+	assert.Equal(t, map[string]State{"GET /reports": {Accepted: 1}}, states)
+}
@@ -0,0 +1,97 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Flusher synchronously flushes the buffered logs, metrics or traces.
+// It's implemented by the OpenTelemetry SDK trace.TracerProvider.
+type Flusher interface {
+	ForceFlush(ctx context.Context) error
+}
+
+// FlusherFunc is an adapter to use the function as Flusher
+type FlusherFunc func(ctx context.Context) error
+
+// ForceFlush calls f(ctx)
+func (f FlusherFunc) ForceFlush(ctx context.Context) error {
+	return f(ctx)
+}
+
+type namedFlusher struct {
+	name    string
+	flusher Flusher
+}
+
+var (
+	flushers      []namedFlusher
+	flushersMutex sync.RWMutex
+)
+
+// RegisterFlusher registers the flusher called by ForceFlush,
+// the flusher registered with the same name is replaced.
+func RegisterFlusher(name string, flusher Flusher) {
+	flushersMutex.Lock()
+	defer flushersMutex.Unlock()
+
+	for i := range flushers {
+		if flushers[i].name == name {
+			flushers[i].flusher = flusher
+			return
+		}
+	}
+	flushers = append(flushers, namedFlusher{name: name, flusher: flusher})
+}
+
+// UnregisterFlusher removes the flusher registered with the name
+func UnregisterFlusher(name string) {
+	flushersMutex.Lock()
+	defer flushersMutex.Unlock()
+
+	for i := range flushers {
+		if flushers[i].name == name {
+			flushers = append(flushers[:i:i], flushers[i+1:]...)
+			return
+		}
+	}
+}
+
+// ForceFlush synchronously flushes the logs, metrics and traces of the registered flushers in the registration order,
+// e.g. before the response is completed in the serverless environment where the process may freeze afterwards,
+// or in the tests before asserting the output.
+// All flushers are called even if some of them fail, the failures are returned as a single error.
+func ForceFlush(ctx context.Context) error {
+	flushersMutex.RLock()
+	registered := make([]namedFlusher, len(flushers))
+	copy(registered, flushers)
+	flushersMutex.RUnlock()
+
+	var failures []string
+	for _, f := range registered {
+		if err := f.flusher.ForceFlush(ctx); err != nil {
+			failures = append(failures, f.name+": "+err.Error())
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("unable to flush %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugins
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// nolint:paralleltest
+func TestForceFlush(t *testing.T) {
+	defer func() {
+		flushers = nil
+	}()
+
+	var flushed []string
+	flusher := func(name string, err error) Flusher {
+		return FlusherFunc(func(ctx context.Context) error {
+			flushed = append(flushed, name)
+			return err
+		})
+	}
+
+	RegisterFlusher("logs", flusher("logs", nil))
+	RegisterFlusher("traces", flusher("traces", errors.New("exporter is down")))
+	RegisterFlusher("metrics", flusher("metrics", errors.New("timeout")))
+
+	err := ForceFlush(context.Background())
+	assert.EqualError(t, err, "unable to flush traces: exporter is down; metrics: timeout")
+	assert.Equal(t, []string{"logs", "traces", "metrics"}, flushed)
+
+	// the flusher with the same name is replaced
+	flushed = nil
+	RegisterFlusher("traces", flusher("new traces", nil))
+	UnregisterFlusher("metrics")
+	assert.NoError(t, ForceFlush(context.Background()))
+	assert.Equal(t, []string{"logs", "new traces"}, flushed)
+}
+
+// nolint:paralleltest
+func TestForceFlush_NoFlusher(t *testing.T) {
+	assert.NoError(t, ForceFlush(context.Background()))
+}
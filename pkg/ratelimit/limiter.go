@@ -0,0 +1,182 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/clock"
+)
+
+const defaultMaxKeys = 100000
+
+// Limit is a token bucket limit, the bucket is refilled with Requests tokens per Period
+// and holds up to Burst tokens. A zero Limit disables the rate limit.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int // Default: Requests
+}
+
+// PerSecond creates a limit of n requests per second
+func PerSecond(n int) Limit {
+	return Limit{Requests: n, Period: time.Second}
+}
+
+// PerMinute creates a limit of n requests per minute
+func PerMinute(n int) Limit {
+	return Limit{Requests: n, Period: time.Minute}
+}
+
+// Enabled checks whether the limit restricts the requests
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// Capacity returns the maximum number of tokens of the bucket
+func (l Limit) Capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// rate returns the number of tokens refilled per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Result is the result of taking a token from the bucket
+type Result struct {
+	Allowed    bool
+	Limit      int           // Capacity of the bucket
+	Remaining  int           // Tokens left in the bucket
+	ResetAfter time.Duration // Time until the bucket is full again
+	RetryAfter time.Duration // Time until the next request is allowed, zero if the request is allowed
+}
+
+// Limiter takes a token from the bucket of the key.
+// Implement this interface to share the buckets between the replicas of the service, e.g. using Redis.
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+type bucket struct {
+	tokens    float64
+	capacity  float64
+	rate      float64
+	updatedAt time.Time
+}
+
+// refill adds the tokens refilled since the last update
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.updatedAt).Seconds()*b.rate)
+	b.updatedAt = now
+}
+
+// MemoryLimiter keeps the token buckets in memory,
+// so the limit applies to each replica of the service separately.
+type MemoryLimiter struct {
+	maxKeys int
+	clock   clock.Clock
+
+	mutex   sync.Mutex
+	buckets map[string]*bucket
+}
+
+// NewMemoryLimiter creates new MemoryLimiter instance tracking up to maxKeys buckets.
+// The full buckets are evicted first when the limiter runs out of keys. Default maxKeys: 100000
+func NewMemoryLimiter(maxKeys int) *MemoryLimiter {
+	if maxKeys <= 0 {
+		maxKeys = defaultMaxKeys
+	}
+	return &MemoryLimiter{
+		maxKeys: maxKeys,
+		clock:   clock.System(),
+		buckets: map[string]*bucket{},
+	}
+}
+
+// SetClock sets the time source of the token buckets, e.g. a fake clock in the tests. Nil restores the system clock.
+func (l *MemoryLimiter) SetClock(c clock.Clock) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.clock = clock.OrSystem(c)
+}
+
+// Allow takes a token from the bucket of the key
+func (l *MemoryLimiter) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	capacity := float64(limit.Capacity())
+	rate := limit.rate()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.clock.Now()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		l.evict(now)
+		b = &bucket{tokens: capacity, updatedAt: now}
+		l.buckets[key] = b
+	}
+	// the limit of the key might change, e.g. the route's limit is updated
+	b.capacity = capacity
+	b.rate = rate
+	b.refill(now)
+
+	result := Result{Limit: limit.Capacity()}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - b.tokens) / rate)
+	}
+	result.Remaining = int(b.tokens)
+	result.ResetAfter = secondsToDuration((capacity - b.tokens) / rate)
+
+	return result, nil
+}
+
+// evict removes the buckets which are full by now, since they behave the same as new buckets.
+// A random bucket is removed when none of them is full.
+func (l *MemoryLimiter) evict(now time.Time) {
+	if len(l.buckets) < l.maxKeys {
+		return
+	}
+
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= b.capacity {
+			delete(l.buckets, key)
+		}
+	}
+	if len(l.buckets) < l.maxKeys {
+		return
+	}
+
+	for key := range l.buckets {
+		delete(l.buckets, key)
+		return
+	}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,76 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clock.NewFake(time.Unix(1000, 0))
+	limiter := NewMemoryLimiter(0)
+	limiter.SetClock(fakeClock)
+	limit := Limit{Requests: 2, Period: time.Second, Burst: 3}
+
+	for i := 2; i >= 0; i-- {
+		result, err := limiter.Allow(context.Background(), "key", limit)
+		assert.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result, _ := limiter.Allow(context.Background(), "key", limit)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+	assert.Equal(t, 1500*time.Millisecond, result.ResetAfter)
+
+	// the other keys have their own bucket
+	result, _ = limiter.Allow(context.Background(), "other", limit)
+	assert.True(t, result.Allowed)
+
+	fakeClock.Advance(500 * time.Millisecond)
+	result, _ = limiter.Allow(context.Background(), "key", limit)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+}
+
+func TestMemoryLimiter_Evict(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clock.NewFake(time.Unix(1000, 0))
+	limiter := NewMemoryLimiter(2)
+	limiter.SetClock(fakeClock)
+	limit := PerMinute(1)
+
+	_, _ = limiter.Allow(context.Background(), "a", limit)
+	_, _ = limiter.Allow(context.Background(), "b", limit)
+	_, _ = limiter.Allow(context.Background(), "c", limit)
+	assert.Len(t, limiter.buckets, 2)
+	assert.Contains(t, limiter.buckets, "c")
+
+	// the full buckets are evicted first
+	fakeClock.Advance(time.Minute)
+	_, _ = limiter.Allow(context.Background(), "d", limit)
+	assert.Len(t, limiter.buckets, 1)
+	assert.Contains(t, limiter.buckets, "d")
+}
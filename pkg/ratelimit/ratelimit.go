@@ -0,0 +1,180 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/clock"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/response"
+	publicsourceip "github.com/AccelByte/public-source-ip"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// RateLimitMetadata is the route metadata key to override the limit of the route with a Limit value
+	RateLimitMetadata = "RateLimit"
+
+	// response headers of the rate limit status
+	HeaderLimit      = "X-RateLimit-Limit"
+	HeaderRemaining  = "X-RateLimit-Remaining"
+	HeaderReset      = "X-RateLimit-Reset"
+	HeaderRetryAfter = "Retry-After"
+
+	// TooManyRequests is the error code when the request exceeds the rate limit
+	TooManyRequests = iam.TooManyRequests
+
+	fieldRateLimited = "rate_limited"
+)
+
+var rateLimitedCount uint64
+
+// KeyFunc returns the key of the request's bucket, or empty string if the key isn't available
+type KeyFunc func(req *restful.Request) string
+
+// ByClientID keys the requests by the client ID of the IAM claims,
+// the IAM filter must run before the rate limit filter.
+func ByClientID(req *restful.Request) string {
+	claims := iam.RetrieveJWTClaims(req)
+	if claims == nil || claims.ClientID == "" {
+		return ""
+	}
+	return "client:" + claims.ClientID
+}
+
+// ByUserID keys the requests by the user ID of the IAM claims,
+// the IAM filter must run before the rate limit filter.
+func ByUserID(req *restful.Request) string {
+	claims := iam.RetrieveJWTClaims(req)
+	if claims == nil || claims.Subject == "" {
+		return ""
+	}
+	return "user:" + claims.Subject
+}
+
+// BySourceIP keys the requests by the public source IP of X-Forwarded-For header,
+// or the remote address if the header doesn't contain public IP.
+func BySourceIP(req *restful.Request) string {
+	sourceIP := publicsourceip.PublicIP(&http.Request{Header: req.Request.Header})
+	if sourceIP == "" {
+		sourceIP = req.Request.RemoteAddr
+		if host, _, err := net.SplitHostPort(sourceIP); err == nil {
+			sourceIP = host
+		}
+	}
+	return "ip:" + sourceIP
+}
+
+// Options contains options for the rate limit filter
+type Options struct {
+	// Limit is the default limit of the routes
+	Limit Limit
+	// ClientLimits overrides the limit of specific client IDs, e.g. the internal services.
+	// The overridden client has its own bucket across the routes, apart from the default and the route buckets.
+	ClientLimits map[string]Limit
+	// KeyFunc keys the requests, it falls back to BySourceIP when the key isn't available. Default: ByClientID
+	KeyFunc KeyFunc
+	// Limiter keeps the token buckets. Default: NewMemoryLimiter(0)
+	Limiter Limiter
+	// Clock is the time source of the default limiter, e.g. a fake clock in the tests. Default: the system clock
+	Clock clock.Clock
+}
+
+// RateLimitedCount returns the number of requests rejected by the rate limit since the service started
+func RateLimitedCount() uint64 {
+	return atomic.LoadUint64(&rateLimitedCount)
+}
+
+// Filter limits the request rate of each client using token bucket.
+// The limit can be overridden per route using RateLimitMetadata route metadata,
+// and such route has its own bucket. The request exceeding the limit is rejected with 429 error response.
+// The limiter error doesn't reject the request, so the service stays available when the limiter storage is down.
+func Filter(options *Options) restful.FilterFunction {
+	if options == nil {
+		options = &Options{}
+	}
+	keyFunc := options.KeyFunc
+	if keyFunc == nil {
+		keyFunc = ByClientID
+	}
+	limiter := options.Limiter
+	if limiter == nil {
+		memoryLimiter := NewMemoryLimiter(0)
+		memoryLimiter.SetClock(options.Clock)
+		limiter = memoryLimiter
+	}
+
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		key := keyFunc(req)
+		if key == "" {
+			key = BySourceIP(req)
+		}
+
+		limit := options.Limit
+		if route := req.SelectedRoute(); route != nil {
+			if routeLimit, ok := route.Metadata()[RateLimitMetadata].(Limit); ok {
+				limit = routeLimit
+				key = req.Request.Method + " " + route.Path() + " " + key
+			}
+		}
+		if claims := iam.RetrieveJWTClaims(req); claims != nil {
+			if clientLimit, ok := options.ClientLimits[claims.ClientID]; ok {
+				limit = clientLimit
+				key = "client-limit:" + claims.ClientID
+			}
+		}
+
+		if !limit.Enabled() {
+			chain.ProcessFilter(req, resp)
+			return
+		}
+
+		result, err := limiter.Allow(req.Request.Context(), key, limit)
+		if err != nil {
+			logrus.Errorf("unable to check rate limit of %s: %v", key, err)
+			chain.ProcessFilter(req, resp)
+			return
+		}
+
+		resp.Header().Set(HeaderLimit, strconv.Itoa(result.Limit))
+		resp.Header().Set(HeaderRemaining, strconv.Itoa(result.Remaining))
+		resp.Header().Set(HeaderReset, strconv.Itoa(ceilSeconds(result.ResetAfter)))
+
+		if result.Allowed {
+			chain.ProcessFilter(req, resp)
+			return
+		}
+
+		atomic.AddUint64(&rateLimitedCount, 1)
+		log.AdditionalFields(req, map[string]interface{}{fieldRateLimited: true})
+
+		resp.Header().Set(HeaderRetryAfter, strconv.Itoa(ceilSeconds(result.RetryAfter)))
+		response.WriteErrorEnvelope(req, resp, http.StatusTooManyRequests,
+			response.NewError(TooManyRequests, "too many requests", nil))
+	}
+}
+
+// ceilSeconds rounds up the duration to seconds, so the client doesn't retry too early
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,174 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/clock"
+	iamSDK "github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(context.Context, string, Limit) (Result, error) {
+	return Result{}, errors.New("connection refused")
+}
+
+// withClaims simulates the IAM filter
+func withClaims(clientID string) restful.FilterFunction {
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		if clientID != "" {
+			req.SetAttribute(iam.ClaimsAttribute, &iamSDK.JWTClaims{ClientID: clientID})
+		}
+		chain.ProcessFilter(req, resp)
+	}
+}
+
+func newWebService(clientID string, options *Options) *restful.WebService {
+	handler := func(request *restful.Request, response *restful.Response) {
+		response.WriteHeader(http.StatusNoContent)
+	}
+
+	ws := new(restful.WebService)
+	ws.Filter(withClaims(clientID))
+	ws.Filter(Filter(options))
+	ws.Route(ws.GET("/users").To(handler))
+	ws.Route(ws.POST("/login").Metadata(RateLimitMetadata, PerMinute(1)).To(handler))
+	ws.Route(ws.GET("/health").Metadata(RateLimitMetadata, Limit{}).To(handler))
+	return ws
+}
+
+func serve(ws *restful.WebService, method, path, remoteAddr string) *httptest.ResponseRecorder {
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = remoteAddr
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	fakeClock := clock.NewFake(time.Now())
+	ws := newWebService("client1", &Options{Limit: PerMinute(2), Clock: fakeClock})
+
+	resp := serve(ws, http.MethodGet, "/users", "10.0.0.1:1234")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Equal(t, "2", resp.Header().Get(HeaderLimit))
+	assert.Equal(t, "1", resp.Header().Get(HeaderRemaining))
+	assert.Equal(t, "30", resp.Header().Get(HeaderReset))
+
+	resp = serve(ws, http.MethodGet, "/users", "10.0.0.2:1234")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Equal(t, "0", resp.Header().Get(HeaderRemaining))
+
+	countBefore := RateLimitedCount()
+	resp = serve(ws, http.MethodGet, "/users", "10.0.0.3:1234")
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	assert.Equal(t, "30", resp.Header().Get(HeaderRetryAfter))
+	assert.JSONEq(t, `{"errorCode":20007,"errorMessage":"too many requests"}`, resp.Body.String())
+	assert.True(t, RateLimitedCount() > countBefore)
+
+	// a token is refilled after the retry after
+	fakeClock.Advance(30 * time.Second)
+	resp = serve(ws, http.MethodGet, "/users", "10.0.0.3:1234")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Equal(t, "0", resp.Header().Get(HeaderRemaining))
+
+	// the route with overridden limit has its own bucket
+	resp = serve(ws, http.MethodPost, "/login", "10.0.0.1:1234")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Equal(t, "1", resp.Header().Get(HeaderLimit))
+	resp = serve(ws, http.MethodPost, "/login", "10.0.0.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+
+	// the rate limit is disabled by zero limit
+	for i := 0; i < 3; i++ {
+		resp = serve(ws, http.MethodGet, "/health", "10.0.0.1:1234")
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+		assert.Empty(t, resp.Header().Get(HeaderLimit))
+	}
+}
+
+func TestFilter_SourceIPFallback(t *testing.T) {
+	t.Parallel()
+
+	ws := newWebService("", &Options{Limit: PerMinute(1)})
+
+	assert.Equal(t, http.StatusNoContent, serve(ws, http.MethodGet, "/users", "10.0.0.1:1234").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(ws, http.MethodGet, "/users", "10.0.0.1:5678").Code)
+	assert.Equal(t, http.StatusNoContent, serve(ws, http.MethodGet, "/users", "10.0.0.2:1234").Code)
+}
+
+func TestFilter_ClientLimits(t *testing.T) {
+	t.Parallel()
+
+	ws := newWebService("internal", &Options{
+		Limit:        PerMinute(1),
+		ClientLimits: map[string]Limit{"internal": PerMinute(100)},
+	})
+
+	for i := 0; i < 3; i++ {
+		resp := serve(ws, http.MethodGet, "/users", "10.0.0.1:1234")
+		assert.Equal(t, http.StatusNoContent, resp.Code)
+		assert.Equal(t, "100", resp.Header().Get(HeaderLimit))
+	}
+}
+
+func TestFilter_ClientLimitsOwnBucket(t *testing.T) {
+	t.Parallel()
+
+	limiter := NewMemoryLimiter(0)
+	limited := newWebService("internal", &Options{Limit: PerMinute(1), Limiter: limiter})
+	overridden := newWebService("internal", &Options{
+		Limit:        PerMinute(1),
+		ClientLimits: map[string]Limit{"internal": PerMinute(100)},
+		Limiter:      limiter,
+	})
+
+	// the route bucket of the client is exhausted
+	assert.Equal(t, http.StatusNoContent, serve(limited, http.MethodPost, "/login", "10.0.0.1:1234").Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(limited, http.MethodPost, "/login", "10.0.0.1:1234").Code)
+
+	// the overridden client doesn't share the route bucket, its bucket is shared across the routes
+	resp := serve(overridden, http.MethodPost, "/login", "10.0.0.1:1234")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Equal(t, "99", resp.Header().Get(HeaderRemaining))
+	resp = serve(overridden, http.MethodGet, "/users", "10.0.0.1:1234")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Equal(t, "98", resp.Header().Get(HeaderRemaining))
+}
+
+func TestFilter_LimiterError(t *testing.T) {
+	t.Parallel()
+
+	ws := newWebService("client1", &Options{Limit: PerMinute(1), Limiter: failingLimiter{}})
+
+	resp := serve(ws, http.MethodGet, "/users", "10.0.0.1:1234")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Empty(t, resp.Header().Get(HeaderLimit))
+}
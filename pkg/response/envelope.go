@@ -0,0 +1,77 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package response
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// InternalServerError is the error code of the unexpected error
+	InternalServerError = 20000
+	// ServiceShuttingDown is the error code when the request is rejected because the service is shutting down
+	ServiceShuttingDown = 20025
+	// RequestTimedOut is the error code when the request exceeds its timeout
+	RequestTimedOut = 20026
+	// JobQueueFull is the error code when the job is rejected because the queue is full
+	JobQueueFull = 20027
+	// ResponseTooLarge is the error code when the response exceeds the maximum size
+	ResponseTooLarge = 20028
+	// ServiceOverloaded is the error code when the request is shed because the service is overloaded,
+	// unlike InternalServerError the request can be retried later
+	ServiceOverloaded = 20029
+
+	internalServerErrorMessage = "internal server error"
+)
+
+// NewError creates the error with the message variables, the {name} placeholders in the message are
+// replaced with the variables, e.g.
+//
+//	response.NewError(20008, "user {userId} does not exist", map[string]string{"userId": userID})
+func NewError(errorCode int, errorMessage string, messageVariables map[string]string) *Error {
+	message := errorMessage
+	for name, value := range messageVariables {
+		message = strings.ReplaceAll(message, "{"+name+"}", value)
+	}
+
+	return &Error{
+		ErrorCode:        errorCode,
+		ErrorMessage:     message,
+		MessageVariables: messageVariables,
+	}
+}
+
+// WriteErrorEnvelope sends the error in the standard error format,
+// {"errorCode": 20008, "errorMessage": "...", "messageVariables": {...}}.
+// The error which isn't an *Error is sent as internal server error without exposing its message.
+// The error code is printed as error_code field in the access log.
+func WriteErrorEnvelope(request *restful.Request, response *restful.Response, httpStatusCode int, err error) {
+	var errorResponse *Error
+	if !errors.As(err, &errorResponse) {
+		logrus.Errorf("%s %s: %v", request.Request.Method, request.Request.URL.Path, err)
+		errorResponse = &Error{ErrorCode: InternalServerError, ErrorMessage: internalServerErrorMessage}
+	}
+
+	log.SetErrorCode(request, errorResponse.ErrorCode)
+
+	if writeErr := response.WriteHeaderAndJson(httpStatusCode, errorResponse, restful.MIME_JSON); writeErr != nil {
+		logrus.Errorf("unable to write error response: %v", writeErr)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package response
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func serveRoute(route restful.RouteFunction, filters ...restful.FilterFunction) (*httptest.ResponseRecorder, *restful.Request) {
+	var request *restful.Request
+
+	ws := new(restful.WebService)
+	for _, filter := range filters {
+		ws.Filter(filter)
+	}
+	ws.Route(ws.GET("/user").To(func(req *restful.Request, resp *restful.Response) {
+		request = req
+		route(req, resp)
+	}))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/user", nil))
+	return resp, request
+}
+
+func TestNewError(t *testing.T) {
+	t.Parallel()
+
+	err := NewError(20008, "user {userId} does not exist in {namespace}",
+		map[string]string{"userId": "abc", "namespace": "accelbyte"})
+
+	assert.Equal(t, 20008, err.ErrorCode)
+	assert.Equal(t, "user abc does not exist in accelbyte", err.Error())
+	assert.Equal(t, map[string]string{"userId": "abc", "namespace": "accelbyte"}, err.MessageVariables)
+}
+
+func TestWriteErrorEnvelope(t *testing.T) {
+	t.Parallel()
+
+	resp, request := serveRoute(func(req *restful.Request, resp *restful.Response) {
+		err := NewError(20008, "user {userId} does not exist", map[string]string{"userId": "abc"})
+		WriteErrorEnvelope(req, resp, http.StatusNotFound, fmt.Errorf("unable to get user: %w", err))
+	})
+
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	assert.JSONEq(t,
+		`{"errorCode":20008,"errorMessage":"user abc does not exist","messageVariables":{"userId":"abc"}}`,
+		resp.Body.String())
+	assert.Equal(t, 20008, request.Attribute(log.ErrorCodeAttribute))
+}
+
+func TestWriteErrorEnvelope_UnknownError(t *testing.T) {
+	t.Parallel()
+
+	resp, _ := serveRoute(func(req *restful.Request, resp *restful.Response) {
+		WriteErrorEnvelope(req, resp, http.StatusInternalServerError, errors.New("sql: connection refused"))
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.JSONEq(t, `{"errorCode":20000,"errorMessage":"internal server error"}`, resp.Body.String())
+}
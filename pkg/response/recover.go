@@ -0,0 +1,216 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package response
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/trace"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+// PanicReport is the panic recovered by the Recover filter, the credentials of the request are scrubbed
+type PanicReport struct {
+	Message   string
+	Stack     string
+	TraceID   string
+	RequestID string
+	UserID    string
+	ClientID  string
+	Namespace string
+	Operation string
+	Request   log.RequestSnapshot
+}
+
+// PanicReporter forwards the recovered panic to an error tracking service, e.g. Sentry
+type PanicReporter interface {
+	ReportPanic(report *PanicReport)
+}
+
+// RecoverOptions contains options for the Recover filter
+type RecoverOptions struct {
+	// Reporter receives the recovered panic in addition to the error log. Optional
+	Reporter PanicReporter
+	// DumpAllGoroutines dumps the stacks of all goroutines instead of only the panicking one. Default: false
+	DumpAllGoroutines bool
+}
+
+// maxGoroutineDumpSize is the limit of the all goroutines dump, the rest of the dump is cut off
+const maxGoroutineDumpSize = 64 << 20
+
+// Recover is a filter that converts the panic of the inner filters and the route function
+// into 500 error response in the standard error format, instead of the container's plain text response.
+// The panic with an *Error value keeps its error code.
+// Register it after the access log filter, so the access log records the error response.
+func Recover(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	recoverFilterChain(req, resp, chain, &RecoverOptions{})
+}
+
+// RecoverWithOptions returns the Recover filter forwarding the recovered panic to the reporter
+func RecoverWithOptions(options *RecoverOptions) restful.FilterFunction {
+	if options == nil {
+		options = &RecoverOptions{}
+	}
+
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		recoverFilterChain(req, resp, chain, options)
+	}
+}
+
+func recoverFilterChain(req *restful.Request, resp *restful.Response, chain *restful.FilterChain,
+	options *RecoverOptions) {
+	original := resp.ResponseWriter
+	tracker := &headerTracker{ResponseWriter: original}
+	resp.ResponseWriter = tracker
+
+	defer func() {
+		resp.ResponseWriter = original
+
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+
+		err, ok := recovered.(error)
+		if !ok {
+			err = fmt.Errorf("%v", recovered)
+		}
+
+		var stack []byte
+		if options.DumpAllGoroutines {
+			stack = allGoroutinesStack()
+		} else {
+			stack = debug.Stack()
+		}
+
+		report := newPanicReport(req, err, stack)
+		logrus.WithFields(logrus.Fields{
+			"trace_id":   report.TraceID,
+			"request_id": report.RequestID,
+			"user_id":    report.UserID,
+			"client_id":  report.ClientID,
+			"operation":  report.Operation,
+		}).Errorf("panic recovered on %s %s: %s\n%s", req.Request.Method, req.Request.URL.Path,
+			report.Message, report.Stack)
+		if options.Reporter != nil {
+			reportPanic(options.Reporter, report)
+		}
+
+		if tracker.wroteHeader {
+			// the response is already on the wire, nothing else can be sent
+			return
+		}
+
+		var errorResponse *Error
+		if !errors.As(err, &errorResponse) {
+			errorResponse = &Error{ErrorCode: InternalServerError, ErrorMessage: internalServerErrorMessage}
+		}
+		WriteErrorEnvelope(req, resp, http.StatusInternalServerError, errorResponse)
+	}()
+
+	chain.ProcessFilter(req, resp)
+}
+
+func newPanicReport(req *restful.Request, err error, stack []byte) *PanicReport {
+	report := &PanicReport{
+		Message:   log.ScrubSecrets(req, err.Error()),
+		RequestID: trace.GetRequestID(req),
+		Request:   log.NewRequestSnapshot(req),
+	}
+	if traceID, ok := req.Attribute(trace.TraceIDKey).(string); ok {
+		report.TraceID = traceID
+	}
+	if claims := iam.RetrieveJWTClaims(req); claims != nil {
+		report.UserID = claims.Subject
+		report.ClientID = claims.ClientID
+		report.Namespace = claims.Namespace
+	}
+	if route := req.SelectedRoute(); route != nil {
+		report.Operation = route.Operation()
+	}
+	report.Stack = log.ScrubSecrets(req, annotateStack(report, stack))
+	return report
+}
+
+// annotateStack prefixes the stack dump with the trace ID, request ID and operation of the failing request,
+// so the dump can be attributed to the request when the dumps of several requests are interleaved.
+func annotateStack(report *PanicReport, stack []byte) string {
+	return fmt.Sprintf("panic in request trace_id=%s request_id=%s operation=%s\n%s",
+		report.TraceID, report.RequestID, report.Operation, stack)
+}
+
+// allGoroutinesStack returns the stacks of all goroutines, the panicking goroutine comes first
+func allGoroutinesStack() []byte {
+	buffer := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buffer, true)
+		if n < len(buffer) || len(buffer) >= maxGoroutineDumpSize {
+			return buffer[:n]
+		}
+		buffer = make([]byte, 2*len(buffer))
+	}
+}
+
+// reportPanic calls the reporter, the panic of the reporter itself must not replace the error response
+func reportPanic(reporter PanicReporter, report *PanicReport) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			logrus.Errorf("panic reporter failed: %v", recovered)
+		}
+	}()
+
+	reporter.ReportPanic(report)
+}
+
+// headerTracker decorates http.ResponseWriter to know whether the response header is written
+type headerTracker struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *headerTracker) WriteHeader(statusCode int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *headerTracker) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *headerTracker) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		flusher.Flush()
+	}
+}
+
+func (w *headerTracker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the underlying http.ResponseWriter doesn't implement http.Hijacker")
+	}
+	w.wroteHeader = true
+	return hijacker.Hijack()
+}
@@ -0,0 +1,140 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package response
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/AccelByte/go-jose/jwt"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/trace"
+	iamSDK "github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type testReporter struct {
+	reports []*PanicReport
+}
+
+func (r *testReporter) ReportPanic(report *PanicReport) {
+	r.reports = append(r.reports, report)
+}
+
+type panickingReporter struct{}
+
+func (panickingReporter) ReportPanic(*PanicReport) {
+	panic("reporter is down")
+}
+
+func TestRecover(t *testing.T) {
+	t.Parallel()
+
+	resp, request := serveRoute(func(req *restful.Request, resp *restful.Response) {
+		panic("something went wrong")
+	}, Recover)
+
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.JSONEq(t, `{"errorCode":20000,"errorMessage":"internal server error"}`, resp.Body.String())
+	assert.Equal(t, InternalServerError, request.Attribute(log.ErrorCodeAttribute))
+
+	resp, _ = serveRoute(func(req *restful.Request, resp *restful.Response) {
+		panic(NewError(20019, "unable to parse request body", nil))
+	}, Recover)
+
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.JSONEq(t, `{"errorCode":20019,"errorMessage":"unable to parse request body"}`, resp.Body.String())
+}
+
+func TestRecover_AfterWrite(t *testing.T) {
+	t.Parallel()
+
+	resp, _ := serveRoute(func(req *restful.Request, resp *restful.Response) {
+		resp.WriteHeader(http.StatusAccepted)
+		_, _ = resp.Write([]byte("partial"))
+		panic("something went wrong")
+	}, Recover)
+
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Equal(t, "partial", resp.Body.String())
+}
+
+func TestRecoverWithOptions(t *testing.T) {
+	t.Parallel()
+
+	reporter := &testReporter{}
+	withContext := func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		req.SetAttribute(trace.TraceIDKey, "trace1")
+		req.SetAttribute(trace.RequestIDKey, "request1")
+		req.SetAttribute(iam.ClaimsAttribute, &iamSDK.JWTClaims{Claims: jwt.Claims{Subject: "user1"}, ClientID: "client1"})
+		req.Request.Header.Set("Authorization", "Bearer secret-access-token")
+		chain.ProcessFilter(req, resp)
+	}
+
+	resp, _ := serveRoute(func(req *restful.Request, resp *restful.Response) {
+		panic("invalid token " + req.HeaderParameter("Authorization"))
+	}, withContext, RecoverWithOptions(&RecoverOptions{Reporter: reporter}))
+
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Len(t, reporter.reports, 1)
+
+	report := reporter.reports[0]
+	assert.Equal(t, "invalid token "+log.MaskedValue, report.Message)
+	assert.Contains(t, report.Stack, "recover_test.go")
+	assert.True(t, strings.HasPrefix(report.Stack, "panic in request trace_id=trace1 request_id=request1 operation="))
+	assert.Equal(t, "trace1", report.TraceID)
+	assert.Equal(t, "request1", report.RequestID)
+	assert.Equal(t, "user1", report.UserID)
+	assert.Equal(t, "client1", report.ClientID)
+	assert.Equal(t, http.MethodGet, report.Request.Method)
+	assert.Equal(t, log.MaskedValue, report.Request.Headers["Authorization"])
+}
+
+func TestRecoverWithOptions_ReporterPanic(t *testing.T) {
+	t.Parallel()
+
+	resp, _ := serveRoute(func(req *restful.Request, resp *restful.Response) {
+		panic("something went wrong")
+	}, RecoverWithOptions(&RecoverOptions{Reporter: panickingReporter{}}))
+
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.JSONEq(t, `{"errorCode":20000,"errorMessage":"internal server error"}`, resp.Body.String())
+}
+
+func TestRecoverWithOptions_DumpAllGoroutines(t *testing.T) {
+	t.Parallel()
+
+	reporter := &testReporter{}
+	blocked := make(chan struct{})
+	defer close(blocked)
+	go func() {
+		<-blocked
+	}()
+
+	resp, _ := serveRoute(func(req *restful.Request, resp *restful.Response) {
+		panic("something went wrong")
+	}, RecoverWithOptions(&RecoverOptions{Reporter: reporter, DumpAllGoroutines: true}))
+
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Len(t, reporter.reports, 1)
+
+	stack := reporter.reports[0].Stack
+	assert.True(t, strings.HasPrefix(stack, "panic in request trace_id= request_id= operation="))
+	assert.Contains(t, stack, "\ngoroutine ")
+	assert.Contains(t, stack, "TestRecoverWithOptions_DumpAllGoroutines.func1")
+}
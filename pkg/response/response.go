@@ -19,6 +19,7 @@ import (
 	"net/http"
 
 	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/event"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
 	"github.com/emicklei/go-restful/v3"
 	"github.com/pkg/errors"
 )
@@ -27,9 +28,23 @@ import (
 // Use event ID for error code, register your event ID at:
 // https://docs.google.com/spreadsheets/d/1tUB0BSNLyPgeWEtnNzVQkl6Shud-_ErJja2RjIyt1B0/edit?usp=sharing
 type Error struct {
-	ErrorCode    int    `json:"errorCode"`
-	ErrorMessage string `json:"errorMessage"`
-	ErrorLogMsg  string `json:"-"`
+	ErrorCode        int               `json:"errorCode"`
+	ErrorMessage     string            `json:"errorMessage"`
+	MessageVariables map[string]string `json:"messageVariables,omitempty"`
+	ErrorLogMsg      string            `json:"-"`
+	// FieldErrors are the field level details of the validation error
+	FieldErrors []FieldError `json:"fieldErrors,omitempty"`
+}
+
+// FieldError is the error of a request field, e.g. {"field": "items[0].quantity", "message": "is required"}
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error returns the error message, so the Error can be passed around as error
+func (e *Error) Error() string {
+	return e.ErrorMessage
 }
 
 const (
@@ -67,6 +82,8 @@ func WriteError(request *restful.Request, response *restful.Response, httpStatus
 // WriteErrorWithEventID sends error message with Event ID
 func WriteErrorWithEventID(request *restful.Request, response *restful.Response, httpStatusCode int,
 	serviceType int, eventID int, eventErr error, errorResponse *Error) {
+	log.SetErrorCode(request, errorResponse.ErrorCode)
+
 	err := response.WriteHeaderAndJson(httpStatusCode, errorResponse, restful.MIME_JSON)
 	if err != nil {
 		err = errors.Wrap(err, "unable to write error response")
@@ -0,0 +1,149 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sizelimit
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/response"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// MaxRequestSizeMetadata is the route metadata key to override the maximum request size of the route
+	MaxRequestSizeMetadata = "MaxRequestSize"
+
+	// RequestTooLarge is the error code when the request body exceeds the maximum size
+	RequestTooLarge = 20002
+
+	fieldRequestSizeExceeded = "request_size_exceeded"
+)
+
+// ErrRequestTooLarge is returned to the handler reading beyond the maximum request size
+var ErrRequestTooLarge = errors.New("request body exceeds the maximum size")
+
+var requestSizeExceededCount uint64
+
+// RequestSizeExceededCount returns the number of requests exceeding the maximum size since the service started
+func RequestSizeExceededCount() uint64 {
+	return atomic.LoadUint64(&requestSizeExceededCount)
+}
+
+// requestBodyLimiter decorates the body wrapped with http.MaxBytesReader to know whether the limit is exceeded,
+// since the error of http.MaxBytesReader isn't exported in older Go versions.
+type requestBodyLimiter struct {
+	io.ReadCloser
+	maxSize  int64
+	read     int64
+	exceeded bool
+}
+
+func (r *requestBodyLimiter) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	if err != nil && err != io.EOF && r.read >= r.maxSize {
+		r.exceeded = true
+		return n, ErrRequestTooLarge
+	}
+	return n, err
+}
+
+// writeTracker decorates http.ResponseWriter to know whether the response is written
+type writeTracker struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *writeTracker) WriteHeader(statusCode int) {
+	w.written = true
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *writeTracker) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *writeTracker) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.written = true
+		flusher.Flush()
+	}
+}
+
+// RequestSizeLimit is a filter that limits the request body size of the endpoint.
+// The limit can be overridden per route using MaxRequestSizeMetadata route metadata.
+// The request with larger Content-Length is rejected with 413 error response before the handler is called,
+// otherwise the body is wrapped with http.MaxBytesReader and the handler receives ErrRequestTooLarge error
+// when it reads beyond the limit. If the handler doesn't write any response after that,
+// e.g. it ignores the read error, the request is rejected with 413 error response as well.
+// The violation is printed as request_size_exceeded field in the access log.
+func RequestSizeLimit(maxSize int64) restful.FilterFunction {
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		limit := getRouteLimit(req, MaxRequestSizeMetadata, maxSize)
+		if limit <= 0 || req.Request.Body == nil || req.Request.Body == http.NoBody {
+			chain.ProcessFilter(req, resp)
+			return
+		}
+
+		if req.Request.ContentLength > limit {
+			rejectRequestTooLarge(req, resp, limit)
+			return
+		}
+
+		limiter := &requestBodyLimiter{
+			ReadCloser: http.MaxBytesReader(resp.ResponseWriter, req.Request.Body, limit),
+			maxSize:    limit,
+		}
+		req.Request.Body = limiter
+
+		original := resp.ResponseWriter
+		tracker := &writeTracker{ResponseWriter: original}
+		resp.ResponseWriter = tracker
+		defer func() {
+			resp.ResponseWriter = original
+		}()
+
+		chain.ProcessFilter(req, resp)
+
+		if !limiter.exceeded {
+			return
+		}
+		if tracker.written {
+			countRequestSizeExceeded(req, limit)
+			return
+		}
+		rejectRequestTooLarge(req, resp, limit)
+	}
+}
+
+func rejectRequestTooLarge(req *restful.Request, resp *restful.Response, limit int64) {
+	countRequestSizeExceeded(req, limit)
+
+	response.WriteErrorEnvelope(req, resp, http.StatusRequestEntityTooLarge,
+		response.NewError(RequestTooLarge, ErrRequestTooLarge.Error(), nil))
+}
+
+func countRequestSizeExceeded(req *restful.Request, limit int64) {
+	atomic.AddUint64(&requestSizeExceededCount, 1)
+	log.AdditionalFields(req, map[string]interface{}{fieldRequestSizeExceeded: true})
+	logrus.Warnf("request body of %s %s exceeds the maximum size of %d bytes",
+		req.Request.Method, req.Request.URL.Path, limit)
+}
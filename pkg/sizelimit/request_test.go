@@ -0,0 +1,160 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sizelimit
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRequestSizeLimitService(handlerCalled *bool, readErr *error) *restful.WebService {
+	handler := func(request *restful.Request, response *restful.Response) {
+		*handlerCalled = true
+		body, err := ioutil.ReadAll(request.Request.Body)
+		*readErr = err
+		if err == ErrRequestTooLarge {
+			response.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		_, _ = response.Write(body)
+	}
+
+	ws := new(restful.WebService)
+	ws.Filter(RequestSizeLimit(8))
+	ws.Route(ws.POST("/small").To(handler))
+	ws.Route(ws.POST("/large").Metadata(MaxRequestSizeMetadata, 1024).To(handler))
+	return ws
+}
+
+func serveBody(ws *restful.WebService, path string, body string, chunked bool) *httptest.ResponseRecorder {
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if chunked {
+		// hide the content length, e.g. chunked transfer encoding
+		req.ContentLength = -1
+		req.Body = ioutil.NopCloser(strings.NewReader(body))
+	}
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestRequestSizeLimit(t *testing.T) {
+	t.Parallel()
+
+	var handlerCalled bool
+	var readErr error
+	ws := createRequestSizeLimitService(&handlerCalled, &readErr)
+
+	resp := serveBody(ws, "/small", "12345678", false)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "12345678", resp.Body.String())
+
+	// the request is rejected before the handler is called
+	handlerCalled = false
+	countBefore := RequestSizeExceededCount()
+	resp = serveBody(ws, "/small", "123456789", false)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	assert.JSONEq(t, `{"errorCode":20002,"errorMessage":"request body exceeds the maximum size"}`, resp.Body.String())
+	assert.False(t, handlerCalled)
+	assert.True(t, RequestSizeExceededCount() > countBefore)
+
+	resp = serveBody(ws, "/large", "123456789", false)
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestRequestSizeLimit_UnknownContentLength(t *testing.T) {
+	t.Parallel()
+
+	var handlerCalled bool
+	var readErr error
+	ws := createRequestSizeLimitService(&handlerCalled, &readErr)
+
+	resp := serveBody(ws, "/small", "12345678", true)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.NoError(t, readErr)
+
+	resp = serveBody(ws, "/small", "123456789", true)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	assert.Equal(t, ErrRequestTooLarge, readErr)
+}
+
+func TestRequestSizeLimit_UnknownContentLengthNotWritten(t *testing.T) {
+	t.Parallel()
+
+	var readErr error
+	ws := new(restful.WebService)
+	ws.Filter(RequestSizeLimit(8))
+	ws.Route(ws.POST("/ignored").To(func(request *restful.Request, response *restful.Response) {
+		// the read error is ignored and nothing is written
+		_, readErr = ioutil.ReadAll(request.Request.Body)
+	}))
+
+	countBefore := RequestSizeExceededCount()
+	resp := serveBody(ws, "/ignored", "123456789", true)
+	assert.Equal(t, ErrRequestTooLarge, readErr)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	assert.JSONEq(t, `{"errorCode":20002,"errorMessage":"request body exceeds the maximum size"}`, resp.Body.String())
+	assert.True(t, RequestSizeExceededCount() > countBefore)
+}
+
+// nolint:paralleltest
+func TestRequestSizeLimit_AccessLog(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	log.SetAccessLogOutput(buffer)
+	log.FullAccessLogEnabled = true
+	defer func() {
+		log.SetAccessLogOutput(os.Stdout)
+		log.FullAccessLogEnabled = false
+	}()
+
+	var body string
+	ws := new(restful.WebService)
+	ws.Filter(log.AccessLog)
+	ws.Filter(RequestSizeLimit(32))
+	ws.Route(ws.POST("/users").Consumes("application/json").
+		To(func(request *restful.Request, response *restful.Response) {
+			content, _ := ioutil.ReadAll(request.Request.Body)
+			body = string(content)
+		}))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	// the body captured by the access log is still read by the handler
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"john"}`))
+	req.Header.Set("Content-Type", "application/json")
+	container.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, `{"name":"john"}`, body)
+
+	buffer.Reset()
+	req = httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"a very long name of the user"}`))
+	req.Header.Set("Content-Type", "application/json")
+	container.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Contains(t, buffer.String(), "status=413")
+	assert.Contains(t, buffer.String(), fieldRequestSizeExceeded+"=true")
+}
@@ -0,0 +1,158 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sizelimit
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/response"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// MaxResponseSizeMetadata is the route metadata key to override the maximum response size of the route
+	MaxResponseSizeMetadata = "MaxResponseSize"
+
+	// ResponseTooLarge is the error code when the response exceeds the maximum size
+	ResponseTooLarge = response.ResponseTooLarge
+
+	fieldResponseSizeExceeded = "response_size_exceeded"
+)
+
+// ErrResponseTooLarge is returned to the handler writing beyond the maximum response size
+var ErrResponseTooLarge = errors.New("response exceeds the maximum size")
+
+var responseSizeExceededCount uint64
+
+// ResponseSizeExceededCount returns the number of responses exceeding the maximum size since the service started
+func ResponseSizeExceededCount() uint64 {
+	return atomic.LoadUint64(&responseSizeExceededCount)
+}
+
+// responseSizeLimiter decorates http.ResponseWriter to stop writing the response beyond the maximum size.
+// The status code is deferred until the first write, so the response can still be replaced with an error
+// when the first write already exceeds the maximum size.
+type responseSizeLimiter struct {
+	http.ResponseWriter
+	maxSize     int64
+	written     int64
+	statusCode  int
+	wroteHeader bool
+	exceeded    bool
+}
+
+func (w *responseSizeLimiter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+}
+
+func (w *responseSizeLimiter) Write(b []byte) (int, error) {
+	if w.exceeded {
+		return 0, ErrResponseTooLarge
+	}
+	if w.written+int64(len(b)) > w.maxSize {
+		w.exceeded = true
+		return 0, ErrResponseTooLarge
+	}
+
+	w.writeHeader()
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+	return n, err
+}
+
+func (w *responseSizeLimiter) Flush() {
+	if w.exceeded {
+		return
+	}
+	w.writeHeader()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *responseSizeLimiter) writeHeader() {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if w.statusCode != 0 {
+		w.ResponseWriter.WriteHeader(w.statusCode)
+	}
+}
+
+// ResponseSizeLimit is a filter that limits the response body size of the endpoint.
+// The limit can be overridden per route using MaxResponseSizeMetadata route metadata.
+// When the response exceeds the limit before anything is written, it is replaced with 500 error response,
+// otherwise the rest of the response is discarded.
+// The violation is printed as response_size_exceeded field in the access log.
+func ResponseSizeLimit(maxSize int64) restful.FilterFunction {
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		limit := getRouteLimit(req, MaxResponseSizeMetadata, maxSize)
+		if limit <= 0 {
+			chain.ProcessFilter(req, resp)
+			return
+		}
+
+		original := resp.ResponseWriter
+		limiter := &responseSizeLimiter{ResponseWriter: original, maxSize: limit}
+		resp.ResponseWriter = limiter
+
+		chain.ProcessFilter(req, resp)
+
+		resp.ResponseWriter = original
+
+		if !limiter.exceeded {
+			limiter.writeHeader()
+			return
+		}
+
+		atomic.AddUint64(&responseSizeExceededCount, 1)
+		log.AdditionalFields(req, map[string]interface{}{fieldResponseSizeExceeded: true})
+
+		if limiter.written > 0 {
+			logrus.Errorf("response of %s %s exceeds the maximum size of %d bytes, the response is truncated",
+				req.Request.Method, req.Request.URL.Path, limit)
+			return
+		}
+
+		logrus.Errorf("response of %s %s exceeds the maximum size of %d bytes",
+			req.Request.Method, req.Request.URL.Path, limit)
+		response.WriteErrorEnvelope(req, resp, http.StatusInternalServerError,
+			response.NewError(ResponseTooLarge, ErrResponseTooLarge.Error(), nil))
+	}
+}
+
+// getRouteLimit returns the limit from the route metadata, or the default limit
+func getRouteLimit(req *restful.Request, metadata string, defaultLimit int64) int64 {
+	route := req.SelectedRoute()
+	if route == nil {
+		return defaultLimit
+	}
+
+	switch value := route.Metadata()[metadata].(type) {
+	case int:
+		return int64(value)
+	case int64:
+		return value
+	default:
+		return defaultLimit
+	}
+}
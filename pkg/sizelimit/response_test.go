@@ -0,0 +1,90 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sizelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(ws *restful.WebService, path string) *httptest.ResponseRecorder {
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, path, nil))
+	return resp
+}
+
+func TestResponseSizeLimit(t *testing.T) {
+	t.Parallel()
+
+	var writeErr error
+
+	ws := new(restful.WebService)
+	ws.Filter(ResponseSizeLimit(16))
+	ws.Route(ws.GET("/small").
+		To(func(request *restful.Request, response *restful.Response) {
+			_ = response.WriteHeaderAndJson(http.StatusCreated, []int{1, 2}, restful.MIME_JSON)
+		}))
+	ws.Route(ws.GET("/large").
+		To(func(request *restful.Request, response *restful.Response) {
+			writeErr = response.WriteHeaderAndJson(http.StatusOK, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, restful.MIME_JSON)
+		}))
+	ws.Route(ws.GET("/override").
+		Metadata(MaxResponseSizeMetadata, 1024).
+		To(func(request *restful.Request, response *restful.Response) {
+			_ = response.WriteAsJson([]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+		}))
+
+	resp := serve(ws, "/small")
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.JSONEq(t, "[1,2]", resp.Body.String())
+
+	countBefore := ResponseSizeExceededCount()
+	resp = serve(ws, "/large")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.JSONEq(t, `{"errorCode":20028,"errorMessage":"response exceeds the maximum size"}`, resp.Body.String())
+	assert.Equal(t, ErrResponseTooLarge, writeErr)
+	assert.True(t, ResponseSizeExceededCount() > countBefore)
+
+	resp = serve(ws, "/override")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, "[1,2,3,4,5,6,7,8,9,10]", resp.Body.String())
+}
+
+func TestResponseSizeLimit_Streaming(t *testing.T) {
+	t.Parallel()
+
+	ws := new(restful.WebService)
+	ws.Filter(ResponseSizeLimit(8))
+	ws.Route(ws.GET("/stream").
+		To(func(request *restful.Request, response *restful.Response) {
+			for i := 0; i < 4; i++ {
+				if _, err := response.Write([]byte("abc")); err != nil {
+					return
+				}
+				response.Flush()
+			}
+		}))
+
+	resp := serve(ws, "/stream")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "abcabc", resp.Body.String())
+}
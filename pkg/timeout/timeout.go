@@ -0,0 +1,274 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timeout
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/response"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// TimeoutMetadata is the route metadata key to override the timeout of the route (time.Duration)
+	TimeoutMetadata = "Timeout"
+
+	// RequestTimedOut is the error code when the request exceeds its timeout
+	RequestTimedOut = response.RequestTimedOut
+
+	requestTimedOutMessage = "request timed out"
+
+	fieldTimedOut = "timed_out"
+)
+
+// timeoutWriter decorates http.ResponseWriter to discard the response written by the handler
+// after the request context exceeds its deadline, so the late write doesn't corrupt the 504 error response.
+// The headers are kept apart until the response is committed, the response started before the deadline
+// is written as is. It also passes through http.Hijacker and http.CloseNotifier of the decorated writer,
+// the hijacked connection commits the response, so the upgraded connection isn't replaced with 504.
+type timeoutWriter struct {
+	http.ResponseWriter
+	ctx context.Context
+
+	mutex     sync.Mutex
+	header    http.Header
+	committed bool
+	timedOut  bool
+}
+
+func newTimeoutWriter(ctx context.Context, w http.ResponseWriter) *timeoutWriter {
+	header := make(http.Header, len(w.Header()))
+	for key, values := range w.Header() {
+		header[key] = append([]string(nil), values...)
+	}
+	return &timeoutWriter{ResponseWriter: w, ctx: ctx, header: header}
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(statusCode int) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !w.commit() {
+		return
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !w.commit() {
+		return 0, http.ErrHandlerTimeout
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *timeoutWriter) Flush() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if !w.commit() {
+		return
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker
+func (w *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("the decorated http.ResponseWriter doesn't implement http.Hijacker")
+	}
+	if !w.commit() {
+		return nil, nil, http.ErrHandlerTimeout
+	}
+	return hijacker.Hijack()
+}
+
+// CloseNotify implements http.CloseNotifier
+func (w *timeoutWriter) CloseNotify() <-chan bool {
+	// nolint:staticcheck // passed through for the handlers still using it
+	if notifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+	return make(chan bool)
+}
+
+// commit copies the headers into the underlying writer on the first write before the deadline,
+// it returns false when the write must be discarded
+func (w *timeoutWriter) commit() bool {
+	if w.committed {
+		return true
+	}
+	if w.timedOut || w.ctx.Err() == context.DeadlineExceeded {
+		w.timedOut = true
+		return false
+	}
+
+	w.committed = true
+	header := w.ResponseWriter.Header()
+	for key := range header {
+		delete(header, key)
+	}
+	for key, values := range w.header {
+		header[key] = values
+	}
+	return true
+}
+
+// expire marks the response as timed out, it returns true when nothing has been written,
+// so the response can still be replaced with the 504 error response
+func (w *timeoutWriter) expire() bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.timedOut = true
+	return !w.committed
+}
+
+// RequestTimeout is a filter that applies the timeout to the request by wrapping the request context
+// with context.WithTimeout. The timeout can be overridden per route using TimeoutMetadata route metadata,
+// zero or negative timeout disables it.
+// The rest of the chain runs in its own goroutine. When the deadline is exceeded before anything is written,
+// the 504 error response in the standard error format is written right away, without waiting for the handler,
+// and the handler's late writes are discarded. The response started before the deadline is completed by the handler.
+// The handler is still expected to stop when the context is done, and it must not use the request
+// after the deadline, since the outer filters have already continued with it.
+// The timeout is printed as timed_out field in the access log.
+func RequestTimeout(defaultTimeout time.Duration) restful.FilterFunction {
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		timeout := getRouteTimeout(req, defaultTimeout)
+		if timeout <= 0 {
+			chain.ProcessFilter(req, resp)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(req.Request.Context(), timeout)
+		defer cancel()
+
+		// the chain gets its own copy of the request and the response, so the outer filters can continue
+		// with the originals while the abandoned handler is still running. The attributes are shared.
+		chainRequest := *req
+		chainRequest.Request = req.Request.WithContext(ctx)
+		writer := newTimeoutWriter(ctx, resp.ResponseWriter)
+		chainResponse := *resp
+		chainResponse.ResponseWriter = writer
+
+		done := make(chan struct{})
+		panicked := make(chan handlerPanic, 1)
+		go func() {
+			defer close(done)
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					panicked <- handlerPanic{value: recovered, stack: debug.Stack()}
+				}
+			}()
+			chain.ProcessFilter(&chainRequest, &chainResponse)
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded && writer.expire() {
+				go logAbandonedPanic(req.Request, done, panicked)
+				log.AdditionalFields(req, map[string]interface{}{fieldTimedOut: true})
+				logrus.Warnf("%s %s exceeds the timeout of %v", req.Request.Method, req.Request.URL.Path, timeout)
+				response.WriteErrorEnvelope(req, resp, http.StatusGatewayTimeout,
+					response.NewError(RequestTimedOut, requestTimedOutMessage, nil))
+				return
+			}
+			// the response is already started or the request is canceled, the handler completes it
+			<-done
+		}
+
+		select {
+		case handlerPanic := <-panicked:
+			// the panic is raised again on the request goroutine, so the recover filter handles it
+			panic(handlerPanic.value)
+		default:
+		}
+
+		// the status and the length written by the handler are kept for the outer filters, e.g. the access log
+		original := resp.ResponseWriter
+		*resp = chainResponse
+		resp.ResponseWriter = original
+
+		if ctx.Err() != context.DeadlineExceeded {
+			return
+		}
+
+		log.AdditionalFields(req, map[string]interface{}{fieldTimedOut: true})
+
+		if !writer.expire() {
+			logrus.Warnf("%s %s exceeds the timeout of %v after the response is written",
+				req.Request.Method, req.Request.URL.Path, timeout)
+			return
+		}
+
+		logrus.Warnf("%s %s exceeds the timeout of %v", req.Request.Method, req.Request.URL.Path, timeout)
+		response.WriteErrorEnvelope(req, resp, http.StatusGatewayTimeout,
+			response.NewError(RequestTimedOut, requestTimedOutMessage, nil))
+	}
+}
+
+// handlerPanic is the panic recovered from the chain goroutine
+type handlerPanic struct {
+	value interface{}
+	stack []byte
+}
+
+// logAbandonedPanic waits for the handler abandoned after the deadline and logs its panic,
+// since it can't be raised on the request goroutine anymore
+func logAbandonedPanic(req *http.Request, done <-chan struct{}, panicked <-chan handlerPanic) {
+	<-done
+	select {
+	case handlerPanic := <-panicked:
+		logrus.Errorf("panic recovered on %s %s after the timeout: %v\n%s", req.Method, req.URL.Path,
+			handlerPanic.value, handlerPanic.stack)
+	default:
+	}
+}
+
+// getRouteTimeout returns the timeout from the route metadata, or the default timeout
+func getRouteTimeout(req *restful.Request, defaultTimeout time.Duration) time.Duration {
+	route := req.SelectedRoute()
+	if route == nil {
+		return defaultTimeout
+	}
+
+	if timeout, ok := route.Metadata()[TimeoutMetadata].(time.Duration); ok {
+		return timeout
+	}
+	return defaultTimeout
+}
@@ -0,0 +1,196 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timeout
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/log"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(ws *restful.WebService, path string) *httptest.ResponseRecorder {
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, path, nil))
+	return resp
+}
+
+func TestRequestTimeout(t *testing.T) {
+	t.Parallel()
+
+	writeErr := make(chan error, 1)
+	var timedOut interface{}
+
+	ws := new(restful.WebService)
+	ws.Filter(func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		chain.ProcessFilter(req, resp)
+		if fields, ok := req.Attribute(log.AdditionalFieldsAttribute).(map[string]interface{}); ok {
+			timedOut = fields[fieldTimedOut]
+		}
+	})
+	ws.Filter(RequestTimeout(20 * time.Millisecond))
+	ws.Route(ws.GET("/fast").
+		To(func(request *restful.Request, response *restful.Response) {
+			_ = response.WriteHeaderAndJson(http.StatusCreated, []int{1, 2}, restful.MIME_JSON)
+		}))
+	ws.Route(ws.GET("/slow").
+		To(func(request *restful.Request, response *restful.Response) {
+			<-request.Request.Context().Done()
+			response.Header().Set("X-Late", "true")
+			writeErr <- response.WriteHeaderAndJson(http.StatusInternalServerError,
+				map[string]string{"error": request.Request.Context().Err().Error()}, restful.MIME_JSON)
+		}))
+	ws.Route(ws.GET("/override").
+		Metadata(TimeoutMetadata, time.Second).
+		To(func(request *restful.Request, response *restful.Response) {
+			time.Sleep(40 * time.Millisecond)
+			_ = response.WriteAsJson([]int{1})
+		}))
+	ws.Route(ws.GET("/disabled").
+		Metadata(TimeoutMetadata, time.Duration(0)).
+		To(func(request *restful.Request, response *restful.Response) {
+			_, hasDeadline := request.Request.Context().Deadline()
+			_ = response.WriteAsJson(hasDeadline)
+		}))
+
+	resp := serve(ws, "/fast")
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.JSONEq(t, "[1,2]", resp.Body.String())
+	assert.Nil(t, timedOut)
+
+	resp = serve(ws, "/slow")
+	assert.Equal(t, http.StatusGatewayTimeout, resp.Code)
+	assert.JSONEq(t, `{"errorCode":20026,"errorMessage":"request timed out"}`, resp.Body.String())
+	assert.Empty(t, resp.Header().Get("X-Late"))
+	assert.Equal(t, http.ErrHandlerTimeout, <-writeErr)
+	assert.Equal(t, true, timedOut)
+
+	timedOut = nil
+	resp = serve(ws, "/override")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, "[1]", resp.Body.String())
+	assert.Nil(t, timedOut)
+
+	resp = serve(ws, "/disabled")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "false", resp.Body.String())
+}
+
+func TestRequestTimeout_ResponseStarted(t *testing.T) {
+	t.Parallel()
+
+	var timedOut interface{}
+
+	ws := new(restful.WebService)
+	ws.Filter(func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		chain.ProcessFilter(req, resp)
+		if fields, ok := req.Attribute(log.AdditionalFieldsAttribute).(map[string]interface{}); ok {
+			timedOut = fields[fieldTimedOut]
+		}
+	})
+	ws.Filter(RequestTimeout(20 * time.Millisecond))
+	ws.Route(ws.GET("/stream").
+		To(func(request *restful.Request, response *restful.Response) {
+			_, _ = response.Write([]byte("abc"))
+			<-request.Request.Context().Done()
+			_, _ = response.Write([]byte("def"))
+		}))
+
+	resp := serve(ws, "/stream")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "abcdef", resp.Body.String())
+	assert.Equal(t, true, timedOut)
+}
+
+func TestRequestTimeout_HandlerIgnoresDeadline(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	writeErr := make(chan error, 1)
+
+	ws := new(restful.WebService)
+	ws.Filter(RequestTimeout(20 * time.Millisecond))
+	ws.Route(ws.GET("/blocking").
+		To(func(request *restful.Request, response *restful.Response) {
+			<-release
+			_, err := response.Write([]byte("late"))
+			writeErr <- err
+		}))
+
+	start := time.Now()
+	resp := serve(ws, "/blocking")
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	assert.Equal(t, http.StatusGatewayTimeout, resp.Code)
+	assert.JSONEq(t, `{"errorCode":20026,"errorMessage":"request timed out"}`, resp.Body.String())
+
+	close(release)
+	assert.Equal(t, http.ErrHandlerTimeout, <-writeErr)
+	assert.NotContains(t, resp.Body.String(), "late")
+}
+
+type hijackableRecorder struct {
+	*httptest.ResponseRecorder
+	conn net.Conn
+}
+
+func (r *hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return r.conn, bufio.NewReadWriter(bufio.NewReader(r.conn), bufio.NewWriter(r.conn)), nil
+}
+
+func TestRequestTimeout_Hijack(t *testing.T) {
+	t.Parallel()
+
+	ws := new(restful.WebService)
+	ws.Filter(RequestTimeout(20 * time.Millisecond))
+	ws.Route(ws.GET("/upgrade").
+		To(func(request *restful.Request, response *restful.Response) {
+			hijacker, ok := response.ResponseWriter.(http.Hijacker)
+			require.True(t, ok)
+			conn, _, err := hijacker.Hijack()
+			require.NoError(t, err)
+			defer conn.Close()
+			<-request.Request.Context().Done()
+		}))
+	ws.Route(ws.GET("/unsupported").
+		To(func(request *restful.Request, response *restful.Response) {
+			_, _, err := response.ResponseWriter.(http.Hijacker).Hijack()
+			_ = response.WriteAsJson(err.Error())
+		}))
+
+	server, client := net.Pipe()
+	defer client.Close()
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	resp := &hijackableRecorder{ResponseRecorder: httptest.NewRecorder(), conn: server}
+	container.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/upgrade", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Empty(t, resp.Body.String())
+
+	recorder := serve(ws, "/unsupported")
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `"the decorated http.ResponseWriter doesn't implement http.Hijacker"`, recorder.Body.String())
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// FlightIDKey is the header of the flight ID sent by the AccelByte game client SDK,
+	// identifying a launch of the game client
+	FlightIDKey = "X-Flight-Id"
+	// GameClientVersionKey is the header of the game client version sent by the AccelByte game client SDK
+	GameClientVersionKey = "Game-Client-Version"
+	// PlatformKey is the header of the platform the game client runs on, e.g. steam, ps5 or xbox
+	PlatformKey = "X-Ab-Platform"
+	// GameClientAttribute is the request attribute key of the parsed GameClient
+	GameClientAttribute = "GameClient"
+)
+
+// gameClientValuePattern is the valid game client header value format:
+// 1-128 characters of alphanumeric, dash, underscore, dot, colon, plus or slash
+var gameClientValuePattern = regexp.MustCompile(`^[A-Za-z0-9._:+/-]{1,128}$`)
+
+// GameClient is the game client sending the request, parsed from the AccelByte game client SDK headers
+type GameClient struct {
+	FlightID string
+	Version  string
+	Platform string
+}
+
+// GetGameClient returns the game client parsed from FlightIDKey, GameClientVersionKey and PlatformKey headers,
+// the missing or invalid header is left empty.
+// The game client is parsed on the first call and stored as request attribute.
+func GetGameClient(req *restful.Request) GameClient {
+	if gameClient, ok := req.Attribute(GameClientAttribute).(GameClient); ok {
+		return gameClient
+	}
+
+	gameClient := GameClient{
+		FlightID: gameClientHeader(req, FlightIDKey),
+		Version:  gameClientHeader(req, GameClientVersionKey),
+		Platform: gameClientHeader(req, PlatformKey),
+	}
+
+	req.SetAttribute(GameClientAttribute, gameClient)
+	return gameClient
+}
+
+// InjectGameClient propagates the game client headers of the incoming request into the outgoing request
+func InjectGameClient(outgoingReq *http.Request, incomingReq *restful.Request) {
+	gameClient := GetGameClient(incomingReq)
+	if gameClient.FlightID != "" {
+		outgoingReq.Header.Set(FlightIDKey, gameClient.FlightID)
+	}
+	if gameClient.Version != "" {
+		outgoingReq.Header.Set(GameClientVersionKey, gameClient.Version)
+	}
+	if gameClient.Platform != "" {
+		outgoingReq.Header.Set(PlatformKey, gameClient.Platform)
+	}
+}
+
+func gameClientHeader(req *restful.Request, header string) string {
+	value := req.HeaderParameter(header)
+	if value == "" {
+		return ""
+	}
+	if !gameClientValuePattern.MatchString(value) {
+		logrus.Debugf("ignoring invalid game client value in %s header: %q", header, value)
+		return ""
+	}
+	return value
+}
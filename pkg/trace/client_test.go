@@ -0,0 +1,61 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetGameClient(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set(FlightIDKey, "3f2c9a7e1b4d4c8e9f0a1b2c3d4e5f60")
+	req.Header.Set(GameClientVersionKey, "1.2.3-beta+42")
+	req.Header.Set(PlatformKey, "steam")
+	request := restful.NewRequest(req)
+
+	expected := GameClient{FlightID: "3f2c9a7e1b4d4c8e9f0a1b2c3d4e5f60", Version: "1.2.3-beta+42", Platform: "steam"}
+	assert.Equal(t, expected, GetGameClient(request))
+	assert.Equal(t, expected, request.Attribute(GameClientAttribute))
+
+	outgoingReq := httptest.NewRequest(http.MethodGet, "/downstream", nil)
+	InjectGameClient(outgoingReq, request)
+	assert.Equal(t, "3f2c9a7e1b4d4c8e9f0a1b2c3d4e5f60", outgoingReq.Header.Get(FlightIDKey))
+	assert.Equal(t, "1.2.3-beta+42", outgoingReq.Header.Get(GameClientVersionKey))
+	assert.Equal(t, "steam", outgoingReq.Header.Get(PlatformKey))
+}
+
+func TestGetGameClient_Invalid(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set(FlightIDKey, "flight id\n")
+	req.Header.Set(PlatformKey, "ps5")
+	request := restful.NewRequest(req)
+
+	assert.Equal(t, GameClient{Platform: "ps5"}, GetGameClient(request))
+
+	outgoingReq := httptest.NewRequest(http.MethodGet, "/downstream", nil)
+	InjectGameClient(outgoingReq, request)
+	assert.Empty(t, outgoingReq.Header.Get(FlightIDKey))
+	assert.Empty(t, outgoingReq.Header.Get(GameClientVersionKey))
+	assert.Equal(t, "ps5", outgoingReq.Header.Get(PlatformKey))
+}
@@ -0,0 +1,99 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/envdoc"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ConsumerIDKey is the header of the consumer ID injected by Kong, used to propagate the consumer ID
+	ConsumerIDKey = "X-Consumer-ID"
+	// ConsumerIDAttribute is the request attribute key of the parsed consumer ID
+	ConsumerIDAttribute = "GatewayConsumerID"
+
+	consumerIDHeadersEnv = "GATEWAY_CONSUMER_ID_HEADERS"
+)
+
+// ConsumerIDHeaders are the request headers carrying the consumer (application) ID injected by the API gateway,
+// the first header with a valid value is used. It can be set using comma-separated
+// GATEWAY_CONSUMER_ID_HEADERS env var, e.g. "X-Consumer-ID,X-Apigee-App-ID". Default: X-Consumer-ID
+var ConsumerIDHeaders = []string{ConsumerIDKey}
+
+// consumerIDPattern is the valid consumer ID format:
+// 1-128 characters of alphanumeric, dash, underscore, dot, colon or at sign
+var consumerIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:@-]{1,128}$`)
+
+func init() {
+	envdoc.Register(envdoc.Variable{Name: consumerIDHeadersEnv, Package: "trace", Type: envdoc.TypeList,
+		Default: ConsumerIDKey, Description: "Headers of the consumer ID injected by the API gateway"})
+
+	if value, ok := os.LookupEnv(consumerIDHeadersEnv); ok {
+		headers := make([]string, 0)
+		for _, header := range strings.Split(value, ",") {
+			if header = strings.TrimSpace(header); header != "" {
+				headers = append(headers, header)
+			}
+		}
+		if len(headers) > 0 {
+			ConsumerIDHeaders = headers
+		}
+	}
+}
+
+// IsValidConsumerID checks the consumer ID format
+func IsValidConsumerID(consumerID string) bool {
+	return consumerIDPattern.MatchString(consumerID)
+}
+
+// GetConsumerID returns the consumer ID injected by the API gateway, or empty string if there is none.
+// The consumer ID is parsed from ConsumerIDHeaders on the first call and stored as request attribute.
+// Notes: the gateway must overwrite these headers, otherwise the client can set any consumer ID.
+func GetConsumerID(req *restful.Request) string {
+	if consumerID, ok := req.Attribute(ConsumerIDAttribute).(string); ok {
+		return consumerID
+	}
+
+	consumerID := ""
+	for _, header := range ConsumerIDHeaders {
+		value := req.HeaderParameter(header)
+		if value == "" {
+			continue
+		}
+		if !IsValidConsumerID(value) {
+			logrus.Debugf("ignoring invalid consumer ID in %s header: %q", header, value)
+			continue
+		}
+		consumerID = value
+		break
+	}
+
+	req.SetAttribute(ConsumerIDAttribute, consumerID)
+	return consumerID
+}
+
+// InjectConsumerID propagates the consumer ID of the incoming request into the outgoing request header
+func InjectConsumerID(outgoingReq *http.Request, incomingReq *restful.Request) {
+	if consumerID := GetConsumerID(incomingReq); consumerID != "" {
+		outgoingReq.Header.Set(ConsumerIDKey, consumerID)
+	}
+}
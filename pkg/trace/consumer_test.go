@@ -0,0 +1,78 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidConsumerID(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsValidConsumerID("4f8a1c2e-mobile_app.v2"))
+	assert.True(t, IsValidConsumerID("app:partner@example.com"))
+	assert.False(t, IsValidConsumerID(""))
+	assert.False(t, IsValidConsumerID("mobile app"))
+	assert.False(t, IsValidConsumerID(strings.Repeat("a", 129)))
+}
+
+func TestGetConsumerID(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set(ConsumerIDKey, "mobile-app")
+	request := restful.NewRequest(req)
+
+	assert.Equal(t, "mobile-app", GetConsumerID(request))
+	assert.Equal(t, "mobile-app", request.Attribute(ConsumerIDAttribute))
+
+	outgoingReq := httptest.NewRequest(http.MethodGet, "/downstream", nil)
+	InjectConsumerID(outgoingReq, request)
+	assert.Equal(t, "mobile-app", outgoingReq.Header.Get(ConsumerIDKey))
+}
+
+func TestGetConsumerID_Invalid(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set(ConsumerIDKey, "mobile app\n")
+	request := restful.NewRequest(req)
+
+	assert.Empty(t, GetConsumerID(request))
+
+	outgoingReq := httptest.NewRequest(http.MethodGet, "/downstream", nil)
+	InjectConsumerID(outgoingReq, request)
+	assert.Empty(t, outgoingReq.Header.Get(ConsumerIDKey))
+}
+
+// nolint:paralleltest
+func TestGetConsumerID_Headers(t *testing.T) {
+	defer func(headers []string) { ConsumerIDHeaders = headers }(ConsumerIDHeaders)
+	ConsumerIDHeaders = []string{"X-Apigee-App-ID", ConsumerIDKey}
+
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set(ConsumerIDKey, "kong-consumer")
+	req.Header.Set("X-Apigee-App-ID", "apigee-app")
+	assert.Equal(t, "apigee-app", GetConsumerID(restful.NewRequest(req)))
+
+	req.Header.Set("X-Apigee-App-ID", "invalid app")
+	assert.Equal(t, "kong-consumer", GetConsumerID(restful.NewRequest(req)))
+}
@@ -0,0 +1,70 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// JourneyIDKey is the header and attribute key of the client-provided journey ID,
+	// which correlates the requests of a multi-request flow, e.g. login -> create character -> join match
+	JourneyIDKey = "X-Ab-JourneyID"
+)
+
+// journeyIDPattern is the valid journey ID format: 1-64 characters of alphanumeric, dash, underscore or dot
+var journeyIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// JourneyFilter is a filter that reads the journey ID from X-Ab-JourneyID request header,
+// stores it as request attribute and echoes it in the response header.
+// The journey ID with invalid format is ignored.
+func JourneyFilter() restful.FilterFunction {
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		journeyID := req.HeaderParameter(JourneyIDKey)
+		if journeyID != "" {
+			if IsValidJourneyID(journeyID) {
+				req.SetAttribute(JourneyIDKey, journeyID)
+				resp.Header().Set(JourneyIDKey, journeyID)
+			} else {
+				logrus.Debugf("ignoring invalid journey ID: %q", journeyID)
+				req.Request.Header.Del(JourneyIDKey)
+			}
+		}
+
+		chain.ProcessFilter(req, resp)
+	}
+}
+
+// IsValidJourneyID checks the journey ID format
+func IsValidJourneyID(journeyID string) bool {
+	return journeyIDPattern.MatchString(journeyID)
+}
+
+// GetJourneyID returns the journey ID of the request, or empty string if there is none
+func GetJourneyID(req *restful.Request) string {
+	journeyID, _ := req.Attribute(JourneyIDKey).(string)
+	return journeyID
+}
+
+// InjectJourneyID propagates the journey ID of the incoming request into the outgoing request header
+func InjectJourneyID(outgoingReq *http.Request, incomingReq *restful.Request) {
+	if journeyID := GetJourneyID(incomingReq); journeyID != "" {
+		outgoingReq.Header.Set(JourneyIDKey, journeyID)
+	}
+}
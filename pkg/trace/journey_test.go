@@ -0,0 +1,93 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidJourneyID(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsValidJourneyID("login-flow_01.abc"))
+	assert.False(t, IsValidJourneyID(""))
+	assert.False(t, IsValidJourneyID("journey id"))
+	assert.False(t, IsValidJourneyID("journey\nid"))
+	assert.False(t, IsValidJourneyID(strings.Repeat("a", 65)))
+}
+
+func TestJourneyFilter(t *testing.T) {
+	t.Parallel()
+
+	var journeyID string
+	outgoingReq := httptest.NewRequest(http.MethodGet, "/downstream", nil)
+
+	ws := new(restful.WebService)
+	ws.Filter(JourneyFilter())
+	ws.Route(
+		ws.GET("/user").
+			To(func(request *restful.Request, response *restful.Response) {
+				journeyID = GetJourneyID(request)
+				InjectJourneyID(outgoingReq, request)
+			}))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set(JourneyIDKey, "login-flow-1")
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, req)
+
+	assert.Equal(t, "login-flow-1", journeyID)
+	assert.Equal(t, "login-flow-1", resp.Header().Get(JourneyIDKey))
+	assert.Equal(t, "login-flow-1", outgoingReq.Header.Get(JourneyIDKey))
+}
+
+func TestJourneyFilter_InvalidJourneyID(t *testing.T) {
+	t.Parallel()
+
+	var journeyID, journeyIDHeader string
+	outgoingReq := httptest.NewRequest(http.MethodGet, "/downstream", nil)
+
+	ws := new(restful.WebService)
+	ws.Filter(JourneyFilter())
+	ws.Route(
+		ws.GET("/user").
+			To(func(request *restful.Request, response *restful.Response) {
+				journeyID = GetJourneyID(request)
+				journeyIDHeader = request.HeaderParameter(JourneyIDKey)
+				InjectJourneyID(outgoingReq, request)
+			}))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set(JourneyIDKey, "<script>")
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, req)
+
+	assert.Empty(t, journeyID)
+	assert.Empty(t, journeyIDHeader)
+	assert.Empty(t, resp.Header().Get(JourneyIDKey))
+	assert.Empty(t, outgoingReq.Header.Get(JourneyIDKey))
+}
@@ -0,0 +1,133 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"context"
+	"encoding/binary"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// RequestIDKey is the header and attribute key of the request ID
+	RequestIDKey = "X-Request-Id"
+
+	// RequestIDLogField is the logrus field of the request ID
+	RequestIDLogField = "request_id"
+)
+
+type loggerContextKey struct{}
+
+// requestIDPattern is the valid incoming request ID format: 1-128 characters of alphanumeric, dash, underscore, dot or colon
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestIDOptions contains options for RequestIDFilter
+type RequestIDOptions struct {
+	// Generator generates the request ID when the request doesn't have a valid one. Default: NewUUIDv7
+	Generator func() (string, error)
+}
+
+// RequestIDFilter is a filter that generates the request ID when X-Request-Id request header is absent or invalid,
+// stores it as request attribute, echoes it in the response header,
+// and binds it into the logrus entry of the request context, see Logger.
+func RequestIDFilter(options *RequestIDOptions) restful.FilterFunction {
+	if options == nil {
+		options = &RequestIDOptions{}
+	}
+	generator := options.Generator
+	if generator == nil {
+		generator = NewUUIDv7
+	}
+
+	return func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		requestID := req.HeaderParameter(RequestIDKey)
+		if !IsValidRequestID(requestID) {
+			if requestID != "" {
+				logrus.Debugf("ignoring invalid request ID: %q", requestID)
+			}
+
+			var err error
+			requestID, err = generator()
+			if err != nil {
+				logrus.Errorf("Unable to generate request ID %s", err.Error())
+			}
+			req.Request.Header.Set(RequestIDKey, requestID)
+		}
+
+		req.SetAttribute(RequestIDKey, requestID)
+		resp.Header().Set(RequestIDKey, requestID)
+
+		entry := logrus.WithField(RequestIDLogField, requestID)
+		req.Request = req.Request.WithContext(context.WithValue(req.Request.Context(), loggerContextKey{}, entry))
+
+		chain.ProcessFilter(req, resp)
+	}
+}
+
+// IsValidRequestID checks the incoming request ID format
+func IsValidRequestID(requestID string) bool {
+	return requestIDPattern.MatchString(requestID)
+}
+
+// GetRequestID returns the request ID of the request, or empty string if there is none
+func GetRequestID(req *restful.Request) string {
+	requestID, _ := req.Attribute(RequestIDKey).(string)
+	return requestID
+}
+
+// InjectRequestID propagates the request ID of the incoming request into the outgoing request header
+func InjectRequestID(outgoingReq *http.Request, incomingReq *restful.Request) {
+	if requestID := GetRequestID(incomingReq); requestID != "" {
+		outgoingReq.Header.Set(RequestIDKey, requestID)
+	}
+}
+
+// Logger returns the logrus entry bound to the request ID of the request,
+// or the standard logger entry if the request isn't filtered through RequestIDFilter
+func Logger(req *restful.Request) *logrus.Entry {
+	return LoggerFromContext(req.Request.Context())
+}
+
+// LoggerFromContext returns the logrus entry bound to the request ID of the request context,
+// or the standard logger entry if there is none
+func LoggerFromContext(ctx context.Context) *logrus.Entry {
+	if entry, ok := ctx.Value(loggerContextKey{}).(*logrus.Entry); ok {
+		return entry.WithContext(ctx)
+	}
+	return logrus.WithContext(ctx)
+}
+
+// NewUUIDv7 generates the time-ordered UUID version 7, e.g. "01890a5d-ac96-774b-bcce-b302099a8057"
+func NewUUIDv7() (string, error) {
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return "", err
+	}
+
+	// the first 48 bits are the unix timestamp in milliseconds,
+	// the variant bits are already set by uuid.NewRandom
+	var timestamp [8]byte
+	binary.BigEndian.PutUint64(timestamp[:], uint64(time.Now().UnixNano()/int64(time.Millisecond)))
+	copy(id[0:6], timestamp[2:8])
+	id[6] = (id[6] & 0x0f) | 0x70
+
+	return id.String(), nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+var uuidV7Pattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func serveRequestID(options *RequestIDOptions, requestID string, handler restful.RouteFunction) *httptest.ResponseRecorder {
+	ws := new(restful.WebService)
+	ws.Filter(RequestIDFilter(options))
+	ws.Route(ws.GET("/user").To(handler))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+
+	req := httptest.NewRequest(http.MethodGet, "/user", nil)
+	if requestID != "" {
+		req.Header.Set(RequestIDKey, requestID)
+	}
+	resp := httptest.NewRecorder()
+	container.ServeHTTP(resp, req)
+	return resp
+}
+
+func TestNewUUIDv7(t *testing.T) {
+	t.Parallel()
+
+	first, err := NewUUIDv7()
+	assert.NoError(t, err)
+	assert.Regexp(t, uuidV7Pattern, first)
+
+	second, err := NewUUIDv7()
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	// the timestamp prefix is time-ordered
+	assert.True(t, first[:8] <= second[:8])
+}
+
+func TestIsValidRequestID(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsValidRequestID("01890a5d-ac96-774b-bcce-b302099a8057"))
+	assert.True(t, IsValidRequestID("req:abc_1.2"))
+	assert.False(t, IsValidRequestID(""))
+	assert.False(t, IsValidRequestID("request id"))
+	assert.False(t, IsValidRequestID(strings.Repeat("a", 129)))
+}
+
+func TestRequestIDFilter(t *testing.T) {
+	t.Parallel()
+
+	var requestID string
+	outgoingReq := httptest.NewRequest(http.MethodGet, "/downstream", nil)
+
+	resp := serveRequestID(nil, "", func(request *restful.Request, response *restful.Response) {
+		requestID = GetRequestID(request)
+		InjectRequestID(outgoingReq, request)
+	})
+
+	assert.Regexp(t, uuidV7Pattern, requestID)
+	assert.Equal(t, requestID, resp.Header().Get(RequestIDKey))
+	assert.Equal(t, requestID, outgoingReq.Header.Get(RequestIDKey))
+}
+
+func TestRequestIDFilter_Incoming(t *testing.T) {
+	t.Parallel()
+
+	var requestID string
+	resp := serveRequestID(nil, "abc-123", func(request *restful.Request, response *restful.Response) {
+		requestID = GetRequestID(request)
+	})
+
+	assert.Equal(t, "abc-123", requestID)
+	assert.Equal(t, "abc-123", resp.Header().Get(RequestIDKey))
+
+	// the invalid request ID is replaced
+	resp = serveRequestID(nil, "abc 123", func(request *restful.Request, response *restful.Response) {
+		requestID = GetRequestID(request)
+	})
+
+	assert.Regexp(t, uuidV7Pattern, requestID)
+	assert.Equal(t, requestID, resp.Header().Get(RequestIDKey))
+}
+
+func TestRequestIDFilter_Generator(t *testing.T) {
+	t.Parallel()
+
+	var requestID string
+	serveRequestID(&RequestIDOptions{Generator: func() (string, error) { return "generated", nil }}, "",
+		func(request *restful.Request, response *restful.Response) {
+			requestID = GetRequestID(request)
+		})
+	assert.Equal(t, "generated", requestID)
+
+	resp := serveRequestID(&RequestIDOptions{Generator: func() (string, error) { return "", errors.New("unexpected") }}, "",
+		func(request *restful.Request, response *restful.Response) {})
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestRequestIDFilter_Logger(t *testing.T) {
+	t.Parallel()
+
+	buffer := new(bytes.Buffer)
+	logger := logrus.New()
+	logger.Out = buffer
+	logger.Formatter = &logrus.JSONFormatter{}
+
+	var entry *logrus.Entry
+	serveRequestID(nil, "abc-123", func(request *restful.Request, response *restful.Response) {
+		entry = Logger(request)
+	})
+
+	if assert.NotNil(t, entry) {
+		assert.Equal(t, "abc-123", entry.Data[RequestIDLogField])
+		entry.Logger = logger
+		entry.Info("user fetched")
+		assert.Contains(t, buffer.String(), `"request_id":"abc-123"`)
+	}
+
+	// the request isn't filtered through the filter
+	req := restful.NewRequest(httptest.NewRequest(http.MethodGet, "/user", nil))
+	assert.Empty(t, Logger(req).Data)
+}
@@ -0,0 +1,433 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package undo
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/audit"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/response"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// WindowMetadata is the route metadata key of the undo window, the time.Duration the request is delayed for
+	WindowMetadata = "UndoWindow"
+	// ConfirmMetadata is the route metadata key to require the confirmation header with a bool value
+	ConfirmMetadata = "UndoConfirm"
+
+	// ConfirmHeader is the header confirming the destructive request, its value must be "true"
+	ConfirmHeader = "X-Confirm-Delete"
+	// TokenParameter is the path parameter of the undo token read by UndoHandler
+	TokenParameter = "undoToken"
+
+	defaultWindow      = 10 * time.Second
+	defaultMaxBodySize = 1 << 20
+)
+
+// defaultAttributes are the request attributes kept for the handler of the scheduled request
+var defaultAttributes = []string{
+	iam.ClaimsAttribute,
+	iam.AuthModeAttribute,
+	iam.ResourceNamespaceAttribute,
+	iam.TokenIssuerAttribute,
+}
+
+// errBodyTooLarge is returned when the body of the scheduled request exceeds MaxBodySize
+var errBodyTooLarge = errors.New("request body exceeds the maximum size of the scheduled request")
+
+// Options contains options for the Manager
+type Options struct {
+	// Window is the default undo window of the routes. Default: 10 seconds
+	Window time.Duration
+	// Publisher receives the audit events of the scheduled, undone and finalized requests. Default: audit.LogPublisher
+	Publisher audit.Publisher
+	// MaxBodySize is the maximum request body size kept in the memory until the request is finalized,
+	// the larger request is rejected with 413 error response. Default: 1MB
+	MaxBodySize int64
+	// Attributes are the keys of the request attributes set by the previous filters and kept for the handler
+	// of the scheduled request, in addition to the IAM attributes (e.g. the JWT claims)
+	Attributes []string
+}
+
+// Scheduled is the response of the request accepted within its undo window
+type Scheduled struct {
+	UndoToken  string    `json:"undoToken"`
+	FinalizeAt time.Time `json:"finalizeAt"`
+}
+
+// pending is the request waiting for its undo window to pass
+type pending struct {
+	req   *restful.Request
+	chain restful.FilterChain
+	actor audit.Actor
+	event audit.Event
+	timer *time.Timer
+}
+
+// newEvent creates the audit event of the pending request from the event of the original request,
+// since the detached request doesn't keep the selected route
+func (p *pending) newEvent(status int) *audit.Event {
+	event := p.event
+	event.Time = time.Now().UTC()
+	event.Status = status
+	event.Result = audit.ResultSuccess
+	if status >= http.StatusBadRequest {
+		event.Result = audit.ResultFailure
+	}
+	return &event
+}
+
+// Manager delays the destructive requests for their undo window, so they can be undone before they're finalized
+type Manager struct {
+	window      time.Duration
+	publisher   audit.Publisher
+	maxBodySize int64
+	attributes  []string
+
+	mutex   sync.Mutex
+	pending map[string]*pending
+	running sync.WaitGroup
+}
+
+// New creates new Manager instance
+func New(options Options) *Manager {
+	window := options.Window
+	if window <= 0 {
+		window = defaultWindow
+	}
+	publisher := options.Publisher
+	if publisher == nil {
+		publisher = audit.LogPublisher()
+	}
+	maxBodySize := options.MaxBodySize
+	if maxBodySize <= 0 {
+		maxBodySize = defaultMaxBodySize
+	}
+	return &Manager{
+		window:      window,
+		publisher:   publisher,
+		maxBodySize: maxBodySize,
+		attributes:  append(append([]string(nil), defaultAttributes...), options.Attributes...),
+		pending:     make(map[string]*pending),
+	}
+}
+
+// RouteConfirm requires the route's request to be confirmed with "X-Confirm-Delete: true" header
+func RouteConfirm() func(*restful.RouteBuilder) {
+	return func(builder *restful.RouteBuilder) {
+		builder.Metadata(ConfirmMetadata, true)
+	}
+}
+
+// RouteWindow delays the route's request for the undo window, the Manager's default window is used when it's zero.
+// The route is excluded from the audit filter, the Manager publishes its own audit events instead.
+func RouteWindow(window time.Duration) func(*restful.RouteBuilder) {
+	return func(builder *restful.RouteBuilder) {
+		builder.Metadata(WindowMetadata, window)
+		builder.Metadata(audit.AuditMetadata, false)
+	}
+}
+
+// Filter rejects the unconfirmed request of the RouteConfirm route with 428 error response,
+// and accepts the request of the RouteWindow route with 202 and the undo token.
+// The rest of the filter chain and the handler run when the undo window passes, detached from the original request.
+// It must be registered after the auth filter, since the filters before it don't run again when the request
+// is finalized, and the actor allowed to undo the request is taken from the JWT claims.
+func (m *Manager) Filter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	route := req.SelectedRoute()
+	if route == nil {
+		chain.ProcessFilter(req, resp)
+		return
+	}
+	metadata := route.Metadata()
+
+	if confirm, _ := metadata[ConfirmMetadata].(bool); confirm &&
+		!strings.EqualFold(req.Request.Header.Get(ConfirmHeader), "true") {
+		response.WriteErrorEnvelope(req, resp, http.StatusPreconditionRequired, &response.Error{
+			ErrorCode:    iam.ValidationError,
+			ErrorMessage: "the request must be confirmed with " + ConfirmHeader + ": true header",
+		})
+		return
+	}
+
+	window, ok := metadata[WindowMetadata].(time.Duration)
+	if !ok {
+		chain.ProcessFilter(req, resp)
+		return
+	}
+	if window <= 0 {
+		window = m.window
+	}
+
+	scheduled, err := m.schedule(req, chain, window)
+	if err == errBodyTooLarge {
+		response.WriteErrorEnvelope(req, resp, http.StatusRequestEntityTooLarge, &response.Error{
+			ErrorCode:    iam.ValidationError,
+			ErrorMessage: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		response.WriteErrorEnvelope(req, resp, http.StatusInternalServerError, err)
+		return
+	}
+	m.publish(audit.NewEvent(req, http.StatusAccepted), audit.ResultScheduled)
+
+	if err = resp.WriteHeaderAndJson(http.StatusAccepted, scheduled, restful.MIME_JSON); err != nil {
+		logrus.Error(err)
+	}
+}
+
+// schedule detaches the request from the original one and finalizes it after the window
+func (m *Manager) schedule(req *restful.Request, chain *restful.FilterChain, window time.Duration) (*Scheduled, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// the handler runs after the original request is completed, so the request can't share its body, context,
+	// path parameters and attributes
+	detached := restful.NewRequest(req.Request.Clone(context.Background()))
+	for name, value := range req.PathParameters() {
+		detached.PathParameters()[name] = value
+	}
+	for _, name := range m.attributes {
+		if value := req.Attribute(name); value != nil {
+			detached.SetAttribute(name, value)
+		}
+	}
+	if req.Request.Body != nil {
+		body, err := ioutil.ReadAll(io.LimitReader(req.Request.Body, m.maxBodySize+1))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(body)) > m.maxBodySize {
+			return nil, errBodyTooLarge
+		}
+		detached.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	p := &pending{req: detached, chain: *chain, event: *audit.NewEvent(req, http.StatusAccepted)}
+	p.event.Target = make(map[string]string, len(req.PathParameters()))
+	for name, value := range req.PathParameters() {
+		p.event.Target[name] = value
+	}
+	if claims := iam.RetrieveJWTClaims(req); claims != nil {
+		p.actor = audit.Actor{UserID: claims.Subject, ClientID: claims.ClientID}
+	}
+
+	m.mutex.Lock()
+	m.pending[token] = p
+	p.timer = time.AfterFunc(window, func() { m.finalize(token) })
+	m.mutex.Unlock()
+
+	return &Scheduled{UndoToken: token, FinalizeAt: time.Now().Add(window).UTC()}, nil
+}
+
+// take removes the pending request, so it's either undone or finalized
+func (m *Manager) take(token string) *pending {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	p := m.pending[token]
+	delete(m.pending, token)
+	return p
+}
+
+// finalize runs the rest of the filter chain and the handler of the pending request
+func (m *Manager) finalize(token string) {
+	p := m.take(token)
+	if p == nil {
+		return
+	}
+	p.timer.Stop()
+
+	m.running.Add(1)
+	defer m.running.Done()
+
+	status := m.process(p)
+	if status >= http.StatusBadRequest {
+		logrus.Warnf("scheduled %s %s is finalized with status %d", p.req.Request.Method, p.req.Request.URL.Path, status)
+	}
+	m.publish(p.newEvent(status), "")
+}
+
+// process runs the rest of the filter chain and the handler, and returns the response status.
+// It runs in the timer's goroutine, so the panic is recovered into 500 status instead of crashing the service.
+func (m *Manager) process(p *pending) (status int) {
+	recorder := &statusRecorder{header: make(http.Header)}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			logrus.Errorf("panic recovered on scheduled %s %s: %v\n%s", p.req.Request.Method, p.req.Request.URL.Path,
+				recovered, debug.Stack())
+			status = http.StatusInternalServerError
+		}
+	}()
+
+	resp := restful.NewResponse(recorder)
+	resp.SetRequestAccepts(p.req.Request.Header.Get(restful.HEADER_Accept))
+	p.chain.ProcessFilter(p.req, resp)
+
+	if recorder.status == 0 {
+		return http.StatusOK
+	}
+	return recorder.status
+}
+
+// Undo cancels the pending request before its undo window passes. It returns false when the token is unknown,
+// or the request is already finalized.
+func (m *Manager) Undo(token string) bool {
+	p := m.take(token)
+	if p == nil {
+		return false
+	}
+	p.timer.Stop()
+	m.publish(p.newEvent(http.StatusOK), audit.ResultUndone)
+	return true
+}
+
+// UndoHandler returns the route function undoing the pending request of the {undoToken} path parameter, e.g.
+//
+//	ws.Route(ws.DELETE("/undo/{undoToken}").To(manager.UndoHandler()))
+//
+// Only the actor of the pending request can undo it. It responds 204, or 404 when the token is unknown or expired.
+func (m *Manager) UndoHandler() restful.RouteFunction {
+	return func(req *restful.Request, resp *restful.Response) {
+		token := req.PathParameter(TokenParameter)
+
+		m.mutex.Lock()
+		p := m.pending[token]
+		m.mutex.Unlock()
+		if p == nil {
+			response.WriteErrorEnvelope(req, resp, http.StatusNotFound, &response.Error{
+				ErrorCode:    iam.ValidationError,
+				ErrorMessage: "undo token is unknown or the request is already finalized",
+			})
+			return
+		}
+
+		var actor audit.Actor
+		if claims := iam.RetrieveJWTClaims(req); claims != nil {
+			actor = audit.Actor{UserID: claims.Subject, ClientID: claims.ClientID}
+		}
+		if actor != p.actor {
+			response.WriteErrorEnvelope(req, resp, http.StatusForbidden, &response.Error{
+				ErrorCode:    iam.ForbiddenAccess,
+				ErrorMessage: "only the caller of the scheduled request can undo it",
+			})
+			return
+		}
+
+		if !m.Undo(token) {
+			response.WriteErrorEnvelope(req, resp, http.StatusNotFound, &response.Error{
+				ErrorCode:    iam.ValidationError,
+				ErrorMessage: "undo token is unknown or the request is already finalized",
+			})
+			return
+		}
+		resp.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// Pending returns the number of the requests waiting for their undo window to pass
+func (m *Manager) Pending() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.pending)
+}
+
+// FinalizeAll finalizes the pending requests immediately and waits until they're completed,
+// it should be called when the service is shutting down, so the accepted requests aren't lost.
+func (m *Manager) FinalizeAll(ctx context.Context) error {
+	m.mutex.Lock()
+	tokens := make([]string, 0, len(m.pending))
+	for token := range m.pending {
+		tokens = append(tokens, token)
+	}
+	m.mutex.Unlock()
+
+	for _, token := range tokens {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		m.finalize(token)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		m.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *Manager) publish(event *audit.Event, result string) {
+	if result != "" {
+		event.Result = result
+	}
+	if err := m.publisher.Publish(event); err != nil {
+		logrus.Errorf("unable to publish audit event of %s %s: %v", event.Method, event.Route, err)
+	}
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// statusRecorder keeps the status of the finalized request, the response body is discarded
+type statusRecorder struct {
+	header http.Header
+	status int
+}
+
+func (r *statusRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return len(p), nil
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
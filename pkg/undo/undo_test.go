@@ -0,0 +1,281 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package undo
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/auth/iam"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/logger/audit"
+	iamSDK "github.com/AccelByte/iam-go-sdk"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testService serves the destructive routes and records the deleted items and the audit events
+type testService struct {
+	container *restful.Container
+	manager   *Manager
+
+	mutex   sync.Mutex
+	deleted []string
+	bodies  []string
+	events  []audit.Event
+}
+
+func newTestService(window time.Duration) *testService {
+	service := &testService{}
+	service.manager = New(Options{
+		Window: window,
+		Publisher: audit.PublisherFunc(func(event *audit.Event) error {
+			service.mutex.Lock()
+			defer service.mutex.Unlock()
+			service.events = append(service.events, *event)
+			return nil
+		}),
+	})
+
+	ws := new(restful.WebService)
+	ws.Filter(func(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+		if userID := req.Request.Header.Get("User"); userID != "" {
+			req.SetAttribute(iam.ClaimsAttribute, &iamSDK.JWTClaims{})
+			iam.RetrieveJWTClaims(req).Subject = userID
+		}
+		chain.ProcessFilter(req, resp)
+	})
+	ws.Filter(service.manager.Filter)
+	ws.Route(ws.DELETE("/items/{id}").
+		Do(RouteWindow(0)).
+		To(func(req *restful.Request, resp *restful.Response) {
+			body, _ := ioutil.ReadAll(req.Request.Body)
+			service.mutex.Lock()
+			service.deleted = append(service.deleted, req.PathParameter("id"))
+			service.bodies = append(service.bodies, string(body))
+			service.mutex.Unlock()
+			resp.WriteHeader(http.StatusNoContent)
+		}))
+	ws.Route(ws.DELETE("/panics/{id}").
+		Do(RouteWindow(0)).
+		To(func(req *restful.Request, resp *restful.Response) {
+			panic("boom")
+		}))
+	ws.Route(ws.DELETE("/namespaces/{namespace}").
+		Do(RouteConfirm()).
+		To(func(req *restful.Request, resp *restful.Response) {
+			resp.WriteHeader(http.StatusNoContent)
+		}))
+	ws.Route(ws.DELETE("/undo/{undoToken}").To(service.manager.UndoHandler()))
+
+	service.container = restful.NewContainer()
+	service.container.Add(ws)
+	return service
+}
+
+func (s *testService) serve(path string, user string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodDelete, path, strings.NewReader(`{"reason":"test"}`))
+	for key := range header {
+		req.Header.Set(key, header.Get(key))
+	}
+	if user != "" {
+		req.Header.Set("User", user)
+	}
+	resp := httptest.NewRecorder()
+	s.container.ServeHTTP(resp, req)
+	return resp
+}
+
+func (s *testService) schedule(t *testing.T, path string, user string) Scheduled {
+	t.Helper()
+
+	resp := s.serve(path, user, nil)
+	require.Equal(t, http.StatusAccepted, resp.Code)
+
+	var scheduled Scheduled
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &scheduled))
+	require.NotEmpty(t, scheduled.UndoToken)
+	return scheduled
+}
+
+func (s *testService) results() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	results := make([]string, 0, len(s.events))
+	for _, event := range s.events {
+		results = append(results, event.Result)
+	}
+	return results
+}
+
+func (s *testService) deletedItems() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.deleted...)
+}
+
+func TestWindowFinalizesAfterWindow(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(20 * time.Millisecond)
+
+	scheduled := service.schedule(t, "/items/1", "user1")
+	assert.WithinDuration(t, time.Now().Add(20*time.Millisecond), scheduled.FinalizeAt, time.Second)
+	assert.Empty(t, service.deletedItems())
+	assert.Equal(t, 1, service.manager.Pending())
+
+	assert.Eventually(t, func() bool { return len(service.deletedItems()) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"1"}, service.deletedItems())
+	assert.Equal(t, []string{`{"reason":"test"}`}, service.bodies)
+	assert.Eventually(t, func() bool { return len(service.results()) == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{audit.ResultScheduled, audit.ResultSuccess}, service.results())
+
+	service.mutex.Lock()
+	finalized := service.events[1]
+	service.mutex.Unlock()
+	assert.Equal(t, http.StatusNoContent, finalized.Status)
+	assert.Equal(t, "user1", finalized.Actor.UserID)
+	assert.Equal(t, map[string]string{"id": "1"}, finalized.Target)
+	assert.Equal(t, "/items/{id}", finalized.Route)
+}
+
+func TestScheduleDetachesRequest(t *testing.T) {
+	t.Parallel()
+
+	manager := New(Options{Window: time.Hour, Attributes: []string{"Kept"}})
+
+	req := restful.NewRequest(httptest.NewRequest(http.MethodDelete, "/items/1", nil))
+	req.PathParameters()["id"] = "1"
+	req.SetAttribute(iam.ClaimsAttribute, &iamSDK.JWTClaims{ClientID: "client1"})
+	req.SetAttribute("Kept", "kept")
+	req.SetAttribute("Dropped", "dropped")
+
+	scheduled, err := manager.schedule(req, &restful.FilterChain{}, time.Hour)
+	require.NoError(t, err)
+	defer manager.Undo(scheduled.UndoToken)
+
+	detached := manager.pending[scheduled.UndoToken].req
+	assert.Equal(t, "1", detached.PathParameter("id"))
+	assert.Equal(t, "client1", iam.RetrieveJWTClaims(detached).ClientID)
+	assert.Equal(t, "kept", detached.Attribute("Kept"))
+	assert.Nil(t, detached.Attribute("Dropped"))
+
+	// the handler of the detached request doesn't change the original request
+	detached.PathParameters()["id"] = "2"
+	detached.SetAttribute("Kept", "changed")
+	assert.Equal(t, "1", req.PathParameter("id"))
+	assert.Equal(t, "kept", req.Attribute("Kept"))
+	assert.Equal(t, map[string]string{"id": "1"}, manager.pending[scheduled.UndoToken].event.Target)
+}
+
+func TestWindowFinalizePanic(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(time.Millisecond)
+	service.schedule(t, "/panics/1", "user1")
+
+	assert.Eventually(t, func() bool { return len(service.results()) == 2 }, time.Second, time.Millisecond)
+	assert.Equal(t, []string{audit.ResultScheduled, audit.ResultFailure}, service.results())
+
+	service.mutex.Lock()
+	finalized := service.events[1]
+	service.mutex.Unlock()
+	assert.Equal(t, http.StatusInternalServerError, finalized.Status)
+}
+
+func TestWindowBodyTooLarge(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(time.Hour)
+	service.manager.maxBodySize = 8
+
+	resp := service.serve("/items/1", "user1", nil)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.Code)
+	assert.Equal(t, 0, service.manager.Pending())
+	assert.Empty(t, service.results())
+}
+
+func TestUndoCancelsPendingRequest(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(time.Hour)
+	scheduled := service.schedule(t, "/items/1", "user1")
+
+	resp := service.serve("/undo/"+scheduled.UndoToken, "user1", nil)
+
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Equal(t, 0, service.manager.Pending())
+	assert.Equal(t, []string{audit.ResultScheduled, audit.ResultUndone}, service.results())
+
+	// the token can't be used twice
+	resp = service.serve("/undo/"+scheduled.UndoToken, "user1", nil)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	assert.Empty(t, service.deletedItems())
+}
+
+func TestUndoByAnotherActor(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(time.Hour)
+	scheduled := service.schedule(t, "/items/1", "user1")
+
+	resp := service.serve("/undo/"+scheduled.UndoToken, "user2", nil)
+
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	assert.Equal(t, 1, service.manager.Pending())
+}
+
+func TestUndoUnknownToken(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(time.Hour)
+
+	assert.Equal(t, http.StatusNotFound, service.serve("/undo/unknown", "", nil).Code)
+	assert.False(t, service.manager.Undo("unknown"))
+}
+
+func TestFinalizeAll(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(time.Hour)
+	service.schedule(t, "/items/1", "")
+	service.schedule(t, "/items/2", "")
+
+	require.NoError(t, service.manager.FinalizeAll(context.Background()))
+
+	assert.ElementsMatch(t, []string{"1", "2"}, service.deletedItems())
+	assert.Equal(t, 0, service.manager.Pending())
+}
+
+func TestConfirmHeader(t *testing.T) {
+	t.Parallel()
+
+	service := newTestService(time.Hour)
+
+	resp := service.serve("/namespaces/ab", "", nil)
+	assert.Equal(t, http.StatusPreconditionRequired, resp.Code)
+
+	resp = service.serve("/namespaces/ab", "", http.Header{ConfirmHeader: []string{"true"}})
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+}
@@ -0,0 +1,269 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/response"
+)
+
+// JSON Schema types
+const (
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+)
+
+// Schema is the subset of JSON Schema used to validate the request body and the query parameters:
+// type, properties, required, additionalProperties, items, enum, minimum, maximum,
+// minLength, maxLength, minItems, maxItems and pattern.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// ParseSchema parses the JSON Schema and compiles its patterns
+func ParseSchema(data []byte) (*Schema, error) {
+	schema := &Schema{}
+	if err := json.Unmarshal(data, schema); err != nil {
+		return nil, fmt.Errorf("unable to parse schema: %v", err)
+	}
+	if err := schema.Compile(); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// MustParseSchema parses the JSON Schema, it panics if the schema is invalid,
+// so the misconfigured route fails when the service starts
+func MustParseSchema(data string) *Schema {
+	schema, err := ParseSchema([]byte(data))
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+// Compile compiles the patterns of the schema, it must be called before validating the schema built in the code
+func (s *Schema) Compile() error {
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", s.Pattern, err)
+		}
+		s.pattern = pattern
+	}
+	for name, property := range s.Properties {
+		if err := property.Compile(); err != nil {
+			return fmt.Errorf("invalid property %s: %v", name, err)
+		}
+	}
+	if s.Items != nil {
+		if err := s.Items.Compile(); err != nil {
+			return fmt.Errorf("invalid items: %v", err)
+		}
+	}
+	return nil
+}
+
+// Validate validates the JSON decoded value against the schema and returns the field errors.
+// The numbers must be decoded as json.Number or float64.
+func (s *Schema) Validate(value interface{}) []response.FieldError {
+	errs := make([]response.FieldError, 0)
+	s.validate("", value, &errs)
+	return errs
+}
+
+func (s *Schema) validate(path string, value interface{}, errs *[]response.FieldError) {
+	addError := func(format string, args ...interface{}) {
+		*errs = append(*errs, response.FieldError{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if s.Type != "" && !matchType(s.Type, value) {
+		addError("must be %s", typeDescription(s.Type))
+		return
+	}
+
+	if len(s.Enum) > 0 && !matchEnum(s.Enum, value) {
+		addError("must be one of %s", formatEnum(s.Enum))
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		s.validateObject(path, v, errs)
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			addError("must have at least %d items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			addError("must have at most %d items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				s.Items.validate(path+"["+strconv.Itoa(i)+"]", item, errs)
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			addError("must be at least %d characters", *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			addError("must be at most %d characters", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			addError("must match pattern %s", s.Pattern)
+		}
+	default:
+		if number, ok := toFloat(value); ok {
+			if s.Minimum != nil && number < *s.Minimum {
+				addError("must be greater than or equal to %v", *s.Minimum)
+			}
+			if s.Maximum != nil && number > *s.Maximum {
+				addError("must be less than or equal to %v", *s.Maximum)
+			}
+		}
+	}
+}
+
+func (s *Schema) validateObject(path string, object map[string]interface{}, errs *[]response.FieldError) {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			*errs = append(*errs, response.FieldError{Field: joinPath(path, name), Message: "is required"})
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				*errs = append(*errs, response.FieldError{Field: joinPath(path, name), Message: "is not allowed"})
+			}
+			continue
+		}
+		property.validate(joinPath(path, name), object[name], errs)
+	}
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func matchType(schemaType string, value interface{}) bool {
+	switch schemaType {
+	case TypeObject:
+		_, ok := value.(map[string]interface{})
+		return ok
+	case TypeArray:
+		_, ok := value.([]interface{})
+		return ok
+	case TypeString:
+		_, ok := value.(string)
+		return ok
+	case TypeBoolean:
+		_, ok := value.(bool)
+		return ok
+	case TypeNumber:
+		_, ok := toFloat(value)
+		return ok
+	case TypeInteger:
+		number, ok := toFloat(value)
+		return ok && number == math.Trunc(number)
+	default:
+		return true
+	}
+}
+
+func typeDescription(schemaType string) string {
+	switch schemaType {
+	case TypeObject, TypeArray, TypeInteger:
+		return "an " + schemaType
+	default:
+		return "a " + schemaType
+	}
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		number, err := v.Float64()
+		return number, err == nil
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func matchEnum(enum []interface{}, value interface{}) bool {
+	for _, candidate := range enum {
+		if candidateNumber, ok := toFloat(candidate); ok {
+			if number, ok := toFloat(value); ok && number == candidateNumber {
+				return true
+			}
+			continue
+		}
+		if reflect.DeepEqual(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []interface{}) string {
+	values := make([]string, 0, len(enum))
+	for _, value := range enum {
+		values = append(values, fmt.Sprintf("%v", value))
+	}
+	return "[" + strings.Join(values, ", ") + "]"
+}
@@ -0,0 +1,112 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/response"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const itemSchema = `{
+	"type": "object",
+	"required": ["name", "quantity"],
+	"additionalProperties": false,
+	"properties": {
+		"name": {"type": "string", "minLength": 1, "maxLength": 8, "pattern": "^[a-z]+$"},
+		"quantity": {"type": "integer", "minimum": 1, "maximum": 99},
+		"rarity": {"enum": ["common", "rare"]},
+		"tags": {"type": "array", "maxItems": 2, "items": {"type": "string"}}
+	}
+}`
+
+func decode(t *testing.T, data string) interface{} {
+	t.Helper()
+
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(data))
+	decoder.UseNumber()
+	require.NoError(t, decoder.Decode(&value))
+	return value
+}
+
+func TestSchemaValidateValid(t *testing.T) {
+	t.Parallel()
+
+	schema := MustParseSchema(itemSchema)
+
+	errs := schema.Validate(decode(t, `{"name":"sword","quantity":2,"rarity":"rare","tags":["a"]}`))
+
+	assert.NotNil(t, errs)
+	assert.Empty(t, errs)
+}
+
+func TestSchemaValidateFieldErrors(t *testing.T) {
+	t.Parallel()
+
+	schema := MustParseSchema(itemSchema)
+
+	errs := schema.Validate(decode(t, `{"name":"Sword!","quantity":1.5,"rarity":"epic","tags":["a",1,"c"],"color":"red"}`))
+
+	assert.Equal(t, []response.FieldError{
+		{Field: "color", Message: "is not allowed"},
+		{Field: "name", Message: "must match pattern ^[a-z]+$"},
+		{Field: "quantity", Message: "must be an integer"},
+		{Field: "rarity", Message: "must be one of [common, rare]"},
+		{Field: "tags", Message: "must have at most 2 items"},
+		{Field: "tags[1]", Message: "must be a string"},
+	}, errs)
+}
+
+func TestSchemaValidateRequiredAndBounds(t *testing.T) {
+	t.Parallel()
+
+	schema := MustParseSchema(itemSchema)
+
+	errs := schema.Validate(decode(t, `{"quantity":100}`))
+
+	assert.Equal(t, []response.FieldError{
+		{Field: "name", Message: "is required"},
+		{Field: "quantity", Message: "must be less than or equal to 99"},
+	}, errs)
+}
+
+func TestSchemaValidateNestedPath(t *testing.T) {
+	t.Parallel()
+
+	schema := MustParseSchema(`{
+		"type": "object",
+		"properties": {
+			"items": {"type": "array", "items": {"type": "object", "required": ["id"]}}
+		}
+	}`)
+
+	errs := schema.Validate(decode(t, `{"items":[{"id":"a"},{}]}`))
+
+	assert.Equal(t, []response.FieldError{{Field: "items[1].id", Message: "is required"}}, errs)
+}
+
+func TestParseSchemaInvalidPattern(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseSchema([]byte(`{"properties":{"name":{"pattern":"("}}}`))
+
+	assert.Error(t, err)
+	assert.Panics(t, func() { MustParseSchema(`{"type":`) })
+}
@@ -0,0 +1,196 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/response"
+)
+
+// TagName is the struct tag of the validation rules, e.g.
+//
+//	type CreateItemRequest struct {
+//		Name     string   `json:"name" validate:"required,max=64"`
+//		Quantity int      `json:"quantity" validate:"min=1,max=99"`
+//		Rarity   string   `json:"rarity" validate:"oneof=common rare epic"`
+//		Tags     []string `json:"tags" validate:"max=10"`
+//	}
+//
+// The supported rules are required (non-zero value), min and max (the value of a number,
+// the length of a string, a slice or a map) and oneof (space separated values).
+// The nested structs and the slices of structs are validated as well.
+const TagName = "validate"
+
+// ValidateStruct validates the struct (or the pointer to the struct) using its validate tags
+// and returns the field errors, the fields are named by their json tags.
+func ValidateStruct(value interface{}) []response.FieldError {
+	errs := make([]response.FieldError, 0)
+	validateValue("", reflect.ValueOf(value), &errs)
+	return errs
+}
+
+func validateValue(path string, value reflect.Value, errs *[]response.FieldError) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		validateStructFields(path, value, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			validateValue(path+"["+strconv.Itoa(i)+"]", value.Index(i), errs)
+		}
+	}
+}
+
+func validateStructFields(path string, value reflect.Value, errs *[]response.FieldError) {
+	structType := value.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" {
+			// unexported field
+			continue
+		}
+
+		name := jsonName(field)
+		if name == "-" {
+			continue
+		}
+		fieldPath := path
+		if !field.Anonymous {
+			fieldPath = joinPath(path, name)
+		}
+		fieldValue := value.Field(i)
+
+		if rules, ok := field.Tag.Lookup(TagName); ok {
+			for _, message := range validateRules(rules, fieldValue) {
+				*errs = append(*errs, response.FieldError{Field: fieldPath, Message: message})
+			}
+		}
+		validateValue(fieldPath, fieldValue, errs)
+	}
+}
+
+// jsonName returns the name of the field in the JSON body
+func jsonName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	if tag == "" {
+		return field.Name
+	}
+	name := strings.Split(tag, ",")[0]
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// validateRules validates the field value against its rules and returns the error messages
+func validateRules(rules string, value reflect.Value) []string {
+	messages := make([]string, 0)
+	for _, rule := range strings.Split(rules, ",") {
+		name, argument := strings.TrimSpace(rule), ""
+		if index := strings.Index(name, "="); index != -1 {
+			name, argument = name[:index], name[index+1:]
+		}
+
+		switch name {
+		case "":
+		case "required":
+			if value.IsZero() {
+				// the rest of the rules are meaningless without the value
+				return append(messages, "is required")
+			}
+		case "min", "max":
+			if message := validateBound(name, argument, value); message != "" {
+				messages = append(messages, message)
+			}
+		case "oneof":
+			if message := validateOneOf(argument, value); message != "" {
+				messages = append(messages, message)
+			}
+		default:
+			messages = append(messages, fmt.Sprintf("has unknown validation rule %s", name))
+		}
+	}
+	return messages
+}
+
+func validateBound(rule string, argument string, value reflect.Value) string {
+	bound, err := strconv.ParseFloat(argument, 64)
+	if err != nil {
+		return fmt.Sprintf("has invalid %s rule %q", rule, argument)
+	}
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+
+	var actual float64
+	// the messages are worded the same way as the schema validation
+	minimum, maximum := "must be greater than or equal to %v", "must be less than or equal to %v"
+	switch value.Kind() {
+	case reflect.String:
+		actual = float64(utf8.RuneCountInString(value.String()))
+		minimum, maximum = "must be at least %v characters", "must be at most %v characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		actual = float64(value.Len())
+		minimum, maximum = "must have at least %v items", "must have at most %v items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		actual = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		actual = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		actual = value.Float()
+	default:
+		return ""
+	}
+
+	if rule == "min" && actual < bound {
+		return fmt.Sprintf(minimum, bound)
+	}
+	if rule == "max" && actual > bound {
+		return fmt.Sprintf(maximum, bound)
+	}
+	return ""
+}
+
+func validateOneOf(argument string, value reflect.Value) string {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return ""
+		}
+		value = value.Elem()
+	}
+
+	allowed := strings.Fields(argument)
+	actual := fmt.Sprintf("%v", value.Interface())
+	for _, candidate := range allowed {
+		if candidate == actual {
+			return ""
+		}
+	}
+	return "must be one of [" + strings.Join(allowed, ", ") + "]"
+}
@@ -0,0 +1,97 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"testing"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/response"
+	"github.com/stretchr/testify/assert"
+)
+
+type testAttribute struct {
+	Key   string `json:"key" validate:"required"`
+	Value string `json:"value" validate:"max=4"`
+}
+
+type testItem struct {
+	Name       string          `json:"name" validate:"required,min=2,max=8"`
+	Quantity   int             `json:"quantity" validate:"min=1,max=99"`
+	Rarity     string          `json:"rarity,omitempty" validate:"oneof=common rare"`
+	Tags       []string        `json:"tags" validate:"max=2"`
+	Attributes []testAttribute `json:"attributes"`
+	Price      *float64        `json:"price" validate:"min=0"`
+	internal   string          // nolint:structcheck,unused
+}
+
+func TestValidateStructValid(t *testing.T) {
+	t.Parallel()
+
+	price := 1.5
+	errs := ValidateStruct(&testItem{
+		Name:       "sword",
+		Quantity:   1,
+		Rarity:     "rare",
+		Attributes: []testAttribute{{Key: "k", Value: "v"}},
+		Price:      &price,
+	})
+
+	assert.NotNil(t, errs)
+	assert.Empty(t, errs)
+}
+
+func TestValidateStructFieldErrors(t *testing.T) {
+	t.Parallel()
+
+	price := -1.0
+	errs := ValidateStruct(testItem{
+		Name:       "s",
+		Quantity:   100,
+		Rarity:     "epic",
+		Tags:       []string{"a", "b", "c"},
+		Attributes: []testAttribute{{Key: "k"}, {Value: "longer"}},
+		Price:      &price,
+	})
+
+	assert.Equal(t, []response.FieldError{
+		{Field: "name", Message: "must be at least 2 characters"},
+		{Field: "quantity", Message: "must be less than or equal to 99"},
+		{Field: "rarity", Message: "must be one of [common, rare]"},
+		{Field: "tags", Message: "must have at most 2 items"},
+		{Field: "attributes[1].key", Message: "is required"},
+		{Field: "attributes[1].value", Message: "must be at most 4 characters"},
+		{Field: "price", Message: "must be greater than or equal to 0"},
+	}, errs)
+}
+
+func TestValidateStructRequiredSkipsOtherRules(t *testing.T) {
+	t.Parallel()
+
+	errs := ValidateStruct(testItem{Quantity: 1, Rarity: "common"})
+
+	assert.Equal(t, []response.FieldError{{Field: "name", Message: "is required"}}, errs)
+}
+
+func TestValidateStructUnknownRule(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		Email string `json:"email" validate:"email"`
+	}
+
+	errs := ValidateStruct(request{})
+
+	assert.Equal(t, []response.FieldError{{Field: "email", Message: "has unknown validation rule email"}}, errs)
+}
@@ -0,0 +1,248 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/codec"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/response"
+	"github.com/emicklei/go-restful/v3"
+)
+
+const (
+	// BodySchemaMetadata is the route metadata key of the *Schema of the JSON request body
+	BodySchemaMetadata = "ValidationBodySchema"
+	// QuerySchemaMetadata is the route metadata key of the *Schema of the query parameters,
+	// each query parameter is a property of the object schema
+	QuerySchemaMetadata = "ValidationQuerySchema"
+	// BodyStructMetadata is the route metadata key of the reflect.Type of the struct validated by its validate tags
+	BodyStructMetadata = "ValidationBodyStruct"
+
+	// ValidationError is the error code when the request doesn't pass the validation
+	ValidationError = 20002
+	// UnableToParseRequestBody is the error code when the request body can't be decoded
+	UnableToParseRequestBody = 20019
+)
+
+// RouteBodySchema declares the JSON Schema of the route's request body. Example:
+//
+//	ws.Route(ws.POST("/items").
+//		Do(validation.RouteBodySchema(validation.MustParseSchema(createItemSchema))).
+//		To(createItem))
+func RouteBodySchema(schema *Schema) func(*restful.RouteBuilder) {
+	return func(builder *restful.RouteBuilder) {
+		builder.Metadata(BodySchemaMetadata, schema)
+	}
+}
+
+// RouteQuerySchema declares the schema of the route's query parameters, the values are converted
+// into the type of their property before they're validated, and the array property accepts the repeated parameter.
+func RouteQuerySchema(schema *Schema) func(*restful.RouteBuilder) {
+	return func(builder *restful.RouteBuilder) {
+		builder.Metadata(QuerySchemaMetadata, schema)
+	}
+}
+
+// RouteBodyStruct declares the struct of the route's request body validated by its validate tags,
+// e.g. validation.RouteBodyStruct(CreateItemRequest{}), see TagName.
+func RouteBodyStruct(sample interface{}) func(*restful.RouteBuilder) {
+	sampleType := reflect.TypeOf(sample)
+	for sampleType.Kind() == reflect.Ptr {
+		sampleType = sampleType.Elem()
+	}
+	return func(builder *restful.RouteBuilder) {
+		builder.Metadata(BodyStructMetadata, sampleType)
+	}
+}
+
+// Filter validates the query parameters and the JSON request body (or the body of the other content type
+// with a registered decoder, see codec.Register) against the schemas declared in the route metadata
+// before the handler runs. The invalid request is rejected with 400 error response in the standard error format,
+// along with the field errors. The request body is kept for the handler to read.
+func Filter(req *restful.Request, resp *restful.Response, chain *restful.FilterChain) {
+	route := req.SelectedRoute()
+	if route == nil {
+		chain.ProcessFilter(req, resp)
+		return
+	}
+	metadata := route.Metadata()
+
+	fieldErrors := make([]response.FieldError, 0)
+	if schema, ok := metadata[QuerySchemaMetadata].(*Schema); ok {
+		fieldErrors = append(fieldErrors, schema.Validate(queryValues(req, schema))...)
+	}
+
+	bodySchema, hasBodySchema := metadata[BodySchemaMetadata].(*Schema)
+	bodyStruct, hasBodyStruct := metadata[BodyStructMetadata].(reflect.Type)
+	if (hasBodySchema || hasBodyStruct) && isDecodable(req) {
+		body, err := readBody(req)
+		if err != nil {
+			response.WriteErrorEnvelope(req, resp, http.StatusBadRequest,
+				&response.Error{ErrorCode: UnableToParseRequestBody, ErrorMessage: "unable to parse request body"})
+			return
+		}
+
+		if hasBodySchema {
+			errs, err := validateBodySchema(bodySchema, body)
+			if err != nil {
+				response.WriteErrorEnvelope(req, resp, http.StatusBadRequest,
+					&response.Error{ErrorCode: UnableToParseRequestBody, ErrorMessage: "unable to parse request body"})
+				return
+			}
+			fieldErrors = append(fieldErrors, errs...)
+		}
+		if hasBodyStruct {
+			errs, err := validateBodyStruct(bodyStruct, body)
+			if err != nil {
+				response.WriteErrorEnvelope(req, resp, http.StatusBadRequest,
+					&response.Error{ErrorCode: UnableToParseRequestBody, ErrorMessage: "unable to parse request body"})
+				return
+			}
+			fieldErrors = append(fieldErrors, errs...)
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		response.WriteErrorEnvelope(req, resp, http.StatusBadRequest, &response.Error{
+			ErrorCode:    ValidationError,
+			ErrorMessage: "validation error",
+			FieldErrors:  fieldErrors,
+		})
+		return
+	}
+
+	chain.ProcessFilter(req, resp)
+}
+
+// isDecodable checks whether the request body can be validated: the JSON body or the body of the content type
+// with a registered decoder (see codec.Register), the request without content type is considered as JSON
+func isDecodable(req *restful.Request) bool {
+	contentType := req.Request.Header.Get(restful.HEADER_ContentType)
+	return contentType == "" || codec.Supported(contentType)
+}
+
+// readBody reads the request body and sets it back, so the handler can read it.
+// The body of the other content type than JSON is converted into JSON to be validated.
+func readBody(req *restful.Request) ([]byte, error) {
+	body, err := codec.ReadBody(req)
+	if err != nil {
+		return nil, err
+	}
+	contentType := req.Request.Header.Get(restful.HEADER_ContentType)
+	if contentType == "" || len(bytes.TrimSpace(body)) == 0 {
+		return body, nil
+	}
+	return codec.ToJSON(req.Request.Context(), contentType, body)
+}
+
+func validateBodySchema(schema *Schema, body []byte) ([]response.FieldError, error) {
+	var value interface{}
+	if len(bytes.TrimSpace(body)) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+	}
+	return schema.Validate(value), nil
+}
+
+func validateBodyStruct(structType reflect.Type, body []byte) ([]response.FieldError, error) {
+	value := reflect.New(structType)
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, value.Interface()); err != nil {
+			var typeError *json.UnmarshalTypeError
+			if errors.As(err, &typeError) && typeError.Field != "" {
+				return []response.FieldError{{Field: typeError.Field, Message: "must be " + typeName(typeError.Type)}}, nil
+			}
+			return nil, err
+		}
+	}
+	return ValidateStruct(value.Interface()), nil
+}
+
+// typeName returns the JSON type name of the Go type
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return typeDescription(TypeString)
+	case reflect.Bool:
+		return typeDescription(TypeBoolean)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return typeDescription(TypeInteger)
+	case reflect.Float32, reflect.Float64:
+		return typeDescription(TypeNumber)
+	case reflect.Slice, reflect.Array:
+		return typeDescription(TypeArray)
+	default:
+		return typeDescription(TypeObject)
+	}
+}
+
+// queryValues converts the query parameters into the types of their properties,
+// the value which can't be converted is kept as string so it fails the type validation
+func queryValues(req *restful.Request, schema *Schema) map[string]interface{} {
+	query := req.Request.URL.Query()
+	values := make(map[string]interface{}, len(query))
+
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property := schema.Properties[name]
+		if property == nil {
+			values[name] = query.Get(name)
+			continue
+		}
+		if property.Type == TypeArray {
+			items := make([]interface{}, 0, len(query[name]))
+			for _, value := range query[name] {
+				items = append(items, convertQueryValue(property.Items, value))
+			}
+			values[name] = items
+			continue
+		}
+		values[name] = convertQueryValue(property, query.Get(name))
+	}
+	return values
+}
+
+func convertQueryValue(schema *Schema, value string) interface{} {
+	if schema == nil {
+		return value
+	}
+	switch schema.Type {
+	case TypeInteger, TypeNumber:
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return json.Number(value)
+		}
+	case TypeBoolean:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
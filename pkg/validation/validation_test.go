@@ -0,0 +1,193 @@
+// Copyright 2022 AccelByte Inc
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/codec"
+	"github.com/AccelByte/go-restful-plugins/v4/pkg/response"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const querySchema = `{
+	"type": "object",
+	"additionalProperties": false,
+	"required": ["limit"],
+	"properties": {
+		"limit": {"type": "integer", "minimum": 1, "maximum": 100},
+		"active": {"type": "boolean"},
+		"ids": {"type": "array", "maxItems": 2, "items": {"type": "integer"}}
+	}
+}`
+
+// newTestContainer serves the validated routes, the handler echoes the request body
+func newTestContainer() *restful.Container {
+	echo := func(req *restful.Request, resp *restful.Response) {
+		body, _ := ioutil.ReadAll(req.Request.Body)
+		_, _ = resp.Write(body)
+	}
+
+	ws := new(restful.WebService)
+	ws.Filter(Filter)
+	ws.Route(ws.POST("/schema").Do(RouteBodySchema(MustParseSchema(itemSchema))).To(echo))
+	ws.Route(ws.POST("/struct").Do(RouteBodyStruct(&testItem{})).To(echo))
+	ws.Route(ws.GET("/query").Do(RouteQuerySchema(MustParseSchema(querySchema))).To(echo))
+	ws.Route(ws.POST("/none").To(echo))
+
+	container := restful.NewContainer()
+	container.Add(ws)
+	return container
+}
+
+func serve(method string, target string, body string, contentType string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set(restful.HEADER_ContentType, contentType)
+	}
+	resp := httptest.NewRecorder()
+	newTestContainer().ServeHTTP(resp, req)
+	return resp
+}
+
+func decodeError(t *testing.T, resp *httptest.ResponseRecorder) response.Error {
+	t.Helper()
+
+	var errorResponse response.Error
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &errorResponse))
+	return errorResponse
+}
+
+func TestFilterValidBodyReachesHandler(t *testing.T) {
+	t.Parallel()
+
+	body := `{"name":"sword","quantity":2}`
+	resp := serve(http.MethodPost, "/schema", body, restful.MIME_JSON)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, body, resp.Body.String())
+}
+
+func TestFilterInvalidBodySchema(t *testing.T) {
+	t.Parallel()
+
+	resp := serve(http.MethodPost, "/schema", `{"name":"sword","quantity":0}`, restful.MIME_JSON)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Equal(t, response.Error{
+		ErrorCode:    ValidationError,
+		ErrorMessage: "validation error",
+		FieldErrors:  []response.FieldError{{Field: "quantity", Message: "must be greater than or equal to 1"}},
+	}, decodeError(t, resp))
+}
+
+func TestFilterInvalidBodyStruct(t *testing.T) {
+	t.Parallel()
+
+	resp := serve(http.MethodPost, "/struct", `{"name":"sword","quantity":1,"rarity":"epic"}`, "")
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Equal(t, []response.FieldError{{Field: "rarity", Message: "must be one of [common, rare]"}},
+		decodeError(t, resp).FieldErrors)
+}
+
+func TestFilterBodyStructTypeMismatch(t *testing.T) {
+	t.Parallel()
+
+	resp := serve(http.MethodPost, "/struct", `{"name":"sword","quantity":"one"}`, restful.MIME_JSON)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Equal(t, []response.FieldError{{Field: "quantity", Message: "must be an integer"}},
+		decodeError(t, resp).FieldErrors)
+}
+
+func TestFilterMalformedBody(t *testing.T) {
+	t.Parallel()
+
+	resp := serve(http.MethodPost, "/schema", `{"name":`, restful.MIME_JSON)
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	errorResponse := decodeError(t, resp)
+	assert.Equal(t, UnableToParseRequestBody, errorResponse.ErrorCode)
+	assert.Empty(t, errorResponse.FieldErrors)
+}
+
+func TestFilterSkipsNonJSONBody(t *testing.T) {
+	t.Parallel()
+
+	resp := serve(http.MethodPost, "/schema", "name=sword", "application/x-www-form-urlencoded")
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+// nolint:paralleltest
+func TestFilterRegisteredContentType(t *testing.T) {
+	codec.Register("application/x-test-yaml", codec.YAML)
+	defer codec.Register("application/x-test-yaml", nil)
+
+	body := "name: sword\nquantity: 2\n"
+	resp := serve(http.MethodPost, "/schema", body, "application/x-test-yaml")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	// the handler reads the original body
+	assert.Equal(t, body, resp.Body.String())
+
+	resp = serve(http.MethodPost, "/struct", "name: sword\nquantity: 1\nrarity: epic\n", "application/x-test-yaml")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Equal(t, []response.FieldError{{Field: "rarity", Message: "must be one of [common, rare]"}},
+		decodeError(t, resp).FieldErrors)
+
+	resp = serve(http.MethodPost, "/schema", "name: [", "application/x-test-yaml")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Equal(t, UnableToParseRequestBody, decodeError(t, resp).ErrorCode)
+}
+
+func TestFilterValidQuery(t *testing.T) {
+	t.Parallel()
+
+	resp := serve(http.MethodGet, "/query?limit=10&active=true&ids=1&ids=2", "", "")
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestFilterInvalidQuery(t *testing.T) {
+	t.Parallel()
+
+	resp := serve(http.MethodGet, "/query?active=yes&ids=1&ids=a&ids=3&sort=name", "", "")
+
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Equal(t, []response.FieldError{
+		{Field: "limit", Message: "is required"},
+		{Field: "active", Message: "must be a boolean"},
+		{Field: "ids", Message: "must have at most 2 items"},
+		{Field: "ids[1]", Message: "must be an integer"},
+		{Field: "sort", Message: "is not allowed"},
+	}, decodeError(t, resp).FieldErrors)
+}
+
+func TestFilterRouteWithoutSchema(t *testing.T) {
+	t.Parallel()
+
+	resp := serve(http.MethodPost, "/none", "anything", restful.MIME_JSON)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "anything", resp.Body.String())
+}